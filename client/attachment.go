@@ -29,7 +29,18 @@ func NewAttachment(request io.Reader) *Attachment {
 	return &Attachment{attachment: attachment.Attachment{Request: request, Response: attachment.NoopAttachment{}}}
 }
 
+// NewStreamingAttachment returns a new Attachment whose request Attachment of size bytes is streamed
+// from request. The default tcp transport writes it to the connection in chunks right after the frame,
+// blocking while the connection is not writable, instead of reading it into memory. Other transports,
+// multiplexed connections, encryption and checksums read it into memory as other attachments.
+// It's encoded in the attachment size field of trpc protocol, and stays compatible with all servers.
+func NewStreamingAttachment(request io.Reader, size int64) *Attachment {
+	return NewAttachment(&attachment.Sized{Reader: request, Size: size})
+}
+
 // Response returns Response Attachment.
+// A response attachment spilled to a temp file by WithAttachmentSpill is an io.ReadCloser,
+// and the file is removed once it's read to the end or closed.
 func (a *Attachment) Response() io.Reader {
 	return a.attachment.Response
 }
@@ -17,15 +17,19 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"time"
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/filter"
 	"trpc.group/trpc-go/trpc-go/internal/attachment"
+	"trpc.group/trpc-go/trpc-go/internal/checksum"
 	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
+	"trpc.group/trpc-go/trpc-go/internal/envelope"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	"trpc.group/trpc-go/trpc-go/internal/report"
 	"trpc.group/trpc-go/trpc-go/naming/registry"
@@ -188,6 +192,12 @@ func (c *client) updateMsg(msg codec.Msg, opts *Options) {
 	if opts.attachment != nil {
 		setAttachment(msg, opts.attachment)
 	}
+	if opts.Checksum {
+		checksum.EnableClient(msg)
+	}
+	if opts.KeyProvider != nil {
+		envelope.SetClient(msg, opts.KeyProvider)
+	}
 }
 
 // SetAttachment sets attachment to msg.
@@ -249,6 +259,10 @@ func callFunc(ctx context.Context, reqBody interface{}, rspBody interface{}) (er
 		return errs.NewFrameError(errs.RetClientEncodeFail, "client: codec empty")
 	}
 
+	// The mark is cleared for other transports, as msg may be cloned from a msg whose transport writes
+	// deferred attachments.
+	_, ok := opts.Transport.(attachment.DeferredWriter)
+	attachment.AllowClientDeferred(msg, ok)
 	reqBuf, err := prepareRequestBuf(ctx, msg, reqBody, opts)
 	if err != nil {
 		return err
@@ -284,7 +298,7 @@ func prepareRequestBuf(
 	reqBody interface{},
 	opts *Options,
 ) ([]byte, error) {
-	reqBodyBuf, err := serializeAndCompress(ctx, msg, reqBody, opts)
+	reqBodyBuf, err := serializeAndCompress(ctx, msg, reqBody, opts, opts.CompressPolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -298,6 +312,11 @@ func prepareRequestBuf(
 	if err != nil {
 		return nil, errs.NewFrameError(errs.RetClientEncodeFail, "client codec Encode: "+err.Error())
 	}
+	if reqSize := int64(len(reqBuf)) + attachment.DeferredSize(msg); opts.MaxRequestSize > 0 &&
+		reqSize > int64(opts.MaxRequestSize) {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail, fmt.Sprintf(
+			"client codec Encode: request size %d exceeds the max request size %d", reqSize, opts.MaxRequestSize))
+	}
 
 	return reqBuf, nil
 }
@@ -353,7 +372,15 @@ func processResponseBuf(
 }
 
 // serializeAndCompress serializes and compresses reqBody.
-func serializeAndCompress(ctx context.Context, msg codec.Msg, reqBody interface{}, opts *Options) ([]byte, error) {
+// If the policy decides not to compress reqBody, the frame tells the server that it's sent uncompressed,
+// and the server is still asked to compress the response with the compress type of msg.
+func serializeAndCompress(
+	ctx context.Context,
+	msg codec.Msg,
+	reqBody interface{},
+	opts *Options,
+	policy codec.CompressPolicy,
+) ([]byte, error) {
 	// Marshal reqBody into binary body.
 	span := rpcz.SpanFromContext(ctx)
 	_, end := span.NewChild("Marshal")
@@ -378,9 +405,19 @@ func serializeAndCompress(ctx context.Context, msg codec.Msg, reqBody interface{
 	compressType := msg.CompressType()
 	if icodec.IsValidCompressType(opts.CurrentCompressType) {
 		compressType = opts.CurrentCompressType
+		// The server is expected to force the same compress type, the body is always compressed.
+		policy = codec.CompressPolicy{}
 	}
 	if icodec.IsValidCompressType(compressType) && compressType != codec.CompressTypeNoop {
-		reqBodyBuf, err = codec.Compress(compressType, reqBodyBuf)
+		if compressType == codec.CompressTypeZstd {
+			reqBodyBuf, err = codec.CompressZstd(opts.ZstdDictionary, reqBodyBuf, policy)
+		} else {
+			reqBodyBuf, err = codec.Compress(compressType, reqBodyBuf, policy)
+		}
+		if errors.Is(err, codec.ErrCompressSkipped) {
+			err = nil
+			withRspCompressType(msg, compressType)
+		}
 	}
 	end.End()
 	if err != nil {
@@ -389,6 +426,18 @@ func serializeAndCompress(ctx context.Context, msg codec.Msg, reqBody interface{
 	return reqBodyBuf, nil
 }
 
+// withRspCompressType resets the compress type of msg as the request body is sent uncompressed,
+// and sets compressType, by which the response is compressed, into the metadata of msg.
+func withRspCompressType(msg codec.Msg, compressType int) {
+	msg.WithCompressType(codec.CompressTypeNoop)
+	md := msg.ClientMetaData()
+	if md == nil {
+		md = codec.MetaData{}
+	}
+	md[codec.RspCompressTypeKey] = []byte(strconv.Itoa(compressType))
+	msg.WithClientMetaData(md)
+}
+
 // -------------------------------- client selector filter ------------------------------------- //
 
 // selectorFilter is the client selector filter.
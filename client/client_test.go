@@ -14,6 +14,7 @@
 package client_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -30,6 +31,7 @@ import (
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/filter"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
 	"trpc.group/trpc-go/trpc-go/naming/registry"
 	"trpc.group/trpc-go/trpc-go/naming/selector"
 	"trpc.group/trpc-go/trpc-go/transport"
@@ -463,6 +465,41 @@ func TestSelectorFailKeepsCalleeSetName(t *testing.T) {
 	require.Equal(t, calleeSet, msg.CalleeSetName())
 }
 
+func TestStreamingAttachmentOfNonDeferredWriter(t *testing.T) {
+	// msg is cloned from a msg whose transport writes deferred attachments, such as the msg of a handler.
+	ctx, msg := codec.WithNewMessage(context.Background())
+	attachment.AllowClientDeferred(msg, true)
+	attachment.AllowServerDeferred(msg)
+
+	tp := &recordingTransport{}
+	err := client.New().Invoke(ctx, &codec.Body{Data: []byte("body")}, &codec.Body{},
+		client.WithTarget("ip://127.0.0.1:8080"),
+		client.WithProtocol("trpc"),
+		client.WithSerializationType(codec.SerializationTypeNoop),
+		client.WithTransport(tp),
+		client.WithAttachment(client.NewStreamingAttachment(bytes.NewReader([]byte("attachment")), 10)),
+	)
+	require.NotNil(t, err)
+	// The attachment is read into the frame, as the transport doesn't write deferred attachments.
+	require.True(t, bytes.HasSuffix(tp.req, []byte("attachment")))
+	require.False(t, attachment.ClientDeferredAllowed(msg))
+}
+
+// recordingTransport records the request and fails the call.
+type recordingTransport struct {
+	fakeTransport
+	req []byte
+}
+
+func (t *recordingTransport) RoundTrip(
+	ctx context.Context,
+	req []byte,
+	opts ...transport.RoundTripOption,
+) ([]byte, error) {
+	t.req = req
+	return nil, errors.New("recorded")
+}
+
 type multiplexedTransport struct {
 	require func(context.Context, []byte, ...transport.RoundTripOption)
 	fakeTransport
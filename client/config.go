@@ -20,6 +20,7 @@ import (
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/config"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/filter"
 	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
@@ -71,6 +72,24 @@ type BackendConfig struct {
 	// Serialization type. Use a pointer to check if it has been set (0 means pb).
 	Serialization *int `yaml:"serialization"`
 	Compression   int  `yaml:"compression"` // Compression type.
+	// CompressMinSize is the min size of request bodies to compress, smaller ones are sent uncompressed.
+	CompressMinSize int `yaml:"compress_min_size"`
+	// CompressMaxRatio is the max ratio of the compressed size to the original size, request bodies
+	// which can't be compressed to it are sent uncompressed. Zero disables the check.
+	CompressMaxRatio float64 `yaml:"compress_max_ratio"`
+	// ZstdDictionary is the id of the registered zstd dictionary to compress requests of zstd compression.
+	ZstdDictionary uint32 `yaml:"zstd_dictionary"`
+	// Checksum is whether request frames carry checksums.
+	Checksum bool `yaml:"checksum"`
+	// Encryption is the name of the registered key provider to encrypt payloads.
+	Encryption string `yaml:"encryption"`
+	// MaxRequestSize is the max size of encoded requests in bytes, zero means no limit.
+	MaxRequestSize int `yaml:"max_request_size"`
+	// MaxResponseSize is the max size of responses in bytes, zero means no limit.
+	MaxResponseSize int `yaml:"max_response_size"`
+	// StreamLimitPolicy is the policy of new streams refused by the max concurrent streams of connections,
+	// which is one of fail, new_conn and queue. Streams fail by default.
+	StreamLimitPolicy string `yaml:"stream_limit_policy"`
 
 	TLSKey  string `yaml:"tls_key"`  // Client TLS key.
 	TLSCert string `yaml:"tls_cert"` // Client TLS certificate.
@@ -89,6 +108,10 @@ type BackendConfig struct {
 
 	// PreWarm specifies the configuration for client connection prewarming.
 	PreWarm PreWarmConfig `yaml:"pre_warm,omitempty"`
+
+	// UDPFragment enables the fragmentation of large UDP messages if not nil.
+	// It's not supported by the tnet transport, which fails UDP calls with it.
+	UDPFragment *transport.UDPFragmentOptions `yaml:"udp_fragment,omitempty"`
 }
 
 // PreWarmConfig defines the configuration for client connection prewarming.
@@ -130,6 +153,10 @@ func (cfg *BackendConfig) genOptions() (*Options, error) {
 	if icodec.IsValidCompressType(cfg.Compression) && cfg.Compression != codec.CompressTypeNoop {
 		opts.CompressType = cfg.Compression
 	}
+	opts.CompressPolicy = codec.CompressPolicy{MinSize: cfg.CompressMinSize, MaxRatio: cfg.CompressMaxRatio}
+	opts.ZstdDictionary = cfg.ZstdDictionary
+	opts.Checksum = cfg.Checksum
+	opts.MaxRequestSize = cfg.MaxRequestSize
 
 	// Reset the transport to check if the user has specified any transport.
 	opts.Transport = nil
@@ -144,9 +171,25 @@ func (cfg *BackendConfig) genOptions() (*Options, error) {
 		WithCertProvider(cfg.TLSCertProvider)(opts)
 	}
 	cfg.setPreWarm(opts)
+	if cfg.UDPFragment != nil {
+		WithUDPFragment(cfg.UDPFragment)(opts)
+	}
+	if cfg.MaxResponseSize > 0 {
+		WithMaxResponseSize(cfg.MaxResponseSize)(opts)
+	}
 	if cfg.Protocol != "" && opts.Codec == nil {
 		return nil, fmt.Errorf("codec %s not exists", cfg.Protocol)
 	}
+	policy, err := parseStreamLimitPolicy(cfg.StreamLimitPolicy)
+	if err != nil {
+		return nil, err
+	}
+	opts.StreamLimitPolicy = policy
+	if cfg.Encryption != "" {
+		if opts.KeyProvider = encryption.GetKeyProvider(cfg.Encryption); opts.KeyProvider == nil {
+			return nil, fmt.Errorf("encryption key provider %s not exists", cfg.Encryption)
+		}
+	}
 	for _, name := range cfg.Filter {
 		f := filter.GetClient(name)
 		if f == nil {
@@ -446,3 +489,17 @@ func RegisterClientConfig(callee string, conf *BackendConfig) error {
 	mutex.Unlock()
 	return nil
 }
+
+// parseStreamLimitPolicy parses the stream limit policy in config.
+func parseStreamLimitPolicy(s string) (StreamLimitPolicy, error) {
+	switch s {
+	case "", "fail":
+		return StreamLimitFail, nil
+	case "new_conn":
+		return StreamLimitNewConn, nil
+	case "queue":
+		return StreamLimitQueue, nil
+	default:
+		return StreamLimitFail, fmt.Errorf("stream limit policy %s not supported", s)
+	}
+}
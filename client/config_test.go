@@ -385,6 +385,18 @@ stream_filter:
 	require.Nil(t, client.RegisterClientConfig("trpc.test.hello", cfg))
 }
 
+func TestConfigStreamLimitPolicy(t *testing.T) {
+	cfg := &client.BackendConfig{}
+	require.Nil(t, yaml.Unmarshal([]byte(`
+stream_limit_policy: new_conn
+`), cfg))
+	require.Equal(t, "new_conn", cfg.StreamLimitPolicy)
+	require.Nil(t, client.RegisterClientConfig("trpc.test.hello", cfg))
+
+	cfg.StreamLimitPolicy = "unknown"
+	require.NotNil(t, client.RegisterClientConfig("trpc.test.hello", cfg))
+}
+
 func TestConfig(t *testing.T) {
 	require.Nil(t, client.RegisterConfig(make(map[string]*client.BackendConfig)))
 	c := client.Config("empty")
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/filter"
 	"trpc.group/trpc-go/trpc-go/internal/attachment"
 	"trpc.group/trpc-go/trpc-go/naming/circuitbreaker"
@@ -66,6 +67,11 @@ type Options struct {
 	CurrentCompressType      int
 	SerializationType        int
 	CompressType             int
+	CompressPolicy           codec.CompressPolicy   // decides whether a request body is worth compressing
+	ZstdDictionary           uint32                 // id of the zstd dictionary to compress requests, 0 means none
+	Checksum                 bool                   // whether request frames carry checksums
+	KeyProvider              encryption.KeyProvider // provides keys to encrypt requests, nil disables encryption
+	MaxRequestSize           int                    // max size of encoded requests in bytes, zero means no limit
 
 	Codec                 codec.Codec
 	MetaData              codec.MetaData
@@ -81,10 +87,16 @@ type Options struct {
 	Node    *onceNode   // For getting node info.
 
 	DisabledFlowControl bool
-	MaxWindowSize       uint32            // Max size of stream receiver's window.
-	SControl            SendControl       // Sender's flow control.
-	RControl            RecvControl       // Receiver's flow control.
-	StreamFilters       StreamFilterChain // Stream filter chain.
+	MaxWindowSize       uint32                   // Max size of stream receiver's window.
+	MaxAdaptiveWindow   uint32                   // Upper limit of stream receiver's adaptive window, 0 disables it.
+	SControl            SendControl              // Sender's flow control.
+	RControl            RecvControl              // Receiver's flow control.
+	StreamKeepalive     time.Duration            // Interval of stream keepalive pings, 0 disables them.
+	StreamMaxIdleTime   time.Duration            // Max time of stream receiving nothing, 0 means no limit.
+	StreamResumeTimeout time.Duration            // Max time of resuming a server stream, 0 disables resuming.
+	StreamLimitPolicy   StreamLimitPolicy        // Policy of streams refused by the max concurrent streams.
+	StreamPriority      transport.StreamPriority // Priority of the stream on the shared connection.
+	StreamFilters       StreamFilterChain        // Stream filter chain.
 
 	// EnableStreamSelectInFilter toggles selecting stream nodes inside the stream filter chain
 	// instead of during stream.Init. Disabled by default to preserve legacy behavior.
@@ -370,6 +382,84 @@ func WithCompressType(t int) Option {
 	}
 }
 
+// WithCompressMinSize returns an Option that sets the min size of request bodies to compress.
+// Smaller bodies are sent uncompressed, and the server is told by the compress type of the frame.
+// The response is still compressed with the compress type, which is sent along in trans info.
+// It doesn't apply to streaming, or when the current compress type is set.
+func WithCompressMinSize(n int) Option {
+	return func(o *Options) {
+		o.CompressPolicy.MinSize = n
+	}
+}
+
+// WithZstdDictionary returns an Option that sets the id of the registered zstd dictionary,
+// with which request bodies of codec.CompressTypeZstd are compressed.
+// The server decompresses them as long as the dictionary is registered there too.
+func WithZstdDictionary(id uint32) Option {
+	return func(o *Options) {
+		o.ZstdDictionary = id
+	}
+}
+
+// WithCompressMaxRatio returns an Option that sets the max ratio of the compressed size to the
+// original size. Request bodies which can't be compressed to it are sent uncompressed.
+// It doesn't apply to streaming, or when the current compress type is set.
+func WithCompressMaxRatio(r float64) Option {
+	return func(o *Options) {
+		o.CompressPolicy.MaxRatio = r
+	}
+}
+
+// WithChecksum returns an Option that sets whether request frames carry checksums, which are
+// verified by servers supporting them and ignored by others. Servers supporting checksums respond
+// with checksums, which are verified by the client. A mismatch fails with errs.RetClientChecksumFail.
+// Only trpc protocol supports checksums for now, and it doesn't apply to streaming.
+func WithChecksum(enable bool) Option {
+	return func(o *Options) {
+		o.Checksum = enable
+	}
+}
+
+// WithEncryption returns an Option that sets the key provider of payload encryption. Bodies and
+// attachments of requests and stream frames are encrypted with the current key of p, and responses
+// are decrypted with keys of p. The server must support encryption with the same keys.
+// Only trpc protocol supports encryption for now.
+func WithEncryption(p encryption.KeyProvider) Option {
+	return func(o *Options) {
+		o.KeyProvider = p
+	}
+}
+
+// WithMaxRequestSize returns an Option that sets the max size of encoded requests in bytes.
+// Zero means no limit. Sending a larger request or stream message fails with errs.RetClientEncodeFail.
+func WithMaxRequestSize(n int) Option {
+	return func(o *Options) {
+		o.MaxRequestSize = n
+	}
+}
+
+// WithMaxResponseSize returns an Option that sets the max size of responses in bytes. Zero means no limit.
+// Larger responses are discarded before their bodies are allocated, and fail with
+// errs.RetClientMsgExceedLimit. It applies to frames of trpc protocol, including streaming, and
+// bodies of http protocols.
+// Pooled and multiplexed connections are only shared by clients of the same limit.
+func WithMaxResponseSize(n int) Option {
+	return func(o *Options) {
+		o.CallOptions = append(o.CallOptions, transport.WithMaxResponseSize(n))
+	}
+}
+
+// WithAttachmentSpill returns an Option that spills response attachments larger than threshold bytes
+// to temp files in dir, instead of reading them into memory. Zero threshold means never, and the default
+// directory for temporary files is used if dir is empty. Spilled attachments don't count towards
+// trpc.DefaultMaxFrameSize. Like WithMaxResponseSize, pooled and multiplexed connections are only shared by
+// clients of the same options.
+func WithAttachmentSpill(threshold int, dir string) Option {
+	return func(o *Options) {
+		o.CallOptions = append(o.CallOptions, transport.WithAttachmentSpill(threshold, dir))
+	}
+}
+
 // WithTransport returns an Option that sets client transport plugin.
 func WithTransport(t transport.ClientTransport) Option {
 	return func(o *Options) {
@@ -407,6 +497,15 @@ func WithConnectionMode(connMode transport.ConnectionMode) Option {
 	}
 }
 
+// WithUDPFragment returns an Option that enables the fragmentation of large UDP messages.
+// The server must enable it too.
+// It's not supported by the tnet transport, which fails with the option rather than falling back to gonet.
+func WithUDPFragment(fragment *transport.UDPFragmentOptions) Option {
+	return func(o *Options) {
+		o.CallOptions = append(o.CallOptions, transport.WithClientUDPFragment(fragment))
+	}
+}
+
 // WithSendOnly returns an Option that sets CallType SendOnly.
 // Generally it's used for udp async sending.
 func WithSendOnly() Option {
@@ -582,6 +681,78 @@ func WithMaxWindowSize(s uint32) Option {
 	}
 }
 
+// WithAdaptiveWindow returns an Option that makes the receive window adaptive.
+// The window starts at the size set by WithMaxWindowSize, grows up to max when the estimated
+// bandwidth-delay product of the stream exceeds it, and shrinks back when the stream is idle.
+// It has no effect if max is not larger than the initial window, or if WithRecvControl is set.
+func WithAdaptiveWindow(max uint32) Option {
+	return func(o *Options) {
+		o.MaxAdaptiveWindow = max
+	}
+}
+
+// WithStreamKeepalive returns an Option that makes the stream ping the server
+// when it has sent nothing for interval, which keeps the stream from being idle on the server.
+// Pings are ignored by servers without keepalive.
+func WithStreamKeepalive(interval time.Duration) Option {
+	return func(o *Options) {
+		o.StreamKeepalive = interval
+	}
+}
+
+// WithStreamMaxIdleTime returns an Option that resets the stream which has received nothing,
+// including keepalive pings, for d. Recv of the stream returns errs.RetClientStreamReadTimeout,
+// and the server is notified by a reset close frame of the same code.
+func WithStreamMaxIdleTime(d time.Duration) Option {
+	return func(o *Options) {
+		o.StreamMaxIdleTime = d
+	}
+}
+
+// WithResumableStream returns an Option that makes server streams resumable.
+// When the connection of a server stream breaks, the stream reconnects through the selector and
+// resumes from the last message received, retrying for up to timeout. The server should enable
+// resumable streams too, otherwise the stream is not resumable.
+// It doesn't work with WithEnableStreamSelectInFilter.
+func WithResumableStream(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.StreamResumeTimeout = timeout
+	}
+}
+
+// WithStreamPriority returns an Option that sets the priority of the stream. The frames of streams
+// sharing a connection are written by weighted fair queueing of their priorities in both directions,
+// so that streams of higher priorities get larger shares of the bandwidth when the connection is busy.
+// The priority is sent to the server in the Init frame, and the default priority is StreamPriorityNormal.
+func WithStreamPriority(p transport.StreamPriority) Option {
+	return func(o *Options) {
+		o.StreamPriority = p
+		o.CallOptions = append(o.CallOptions, transport.WithStreamPriority(p))
+	}
+}
+
+// StreamLimitPolicy is the policy of a new stream refused by the server, because the connection
+// has reached the max concurrent streams. Other refusals with errs.RetServerThrottled, such as those of
+// rate limiters, always fail the stream.
+type StreamLimitPolicy int
+
+const (
+	// StreamLimitFail fails the stream with errs.RetServerThrottled.
+	StreamLimitFail StreamLimitPolicy = iota
+	// StreamLimitNewConn opens the stream on another connection.
+	StreamLimitNewConn
+	// StreamLimitQueue queues the stream until a stream of the connection finishes, or the context is done.
+	StreamLimitQueue
+)
+
+// WithStreamLimitPolicy returns an Option that sets the policy of a new stream refused by the server,
+// because the connection has reached the max concurrent streams. Streams fail by default.
+func WithStreamLimitPolicy(p StreamLimitPolicy) Option {
+	return func(o *Options) {
+		o.StreamLimitPolicy = p
+	}
+}
+
 // WithDisableStreamFlowControl disables flow control of streaming.
 func WithDisableStreamFlowControl() Option {
 	return func(o *Options) {
@@ -26,6 +26,7 @@ import (
 	trpc "trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/client"
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/filter"
 	"trpc.group/trpc-go/trpc-go/http"
 	"trpc.group/trpc-go/trpc-go/naming/registry"
@@ -242,6 +243,32 @@ func TestOptions(t *testing.T) {
 	o(opts)
 	require.Equal(t, codec.CompressTypeGzip, opts.CompressType)
 
+	client.WithCompressMinSize(1024)(opts)
+	client.WithCompressMaxRatio(0.8)(opts)
+	require.Equal(t, codec.CompressPolicy{MinSize: 1024, MaxRatio: 0.8}, opts.CompressPolicy)
+
+	client.WithZstdDictionary(1001)(opts)
+	require.Equal(t, uint32(1001), opts.ZstdDictionary)
+
+	client.WithChecksum(true)(opts)
+	require.True(t, opts.Checksum)
+
+	keys := &encryption.StaticKeyProvider{}
+	client.WithEncryption(keys)(opts)
+	require.Equal(t, keys, opts.KeyProvider)
+
+	client.WithMaxRequestSize(1024)(opts)
+	require.Equal(t, 1024, opts.MaxRequestSize)
+	client.WithMaxResponseSize(2048)(opts)
+	client.WithAttachmentSpill(4096, "spill")(opts)
+	rtOpts := &transport.RoundTripOptions{}
+	for _, o := range opts.CallOptions {
+		o(rtOpts)
+	}
+	require.Equal(t, 2048, rtOpts.MaxResponseSize)
+	require.Equal(t, 4096, rtOpts.AttachmentSpillThreshold)
+	require.Equal(t, "spill", rtOpts.AttachmentSpillDir)
+
 	o = client.WithClientStreamQueueSize(1024)
 	o(opts)
 	require.Equal(t, 1024, opts.ClientStreamQueueSize)
@@ -250,6 +277,35 @@ func TestOptions(t *testing.T) {
 	o(opts)
 	require.Equal(t, uint32(1024), opts.MaxWindowSize)
 
+	o = client.WithAdaptiveWindow(1 << 20)
+	o(opts)
+	require.Equal(t, uint32(1<<20), opts.MaxAdaptiveWindow)
+
+	o = client.WithStreamKeepalive(time.Second)
+	o(opts)
+	require.Equal(t, time.Second, opts.StreamKeepalive)
+
+	o = client.WithStreamMaxIdleTime(time.Minute)
+	o(opts)
+	require.Equal(t, time.Minute, opts.StreamMaxIdleTime)
+
+	o = client.WithResumableStream(time.Second)
+	o(opts)
+	require.Equal(t, time.Second, opts.StreamResumeTimeout)
+
+	o = client.WithStreamLimitPolicy(client.StreamLimitQueue)
+	o(opts)
+	require.Equal(t, client.StreamLimitQueue, opts.StreamLimitPolicy)
+
+	o = client.WithStreamPriority(transport.StreamPriorityHigh)
+	o(opts)
+	require.Equal(t, transport.StreamPriorityHigh, opts.StreamPriority)
+	callOpts := &transport.RoundTripOptions{}
+	for _, o := range opts.CallOptions {
+		o(callOpts)
+	}
+	require.Equal(t, transport.StreamPriorityHigh, callOpts.StreamPriority)
+
 	o = client.WithDisableStreamFlowControl()
 	o(opts)
 	require.True(t, opts.DisabledFlowControl)
@@ -15,6 +15,7 @@ package client
 
 import (
 	"context"
+	"fmt"
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
@@ -62,11 +63,23 @@ type RecvControl interface {
 	OnRecv(n uint32) error
 }
 
+// SizedSender is implemented by the Stream which reports the size of each message sent.
+type SizedSender interface {
+	// SendSized sends the stream message like Send, and returns the size of its serialized and compressed body.
+	SendSized(ctx context.Context, m interface{}) (int, error)
+}
+
 // Send implements Stream.
 // It serializes the message and sends it to server through stream transport.
 // It's safe to call Recv and Send in different goroutines concurrently, but calling
 // Send in different goroutines concurrently is not thread-safe.
-func (s *stream) Send(ctx context.Context, m interface{}) (err error) {
+func (s *stream) Send(ctx context.Context, m interface{}) error {
+	_, err := s.SendSized(ctx, m)
+	return err
+}
+
+// SendSized implements SizedSender.
+func (s *stream) SendSized(ctx context.Context, m interface{}) (n int, err error) {
 	defer func() {
 		if err != nil {
 			s.opts.StreamTransport.Close(ctx)
@@ -74,27 +87,32 @@ func (s *stream) Send(ctx context.Context, m interface{}) (err error) {
 	}()
 
 	msg := codec.Message(ctx)
-	reqBodyBuf, err := serializeAndCompress(ctx, msg, m, s.opts)
+	// Data frames of a stream share the compress type of the stream, so all of them are compressed.
+	reqBodyBuf, err := serializeAndCompress(ctx, msg, m, s.opts, codec.CompressPolicy{})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// if m != nil, m is Data frame and sender flow control is needed.
 	if m != nil && s.opts.SControl != nil {
 		if err := s.opts.SControl.GetWindow(uint32(len(reqBodyBuf))); err != nil {
-			return err
+			return 0, err
 		}
 	}
 	// encode reqBodyBuf
 	reqBuf, err := s.opts.Codec.Encode(msg, reqBodyBuf)
 	if err != nil {
-		return errs.NewFrameError(errs.RetClientEncodeFail, "client codec Encode: "+err.Error())
+		return 0, errs.NewFrameError(errs.RetClientEncodeFail, "client codec Encode: "+err.Error())
+	}
+	if s.opts.MaxRequestSize > 0 && len(reqBuf) > s.opts.MaxRequestSize {
+		return 0, errs.NewFrameError(errs.RetClientEncodeFail, fmt.Sprintf(
+			"client codec Encode: message size %d exceeds the max request size %d", len(reqBuf), s.opts.MaxRequestSize))
 	}
 
 	if err := s.opts.StreamTransport.Send(ctx, reqBuf); err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return len(reqBodyBuf), nil
 }
 
 // Recv implements Stream.
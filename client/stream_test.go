@@ -60,6 +60,23 @@ func TestStream(t *testing.T) {
 		require.Nil(t, err)
 	})
 
+	t.Run("send sized", func(t *testing.T) {
+		s := client.NewStream()
+		_, err := s.Init(ctx,
+			client.WithTarget("ip://127.0.0.1:8000"),
+			client.WithSerializationType(codec.SerializationTypeNoop),
+			client.WithStreamTransport(&fakeTransport{}),
+			client.WithProtocol("fake"),
+		)
+		require.Nil(t, err)
+		require.Nil(t, s.Invoke(ctx))
+		ss, ok := s.(client.SizedSender)
+		require.True(t, ok)
+		n, err := ss.SendSized(ctx, &codec.Body{Data: []byte("hello")})
+		require.Nil(t, err)
+		require.Equal(t, len("hello"), n)
+	})
+
 	t.Run("test nil Codec", func(t *testing.T) {
 		opts, err := streamCli.Init(ctx,
 			client.WithTarget("ip://127.0.0.1:8080"),
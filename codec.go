@@ -26,8 +26,12 @@ import (
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/allocator"
 	"trpc.group/trpc-go/trpc-go/internal/attachment"
+	"trpc.group/trpc-go/trpc-go/internal/checksum"
+	"trpc.group/trpc-go/trpc-go/internal/envelope"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
+	"trpc.group/trpc-go/trpc-go/internal/report"
 	"trpc.group/trpc-go/trpc-go/transport"
 
 	"google.golang.org/protobuf/proto"
@@ -94,6 +98,15 @@ const (
 	curProtocolVersion = protocolVersion1 // current protocol version
 )
 
+// trpc heartbeat frame, which only consists of a frame head.
+// The stream id field of the frame head carries the sequence of the ping,
+// and the pong answering it carries the same sequence.
+const (
+	heartbeatFrameType = uint8(2) // frame type of heartbeat, next to trpcpb.TrpcDataFrameType.
+	heartbeatFramePing = uint8(1) // stream frame type of heartbeat ping.
+	heartbeatFramePong = uint8(2) // stream frame type of heartbeat pong.
+)
+
 // FrameHead is head of the trpc frame.
 type FrameHead struct {
 	FrameType       uint8  // type of the frame
@@ -123,18 +136,39 @@ func (h *FrameHead) extract(buf []byte) {
 	h.FrameReserved = buf[15]
 }
 
+// isTruncated returns whether buf is a frame truncated by readTruncatedFrame.
+func (h *FrameHead) isTruncated(buf []byte) bool {
+	return h.TotalLen > uint32(len(buf)) && len(buf) == int(frameHeadLen)+int(h.HeaderLen)
+}
+
 // construct constructs bytes data for the whole frame.
 func (h *FrameHead) construct(header, body, attachment []byte) ([]byte, error) {
+	buf, err := h.constructPrefix(header, body, int64(len(attachment)), false)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, attachment...), nil
+}
+
+// constructPrefix constructs bytes data for the frame except the attachment of attachmentLen,
+// and the capacity of the returned buffer is large enough for the attachment if it's not deferred.
+// A deferred attachment is written after the frame by the transport, and doesn't count
+// towards DefaultMaxFrameSize.
+func (h *FrameHead) constructPrefix(header, body []byte, attachmentLen int64, deferred bool) ([]byte, error) {
 	headerLen := len(header)
 	if headerLen > math.MaxUint16 {
 		return nil, errHeadOverflowsUint16
 	}
-	attachmentLen := int64(len(attachment))
 	if attachmentLen > math.MaxUint32 {
 		return nil, errAttachmentOverflowsUint32
 	}
-	totalLen := int64(frameHeadLen) + int64(headerLen) + int64(len(body)) + attachmentLen
-	if totalLen > int64(DefaultMaxFrameSize) {
+	prefixLen := int64(frameHeadLen) + int64(headerLen) + int64(len(body))
+	totalLen := prefixLen + attachmentLen
+	inMemoryLen := totalLen
+	if deferred {
+		inMemoryLen = prefixLen
+	}
+	if inMemoryLen > int64(DefaultMaxFrameSize) {
 		return nil, &errFrameTooLarge{maxFrameSize: DefaultMaxFrameSize}
 	}
 	if totalLen > math.MaxUint32 {
@@ -142,7 +176,7 @@ func (h *FrameHead) construct(header, body, attachment []byte) ([]byte, error) {
 	}
 
 	// construct the buffer
-	buf := make([]byte, totalLen)
+	buf := make([]byte, prefixLen, inMemoryLen)
 	binary.BigEndian.PutUint16(buf[:2], uint16(trpcpb.TrpcMagic_TRPC_MAGIC_VALUE))
 	buf[2] = h.FrameType
 	buf[3] = h.StreamFrameType
@@ -154,8 +188,7 @@ func (h *FrameHead) construct(header, body, attachment []byte) ([]byte, error) {
 
 	frameHeadLen := int(frameHeadLen)
 	copy(buf[frameHeadLen:frameHeadLen+headerLen], header)
-	copy(buf[frameHeadLen+headerLen:frameHeadLen+headerLen+len(body)], body)
-	copy(buf[frameHeadLen+headerLen+len(body):], attachment)
+	copy(buf[frameHeadLen+headerLen:], body)
 	return buf, nil
 }
 
@@ -177,15 +210,41 @@ func (h *FrameHead) upgradeProtocol(protocolVersion uint8, requestID uint32) {
 
 // FramerBuilder is an implementation of codec.FramerBuilder.
 // Used for trpc protocol.
-type FramerBuilder struct{}
+type FramerBuilder struct {
+	maxFrameSize   int
+	spillThreshold int
+	spillDir       string
+}
 
 // New implements codec.FramerBuilder.
 func (fb *FramerBuilder) New(reader io.Reader) codec.Framer {
 	return &framer{
-		reader: reader,
+		reader:         reader,
+		maxFrameSize:   fb.maxFrameSize,
+		spillThreshold: fb.spillThreshold,
+		spillDir:       fb.spillDir,
 	}
 }
 
+// WithMaxFrameSize implements codec.SizeLimitedFramerBuilder.
+// Frames larger than n are truncated to the frame head and the protocol head, and the rest is discarded,
+// so that the codec can still respond to them with RetServerMsgExceedLimit or RetClientMsgExceedLimit.
+// The limit can't exceed DefaultMaxFrameSize.
+func (fb *FramerBuilder) WithMaxFrameSize(n int) codec.FramerBuilder {
+	b := *fb
+	b.maxFrameSize = n
+	return &b
+}
+
+// WithAttachmentSpill implements codec.SpillingFramerBuilder.
+// Attachments of unary frames larger than threshold are copied to temp files in dir, and only the rest of
+// the frames is kept in memory. Spilled attachments don't count towards DefaultMaxFrameSize.
+func (fb *FramerBuilder) WithAttachmentSpill(threshold int, dir string) codec.FramerBuilder {
+	b := *fb
+	b.spillThreshold, b.spillDir = threshold, dir
+	return &b
+}
+
 // Parse implement multiplexed.FrameParser interface.
 func (fb *FramerBuilder) Parse(rc io.Reader) (vid uint32, buf []byte, err error) {
 	buf, err = fb.New(rc).ReadFrame()
@@ -195,48 +254,141 @@ func (fb *FramerBuilder) Parse(rc io.Reader) (vid uint32, buf []byte, err error)
 	return binary.BigEndian.Uint32(buf[10:14]), buf, nil
 }
 
+// Ping implements multiplexed.Heartbeater.
+// It returns a heartbeat ping frame carrying seq.
+func (fb *FramerBuilder) Ping(seq uint32) []byte {
+	return newHeartbeatFrame(heartbeatFramePing, seq)
+}
+
+// IsPong implements multiplexed.Heartbeater.
+func (fb *FramerBuilder) IsPong(frame []byte) bool {
+	return isHeartbeatFrame(frame, heartbeatFramePong)
+}
+
+// Pong implements codec.HeartbeatFramerBuilder.
+// It returns the pong frame answering frame if frame is a heartbeat ping.
+func (fb *FramerBuilder) Pong(frame []byte) ([]byte, bool) {
+	if !isHeartbeatFrame(frame, heartbeatFramePing) {
+		return nil, false
+	}
+	return newHeartbeatFrame(heartbeatFramePong, binary.BigEndian.Uint32(frame[10:14])), true
+}
+
+func newHeartbeatFrame(streamFrameType uint8, seq uint32) []byte {
+	h := &FrameHead{
+		FrameType:       heartbeatFrameType,
+		StreamFrameType: streamFrameType,
+		StreamID:        seq,
+		ProtocolVersion: curProtocolVersion,
+	}
+	buf, _ := h.construct(nil, nil, nil) // a frame head never fails to construct.
+	return buf
+}
+
+func isHeartbeatFrame(frame []byte, streamFrameType uint8) bool {
+	return len(frame) == int(frameHeadLen) &&
+		frame[2] == heartbeatFrameType &&
+		frame[3] == streamFrameType
+}
+
 // framer is an implementation of codec.Framer.
 // Used for trpc protocol.
 type framer struct {
-	reader io.Reader
-	header [frameHeadLen]byte
+	reader         io.Reader
+	header         [frameHeadLen]byte
+	maxFrameSize   int
+	spillThreshold int
+	spillDir       string
 }
 
 // ReadFrame implements codec.Framer.
 func (f *framer) ReadFrame() ([]byte, error) {
+	msg, _, err := f.readFrame(false)
+	return msg, err
+}
+
+// ReadPooledFrame implements icodec.PooledFramer.
+// Only unary frames are read into pooled buffers, as stream frames are consumed after the handler returns.
+func (f *framer) ReadPooledFrame() ([]byte, *allocator.RefBuffer, error) {
+	return f.readFrame(true)
+}
+
+func (f *framer) readFrame(pooled bool) ([]byte, *allocator.RefBuffer, error) {
 	num, err := io.ReadFull(f.reader, f.header[:])
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if num != int(frameHeadLen) {
-		return nil, fmt.Errorf("trpc framer: read frame header num %d != %d, invalid", num, int(frameHeadLen))
+		return nil, nil, fmt.Errorf("trpc framer: read frame header num %d != %d, invalid", num, int(frameHeadLen))
 	}
 	magic := binary.BigEndian.Uint16(f.header[:2])
 	if magic != uint16(trpcpb.TrpcMagic_TRPC_MAGIC_VALUE) {
-		return nil, fmt.Errorf(
+		return nil, nil, fmt.Errorf(
 			"trpc framer: read framer head magic %d != %d, not match", magic, uint16(trpcpb.TrpcMagic_TRPC_MAGIC_VALUE))
 	}
 	totalLen := binary.BigEndian.Uint32(f.header[4:8])
 	if totalLen < uint32(frameHeadLen) {
-		return nil, fmt.Errorf(
+		return nil, nil, fmt.Errorf(
 			"trpc framer: read frame header total len %d < %d, invalid", totalLen, uint32(frameHeadLen))
 	}
+	// the spilled flag is only set by framers, and is never trusted from the peer.
+	f.header[15] &^= frameReservedSpilled
+	unary := f.header[2] == uint8(trpcpb.TrpcDataFrameType_TRPC_UNARY_FRAME)
 
+	if f.maxFrameSize > 0 && int64(totalLen) > int64(f.maxFrameSize) {
+		msg, err := f.readTruncatedFrame(totalLen)
+		return msg, nil, err
+	}
+	if f.spillThreshold > 0 && totalLen-uint32(frameHeadLen) > uint32(f.spillThreshold) && unary {
+		msg, err := f.readSpilledFrame(totalLen)
+		return msg, nil, err
+	}
 	if totalLen > uint32(DefaultMaxFrameSize) {
-		return nil, fmt.Errorf(
+		return nil, nil, fmt.Errorf(
 			"trpc framer: read frame header total len %d > %d, too large", totalLen, uint32(DefaultMaxFrameSize))
 	}
 
-	msg := make([]byte, totalLen)
+	var (
+		msg []byte
+		buf *allocator.RefBuffer
+	)
+	if pooled && unary {
+		buf = allocator.MallocRef(int(totalLen))
+		msg = buf.Bytes()
+	} else {
+		msg = make([]byte, totalLen)
+	}
 	num, err = io.ReadFull(f.reader, msg[frameHeadLen:totalLen])
 	if err != nil {
-		return nil, err
+		buf.Release()
+		return nil, nil, err
 	}
 	if num != int(totalLen-uint32(frameHeadLen)) {
-		return nil, fmt.Errorf(
+		buf.Release()
+		return nil, nil, fmt.Errorf(
 			"trpc framer: read frame total num %d != %d, invalid", num, int(totalLen-uint32(frameHeadLen)))
 	}
 	copy(msg, f.header[:])
+	return msg, buf, nil
+}
+
+// readTruncatedFrame reads the frame head and the protocol head of a frame larger than maxFrameSize,
+// and discards the rest. The total len of the returned frame is larger than its actual len.
+func (f *framer) readTruncatedFrame(totalLen uint32) ([]byte, error) {
+	headerLen := uint32(binary.BigEndian.Uint16(f.header[8:10]))
+	if uint32(frameHeadLen)+headerLen > totalLen {
+		return nil, fmt.Errorf(
+			"trpc framer: read frame header len %d > total len %d, invalid", headerLen, totalLen)
+	}
+	msg := make([]byte, uint32(frameHeadLen)+headerLen)
+	if _, err := io.ReadFull(f.reader, msg[frameHeadLen:]); err != nil {
+		return nil, err
+	}
+	remain := int64(totalLen) - int64(len(msg))
+	if n, err := io.CopyN(io.Discard, f.reader, remain); err != nil {
+		return nil, fmt.Errorf("trpc framer: discard frame of total len %d, read %d: %w", totalLen, n, err)
+	}
+	copy(msg, f.header[:])
 	return msg, nil
 }
 
@@ -261,7 +413,15 @@ func (s *ServerCodec) Decode(msg codec.Msg, reqBuf []byte) ([]byte, error) {
 	frameHead := newDefaultUnaryFrameHead()
 	frameHead.extract(reqBuf)
 	msg.WithFrameHead(frameHead)
-	if frameHead.TotalLen != uint32(len(reqBuf)) {
+	var spill *attachment.Spill
+	if frameHead.isSpilled() {
+		var err error
+		if reqBuf, spill, err = claimSpill(frameHead, reqBuf); err != nil {
+			return nil, err
+		}
+	} else if frameHead.isTruncated(reqBuf) {
+		return s.decodeTooLarge(msg, frameHead, reqBuf)
+	} else if frameHead.TotalLen != uint32(len(reqBuf)) {
 		return nil, fmt.Errorf("total len %d is not actual buf len %d", frameHead.TotalLen, len(reqBuf))
 	}
 	if frameHead.FrameType != uint8(trpcpb.TrpcDataFrameType_TRPC_UNARY_FRAME) { // streaming rpc has its own decoding
@@ -287,17 +447,67 @@ func (s *ServerCodec) Decode(msg codec.Msg, reqBuf []byte) ([]byte, error) {
 	if err := proto.Unmarshal(reqBuf[requestProtocolBegin:requestProtocolEnd], req); err != nil {
 		return nil, err
 	}
-
-	attachmentBegin := frameHead.TotalLen - req.AttachmentSize
-	if s := uint32(len(reqBuf)) - attachmentBegin; s != req.AttachmentSize {
-		return nil, fmt.Errorf("decoding attachment: len of attachment(%d) "+
-			"isn't equal to expected AttachmentSize(%d) ", s, req.AttachmentSize)
+	if spill != nil && (hasTransInfo(req.TransInfo, ReqChecksumKey) || hasTransInfo(req.TransInfo, ReqKeyIDKey)) {
+		var err error
+		if reqBuf, err = loadSpill(reqBuf, spill); err != nil {
+			return nil, err
+		}
+		spill = nil
 	}
 
-	msgWithRequestProtocol(msg, req, reqBuf[attachmentBegin:])
+	var reqBody, attm []byte
+	if spill != nil {
+		if int64(req.AttachmentSize) != spill.Size() {
+			spill.Close()
+			return nil, fmt.Errorf("decoding attachment: size of spilled attachment(%d) "+
+				"isn't equal to expected AttachmentSize(%d) ", spill.Size(), req.AttachmentSize)
+		}
+		reqBody = reqBuf[requestProtocolEnd:]
+	} else {
+		attachmentBegin := frameHead.TotalLen - req.AttachmentSize
+		if s := uint32(len(reqBuf)) - attachmentBegin; s != req.AttachmentSize {
+			return nil, fmt.Errorf("decoding attachment: len of attachment(%d) "+
+				"isn't equal to expected AttachmentSize(%d) ", s, req.AttachmentSize)
+		}
+		requestBodyBegin, requestBodyEnd := requestProtocolEnd, attachmentBegin
+		reqBody, attm = reqBuf[requestBodyBegin:requestBodyEnd], reqBuf[attachmentBegin:]
+	}
+	rspErr := verifyReqChecksum(msg, req, reqBuf[requestProtocolBegin:requestProtocolEnd],
+		reqBuf[requestProtocolEnd:])
+	if rspErr == nil {
+		reqBody, attm, rspErr = openReq(msg, req, reqBody, attm)
+	}
+	msgWithRequestProtocol(msg, req, attm)
+	if spill != nil {
+		attachment.SetServerRequestAttachmentReader(msg, spill)
+	}
+	if rspErr != nil {
+		// request id is acquired, so the error is responded to client.
+		msg.WithServerRspErr(rspErr)
+		return nil, nil
+	}
+	return reqBody, nil
+}
 
-	requestBodyBegin, requestBodyEnd := requestProtocolEnd, attachmentBegin
-	return reqBuf[requestBodyBegin:requestBodyEnd], nil
+// decodeTooLarge decodes the request truncated by the framer for exceeding the max request size,
+// and responds with RetServerMsgExceedLimit.
+func (s *ServerCodec) decodeTooLarge(msg codec.Msg, frameHead *FrameHead, reqBuf []byte) ([]byte, error) {
+	report.ServiceRequestTooLarge.Incr()
+	tooLarge := errs.NewFrameError(errs.RetServerMsgExceedLimit,
+		fmt.Sprintf("request size %d exceeds the max request size", frameHead.TotalLen))
+	if frameHead.FrameType != uint8(trpcpb.TrpcDataFrameType_TRPC_UNARY_FRAME) {
+		// the stream is reset, as the frame is lost.
+		s.streamCodec.buildResetFrame(msg, frameHead, tooLarge)
+		return nil, tooLarge
+	}
+	req := &trpcpb.RequestProtocol{}
+	if err := proto.Unmarshal(reqBuf[frameHeadLen:], req); err != nil {
+		return nil, err
+	}
+	msgWithRequestProtocol(msg, req, nil)
+	// request id is acquired, so the error is responded to client.
+	msg.WithServerRspErr(tooLarge)
+	return nil, nil
 }
 
 func msgWithRequestProtocol(msg codec.Msg, req *trpcpb.RequestProtocol, attm []byte) {
@@ -353,21 +563,48 @@ func (s *ServerCodec) Encode(msg codec.Msg, rspBody []byte) ([]byte, error) {
 
 	rspProtocol := getAndInitResponseProtocol(msg)
 
-	var attm []byte
+	e := envelope.Server(msg)
+	encrypted := e != nil && e.Key != nil
+	var (
+		attm     []byte
+		deferred *attachment.Sized
+	)
 	if a, ok := attachment.ServerResponseAttachment(msg); ok {
 		var err error
-		if attm, err = io.ReadAll(a); err != nil {
-			return nil, fmt.Errorf("encoding attachment: %w", err)
+		deferrable := attachment.ServerDeferredAllowed(msg) && !encrypted && !checksum.ServerEnabled(msg)
+		if attm, deferred, err = readAttachment(a, deferrable); err != nil {
+			return nil, err
+		}
+	}
+	if encrypted {
+		var err error
+		if rspBody, attm, err = sealPayload(e.Key, rspBody, attm,
+			sealedResponse, rspProtocol.GetRequestId(), msg.ServerRPCName()); err != nil {
+			return nil, err
 		}
+		rspProtocol.TransInfo = withKeyID(rspProtocol.TransInfo, RspKeyIDKey, e.Key.ID)
 	}
 	rspProtocol.AttachmentSize = uint32(len(attm))
+	if deferred != nil {
+		rspProtocol.AttachmentSize = uint32(deferred.Size)
+	}
 
 	rspHead, err := proto.Marshal(rspProtocol)
 	if err != nil {
 		return nil, err
 	}
+	if checksum.ServerEnabled(msg) {
+		if rspHead, err = appendRspChecksum(rspHead, rspBody, attm); err != nil {
+			return nil, err
+		}
+	}
 
-	rspBuf, err := frameHead.construct(rspHead, rspBody, attm)
+	var rspBuf []byte
+	if deferred != nil {
+		rspBuf, err = frameHead.constructPrefix(rspHead, rspBody, deferred.Size, true)
+	} else {
+		rspBuf, err = frameHead.construct(rspHead, rspBody, attm)
+	}
 	if errors.Is(err, errHeadOverflowsUint16) {
 		return handleEncodeErr(rspProtocol, frameHead, rspBody, err)
 	}
@@ -376,6 +613,9 @@ func (s *ServerCodec) Encode(msg codec.Msg, rspBody []byte) ([]byte, error) {
 		// If frame len is larger than DefaultMaxFrameSize or overflows uint32, set rspBody nil.
 		return handleEncodeErr(rspProtocol, frameHead, nil, err)
 	}
+	if err == nil && deferred != nil {
+		attachment.SetDeferred(msg, deferred)
+	}
 	return rspBuf, err
 }
 
@@ -438,6 +678,7 @@ func handleEncodeErr(
 	// discard all TransInfo and return RetServerEncodeFail
 	// cover the original no matter what
 	rsp.TransInfo = nil
+	rsp.AttachmentSize = 0 // the attachment is dropped too.
 	rsp.Ret = int32(errs.RetServerEncodeFail)
 	rsp.ErrorMsg = []byte(encodeErr.Error())
 	rspHead, err := proto.Marshal(rsp)
@@ -481,21 +722,52 @@ func (c *ClientCodec) Encode(msg codec.Msg, reqBody []byte) (reqBuf []byte, err
 	frameHead.upgradeProtocol(curProtocolVersion, requestID)
 	msg.WithRequestID(requestID)
 
-	var attm []byte
+	key, err := currentClientKey(msg)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		attm     []byte
+		deferred *attachment.Sized
+	)
 	if a, ok := attachment.ClientRequestAttachment(msg); ok {
-		if attm, err = io.ReadAll(a); err != nil {
-			return nil, fmt.Errorf("encoding attachment: %w", err)
+		deferrable := attachment.ClientDeferredAllowed(msg) && key == nil && !checksum.ClientEnabled(msg)
+		if attm, deferred, err = readAttachment(a, deferrable); err != nil {
+			return nil, err
+		}
+	}
+	if key != nil {
+		if reqBody, attm, err = sealPayload(key, reqBody, attm, sealedRequest, requestID, msg.ClientRPCName()); err != nil {
+			return nil, err
 		}
 	}
 	req.AttachmentSize = uint32(len(attm))
+	if deferred != nil {
+		req.AttachmentSize = uint32(deferred.Size)
+	}
 
 	updateRequestProtocol(req, updateCallerServiceName(msg, c.defaultCaller))
+	if key != nil {
+		req.TransInfo = withKeyID(req.TransInfo, ReqKeyIDKey, key.ID)
+	}
 
 	reqHead, err := proto.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
-	return frameHead.construct(reqHead, reqBody, attm)
+	if checksum.ClientEnabled(msg) {
+		if reqHead, err = appendReqChecksum(reqHead, reqBody, attm); err != nil {
+			return nil, err
+		}
+	}
+	if deferred == nil {
+		return frameHead.construct(reqHead, reqBody, attm)
+	}
+	if reqBuf, err = frameHead.constructPrefix(reqHead, reqBody, deferred.Size, true); err != nil {
+		return nil, err
+	}
+	attachment.SetDeferred(msg, deferred)
+	return reqBuf, nil
 }
 
 // loadOrStoreDefaultRequestProtocol loads the existing RequestProtocol from msg if present.
@@ -595,7 +867,14 @@ func (c *ClientCodec) Decode(msg codec.Msg, rspBuf []byte) (rspBody []byte, err
 	frameHead := newDefaultUnaryFrameHead()
 	frameHead.extract(rspBuf)
 	msg.WithFrameHead(frameHead)
-	if frameHead.TotalLen != uint32(len(rspBuf)) {
+	var spill *attachment.Spill
+	if frameHead.isSpilled() {
+		if rspBuf, spill, err = claimSpill(frameHead, rspBuf); err != nil {
+			return nil, err
+		}
+	} else if frameHead.isTruncated(rspBuf) {
+		return c.decodeTooLarge(msg, frameHead, rspBuf)
+	} else if frameHead.TotalLen != uint32(len(rspBuf)) {
 		return nil, fmt.Errorf("total len %d is not actual buf len %d", frameHead.TotalLen, len(rspBuf))
 	}
 	if trpcpb.TrpcDataFrameType(frameHead.FrameType) != trpcpb.TrpcDataFrameType_TRPC_UNARY_FRAME {
@@ -618,17 +897,69 @@ func (c *ClientCodec) Decode(msg codec.Msg, rspBuf []byte) (rspBody []byte, err
 		return nil, err
 	}
 
+	if spill != nil && (hasTransInfo(rsp.TransInfo, RspChecksumKey) || hasTransInfo(rsp.TransInfo, RspKeyIDKey)) {
+		if rspBuf, err = loadSpill(rspBuf, spill); err != nil {
+			return nil, err
+		}
+		spill = nil
+	}
+
 	attachmentBegin := frameHead.TotalLen - rsp.AttachmentSize
-	if s := uint32(len(rspBuf)) - attachmentBegin; rsp.AttachmentSize != s {
+	if spill != nil {
+		if int64(rsp.AttachmentSize) != spill.Size() {
+			spill.Close()
+			return nil, fmt.Errorf("decoding attachment:(%d) size of spilled attachment"+
+				"isn't equal to expected AttachmentSize(%d)", spill.Size(), rsp.AttachmentSize)
+		}
+		attachmentBegin = uint32(len(rspBuf))
+	} else if s := uint32(len(rspBuf)) - attachmentBegin; rsp.AttachmentSize != s {
 		return nil, fmt.Errorf("decoding attachment:(%d) len of attachment"+
 			"isn't equal to expected AttachmentSize(%d)", s, rsp.AttachmentSize)
 	}
-	if err := updateMsg(msg, frameHead, rsp, rspBuf[attachmentBegin:]); err != nil {
+	if err := verifyRspChecksum(rsp, rspBuf[responseProtocolBegin:responseProtocolEnd],
+		rspBuf[responseProtocolEnd:]); err != nil {
+		msg.WithClientRspErr(err)
+		return nil, nil
+	}
+	bodyBegin, bodyEnd := responseProtocolEnd, attachmentBegin
+	rspBody, attm, err := openRsp(msg, rsp, rspBuf[bodyBegin:bodyEnd], rspBuf[attachmentBegin:])
+	if err != nil {
+		msg.WithClientRspErr(err)
+		return nil, nil
+	}
+	if err := updateMsg(msg, frameHead, rsp, attm); err != nil {
 		return nil, err
 	}
+	if spill != nil {
+		attachment.SetClientResponseAttachmentReader(msg, spill)
+	}
+	return rspBody, nil
+}
 
-	bodyBegin, bodyEnd := responseProtocolEnd, attachmentBegin
-	return rspBuf[bodyBegin:bodyEnd], nil
+// decodeTooLarge decodes the response truncated by the framer for exceeding the max response size,
+// and sets RetClientMsgExceedLimit as the response error.
+func (c *ClientCodec) decodeTooLarge(msg codec.Msg, frameHead *FrameHead, rspBuf []byte) ([]byte, error) {
+	report.ClientResponseTooLarge.Incr()
+	tooLarge := errs.NewFrameError(errs.RetClientMsgExceedLimit,
+		fmt.Sprintf("response size %d exceeds the max response size", frameHead.TotalLen))
+	if trpcpb.TrpcDataFrameType(frameHead.FrameType) != trpcpb.TrpcDataFrameType_TRPC_UNARY_FRAME {
+		// the stream is broken, as the frame is lost.
+		msg.WithClientRspErr(tooLarge)
+		return nil, nil
+	}
+	rsp, err := loadOrStoreResponseHead(msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(rspBuf[frameHeadLen:], rsp); err != nil {
+		return nil, err
+	}
+	// request id mismatch is still checked for the truncated response.
+	if err := updateMsg(msg, frameHead, rsp, nil); err != nil {
+		return nil, err
+	}
+	msg.WithClientRspErr(tooLarge)
+	return nil, nil
 }
 
 func loadOrStoreResponseHead(msg codec.Msg) (*trpcpb.ResponseProtocol, error) {
@@ -33,6 +33,7 @@ const (
 	CompressTypeBlockSnappy
 	CompressTypeStreamLZ4
 	CompressTypeBlockLZ4
+	CompressTypeZstd
 )
 
 var compressors = make(map[int]Compressor)
@@ -50,7 +51,9 @@ func GetCompressor(compressType int) Compressor {
 
 // Compress returns the compressed data, the data is compressed
 // by a specific compressor.
-func Compress(compressorType int, in []byte) ([]byte, error) {
+// If a policy is given, Compress returns in as it is and ErrCompressSkipped when the policy decides
+// that in is not worth compressing, in which case in must be sent with CompressTypeNoop.
+func Compress(compressorType int, in []byte, policy ...CompressPolicy) ([]byte, error) {
 	// Explicitly check for noop to avoid accessing the map.
 	if compressorType == CompressTypeNoop {
 		return in, nil
@@ -62,7 +65,19 @@ func Compress(compressorType int, in []byte) ([]byte, error) {
 	if compressor == nil {
 		return nil, errors.New("compressor not registered")
 	}
-	return compressor.Compress(in)
+	return CompressWith(compressor, in, policy...)
+}
+
+// CompressWith is like Compress, but compresses with the given compressor,
+// such as the one returned by GetZstdCompressor.
+func CompressWith(compressor Compressor, in []byte, policy ...CompressPolicy) ([]byte, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	if len(policy) == 0 {
+		return compressor.Compress(in)
+	}
+	return policy[0].compress(compressor, in)
 }
 
 // Decompress returns the decompressed data, the data is decompressed
@@ -81,3 +96,53 @@ func Decompress(compressorType int, in []byte) ([]byte, error) {
 	}
 	return compressor.Decompress(in)
 }
+
+// compressSampleSize is the size of the sample used to estimate the compression ratio.
+const compressSampleSize = 4096
+
+// CompressPolicy decides whether a message is worth compressing.
+// The zero value compresses every message.
+type CompressPolicy struct {
+	// MinSize is the min size of messages to compress, smaller ones are sent uncompressed.
+	MinSize int
+	// MaxRatio is the max ratio of the compressed size to the original size, messages which can't
+	// be compressed to it are sent uncompressed. For large messages, the ratio is estimated by
+	// compressing a sample first. Zero disables the check.
+	MaxRatio float64
+}
+
+// ErrCompressSkipped is returned by Compress along with the data uncompressed,
+// when the policy decides that the data is not worth compressing.
+var ErrCompressSkipped = errors.New("compression skipped by policy")
+
+// RspCompressTypeKey is the key in trans info of the compress type of the response, which is sent by the
+// client along with a request sent uncompressed by its policy, as the frame tells the server only
+// the compress type of the request body.
+const RspCompressTypeKey = "trpc-rsp-compress-type"
+
+// compress compresses in by the compressor, unless the policy decides not to.
+func (p CompressPolicy) compress(compressor Compressor, in []byte) ([]byte, error) {
+	if len(in) < p.MinSize {
+		return in, ErrCompressSkipped
+	}
+	if p.MaxRatio <= 0 {
+		return compressor.Compress(in)
+	}
+	if len(in) > compressSampleSize {
+		sample, err := compressor.Compress(in[:compressSampleSize])
+		if err != nil {
+			return nil, err
+		}
+		if float64(len(sample)) > p.MaxRatio*compressSampleSize {
+			return in, ErrCompressSkipped
+		}
+	}
+	out, err := compressor.Compress(in)
+	if err != nil {
+		return nil, err
+	}
+	if float64(len(out)) > p.MaxRatio*float64(len(in)) {
+		return in, ErrCompressSkipped
+	}
+	return out, nil
+}
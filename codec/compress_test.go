@@ -14,6 +14,7 @@
 package codec_test
 
 import (
+	"bytes"
 	"crypto/rand"
 	"testing"
 
@@ -75,6 +76,43 @@ func TestCompress(t *testing.T) {
 	})
 }
 
+func TestCompressPolicy(t *testing.T) {
+	text := bytes.Repeat([]byte("compressible "), 1000)
+	random := make([]byte, 10000)
+	_, err := rand.Read(random)
+	require.Nil(t, err)
+
+	out, err := codec.Compress(codec.CompressTypeSnappy, text, codec.CompressPolicy{})
+	require.Nil(t, err)
+	require.Less(t, len(out), len(text))
+
+	out, err = codec.Compress(codec.CompressTypeNoop, text, codec.CompressPolicy{MinSize: 1 << 20})
+	require.Nil(t, err)
+	require.Equal(t, text, out)
+
+	// Messages below the min size are not compressed.
+	out, err = codec.Compress(codec.CompressTypeSnappy, text[:100], codec.CompressPolicy{MinSize: 1024})
+	require.ErrorIs(t, err, codec.ErrCompressSkipped)
+	require.Equal(t, text[:100], out)
+
+	// Incompressible messages are not compressed, either judged by the sample or by the whole.
+	policy := codec.CompressPolicy{MaxRatio: 0.9}
+	for _, in := range [][]byte{random, random[:1000]} {
+		out, err = codec.Compress(codec.CompressTypeSnappy, in, policy)
+		require.ErrorIs(t, err, codec.ErrCompressSkipped)
+		require.Equal(t, in, out)
+	}
+	out, err = codec.Compress(codec.CompressTypeSnappy, text, policy)
+	require.Nil(t, err)
+	got, err := codec.Decompress(codec.CompressTypeSnappy, out)
+	require.Nil(t, err)
+	require.Equal(t, text, got)
+
+	_, err = codec.Compress(100, text, policy)
+	require.NotNil(t, err)
+	require.NotErrorIs(t, err, codec.ErrCompressSkipped)
+}
+
 func TestRegisterNegativeCompress(t *testing.T) {
 	const negativeCompressType = -1
 	codec.RegisterCompressor(negativeCompressType, &codec.NoopCompress{})
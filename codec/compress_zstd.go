@@ -0,0 +1,208 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdDictMagic is the magic number of the zstd dictionary format.
+const zstdDictMagic = 0xec30a437
+
+func init() {
+	c, err := NewZstdCompressor()
+	if err != nil {
+		panic(err)
+	}
+	RegisterCompressor(CompressTypeZstd, c)
+}
+
+var zstdDicts = struct {
+	sync.RWMutex
+	m           map[uint32][]byte
+	compressors map[uint32]*ZstdCompressor // compressors with the dictionaries, created on demand
+	decoder     *zstd.Decoder
+}{m: make(map[uint32][]byte), compressors: make(map[uint32]*ZstdCompressor)}
+
+// RegisterZstdDictionary registers a zstd dictionary with its id. The dictionary must be in the
+// zstd dictionary format, as generated by `zstd --train`, and the id must match the one in it.
+// All registered dictionaries are available for decompression, since the id of the dictionary used
+// is carried by zstd frames. Dictionaries should be registered before creating compressors using them.
+func RegisterZstdDictionary(id uint32, dict []byte) error {
+	dictID, err := ZstdDictionaryID(dict)
+	if err != nil {
+		return err
+	}
+	if dictID != id {
+		return fmt.Errorf("zstd dictionary id mismatch, registered %d, actual %d", id, dictID)
+	}
+	zstdDicts.Lock()
+	defer zstdDicts.Unlock()
+	dicts := [][]byte{dict}
+	for i, d := range zstdDicts.m {
+		if i != id {
+			dicts = append(dicts, d)
+		}
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dicts...))
+	if err != nil {
+		return fmt.Errorf("new zstd decoder with dictionary %d: %w", id, err)
+	}
+	// Decompression holds the read lock while decoding, so the previous decoder is no longer in use.
+	if zstdDicts.decoder != nil {
+		zstdDicts.decoder.Close()
+	}
+	zstdDicts.m[id] = dict
+	zstdDicts.decoder = decoder
+	delete(zstdDicts.compressors, id)
+	return nil
+}
+
+// ZstdDictionaryID returns the id in the zstd dictionary.
+func ZstdDictionaryID(dict []byte) (uint32, error) {
+	if len(dict) < 8 || binary.LittleEndian.Uint32(dict[:4]) != zstdDictMagic {
+		return 0, errors.New("invalid zstd dictionary")
+	}
+	return binary.LittleEndian.Uint32(dict[4:8]), nil
+}
+
+// GetZstdCompressor returns the compressor which compresses with the registered dictionary of id
+// at the default level, or the compressor registered for CompressTypeZstd if id is 0.
+// It's used to select dictionaries by backends and services, as the data compressed by any of them
+// is decompressed by CompressTypeZstd.
+func GetZstdCompressor(id uint32) (Compressor, error) {
+	if id == 0 {
+		if c := GetCompressor(CompressTypeZstd); c != nil {
+			return c, nil
+		}
+		return nil, errors.New("compressor not registered")
+	}
+	zstdDicts.RLock()
+	c, ok := zstdDicts.compressors[id]
+	zstdDicts.RUnlock()
+	if ok {
+		return c, nil
+	}
+	c, err := NewZstdCompressor(WithZstdDictionary(id))
+	if err != nil {
+		return nil, err
+	}
+	zstdDicts.Lock()
+	defer zstdDicts.Unlock()
+	if cached, ok := zstdDicts.compressors[id]; ok {
+		return cached, nil
+	}
+	zstdDicts.compressors[id] = c
+	return c, nil
+}
+
+// CompressZstd is like Compress with CompressTypeZstd, but compresses with the registered dictionary of id,
+// or without a dictionary if id is 0.
+func CompressZstd(id uint32, in []byte, policy ...CompressPolicy) ([]byte, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	compressor, err := GetZstdCompressor(id)
+	if err != nil {
+		return nil, err
+	}
+	return CompressWith(compressor, in, policy...)
+}
+
+// ZstdOption is the option of ZstdCompressor.
+type ZstdOption func(*zstdOptions)
+
+type zstdOptions struct {
+	level  int
+	dictID uint32
+}
+
+// WithZstdLevel sets the zstd compression level, which is roughly mapped to the levels of the
+// zstd command line tool. Zero means the default level.
+func WithZstdLevel(level int) ZstdOption {
+	return func(o *zstdOptions) {
+		o.level = level
+	}
+}
+
+// WithZstdDictionary sets the id of the registered dictionary used for compression.
+func WithZstdDictionary(id uint32) ZstdOption {
+	return func(o *zstdOptions) {
+		o.dictID = id
+	}
+}
+
+// ZstdCompressor is zstd compressor.
+type ZstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// NewZstdCompressor returns a zstd compressor instance.
+func NewZstdCompressor(opts ...ZstdOption) (*ZstdCompressor, error) {
+	var o zstdOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	eopts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	if o.level != 0 {
+		eopts = append(eopts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(o.level)))
+	}
+	if o.dictID != 0 {
+		zstdDicts.RLock()
+		dict, ok := zstdDicts.m[o.dictID]
+		zstdDicts.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("zstd dictionary %d not registered", o.dictID)
+		}
+		eopts = append(eopts, zstd.WithEncoderDict(dict))
+	}
+	encoder, err := zstd.NewWriter(nil, eopts...)
+	if err != nil {
+		return nil, fmt.Errorf("new zstd encoder: %w", err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("new zstd decoder: %w", err)
+	}
+	return &ZstdCompressor{encoder: encoder, decoder: decoder}, nil
+}
+
+// Compress returns binary data compressed by zstd.
+func (c *ZstdCompressor) Compress(in []byte) ([]byte, error) {
+	if len(in) == 0 {
+		return in, nil
+	}
+	return c.encoder.EncodeAll(in, nil), nil
+}
+
+// Decompress returns binary data decompressed by zstd.
+func (c *ZstdCompressor) Decompress(in []byte) ([]byte, error) {
+	if len(in) == 0 {
+		return in, nil
+	}
+	// Use the decoder with dictionaries only if there are any registered.
+	zstdDicts.RLock()
+	defer zstdDicts.RUnlock()
+	decoder := zstdDicts.decoder
+	if decoder == nil {
+		decoder = c.decoder
+	}
+	return decoder.DecodeAll(in, nil)
+}
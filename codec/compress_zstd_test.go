@@ -0,0 +1,142 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+)
+
+func TestZstd(t *testing.T) {
+	in := bytes.Repeat([]byte("A long time ago in a galaxy far, far away..."), 100)
+	out, err := codec.Compress(codec.CompressTypeZstd, in)
+	require.Nil(t, err)
+	require.Less(t, len(out), len(in))
+	got, err := codec.Decompress(codec.CompressTypeZstd, out)
+	require.Nil(t, err)
+	require.Equal(t, in, got)
+
+	for _, level := range []int{1, 3, 9, 19} {
+		c, err := codec.NewZstdCompressor(codec.WithZstdLevel(level))
+		require.Nil(t, err)
+		out, err := c.Compress(in)
+		require.Nil(t, err)
+		got, err := c.Decompress(out)
+		require.Nil(t, err)
+		require.Equal(t, in, got)
+	}
+
+	c, err := codec.NewZstdCompressor()
+	require.Nil(t, err)
+	out, err = c.Compress(nil)
+	require.Nil(t, err)
+	require.Empty(t, out)
+	_, err = c.Decompress([]byte("invalid zstd data"))
+	require.NotNil(t, err)
+}
+
+func TestZstdDictionary(t *testing.T) {
+	dict, err := os.ReadFile("testdata/zstd.dict")
+	require.Nil(t, err)
+	id := binary.LittleEndian.Uint32(dict[4:8])
+
+	_, err = codec.NewZstdCompressor(codec.WithZstdDictionary(id))
+	require.NotNil(t, err)
+	require.NotNil(t, codec.RegisterZstdDictionary(id+1, dict))
+	require.NotNil(t, codec.RegisterZstdDictionary(id, []byte("invalid dictionary")))
+	require.Nil(t, codec.RegisterZstdDictionary(id, dict))
+
+	c, err := codec.NewZstdCompressor(codec.WithZstdDictionary(id))
+	require.Nil(t, err)
+	in := []byte("A long time ago in a galaxy far, far away...")
+	out, err := c.Compress(in)
+	require.Nil(t, err)
+	// The default compressor decompresses with any registered dictionary.
+	got, err := codec.Decompress(codec.CompressTypeZstd, out)
+	require.Nil(t, err)
+	require.Equal(t, in, got)
+
+	// Registering again replaces the decoder, and the previous one is closed.
+	require.Nil(t, codec.RegisterZstdDictionary(id, dict))
+	got, err = codec.Decompress(codec.CompressTypeZstd, out)
+	require.Nil(t, err)
+	require.Equal(t, in, got)
+}
+
+func TestGetZstdCompressor(t *testing.T) {
+	dict, err := os.ReadFile("testdata/zstd.dict")
+	require.Nil(t, err)
+	id, err := codec.ZstdDictionaryID(dict)
+	require.Nil(t, err)
+	require.Nil(t, codec.RegisterZstdDictionary(id, dict))
+
+	c, err := codec.GetZstdCompressor(0)
+	require.Nil(t, err)
+	require.Equal(t, codec.GetCompressor(codec.CompressTypeZstd), c)
+	_, err = codec.GetZstdCompressor(id + 1)
+	require.NotNil(t, err)
+
+	c, err = codec.GetZstdCompressor(id)
+	require.Nil(t, err)
+	cached, err := codec.GetZstdCompressor(id)
+	require.Nil(t, err)
+	require.Same(t, c, cached)
+
+	in := []byte("A long time ago in a galaxy far, far away...")
+	out, err := codec.CompressWith(c, in, codec.CompressPolicy{})
+	require.Nil(t, err)
+	plain, err := codec.Compress(codec.CompressTypeZstd, in)
+	require.Nil(t, err)
+	require.NotEqual(t, plain, out)
+	got, err := codec.Decompress(codec.CompressTypeZstd, out)
+	require.Nil(t, err)
+	require.Equal(t, in, got)
+}
+
+func TestCompressZstd(t *testing.T) {
+	dict, err := os.ReadFile("testdata/zstd.dict")
+	require.Nil(t, err)
+	id, err := codec.ZstdDictionaryID(dict)
+	require.Nil(t, err)
+	require.Nil(t, codec.RegisterZstdDictionary(id, dict))
+
+	in := []byte("A long time ago in a galaxy far, far away...")
+	plain, err := codec.Compress(codec.CompressTypeZstd, in)
+	require.Nil(t, err)
+	out, err := codec.CompressZstd(0, in)
+	require.Nil(t, err)
+	require.Equal(t, plain, out)
+
+	out, err = codec.CompressZstd(id, in, codec.CompressPolicy{})
+	require.Nil(t, err)
+	require.NotEqual(t, plain, out)
+	got, err := codec.Decompress(codec.CompressTypeZstd, out)
+	require.Nil(t, err)
+	require.Equal(t, in, got)
+
+	out, err = codec.CompressZstd(id, in, codec.CompressPolicy{MinSize: len(in) + 1})
+	require.ErrorIs(t, err, codec.ErrCompressSkipped)
+	require.Equal(t, in, out)
+	_, err = codec.CompressZstd(id+1, in)
+	require.NotNil(t, err)
+	out, err = codec.CompressZstd(id+1, nil)
+	require.Nil(t, err)
+	require.Empty(t, out)
+}
@@ -75,3 +75,59 @@ func IsSafeFramer(f interface{}) bool {
 	}
 	return false
 }
+
+// HeartbeatFramerBuilder is a special FramerBuilder whose protocol defines application-level
+// heartbeat frames. Server transports use it to answer pings without involving handlers.
+type HeartbeatFramerBuilder interface {
+	FramerBuilder
+	// Pong returns the pong frame answering frame, ok is false if frame is not a heartbeat ping.
+	Pong(frame []byte) (pong []byte, ok bool)
+}
+
+// HeartbeatPong returns the pong frame answering frame if fb implements HeartbeatFramerBuilder and
+// frame is a heartbeat ping. Otherwise, it returns false.
+func HeartbeatPong(fb FramerBuilder, frame []byte) ([]byte, bool) {
+	hb, ok := fb.(HeartbeatFramerBuilder)
+	if !ok {
+		return nil, false
+	}
+	return hb.Pong(frame)
+}
+
+// SizeLimitedFramerBuilder is a special FramerBuilder which can limit the size of frames it reads.
+// Frames larger than the limit should be discarded without allocating their bodies, and be reported
+// in a protocol-specific way, so that the codec can respond with a clear error.
+type SizeLimitedFramerBuilder interface {
+	FramerBuilder
+	// WithMaxFrameSize returns a FramerBuilder whose framers limit the frame size to n bytes.
+	WithMaxFrameSize(n int) FramerBuilder
+}
+
+// WithMaxFrameSize returns a FramerBuilder limiting the frame size to n bytes if fb implements
+// SizeLimitedFramerBuilder and n > 0. Otherwise, fb is returned as is.
+func WithMaxFrameSize(fb FramerBuilder, n int) FramerBuilder {
+	sl, ok := fb.(SizeLimitedFramerBuilder)
+	if !ok || n <= 0 {
+		return fb
+	}
+	return sl.WithMaxFrameSize(n)
+}
+
+// SpillingFramerBuilder is a special FramerBuilder which can spill large attachments of frames
+// to temp files, instead of reading them into memory.
+type SpillingFramerBuilder interface {
+	FramerBuilder
+	// WithAttachmentSpill returns a FramerBuilder whose framers spill attachments larger than
+	// threshold bytes to temp files in dir.
+	WithAttachmentSpill(threshold int, dir string) FramerBuilder
+}
+
+// WithAttachmentSpill returns a FramerBuilder spilling attachments larger than threshold bytes to temp
+// files in dir if fb implements SpillingFramerBuilder and threshold > 0. Otherwise, fb is returned as is.
+func WithAttachmentSpill(fb FramerBuilder, threshold int, dir string) FramerBuilder {
+	sb, ok := fb.(SpillingFramerBuilder)
+	if !ok || threshold <= 0 {
+		return fb
+	}
+	return sb.WithAttachmentSpill(threshold, dir)
+}
@@ -57,6 +57,14 @@ const (
 	SerializationTypeGet = 130
 	// SerializationTypeFormData is used to handle form data.
 	SerializationTypeFormData = 131
+	// SerializationTypeMsgPack is MessagePack serialization code.
+	SerializationTypeMsgPack = 132
+	// SerializationTypeCBOR is CBOR serialization code.
+	SerializationTypeCBOR = 133
+	// SerializationTypeThriftBinary is Thrift binary protocol serialization code.
+	SerializationTypeThriftBinary = 134
+	// SerializationTypeThriftCompact is Thrift compact protocol serialization code.
+	SerializationTypeThriftCompact = 135
 )
 
 var serializers = make(map[int]Serializer)
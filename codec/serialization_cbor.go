@@ -0,0 +1,50 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	RegisterSerializer(SerializationTypeCBOR, &CBORSerialization{})
+}
+
+// CBORSerialization provides CBOR serialization mode.
+// Proto messages are serialized as maps keyed by proto field names, other objects are serialized
+// by their struct fields, which may be tagged by `cbor` or `json`.
+type CBORSerialization struct{}
+
+// Unmarshal deserializes the in bytes into body.
+func (*CBORSerialization) Unmarshal(in []byte, body interface{}) error {
+	m, ok := body.(proto.Message)
+	if !ok {
+		return cbor.Unmarshal(in, body)
+	}
+	var v interface{}
+	if err := cbor.Unmarshal(in, &v); err != nil {
+		return err
+	}
+	proto.Reset(m)
+	return protoFromValue(v, m.ProtoReflect())
+}
+
+// Marshal returns the serialized bytes in CBOR protocol.
+func (*CBORSerialization) Marshal(body interface{}) ([]byte, error) {
+	if m, ok := body.(proto.Message); ok {
+		return cbor.Marshal(protoToValue(m.ProtoReflect()))
+	}
+	return cbor.Marshal(body)
+}
@@ -0,0 +1,64 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	RegisterSerializer(SerializationTypeMsgPack, &MsgPackSerialization{})
+}
+
+// MsgPackSerialization provides MessagePack serialization mode.
+// Proto messages are serialized as maps keyed by proto field names, other objects are serialized
+// by their struct fields, which may be tagged by `msgpack` or `json`.
+type MsgPackSerialization struct{}
+
+// Unmarshal deserializes the in bytes into body.
+func (*MsgPackSerialization) Unmarshal(in []byte, body interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(in))
+	dec.SetCustomStructTag("json")
+	m, ok := body.(proto.Message)
+	if !ok {
+		return dec.Decode(body)
+	}
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+	v, err := dec.DecodeInterface()
+	if err != nil {
+		return err
+	}
+	proto.Reset(m)
+	return protoFromValue(v, m.ProtoReflect())
+}
+
+// Marshal returns the serialized bytes in MessagePack protocol.
+func (*MsgPackSerialization) Marshal(body interface{}) ([]byte, error) {
+	if m, ok := body.(proto.Message); ok {
+		body = protoToValue(m.ProtoReflect())
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
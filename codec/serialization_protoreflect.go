@@ -0,0 +1,280 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protoToValue converts a proto message to a schemaless value for self-describing formats,
+// such as MessagePack and CBOR. The message is converted to a map keyed by proto field names,
+// in which enums are numbers, repeated fields are slices and map fields are maps.
+// Only populated fields are converted.
+func protoToValue(m protoreflect.Message) map[string]interface{} {
+	out := make(map[string]interface{})
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		out[string(fd.Name())] = protoFieldToValue(fd, v)
+		return true
+	})
+	return out
+}
+
+func protoFieldToValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		l := v.List()
+		out := make([]interface{}, l.Len())
+		for i := range out {
+			out[i] = protoSingularToValue(fd, l.Get(i))
+		}
+		return out
+	case fd.IsMap():
+		out := make(map[interface{}]interface{}, v.Map().Len())
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			out[k.Interface()] = protoSingularToValue(fd.MapValue(), v)
+			return true
+		})
+		return out
+	default:
+		return protoSingularToValue(fd, v)
+	}
+}
+
+func protoSingularToValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return int32(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoToValue(v.Message())
+	default:
+		return v.Interface()
+	}
+}
+
+// protoFromValue sets m by a schemaless value decoded from self-describing formats, which is the
+// reverse of protoToValue. Fields may be keyed by either proto names or json names, and enums may be
+// either numbers or names. Unknown fields are discarded.
+func protoFromValue(v interface{}, m protoreflect.Message) error {
+	fields := m.Descriptor().Fields()
+	return rangeMapValue(v, func(k, v interface{}) error {
+		name, ok := k.(string)
+		if !ok {
+			return fmt.Errorf("invalid field name %v of %s", k, m.Descriptor().FullName())
+		}
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fields.ByJSONName(name)
+		}
+		if fd == nil || v == nil {
+			return nil
+		}
+		if err := protoFieldFromValue(v, m, fd); err != nil {
+			return fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return nil
+	})
+}
+
+func protoFieldFromValue(v interface{}, m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	switch {
+	case fd.IsList():
+		s, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("want a list, got %T", v)
+		}
+		l := m.Mutable(fd).List()
+		for _, e := range s {
+			ev, err := protoSingularFromValue(e, fd, l.NewElement)
+			if err != nil {
+				return err
+			}
+			l.Append(ev)
+		}
+		return nil
+	case fd.IsMap():
+		mp := m.Mutable(fd).Map()
+		return rangeMapValue(v, func(k, e interface{}) error {
+			kv, err := protoScalarFromValue(k, fd.MapKey())
+			if err != nil {
+				return err
+			}
+			ev, err := protoSingularFromValue(e, fd.MapValue(), mp.NewValue)
+			if err != nil {
+				return err
+			}
+			mp.Set(kv.MapKey(), ev)
+			return nil
+		})
+	case fd.Message() != nil:
+		return protoFromValue(v, m.Mutable(fd).Message())
+	default:
+		sv, err := protoScalarFromValue(v, fd)
+		if err != nil {
+			return err
+		}
+		m.Set(fd, sv)
+		return nil
+	}
+}
+
+func protoSingularFromValue(
+	v interface{},
+	fd protoreflect.FieldDescriptor,
+	newMessage func() protoreflect.Value,
+) (protoreflect.Value, error) {
+	if fd.Message() == nil {
+		return protoScalarFromValue(v, fd)
+	}
+	mv := newMessage()
+	if v == nil {
+		return mv, nil
+	}
+	return mv, protoFromValue(v, mv.Message())
+}
+
+func protoScalarFromValue(v interface{}, fd protoreflect.FieldDescriptor) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if b, ok := v.(bool); ok {
+			return protoreflect.ValueOfBool(b), nil
+		}
+	case protoreflect.EnumKind:
+		if s, ok := v.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+			return protoreflect.Value{}, fmt.Errorf("invalid enum value %q of %s", s, fd.Enum().FullName())
+		}
+		if i, ok := toInt64(v); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+			return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if i, ok := toInt64(v); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+			return protoreflect.ValueOfInt32(int32(i)), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if i, ok := toInt64(v); ok {
+			return protoreflect.ValueOfInt64(i), nil
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if u, ok := toUint64(v); ok && u <= math.MaxUint32 {
+			return protoreflect.ValueOfUint32(uint32(u)), nil
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if u, ok := toUint64(v); ok {
+			return protoreflect.ValueOfUint64(u), nil
+		}
+	case protoreflect.FloatKind:
+		if f, ok := toFloat64(v); ok {
+			return protoreflect.ValueOfFloat32(float32(f)), nil
+		}
+	case protoreflect.DoubleKind:
+		if f, ok := toFloat64(v); ok {
+			return protoreflect.ValueOfFloat64(f), nil
+		}
+	case protoreflect.StringKind:
+		switch s := v.(type) {
+		case string:
+			return protoreflect.ValueOfString(s), nil
+		case []byte:
+			return protoreflect.ValueOfString(string(s)), nil
+		}
+	case protoreflect.BytesKind:
+		switch b := v.(type) {
+		case []byte:
+			return protoreflect.ValueOfBytes(b), nil
+		case string:
+			return protoreflect.ValueOfBytes([]byte(b)), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("invalid value %v of type %T for %s", v, v, fd.Kind())
+}
+
+// rangeMapValue calls f for each entry of a decoded map, which may be keyed by strings or anything.
+func rangeMapValue(v interface{}, f func(k, v interface{}) error) error {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			if err := f(k, v); err != nil {
+				return err
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, v := range m {
+			if err := f(k, v); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("want a map, got %T", v)
+	}
+	return nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch i := v.(type) {
+	case int:
+		return int64(i), true
+	case int8:
+		return int64(i), true
+	case int16:
+		return int64(i), true
+	case int32:
+		return int64(i), true
+	case int64:
+		return i, true
+	case uint8, uint16, uint32, uint64, uint:
+		u, _ := toUint64(v)
+		return int64(u), u <= math.MaxInt64
+	}
+	return 0, false
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch u := v.(type) {
+	case uint:
+		return uint64(u), true
+	case uint8:
+		return uint64(u), true
+	case uint16:
+		return uint64(u), true
+	case uint32:
+		return uint64(u), true
+	case uint64:
+		return u, true
+	case int, int8, int16, int32, int64:
+		i, _ := toInt64(v)
+		return uint64(i), i >= 0
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case float32:
+		return float64(f), true
+	case float64:
+		return f, true
+	}
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	if u, ok := toUint64(v); ok {
+		return float64(u), true
+	}
+	return 0, false
+}
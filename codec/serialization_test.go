@@ -14,10 +14,14 @@
 package codec_test
 
 import (
+	"math"
 	"testing"
 
 	flatbuffers "github.com/google/flatbuffers/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
 
 	"trpc.group/trpc-go/trpc-go/codec"
@@ -235,3 +239,81 @@ func TestXML(t *testing.T) {
 		assert.Equal(t, tt.In.B, got.B)
 	}
 }
+
+func TestMsgPackAndCBOR(t *testing.T) {
+	type Data struct {
+		A int               `json:"a"`
+		B string            `json:"b"`
+		C []float64         `json:"c"`
+		D map[string][]byte `json:"d"`
+	}
+	reqs := []proto.Message{
+		&trpcpb.RequestProtocol{
+			Version:   1,
+			RequestId: math.MaxUint32,
+			Callee:    []byte("callee"),
+			TransInfo: map[string][]byte{"k": {0, 1, 2}},
+		},
+		&descriptorpb.FileDescriptorProto{
+			Name:       proto.String("file.proto"),
+			Dependency: []string{"a.proto", "b.proto"},
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Message"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:   proto.String("field"),
+					Number: proto.Int32(-1),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+				}},
+			}},
+			Options: &descriptorpb.FileOptions{JavaPackage: proto.String("java")},
+		},
+	}
+	for _, serializationType := range []int{codec.SerializationTypeMsgPack, codec.SerializationTypeCBOR} {
+		in := Data{A: 1, B: "b", C: []float64{1.5}, D: map[string][]byte{"k": []byte("v")}}
+		buf, err := codec.Marshal(serializationType, in)
+		require.Nil(t, err)
+		got := Data{}
+		require.Nil(t, codec.Unmarshal(serializationType, buf, &got))
+		require.Equal(t, in, got)
+
+		for _, req := range reqs {
+			buf, err = codec.Marshal(serializationType, req)
+			require.Nil(t, err)
+			gotReq := req.ProtoReflect().New().Interface()
+			require.Nil(t, codec.Unmarshal(serializationType, buf, gotReq))
+			require.True(t, proto.Equal(req, gotReq), "got %v", gotReq)
+		}
+
+		// Fields may be keyed by json names, and enums may be names.
+		buf, err = codec.Marshal(serializationType, map[string]interface{}{
+			"name":    "json",
+			"unknown": 1,
+			"field":   []interface{}{map[string]interface{}{"jsonName": "field", "type": "TYPE_INT64"}},
+		})
+		require.Nil(t, err)
+		gotReq := &descriptorpb.DescriptorProto{Name: proto.String("to be reset")}
+		require.Nil(t, codec.Unmarshal(serializationType, buf, gotReq))
+		require.Equal(t, "json", gotReq.GetName())
+		require.Equal(t, "field", gotReq.Field[0].GetJsonName())
+		require.Equal(t, descriptorpb.FieldDescriptorProto_TYPE_INT64, gotReq.Field[0].GetType())
+
+		for _, invalid := range []map[string]interface{}{
+			{"version": -1},
+			{"request_id": uint64(math.MaxUint32 + 1)},
+			{"callee": 1},
+			{"trans_info": []string{"a"}},
+			{"trans_info": map[string]interface{}{"k": 1}},
+			{"caller": map[string]interface{}{}},
+		} {
+			buf, err = codec.Marshal(serializationType, invalid)
+			require.Nil(t, err)
+			require.NotNil(t, codec.Unmarshal(serializationType, buf, &trpcpb.RequestProtocol{}), "%v", invalid)
+		}
+		buf, err = codec.Marshal(serializationType, map[string]interface{}{"field": "a"})
+		require.Nil(t, err)
+		require.NotNil(t, codec.Unmarshal(serializationType, buf, &descriptorpb.DescriptorProto{}))
+		buf, err = codec.Marshal(serializationType, map[string]interface{}{"field": []interface{}{map[string]interface{}{"type": "INVALID"}}})
+		require.Nil(t, err)
+		require.NotNil(t, codec.Unmarshal(serializationType, buf, &descriptorpb.DescriptorProto{}))
+	}
+}
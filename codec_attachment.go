@@ -0,0 +1,173 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
+)
+
+// Large attachments of unary frames may be spilled to temp files by framers. The attachment of a spilled
+// frame is replaced by the 8 bytes id of the spill, and the frame is marked by the spilled bit of the
+// reserved byte of the frame head. The total len of the frame head still counts the attachment.
+const (
+	frameReservedSpilled = uint8(1) // the reserved bit marking spilled frames
+	spillIDLen           = 8        // length of the spill id
+
+	// attachmentSizeField is the field number of attachment_size of both RequestProtocol and ResponseProtocol.
+	attachmentSizeField = protowire.Number(12)
+)
+
+var errSpillReleased = errors.New("spilled attachment has been released before decoding")
+
+// isSpilled returns whether the attachment of the frame is spilled.
+func (h *FrameHead) isSpilled() bool {
+	return h.FrameReserved&frameReservedSpilled != 0
+}
+
+// readSpilledFrame reads the frame whose attachment may be larger than spillThreshold. If so, the attachment
+// is spilled, and the rest of the frame is read into memory.
+func (f *framer) readSpilledFrame(totalLen uint32) ([]byte, error) {
+	headLen := uint32(frameHeadLen) + uint32(binary.BigEndian.Uint16(f.header[8:10]))
+	if headLen > totalLen {
+		return nil, fmt.Errorf(
+			"trpc framer: read frame head len %d > total len %d, invalid", headLen, totalLen)
+	}
+	head := make([]byte, headLen)
+	if _, err := io.ReadFull(f.reader, head[frameHeadLen:]); err != nil {
+		return nil, err
+	}
+	attmSize, err := scanAttachmentSize(head[frameHeadLen:])
+	if err != nil {
+		return nil, fmt.Errorf("trpc framer: scan attachment size: %w", err)
+	}
+	if attmSize > totalLen-headLen {
+		return nil, fmt.Errorf(
+			"trpc framer: attachment size %d > total len %d - head len %d, invalid", attmSize, totalLen, headLen)
+	}
+	if attmSize <= uint32(f.spillThreshold) {
+		attmSize = 0 // the whole frame is read into memory.
+	}
+	prefixLen := totalLen - attmSize
+	if prefixLen > uint32(DefaultMaxFrameSize) {
+		return nil, fmt.Errorf(
+			"trpc framer: read frame len %d without attachment > %d, too large", prefixLen, DefaultMaxFrameSize)
+	}
+
+	msg := make([]byte, prefixLen, prefixLen+spillIDLen)
+	copy(msg, f.header[:])
+	copy(msg[frameHeadLen:], head[frameHeadLen:])
+	if _, err := io.ReadFull(f.reader, msg[headLen:]); err != nil {
+		return nil, err
+	}
+	if attmSize == 0 {
+		return msg, nil
+	}
+	spill, err := attachment.NewSpill(f.spillDir, f.reader, int64(attmSize))
+	if err != nil {
+		return nil, fmt.Errorf("trpc framer: %w", err)
+	}
+	msg[15] |= frameReservedSpilled
+	msg = msg[:prefixLen+spillIDLen]
+	binary.BigEndian.PutUint64(msg[prefixLen:], spill.Register())
+	return msg, nil
+}
+
+// scanAttachmentSize returns the attachment size of the marshaled RequestProtocol or ResponseProtocol
+// without unmarshaling it.
+func scanAttachmentSize(head []byte) (uint32, error) {
+	var size uint64
+	for len(head) > 0 {
+		num, typ, n := protowire.ConsumeTag(head)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		head = head[n:]
+		if num == attachmentSizeField && typ == protowire.VarintType {
+			if size, n = protowire.ConsumeVarint(head); n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+		} else if n = protowire.ConsumeFieldValue(num, typ, head); n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		head = head[n:]
+	}
+	return uint32(size), nil
+}
+
+// claimSpill claims the spilled attachment of the frame, and returns the frame without the spill id.
+func claimSpill(frameHead *FrameHead, buf []byte) ([]byte, *attachment.Spill, error) {
+	n := len(buf) - spillIDLen
+	if n < int(frameHeadLen) {
+		return nil, nil, errors.New("spilled frame len invalid")
+	}
+	spill, ok := attachment.ClaimSpill(binary.BigEndian.Uint64(buf[n:]))
+	if !ok {
+		return nil, nil, errSpillReleased
+	}
+	if int64(frameHead.TotalLen) != int64(n)+spill.Size() {
+		spill.Close()
+		return nil, nil, fmt.Errorf("total len %d is not actual buf len %d + attachment size %d",
+			frameHead.TotalLen, n, spill.Size())
+	}
+	return buf[:n], spill, nil
+}
+
+// loadSpill reads the spilled attachment back to the end of the frame, for checksums and decryption
+// which need the whole payload in memory.
+func loadSpill(buf []byte, spill *attachment.Spill) ([]byte, error) {
+	defer spill.Close()
+	n := len(buf)
+	if int64(n)+spill.Size() > int64(DefaultMaxFrameSize) {
+		return nil, &errFrameTooLarge{maxFrameSize: DefaultMaxFrameSize}
+	}
+	buf = append(buf[:n:n], make([]byte, spill.Size())...)
+	if _, err := io.ReadFull(spill, buf[n:]); err != nil {
+		return nil, fmt.Errorf("reading spilled attachment: %w", err)
+	}
+	return buf, nil
+}
+
+// readAttachment reads the attachment a into memory. If deferrable, a sized attachment is returned
+// as is to be written by the transport after the frame.
+func readAttachment(a io.Reader, deferrable bool) ([]byte, *attachment.Sized, error) {
+	sized, ok := a.(*attachment.Sized)
+	if ok && deferrable {
+		if sized.Size > math.MaxUint32 {
+			return nil, nil, errAttachmentOverflowsUint32
+		}
+		return nil, sized, nil
+	}
+	attm, err := io.ReadAll(a)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding attachment: %w", err)
+	}
+	if ok && int64(len(attm)) != sized.Size {
+		return nil, nil, fmt.Errorf("encoding attachment: len %d is not its size %d", len(attm), sized.Size)
+	}
+	return attm, nil, nil
+}
+
+// hasTransInfo returns whether trans info contains key.
+func hasTransInfo(transInfo map[string][]byte, key string) bool {
+	_, ok := transInfo[key]
+	return ok
+}
@@ -0,0 +1,181 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	trpc "trpc.group/trpc-go/trpc-go"
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
+	"trpc.group/trpc-go/trpc-go/internal/checksum"
+)
+
+func TestFramer_AttachmentSpill(t *testing.T) {
+	encodeRequest := func(t *testing.T, attm []byte) []byte {
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithClientRPCName("/trpc.test.helloworld.Greeter/SayHello")
+		msg.WithCommonMeta(codec.CommonMeta{attachment.ClientAttachmentKey{}: &attachment.Attachment{
+			Request: bytes.NewReader(attm), Response: attachment.NoopAttachment{}}})
+		buf, err := trpc.DefaultClientCodec.Encode(msg, []byte("request body"))
+		require.Nil(t, err)
+		return buf
+	}
+	decodeRequest := func(t *testing.T, frame []byte) (codec.Msg, []byte) {
+		_, msg := codec.WithNewMessage(context.Background())
+		body, err := trpc.DefaultServerCodec.Decode(msg, frame)
+		require.Nil(t, err)
+		require.Nil(t, msg.ServerRspErr())
+		return msg, body
+	}
+	requestAttachment := func(msg codec.Msg) io.Reader {
+		return msg.CommonMeta()[attachment.ServerAttachmentKey{}].(*attachment.Attachment).Request
+	}
+
+	t.Run("spilled", func(t *testing.T) {
+		dir := t.TempDir()
+		attm := bytes.Repeat([]byte("a"), 4096)
+		fb := trpc.DefaultFramerBuilder.WithAttachmentSpill(1024, dir)
+		frame, err := fb.New(bytes.NewReader(encodeRequest(t, attm))).ReadFrame()
+		require.Nil(t, err)
+		require.Less(t, len(frame), len(attm))
+
+		msg, body := decodeRequest(t, frame)
+		require.Equal(t, []byte("request body"), body)
+		r := requestAttachment(msg)
+		require.Implements(t, (*io.ReadCloser)(nil), r)
+		got, err := io.ReadAll(r)
+		require.Nil(t, err)
+		require.Equal(t, attm, got)
+		entries, err := os.ReadDir(dir)
+		require.Nil(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		attm := bytes.Repeat([]byte("a"), 512)
+		fb := trpc.DefaultFramerBuilder.WithAttachmentSpill(1024, t.TempDir())
+		reqBuf := encodeRequest(t, attm)
+		frame, err := fb.New(bytes.NewReader(reqBuf)).ReadFrame()
+		require.Nil(t, err)
+		require.Equal(t, reqBuf, frame)
+
+		msg, _ := decodeRequest(t, frame)
+		got, err := io.ReadAll(requestAttachment(msg))
+		require.Nil(t, err)
+		require.Equal(t, attm, got)
+	})
+
+	t.Run("spilled bit from peer is ignored", func(t *testing.T) {
+		attm := []byte("attachment")
+		reqBuf := encodeRequest(t, attm)
+		reqBuf[15] |= 1
+		frame, err := trpc.DefaultFramerBuilder.New(bytes.NewReader(reqBuf)).ReadFrame()
+		require.Nil(t, err)
+
+		msg, _ := decodeRequest(t, frame)
+		got, err := io.ReadAll(requestAttachment(msg))
+		require.Nil(t, err)
+		require.Equal(t, attm, got)
+	})
+
+	t.Run("spilled with checksum", func(t *testing.T) {
+		attm := bytes.Repeat([]byte("a"), 4096)
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithClientRPCName("/trpc.test.helloworld.Greeter/SayHello")
+		msg.WithCommonMeta(codec.CommonMeta{attachment.ClientAttachmentKey{}: &attachment.Attachment{
+			Request: bytes.NewReader(attm), Response: attachment.NoopAttachment{}}})
+		checksum.EnableClient(msg)
+		reqBuf, err := trpc.DefaultClientCodec.Encode(msg, []byte("request body"))
+		require.Nil(t, err)
+		fb := trpc.DefaultFramerBuilder.WithAttachmentSpill(1024, t.TempDir())
+		frame, err := fb.New(bytes.NewReader(reqBuf)).ReadFrame()
+		require.Nil(t, err)
+		require.Less(t, len(frame), len(attm))
+
+		serverMsg, _ := decodeRequest(t, frame)
+		require.True(t, checksum.ServerEnabled(serverMsg))
+		got, err := io.ReadAll(requestAttachment(serverMsg))
+		require.Nil(t, err)
+		require.Equal(t, attm, got)
+	})
+
+	t.Run("released spill", func(t *testing.T) {
+		fb := trpc.DefaultFramerBuilder.WithAttachmentSpill(1024, t.TempDir())
+		frame, err := fb.New(bytes.NewReader(encodeRequest(t, make([]byte, 4096)))).ReadFrame()
+		require.Nil(t, err)
+		decodeRequest(t, append([]byte(nil), frame...))
+
+		_, msg := codec.WithNewMessage(context.Background())
+		_, err = trpc.DefaultServerCodec.Decode(msg, frame)
+		require.NotNil(t, err)
+	})
+}
+
+func TestServerCodec_EncodeDeferredAttachment(t *testing.T) {
+	attm := bytes.Repeat([]byte("a"), 4096)
+	newMsg := func(deferrable bool) codec.Msg {
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithCommonMeta(codec.CommonMeta{attachment.ServerAttachmentKey{}: &attachment.Attachment{
+			Request:  attachment.NoopAttachment{},
+			Response: &attachment.Sized{Reader: bytes.NewReader(attm), Size: int64(len(attm))},
+		}})
+		if deferrable {
+			attachment.AllowServerDeferred(msg)
+		}
+		return msg
+	}
+
+	t.Run("deferred", func(t *testing.T) {
+		msg := newMsg(true)
+		rsp, err := trpc.DefaultServerCodec.Encode(msg, []byte("response body"))
+		require.Nil(t, err)
+		require.Equal(t, int64(len(attm)), attachment.DeferredSize(msg))
+
+		var frame bytes.Buffer
+		frame.Write(rsp)
+		require.Nil(t, attachment.WriteDeferred(msg, &frame))
+		require.Zero(t, attachment.DeferredSize(msg))
+		require.Equal(t, rsp, frame.Bytes()[:len(rsp)])
+		require.Equal(t, attm, frame.Bytes()[len(rsp):])
+	})
+
+	t.Run("not deferrable", func(t *testing.T) {
+		msg := newMsg(false)
+		rsp, err := trpc.DefaultServerCodec.Encode(msg, []byte("response body"))
+		require.Nil(t, err)
+		require.Zero(t, attachment.DeferredSize(msg))
+		require.Equal(t, attm, rsp[len(rsp)-len(attm):])
+	})
+
+	t.Run("short attachment", func(t *testing.T) {
+		for _, write := range []func(codec.Msg) error{
+			func(msg codec.Msg) error { return attachment.WriteDeferred(msg, io.Discard) },
+			func(msg codec.Msg) error { _, err := attachment.AppendDeferred(msg, nil); return err },
+		} {
+			msg := newMsg(true)
+			a := msg.CommonMeta()[attachment.ServerAttachmentKey{}].(*attachment.Attachment)
+			a.Response.(*attachment.Sized).Size++
+			_, err := trpc.DefaultServerCodec.Encode(msg, nil)
+			require.Nil(t, err)
+			require.NotNil(t, write(msg))
+		}
+	})
+}
@@ -0,0 +1,139 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"google.golang.org/protobuf/proto"
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/checksum"
+	"trpc.group/trpc-go/trpc-go/internal/report"
+)
+
+// Checksums of unary frames are CRC32C over the protocol head, the body and the attachment.
+// A checksum is carried as an entry of trans info, so that peers not supporting checksums simply ignore it.
+// The entry is appended to the end of the encoded protocol head, and the checksum covers the bytes of the
+// protocol head before it.
+// Requests and responses use different keys, because old servers echo trans info of requests in responses.
+const (
+	ReqChecksumKey = "trpc-req-crc32c" // key of request checksum in trans info
+	RspChecksumKey = "trpc-rsp-crc32c" // key of response checksum in trans info
+
+	checksumLen = 4
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// appendReqChecksum appends the checksum entry of the request to reqHead.
+func appendReqChecksum(reqHead, body, attm []byte) ([]byte, error) {
+	entry, err := reqChecksumEntry(checksumOf(reqHead, body, attm))
+	if err != nil {
+		return nil, err
+	}
+	return append(reqHead, entry...), nil
+}
+
+// appendRspChecksum appends the checksum entry of the response to rspHead.
+func appendRspChecksum(rspHead, body, attm []byte) ([]byte, error) {
+	entry, err := rspChecksumEntry(checksumOf(rspHead, body, attm))
+	if err != nil {
+		return nil, err
+	}
+	return append(rspHead, entry...), nil
+}
+
+// reqChecksumEntry returns the encoded request protocol head which only has sum in trans info.
+func reqChecksumEntry(sum []byte) ([]byte, error) {
+	return proto.Marshal(&trpcpb.RequestProtocol{TransInfo: map[string][]byte{ReqChecksumKey: sum}})
+}
+
+// rspChecksumEntry returns the encoded response protocol head which only has sum in trans info.
+func rspChecksumEntry(sum []byte) ([]byte, error) {
+	return proto.Marshal(&trpcpb.ResponseProtocol{TransInfo: map[string][]byte{RspChecksumKey: sum}})
+}
+
+// verifyChecksum verifies sum against the encoded protocol head and the payload after it,
+// where entry is the encoded checksum entry, which should be at the end of head.
+func verifyChecksum(head, entry, payload, sum []byte) error {
+	if len(sum) != checksumLen {
+		return fmt.Errorf("checksum len %d != %d, invalid", len(sum), checksumLen)
+	}
+	if !bytes.HasSuffix(head, entry) {
+		return errors.New("checksum is not at the end of protocol head")
+	}
+	if actual := checksumOf(head[:len(head)-len(entry)], payload); !bytes.Equal(actual, sum) {
+		return fmt.Errorf("checksum %x != actual %x, frame corrupted",
+			binary.BigEndian.Uint32(sum), binary.BigEndian.Uint32(actual))
+	}
+	return nil
+}
+
+// checksumOf returns the big endian CRC32C of all bytes.
+func checksumOf(bs ...[]byte) []byte {
+	var crc uint32
+	for _, b := range bs {
+		crc = crc32.Update(crc, crc32cTable, b)
+	}
+	sum := make([]byte, checksumLen)
+	binary.BigEndian.PutUint32(sum, crc)
+	return sum
+}
+
+// verifyReqChecksum verifies the checksum of the request if it carries one, and removes the checksum from
+// trans info. The response to a request carrying a checksum carries a checksum too.
+func verifyReqChecksum(msg codec.Msg, req *trpcpb.RequestProtocol, reqHead, payload []byte) error {
+	sum, ok := req.TransInfo[ReqChecksumKey]
+	if !ok {
+		return nil
+	}
+	delete(req.TransInfo, ReqChecksumKey)
+	checksum.EnableServer(msg)
+	entry, err := reqChecksumEntry(sum)
+	if err == nil {
+		err = verifyChecksum(reqHead, entry, payload, sum)
+	}
+	if err != nil {
+		report.ServiceCodecChecksumFail.Incr()
+		return errs.NewFrameError(errs.RetServerChecksumFail, "server decode: frame checksum fail: "+err.Error())
+	}
+	return nil
+}
+
+// verifyRspChecksum verifies the checksum of the response if it carries one, and removes checksums from
+// trans info, including the request checksum echoed by servers not supporting checksums.
+func verifyRspChecksum(rsp *trpcpb.ResponseProtocol, rspHead, payload []byte) error {
+	delete(rsp.TransInfo, ReqChecksumKey)
+	sum, ok := rsp.TransInfo[RspChecksumKey]
+	if !ok {
+		return nil
+	}
+	delete(rsp.TransInfo, RspChecksumKey)
+	entry, err := rspChecksumEntry(sum)
+	if err == nil {
+		err = verifyChecksum(rspHead, entry, payload, sum)
+	}
+	if err != nil {
+		report.ClientCodecChecksumFail.Incr()
+		return errs.NewFrameError(errs.RetClientChecksumFail, "client decode: frame checksum fail: "+err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	trpc "trpc.group/trpc-go/trpc-go"
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/checksum"
+)
+
+func TestCodecChecksum(t *testing.T) {
+	newClientMsg := func(enable bool) codec.Msg {
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithClientRPCName("/trpc.test.helloworld.Greeter/SayHello")
+		msg.WithClientMetaData(codec.MetaData{"key": []byte("value")})
+		if enable {
+			checksum.EnableClient(msg)
+		}
+		return msg
+	}
+	newServerMsg := func() codec.Msg {
+		_, msg := codec.WithNewMessage(context.Background())
+		return msg
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		clientMsg := newClientMsg(true)
+		reqBuf, err := trpc.DefaultClientCodec.Encode(clientMsg, []byte("request body"))
+		require.Nil(t, err)
+
+		serverMsg := newServerMsg()
+		reqBody, err := trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		require.Nil(t, serverMsg.ServerRspErr())
+		require.Equal(t, []byte("request body"), reqBody)
+		require.Equal(t, codec.MetaData{"key": []byte("value")}, serverMsg.ServerMetaData())
+		require.True(t, checksum.ServerEnabled(serverMsg))
+
+		rspBuf, err := trpc.DefaultServerCodec.Encode(serverMsg, []byte("response body"))
+		require.Nil(t, err)
+		rspBody, err := trpc.DefaultClientCodec.Decode(clientMsg, rspBuf)
+		require.Nil(t, err)
+		require.Nil(t, clientMsg.ClientRspErr())
+		require.Equal(t, []byte("response body"), rspBody)
+		require.NotContains(t, clientMsg.ClientMetaData(), trpc.ReqChecksumKey)
+		require.NotContains(t, clientMsg.ClientMetaData(), trpc.RspChecksumKey)
+	})
+
+	t.Run("corrupted request", func(t *testing.T) {
+		reqBuf, err := trpc.DefaultClientCodec.Encode(newClientMsg(true), []byte("request body"))
+		require.Nil(t, err)
+		reqBuf[len(reqBuf)-1] ^= 1
+
+		serverMsg := newServerMsg()
+		reqBody, err := trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		require.Nil(t, reqBody)
+		require.Equal(t, errs.RetServerChecksumFail, serverMsg.ServerRspErr().Code)
+		require.Contains(t, serverMsg.ServerRspErr().Msg, "checksum")
+		_, err = trpc.DefaultServerCodec.Encode(serverMsg, nil)
+		require.Nil(t, err)
+	})
+
+	t.Run("corrupted response", func(t *testing.T) {
+		clientMsg := newClientMsg(true)
+		reqBuf, err := trpc.DefaultClientCodec.Encode(clientMsg, []byte("request body"))
+		require.Nil(t, err)
+		serverMsg := newServerMsg()
+		_, err = trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		rspBuf, err := trpc.DefaultServerCodec.Encode(serverMsg, []byte("response body"))
+		require.Nil(t, err)
+		rspBuf[len(rspBuf)-1] ^= 1
+
+		rspBody, err := trpc.DefaultClientCodec.Decode(clientMsg, rspBuf)
+		require.Nil(t, err)
+		require.Nil(t, rspBody)
+		require.Equal(t, errs.RetClientChecksumFail, errs.Code(clientMsg.ClientRspErr()))
+		require.Contains(t, errs.Msg(clientMsg.ClientRspErr()), "checksum")
+	})
+
+	t.Run("peers without checksums", func(t *testing.T) {
+		// A request without checksum is not responded with checksum unless enabled by the service.
+		reqBuf, err := trpc.DefaultClientCodec.Encode(newClientMsg(false), []byte("request body"))
+		require.Nil(t, err)
+		serverMsg := newServerMsg()
+		_, err = trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		require.False(t, checksum.ServerEnabled(serverMsg))
+		rspBuf, err := trpc.DefaultServerCodec.Encode(serverMsg, nil)
+		require.Nil(t, err)
+		rsp := &trpcpb.ResponseProtocol{}
+		require.Nil(t, proto.Unmarshal(rspBuf[16:], rsp))
+		require.NotContains(t, rsp.TransInfo, trpc.RspChecksumKey)
+
+		// The request checksum echoed by servers without checksum support is ignored.
+		clientMsg := newClientMsg(true)
+		reqBuf, err = trpc.DefaultClientCodec.Encode(clientMsg, []byte("request body"))
+		require.Nil(t, err)
+		req := &trpcpb.RequestProtocol{}
+		require.Nil(t, proto.Unmarshal(reqBuf[16:len(reqBuf)-len("request body")], req))
+		require.Len(t, req.TransInfo[trpc.ReqChecksumKey], 4)
+		rspHead, err := proto.Marshal(&trpcpb.ResponseProtocol{RequestId: req.RequestId, TransInfo: req.TransInfo})
+		require.Nil(t, err)
+		rspBuf = append(make([]byte, 16), rspHead...)
+		copy(rspBuf, reqBuf[:16])
+		rspBuf[4], rspBuf[5], rspBuf[6], rspBuf[7] = 0, 0, 0, byte(len(rspBuf))
+		rspBuf[8], rspBuf[9] = 0, byte(len(rspHead))
+		_, err = trpc.DefaultClientCodec.Decode(clientMsg, rspBuf)
+		require.Nil(t, err)
+		require.Nil(t, clientMsg.ClientRspErr())
+		require.NotContains(t, clientMsg.ClientMetaData(), trpc.ReqChecksumKey)
+	})
+}
@@ -0,0 +1,208 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/envelope"
+	"trpc.group/trpc-go/trpc-go/internal/report"
+)
+
+// The bodies and attachments of encrypted frames are sealed separately by the key, whose ID is carried
+// in trans info. The response is encrypted with the key of the request.
+// Each payload is bound to its direction, its part of the frame, the request id and the rpc name,
+// and each Data frame to its direction and stream id, so that ciphertexts can't be swapped, replayed
+// in other requests or reflected as responses.
+// For streaming, the ID is carried by the Init frame, and Data frames of both sides are encrypted
+// with the key of it.
+// Requests and responses use different keys, because servers echo trans info of requests in responses.
+const (
+	ReqKeyIDKey = "trpc-req-key-id" // key of the encryption key id of request in trans info
+	RspKeyIDKey = "trpc-rsp-key-id" // key of the encryption key id of response in trans info
+)
+
+var errNoKeyProvider = errors.New("payload is encrypted but no key provider is configured")
+
+// Directions and parts of the payloads, which are bound to the ciphertexts.
+const (
+	sealedRequest  byte = 1
+	sealedResponse byte = 2
+
+	sealedBody       byte = 1
+	sealedAttachment byte = 2
+	sealedData       byte = 3 // Data frames of streams
+)
+
+// payloadAD returns the additional data of a payload: the direction, the part, the request id or
+// stream id in big endian, followed by the rpc name.
+func payloadAD(direction, part byte, id uint32, rpcName string) []byte {
+	ad := make([]byte, 6, 6+len(rpcName))
+	ad[0], ad[1] = direction, part
+	binary.BigEndian.PutUint32(ad[2:], id)
+	return append(ad, rpcName...)
+}
+
+// sealPayload encrypts body and attachment with key, binding them to the direction, the request id and
+// the rpc name. The empty attachment stays empty.
+func sealPayload(
+	key *encryption.Key,
+	body, attm []byte,
+	direction byte,
+	requestID uint32,
+	rpcName string,
+) ([]byte, []byte, error) {
+	body, err := key.Seal(body, payloadAD(direction, sealedBody, requestID, rpcName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypting body: %w", err)
+	}
+	if len(attm) != 0 {
+		if attm, err = key.Seal(attm, payloadAD(direction, sealedAttachment, requestID, rpcName)); err != nil {
+			return nil, nil, fmt.Errorf("encrypting attachment: %w", err)
+		}
+	}
+	return body, attm, nil
+}
+
+// openPayload decrypts body and attachment sealed by sealPayload with the same direction, request id
+// and rpc name.
+func openPayload(
+	key *encryption.Key,
+	body, attm []byte,
+	direction byte,
+	requestID uint32,
+	rpcName string,
+) ([]byte, []byte, error) {
+	body, err := key.Open(body, payloadAD(direction, sealedBody, requestID, rpcName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypting body: %w", err)
+	}
+	if len(attm) != 0 {
+		if attm, err = key.Open(attm, payloadAD(direction, sealedAttachment, requestID, rpcName)); err != nil {
+			return nil, nil, fmt.Errorf("decrypting attachment: %w", err)
+		}
+	}
+	return body, attm, nil
+}
+
+// findKey returns the key of id from the provider of e.
+func findKey(e *envelope.Envelope, id []byte) (*encryption.Key, error) {
+	if e == nil || e.Provider == nil {
+		return nil, errNoKeyProvider
+	}
+	key, err := e.Provider.Key(string(id))
+	if err != nil {
+		return nil, fmt.Errorf("finding key %s: %w", id, err)
+	}
+	return key, nil
+}
+
+// withKeyID sets the encryption key id to trans info.
+func withKeyID(trans map[string][]byte, k, id string) map[string][]byte {
+	if trans == nil {
+		trans = make(map[string][]byte)
+	}
+	trans[k] = []byte(id)
+	return trans
+}
+
+// currentClientKey picks the current key of the client envelope of msg for the request,
+// and returns nil if encryption is not enabled.
+func currentClientKey(msg codec.Msg) (*encryption.Key, error) {
+	e := envelope.Client(msg)
+	if e == nil {
+		return nil, nil
+	}
+	key, err := e.Provider.CurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("getting current encryption key: %w", err)
+	}
+	e.Key = key
+	return key, nil
+}
+
+// openReq decrypts body and attachment of the request if it's encrypted, and removes the key id from
+// trans info. The key is kept in the server envelope of msg to encrypt the response.
+func openReq(msg codec.Msg, req *trpcpb.RequestProtocol, body, attm []byte) ([]byte, []byte, error) {
+	id, ok := req.TransInfo[ReqKeyIDKey]
+	if !ok {
+		return body, attm, nil
+	}
+	delete(req.TransInfo, ReqKeyIDKey)
+	e := envelope.Server(msg)
+	key, err := findKey(e, id)
+	if err == nil {
+		body, attm, err = openPayload(key, body, attm, sealedRequest, req.GetRequestId(), string(req.GetFunc()))
+	}
+	if err != nil {
+		report.ServiceCodecDecryptFail.Incr()
+		return nil, nil, errs.NewFrameError(errs.RetServerDecodeFail, "server decode: "+err.Error())
+	}
+	e.Key = key
+	return body, attm, nil
+}
+
+// openRsp decrypts body and attachment of the response, and removes key ids from trans info, including
+// the request key id echoed by the server. The response to an encrypted request must be encrypted too,
+// unless it's an error.
+func openRsp(msg codec.Msg, rsp *trpcpb.ResponseProtocol, body, attm []byte) ([]byte, []byte, error) {
+	delete(rsp.TransInfo, ReqKeyIDKey)
+	id, ok := rsp.TransInfo[RspKeyIDKey]
+	delete(rsp.TransInfo, RspKeyIDKey)
+	e := envelope.Client(msg)
+	var (
+		key *encryption.Key
+		err error
+	)
+	switch {
+	case ok:
+		key, err = findKey(e, id)
+	case e != nil && e.Key != nil && rsp.GetRet() == 0 && rsp.GetFuncRet() == 0:
+		err = errors.New("response to encrypted request is not encrypted")
+	default:
+		return body, attm, nil
+	}
+	if err == nil {
+		body, attm, err = openPayload(key, body, attm, sealedResponse, rsp.GetRequestId(), msg.ClientRPCName())
+	}
+	if err != nil {
+		report.ClientCodecDecryptFail.Incr()
+		return nil, nil, errs.NewFrameError(errs.RetClientDecodeFail, "client decode: "+err.Error())
+	}
+	return body, attm, nil
+}
+
+// streamKey returns the key of the stream of msg, or nil if the stream is not encrypted.
+// It's called once at Init, and the key is stored along with the InitMeta for Data frames.
+func (s *ServerStreamCodec) streamKey(msg codec.Msg, initMeta *trpcpb.TrpcStreamInitMeta) (*encryption.Key, error) {
+	id, ok := initMeta.GetRequestMeta().GetTransInfo()[ReqKeyIDKey]
+	if !ok {
+		return nil, nil
+	}
+	e := envelope.Server(msg)
+	key, err := findKey(e, id)
+	if err != nil {
+		report.ServiceCodecDecryptFail.Incr()
+		return nil, err
+	}
+	e.Key = key
+	return key, nil
+}
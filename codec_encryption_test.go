@@ -0,0 +1,293 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	trpc "trpc.group/trpc-go/trpc-go"
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
+	"trpc.group/trpc-go/trpc-go/internal/envelope"
+)
+
+func TestCodecEncryption(t *testing.T) {
+	oldKey := &encryption.Key{ID: "old", Algorithm: encryption.AESGCM, Secret: bytes.Repeat([]byte{1}, 32)}
+	newKey := &encryption.Key{ID: "new", Algorithm: encryption.ChaCha20Poly1305, Secret: bytes.Repeat([]byte{2}, 32)}
+	clientKeys, err := encryption.NewStaticKeyProvider(newKey)
+	require.Nil(t, err)
+	serverKeys, err := encryption.NewStaticKeyProvider(oldKey, newKey)
+	require.Nil(t, err)
+	oldKeys, err := encryption.NewStaticKeyProvider(oldKey)
+	require.Nil(t, err)
+
+	newClientMsg := func(p encryption.KeyProvider) codec.Msg {
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithClientRPCName("/trpc.test.helloworld.Greeter/SayHello")
+		msg.WithCommonMeta(codec.CommonMeta{attachment.ClientAttachmentKey{}: &attachment.Attachment{
+			Request:  bytes.NewReader([]byte("request attachment")),
+			Response: attachment.NoopAttachment{},
+		}})
+		if p != nil {
+			envelope.SetClient(msg, p)
+		}
+		return msg
+	}
+	newServerMsg := func(p encryption.KeyProvider) codec.Msg {
+		_, msg := codec.WithNewMessage(context.Background())
+		if p != nil {
+			envelope.SetServer(msg, p)
+		}
+		return msg
+	}
+	readAll := func(r io.Reader) []byte {
+		b, err := io.ReadAll(r)
+		require.Nil(t, err)
+		return b
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		clientMsg := newClientMsg(clientKeys)
+		reqBuf, err := trpc.DefaultClientCodec.Encode(clientMsg, []byte("request body"))
+		require.Nil(t, err)
+		require.NotContains(t, string(reqBuf), "request")
+
+		serverMsg := newServerMsg(serverKeys)
+		reqBody, err := trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		require.Nil(t, serverMsg.ServerRspErr())
+		require.Equal(t, []byte("request body"), reqBody)
+		serverAttm := serverMsg.CommonMeta()[attachment.ServerAttachmentKey{}].(*attachment.Attachment)
+		require.Equal(t, []byte("request attachment"), readAll(serverAttm.Request))
+		require.NotContains(t, serverMsg.ServerMetaData(), trpc.ReqKeyIDKey)
+
+		serverAttm.Response = bytes.NewReader([]byte("response attachment"))
+		rspBuf, err := trpc.DefaultServerCodec.Encode(serverMsg, []byte("response body"))
+		require.Nil(t, err)
+		require.NotContains(t, string(rspBuf), "response")
+
+		rspBody, err := trpc.DefaultClientCodec.Decode(clientMsg, rspBuf)
+		require.Nil(t, err)
+		require.Nil(t, clientMsg.ClientRspErr())
+		require.Equal(t, []byte("response body"), rspBody)
+		clientAttm := clientMsg.CommonMeta()[attachment.ClientAttachmentKey{}].(*attachment.Attachment)
+		require.Equal(t, []byte("response attachment"), readAll(clientAttm.Response))
+		require.NotContains(t, clientMsg.ClientMetaData(), trpc.ReqKeyIDKey)
+		require.NotContains(t, clientMsg.ClientMetaData(), trpc.RspKeyIDKey)
+	})
+
+	t.Run("plaintext request", func(t *testing.T) {
+		reqBuf, err := trpc.DefaultClientCodec.Encode(newClientMsg(nil), []byte("request body"))
+		require.Nil(t, err)
+		serverMsg := newServerMsg(serverKeys)
+		reqBody, err := trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		require.Equal(t, []byte("request body"), reqBody)
+		rspBuf, err := trpc.DefaultServerCodec.Encode(serverMsg, []byte("response body"))
+		require.Nil(t, err)
+		require.Contains(t, string(rspBuf), "response body")
+	})
+
+	for _, tt := range []struct {
+		name      string
+		serverMsg codec.Msg
+	}{
+		{"unknown key", newServerMsg(oldKeys)},
+		{"server without key provider", newServerMsg(nil)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clientMsg := newClientMsg(clientKeys)
+			reqBuf, err := trpc.DefaultClientCodec.Encode(clientMsg, []byte("request body"))
+			require.Nil(t, err)
+
+			reqBody, err := trpc.DefaultServerCodec.Decode(tt.serverMsg, reqBuf)
+			require.Nil(t, err)
+			require.Nil(t, reqBody)
+			require.Equal(t, errs.RetServerDecodeFail, tt.serverMsg.ServerRspErr().Code)
+
+			// the error response is not encrypted, and is accepted by the client.
+			rspBuf, err := trpc.DefaultServerCodec.Encode(tt.serverMsg, nil)
+			require.Nil(t, err)
+			_, err = trpc.DefaultClientCodec.Decode(clientMsg, rspBuf)
+			require.Nil(t, err)
+			require.Equal(t, errs.RetServerDecodeFail, errs.Code(clientMsg.ClientRspErr()))
+		})
+	}
+
+	t.Run("tampered ciphertexts", func(t *testing.T) {
+		decodeReq := func(reqBuf []byte) *errs.Error {
+			serverMsg := newServerMsg(serverKeys)
+			_, err := trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+			require.Nil(t, err)
+			return serverMsg.ServerRspErr()
+		}
+		reqBuf, err := trpc.DefaultClientCodec.Encode(newClientMsg(clientKeys), []byte("request body"))
+		require.Nil(t, err)
+		require.Nil(t, decodeReq(reqBuf))
+		req, body, attm := splitRequest(t, reqBuf)
+
+		// The body and the attachment are swapped.
+		swapped := proto.Clone(req).(*trpcpb.RequestProtocol)
+		swapped.AttachmentSize = uint32(len(body))
+		require.Equal(t, errs.RetServerDecodeFail, decodeReq(joinFrame(t, reqBuf, swapped, attm, body)).Code)
+
+		// The body is replayed in another request.
+		otherBuf, err := trpc.DefaultClientCodec.Encode(newClientMsg(clientKeys), []byte("other body"))
+		require.Nil(t, err)
+		other, _, otherAttm := splitRequest(t, otherBuf)
+		require.Equal(t, errs.RetServerDecodeFail, decodeReq(joinFrame(t, otherBuf, other, body, otherAttm)).Code)
+
+		// The request is sent to another rpc.
+		renamed := proto.Clone(req).(*trpcpb.RequestProtocol)
+		renamed.Func = []byte("/trpc.test.helloworld.Greeter/SayHi")
+		require.Equal(t, errs.RetServerDecodeFail, decodeReq(joinFrame(t, reqBuf, renamed, body, attm)).Code)
+
+		// The request is reflected as the response.
+		clientMsg := newClientMsg(clientKeys)
+		reqBuf, err = trpc.DefaultClientCodec.Encode(clientMsg, []byte("request body"))
+		require.Nil(t, err)
+		req, body, attm = splitRequest(t, reqBuf)
+		rsp := &trpcpb.ResponseProtocol{
+			RequestId:      req.RequestId,
+			AttachmentSize: req.AttachmentSize,
+			TransInfo:      map[string][]byte{trpc.RspKeyIDKey: req.TransInfo[trpc.ReqKeyIDKey]},
+		}
+		rspBody, err := trpc.DefaultClientCodec.Decode(clientMsg, joinFrame(t, reqBuf, rsp, body, attm))
+		require.Nil(t, err)
+		require.Nil(t, rspBody)
+		require.Equal(t, errs.RetClientDecodeFail, errs.Code(clientMsg.ClientRspErr()))
+	})
+
+	t.Run("plaintext response to encrypted request", func(t *testing.T) {
+		clientMsg := newClientMsg(clientKeys)
+		reqBuf, err := trpc.DefaultClientCodec.Encode(clientMsg, []byte("request body"))
+		require.Nil(t, err)
+		serverMsg := newServerMsg(serverKeys)
+		_, err = trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		envelope.Server(serverMsg).Key = nil
+
+		rspBuf, err := trpc.DefaultServerCodec.Encode(serverMsg, []byte("response body"))
+		require.Nil(t, err)
+		rspBody, err := trpc.DefaultClientCodec.Decode(clientMsg, rspBuf)
+		require.Nil(t, err)
+		require.Nil(t, rspBody)
+		require.Equal(t, errs.RetClientDecodeFail, errs.Code(clientMsg.ClientRspErr()))
+	})
+}
+
+// splitRequest splits the request frame into its protocol head, body and attachment.
+func splitRequest(t *testing.T, frame []byte) (*trpcpb.RequestProtocol, []byte, []byte) {
+	headLen := int(binary.BigEndian.Uint16(frame[8:10]))
+	req := &trpcpb.RequestProtocol{}
+	require.Nil(t, proto.Unmarshal(frame[16:16+headLen], req))
+	payload := frame[16+headLen:]
+	n := len(payload) - int(req.AttachmentSize)
+	return req, payload[:n], payload[n:]
+}
+
+// joinFrame builds a frame of the frame head of frame, the protocol head, body and attachment.
+func joinFrame(t *testing.T, frame []byte, head proto.Message, body, attm []byte) []byte {
+	h, err := proto.Marshal(head)
+	require.Nil(t, err)
+	buf := append(append([]byte(nil), frame[:16]...), h...)
+	buf = append(append(buf, body...), attm...)
+	binary.BigEndian.PutUint32(buf[4:8], uint32(len(buf)))
+	binary.BigEndian.PutUint16(buf[8:10], uint16(len(h)))
+	return buf
+}
+
+func TestStreamCodecEncryption(t *testing.T) {
+	key := &encryption.Key{ID: "key", Algorithm: encryption.AESGCM, Secret: bytes.Repeat([]byte{1}, 16)}
+	static, err := encryption.NewStaticKeyProvider(key)
+	require.Nil(t, err)
+	keys := &countingKeyProvider{KeyProvider: static}
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8000}
+	const streamID = 100
+
+	_, clientMsg := codec.WithNewMessage(context.Background())
+	envelope.SetClient(clientMsg, keys)
+	newServerMsg := func() codec.Msg {
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithLocalAddr(addr)
+		msg.WithRemoteAddr(addr)
+		envelope.SetServer(msg, keys)
+		return msg
+	}
+	frameHead := func(t trpcpb.TrpcStreamFrameType) *trpc.FrameHead {
+		return &trpc.FrameHead{
+			FrameType:       uint8(trpcpb.TrpcDataFrameType_TRPC_STREAM_FRAME),
+			StreamFrameType: uint8(t),
+			StreamID:        streamID,
+		}
+	}
+
+	clientMsg.WithFrameHead(frameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_INIT))
+	initBuf, err := trpc.DefaultClientCodec.Encode(clientMsg, nil)
+	require.Nil(t, err)
+	serverInitMsg := newServerMsg()
+	_, err = trpc.DefaultServerCodec.Decode(serverInitMsg, initBuf)
+	require.Nil(t, err)
+	require.Same(t, key, envelope.Server(serverInitMsg).Key)
+	require.NotContains(t, serverInitMsg.ServerMetaData(), trpc.ReqKeyIDKey)
+
+	clientMsg.WithFrameHead(frameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA))
+	dataBuf, err := trpc.DefaultClientCodec.Encode(clientMsg, []byte("request data"))
+	require.Nil(t, err)
+	require.NotContains(t, string(dataBuf), "request data")
+	serverMsg := newServerMsg()
+	reqBody, err := trpc.DefaultServerCodec.Decode(serverMsg, dataBuf)
+	require.Nil(t, err)
+	require.Equal(t, []byte("request data"), reqBody)
+	// the key is found once at Init, not for every Data frame.
+	require.Equal(t, 1, keys.lookups)
+
+	serverMsg.WithFrameHead(frameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA))
+	dataBuf, err = trpc.DefaultServerCodec.Encode(serverMsg, []byte("response data"))
+	require.Nil(t, err)
+	require.NotContains(t, string(dataBuf), "response data")
+	rspBody, err := trpc.DefaultClientCodec.Decode(clientMsg, dataBuf)
+	require.Nil(t, err)
+	require.Equal(t, []byte("response data"), rspBody)
+
+	// The response Data frame is reflected as a request Data frame.
+	_, err = trpc.DefaultServerCodec.Decode(newServerMsg(), dataBuf)
+	require.NotNil(t, err)
+
+	dataBuf[len(dataBuf)-1] ^= 1
+	_, err = trpc.DefaultClientCodec.Decode(clientMsg, dataBuf)
+	require.NotNil(t, err)
+}
+
+// countingKeyProvider counts the lookups of keys by id.
+type countingKeyProvider struct {
+	encryption.KeyProvider
+	lookups int
+}
+
+func (p *countingKeyProvider) Key(id string) (*encryption.Key, error) {
+	p.lookups++
+	return p.KeyProvider.Key(id)
+}
@@ -21,8 +21,11 @@ import (
 	"sync"
 
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/internal/addrutil"
+	"trpc.group/trpc-go/trpc-go/internal/envelope"
+	"trpc.group/trpc-go/trpc-go/internal/report"
 	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
 
 	"google.golang.org/protobuf/proto"
@@ -45,7 +48,7 @@ var (
 
 // NewServerStreamCodec initializes and returns a ServerStreamCodec.
 func NewServerStreamCodec() *ServerStreamCodec {
-	return &ServerStreamCodec{initMetas: make(map[string]map[uint32]*trpcpb.TrpcStreamInitMeta), m: &sync.RWMutex{}}
+	return &ServerStreamCodec{initMetas: make(map[string]map[uint32]*serverStreamInit), m: &sync.RWMutex{}}
 }
 
 // NewClientStreamCodec initializes and returns a ClientStreamCodec.
@@ -57,7 +60,14 @@ func NewClientStreamCodec() *ClientStreamCodec {
 // Used for trpc server streaming codec.
 type ServerStreamCodec struct {
 	m         *sync.RWMutex
-	initMetas map[string]map[uint32]*trpcpb.TrpcStreamInitMeta // addr->streamID->TrpcStreamInitMeta
+	initMetas map[string]map[uint32]*serverStreamInit // addr->streamID->serverStreamInit
+}
+
+// serverStreamInit is the InitMeta of a server stream, along with the key resolved from it at Init,
+// which decrypts the Data frames of the stream, nil if the stream is not encrypted.
+type serverStreamInit struct {
+	meta *trpcpb.TrpcStreamInitMeta
+	key  *encryption.Key
 }
 
 // ClientStreamCodec is an implementation of codec.Codec.
@@ -196,7 +206,16 @@ func (c *ClientStreamCodec) decodeInitFrame(msg codec.Msg, rspBuf []byte) ([]byt
 func (c *ClientStreamCodec) decodeDataFrame(msg codec.Msg, rspBuf []byte) ([]byte, error) {
 	// decoding Data frame is straightforward,
 	// as it just returns all data following the frame head
-	return rspBuf[frameHeadLen:], nil
+	rspBody := rspBuf[frameHeadLen:]
+	if e := envelope.Client(msg); e != nil && e.Key != nil {
+		body, err := e.Key.Open(rspBody, payloadAD(sealedResponse, sealedData, msg.StreamID(), ""))
+		if err != nil {
+			report.ClientCodecDecryptFail.Incr()
+			return nil, fmt.Errorf("decrypting data frame: %w", err)
+		}
+		return body, nil
+	}
+	return rspBody, nil
 }
 
 // encodeInitFrame encodes the Init frame.
@@ -227,6 +246,14 @@ func (c *ClientStreamCodec) encodeInitFrame(frameHead *FrameHead, msg codec.Msg,
 	}
 	// set client transinfo
 	req.TransInfo = setClientTransInfo(msg, req.TransInfo)
+	// set the key of the stream, Data frames of both sides are encrypted with it
+	key, err := currentClientKey(msg)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		req.TransInfo = withKeyID(req.TransInfo, ReqKeyIDKey, key.ID)
+	}
 	streamBuf, err := proto.Marshal(initMeta)
 	if err != nil {
 		return nil, err
@@ -236,6 +263,13 @@ func (c *ClientStreamCodec) encodeInitFrame(frameHead *FrameHead, msg codec.Msg,
 
 // encodeDataFrame encodes the Data frame.
 func (c *ClientStreamCodec) encodeDataFrame(frameHead *FrameHead, msg codec.Msg, reqBuf []byte) ([]byte, error) {
+	if e := envelope.Client(msg); e != nil && e.Key != nil {
+		body, err := e.Key.Seal(reqBuf, payloadAD(sealedRequest, sealedData, frameHead.StreamID, ""))
+		if err != nil {
+			return nil, fmt.Errorf("encrypting data frame: %w", err)
+		}
+		reqBuf = body
+	}
 	return frameWrite(frameHead, reqBuf)
 }
 
@@ -308,6 +342,13 @@ func (s *ServerStreamCodec) encodeDataFrame(frameHead *FrameHead, msg codec.Msg,
 		s.buildResetFrame(msg, frameHead, err)
 		return s.encodeCloseFrame(frameHead, msg, reqBuf)
 	}
+	if e := envelope.Server(msg); e != nil && e.Key != nil {
+		body, err := e.Key.Seal(reqBuf, payloadAD(sealedResponse, sealedData, frameHead.StreamID, ""))
+		if err != nil {
+			return nil, fmt.Errorf("encrypting data frame: %w", err)
+		}
+		reqBuf = body
+	}
 	return frameWrite(frameHead, reqBuf)
 }
 
@@ -396,7 +437,7 @@ func (s *ServerStreamCodec) Decode(msg codec.Msg, reqBuf []byte) ([]byte, error)
 
 // decodeFeedbackFrame decodes the Feedback frame.
 func (s *ServerStreamCodec) decodeFeedbackFrame(msg codec.Msg, reqBuf []byte) ([]byte, error) {
-	if err := s.setInitMeta(msg); err != nil {
+	if _, err := s.setInitMeta(msg); err != nil {
 		return nil, err
 	}
 	feedback := &trpcpb.TrpcStreamFeedBackMeta{}
@@ -408,18 +449,18 @@ func (s *ServerStreamCodec) decodeFeedbackFrame(msg codec.Msg, reqBuf []byte) ([
 }
 
 // setInitMeta finds the InitMeta and sets the ServerRPCName by the server handler in the InitMeta.
-func (s *ServerStreamCodec) setInitMeta(msg codec.Msg) error {
+func (s *ServerStreamCodec) setInitMeta(msg codec.Msg) (*serverStreamInit, error) {
 	streamID := msg.StreamID()
 	addr := addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr())
 	s.m.RLock()
 	defer s.m.RUnlock()
 	if streamIDToInitMeta, ok := s.initMetas[addr]; ok {
-		if initMeta, ok := streamIDToInitMeta[streamID]; ok {
-			msg.WithServerRPCName(string(initMeta.GetRequestMeta().GetFunc()))
-			return nil
+		if init, ok := streamIDToInitMeta[streamID]; ok {
+			msg.WithServerRPCName(string(init.meta.GetRequestMeta().GetFunc()))
+			return init, nil
 		}
 	}
-	return errUninitializedMeta
+	return nil, errUninitializedMeta
 }
 
 // deleteInitMeta deletes the cached info by msg.
@@ -436,7 +477,7 @@ func (s *ServerStreamCodec) deleteInitMeta(msg codec.Msg) {
 
 // decodeCloseFrame decodes the Close frame.
 func (s *ServerStreamCodec) decodeCloseFrame(msg codec.Msg, rspBuf []byte) ([]byte, error) {
-	if err := s.setInitMeta(msg); err != nil {
+	if _, err := s.setInitMeta(msg); err != nil {
 		return nil, err
 	}
 	close := &trpcpb.TrpcStreamCloseMeta{}
@@ -461,10 +502,20 @@ func (s *ServerStreamCodec) decodeCloseFrame(msg codec.Msg, rspBuf []byte) ([]by
 
 // decodeDataFrame decodes the Data frame.
 func (s *ServerStreamCodec) decodeDataFrame(msg codec.Msg, reqBuf []byte) ([]byte, error) {
-	if err := s.setInitMeta(msg); err != nil {
+	init, err := s.setInitMeta(msg)
+	if err != nil {
 		return nil, err
 	}
 	reqBody := reqBuf[frameHeadLen:]
+	if key := init.key; key != nil {
+		if e := envelope.Server(msg); e != nil {
+			e.Key = key
+		}
+		if reqBody, err = key.Open(reqBody, payloadAD(sealedRequest, sealedData, msg.StreamID(), "")); err != nil {
+			report.ServiceCodecDecryptFail.Incr()
+			return nil, fmt.Errorf("decrypting data frame: %w", err)
+		}
+	}
 	return reqBody, nil
 }
 
@@ -474,23 +525,29 @@ func (s *ServerStreamCodec) decodeInitFrame(msg codec.Msg, reqBuf []byte) ([]byt
 	if err := proto.Unmarshal(reqBuf[frameHeadLen:], initMeta); err != nil {
 		return nil, err
 	}
+	key, err := s.streamKey(msg, initMeta)
+	if err != nil {
+		return nil, err
+	}
 	s.updateMsg(msg, initMeta)
-	s.storeInitMeta(msg, initMeta)
+	// the key id isn't a part of metadata, the key is stored along with the InitMeta for Data frames.
+	delete(msg.ServerMetaData(), ReqKeyIDKey)
+	s.storeInitMeta(msg, &serverStreamInit{meta: initMeta, key: key})
 	msg.WithStreamFrame(initMeta)
 	return nil, nil
 }
 
 // storeInitMeta stores the InitMeta every time when a new frame is received.
-func (s *ServerStreamCodec) storeInitMeta(msg codec.Msg, initMeta *trpcpb.TrpcStreamInitMeta) {
+func (s *ServerStreamCodec) storeInitMeta(msg codec.Msg, init *serverStreamInit) {
 	streamID := msg.StreamID()
 	addr := addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr())
 	s.m.Lock()
 	defer s.m.Unlock()
 	if _, ok := s.initMetas[addr]; ok {
-		s.initMetas[addr][streamID] = initMeta
+		s.initMetas[addr][streamID] = init
 	} else {
-		t := make(map[uint32]*trpcpb.TrpcStreamInitMeta)
-		t[streamID] = initMeta
+		t := make(map[uint32]*serverStreamInit)
+		t[streamID] = init
 		s.initMetas[addr] = t
 	}
 }
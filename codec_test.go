@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
+	"io"
 	"log"
 	"net"
 	"regexp"
@@ -33,6 +34,7 @@ import (
 	trpc "trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
 	"trpc.group/trpc-go/trpc-go/pool/multiplexed"
 	pb "trpc.group/trpc-go/trpc-go/testdata/trpc/helloworld"
 )
@@ -94,6 +96,37 @@ func TestFramer_ReadFrame(t *testing.T) {
 	}
 }
 
+func TestFramer_ReadPooledFrame(t *testing.T) {
+	_, msg := codec.WithNewMessage(context.Background())
+	msg.WithClientRPCName("/trpc.test.helloworld.Greeter/SayHello")
+	unary, err := trpc.DefaultClientCodec.Encode(msg, []byte("request body"))
+	require.Nil(t, err)
+	ping := trpc.DefaultFramerBuilder.Ping(1)
+
+	frames := append(append([]byte(nil), unary...), ping...)
+	fr, ok := trpc.DefaultFramerBuilder.New(bytes.NewReader(frames)).(icodec.PooledFramer)
+	require.True(t, ok)
+
+	frame, buf, err := fr.ReadPooledFrame()
+	require.Nil(t, err)
+	require.NotNil(t, buf)
+	require.Equal(t, unary, frame)
+	buf.Release()
+
+	frame, buf, err = fr.ReadPooledFrame()
+	require.Nil(t, err)
+	require.Nil(t, buf, "frames other than unary ones are not pooled")
+	require.Equal(t, ping, frame)
+
+	_, _, err = fr.ReadPooledFrame()
+	require.Equal(t, io.EOF, err)
+
+	fr = trpc.DefaultFramerBuilder.New(bytes.NewReader(unary[:len(unary)-1])).(icodec.PooledFramer)
+	_, buf, err = fr.ReadPooledFrame()
+	require.NotNil(t, err)
+	require.Nil(t, buf)
+}
+
 func TestClientCodecEnvTransfer(t *testing.T) {
 	envTransfer := []byte("env transfer")
 	cliCodec := &trpc.ClientCodec{}
@@ -336,6 +369,104 @@ func TestMultiplexFrame(t *testing.T) {
 	require.Equal(t, buf, frame)
 }
 
+func TestHeartbeatFrame(t *testing.T) {
+	fb := &trpc.FramerBuilder{}
+	ping := fb.Ping(7)
+	require.False(t, fb.IsPong(ping))
+
+	frame, err := fb.New(bytes.NewReader(ping)).ReadFrame()
+	require.Nil(t, err)
+	pong, ok := fb.Pong(frame)
+	require.True(t, ok)
+	require.True(t, fb.IsPong(pong))
+
+	vid, frame, err := fb.Parse(bytes.NewReader(pong))
+	require.Nil(t, err)
+	require.Equal(t, uint32(7), vid)
+	require.True(t, fb.IsPong(frame))
+
+	_, ok = fb.Pong(pong)
+	require.False(t, ok)
+	_, ok = codec.HeartbeatPong(fb, mustEncode(t, []byte("helloworld")))
+	require.False(t, ok)
+}
+
+func TestMaxFrameSize(t *testing.T) {
+	fb := codec.WithMaxFrameSize(trpc.DefaultFramerBuilder, 100)
+	require.NotSame(t, trpc.DefaultFramerBuilder, fb)
+	require.Same(t, trpc.DefaultFramerBuilder, codec.WithMaxFrameSize(trpc.DefaultFramerBuilder, 0))
+
+	newReqBuf := func(body []byte) ([]byte, codec.Msg) {
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithRequestID(1)
+		msg.WithClientRPCName("/trpc.test.helloworld.Greeter/SayHello")
+		buf, err := trpc.DefaultClientCodec.Encode(msg, body)
+		require.Nil(t, err)
+		return buf, msg
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		large, clientMsg := newReqBuf(make([]byte, 100))
+		small, _ := newReqBuf([]byte("hello"))
+		framer := fb.New(bytes.NewReader(append(append([]byte{}, large...), small...)))
+		reqBuf, err := framer.ReadFrame()
+		require.Nil(t, err)
+		require.Less(t, len(reqBuf), len(large))
+		// the discarded frame doesn't affect the next one.
+		next, err := framer.ReadFrame()
+		require.Nil(t, err)
+		require.Equal(t, small, next)
+
+		_, serverMsg := codec.WithNewMessage(context.Background())
+		reqBody, err := trpc.DefaultServerCodec.Decode(serverMsg, reqBuf)
+		require.Nil(t, err)
+		require.Nil(t, reqBody)
+		require.Equal(t, errs.RetServerMsgExceedLimit, errs.Code(serverMsg.ServerRspErr()))
+		require.Equal(t, "/trpc.test.helloworld.Greeter/SayHello", serverMsg.ServerRPCName())
+
+		serverMsg.WithServerRspErr(nil)
+		rspBuf, err := trpc.DefaultServerCodec.Encode(serverMsg, make([]byte, 100))
+		require.Nil(t, err)
+		rspBuf, err = fb.New(bytes.NewReader(rspBuf)).ReadFrame()
+		require.Nil(t, err)
+		rspBody, err := trpc.DefaultClientCodec.Decode(clientMsg, rspBuf)
+		require.Nil(t, err)
+		require.Nil(t, rspBody)
+		require.Equal(t, errs.RetClientMsgExceedLimit, errs.Code(clientMsg.ClientRspErr()))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		_, msg := codec.WithNewMessage(context.Background())
+		msg.WithFrameHead(&trpc.FrameHead{
+			FrameType:       uint8(trpcpb.TrpcDataFrameType_TRPC_STREAM_FRAME),
+			StreamFrameType: uint8(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA),
+			StreamID:        100,
+		})
+		dataBuf, err := trpc.DefaultClientCodec.Encode(msg, make([]byte, 100))
+		require.Nil(t, err)
+		dataBuf, err = fb.New(bytes.NewReader(dataBuf)).ReadFrame()
+		require.Nil(t, err)
+
+		_, serverMsg := codec.WithNewMessage(context.Background())
+		_, err = trpc.DefaultServerCodec.Decode(serverMsg, dataBuf)
+		require.Equal(t, errs.RetServerMsgExceedLimit, errs.Code(err))
+		closeMeta, ok := serverMsg.StreamFrame().(*trpcpb.TrpcStreamCloseMeta)
+		require.True(t, ok)
+		require.Equal(t, int32(errs.RetServerMsgExceedLimit), closeMeta.Ret)
+
+		_, clientMsg := codec.WithNewMessage(context.Background())
+		_, err = trpc.DefaultClientCodec.Decode(clientMsg, dataBuf)
+		require.Nil(t, err)
+		require.Equal(t, errs.RetClientMsgExceedLimit, errs.Code(clientMsg.ClientRspErr()))
+	})
+
+	t.Run("discard fails", func(t *testing.T) {
+		large, _ := newReqBuf(make([]byte, 100))
+		_, err := fb.New(bytes.NewReader(large[:len(large)-1])).ReadFrame()
+		require.NotNil(t, err)
+	})
+}
+
 func TestClientCodecNoModifyOriginalFrameHead(t *testing.T) {
 	_, msg := codec.WithNewMessage(context.Background())
 	fh := &trpc.FrameHead{
@@ -0,0 +1,78 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc
+
+import (
+	"fmt"
+	"os"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/plugin"
+)
+
+func init() {
+	plugin.Register("zstd", &zstdPlugin{})
+}
+
+// zstdPlugin registers zstd dictionaries and configures the default zstd compressor by the framework
+// config, such as:
+//
+//	plugins:
+//	  codec:
+//	    zstd:
+//	      level: 3
+//	      dictionary: 1001
+//	      dictionary_files:
+//	        - ./dict/1001.zstd
+//
+// Backends and services may compress with other registered dictionaries by zstd_dictionary of their configs.
+type zstdPlugin struct{}
+
+type zstdConfig struct {
+	Level           int      `yaml:"level"`            // compression level
+	Dictionary      uint32   `yaml:"dictionary"`       // id of the dictionary used for compression
+	DictionaryFiles []string `yaml:"dictionary_files"` // dictionary files to register
+}
+
+// Type implements plugin.Factory.
+func (*zstdPlugin) Type() string {
+	return "codec"
+}
+
+// Setup implements plugin.Factory.
+func (*zstdPlugin) Setup(name string, dec plugin.Decoder) error {
+	var cfg zstdConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return err
+	}
+	for _, file := range cfg.DictionaryFiles {
+		dict, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("read zstd dictionary file %s: %w", file, err)
+		}
+		id, err := codec.ZstdDictionaryID(dict)
+		if err != nil {
+			return fmt.Errorf("zstd dictionary file %s: %w", file, err)
+		}
+		if err := codec.RegisterZstdDictionary(id, dict); err != nil {
+			return err
+		}
+	}
+	c, err := codec.NewZstdCompressor(codec.WithZstdLevel(cfg.Level), codec.WithZstdDictionary(cfg.Dictionary))
+	if err != nil {
+		return err
+	}
+	codec.RegisterCompressor(codec.CompressTypeZstd, c)
+	return nil
+}
@@ -0,0 +1,66 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package trpc_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/plugin"
+)
+
+type yamlDecoder struct {
+	node *yaml.Node
+}
+
+func (d *yamlDecoder) Decode(cfg interface{}) error {
+	return d.node.Decode(cfg)
+}
+
+func TestZstdPlugin(t *testing.T) {
+	origin := codec.GetCompressor(codec.CompressTypeZstd)
+	defer codec.RegisterCompressor(codec.CompressTypeZstd, origin)
+
+	dict, err := os.ReadFile("codec/testdata/zstd.dict")
+	require.Nil(t, err)
+	id, err := codec.ZstdDictionaryID(dict)
+	require.Nil(t, err)
+	require.Equal(t, uint32(1057719328), id)
+
+	f := plugin.Get("codec", "zstd")
+	require.NotNil(t, f)
+	var node yaml.Node
+	require.Nil(t, yaml.Unmarshal([]byte(`
+level: 9
+dictionary: 1057719328
+dictionary_files:
+  - codec/testdata/zstd.dict
+`), &node))
+	require.Nil(t, f.Setup("zstd", &yamlDecoder{node: node.Content[0]}))
+	require.NotEqual(t, origin, codec.GetCompressor(codec.CompressTypeZstd))
+
+	in := []byte("A long time ago in a galaxy far, far away...")
+	out, err := codec.Compress(codec.CompressTypeZstd, in)
+	require.Nil(t, err)
+	got, err := origin.Decompress(out)
+	require.Nil(t, err)
+	require.Equal(t, in, got)
+
+	require.Nil(t, yaml.Unmarshal([]byte(`dictionary_files: [codec/testdata/not_exist.dict]`), &node))
+	require.NotNil(t, f.Setup("zstd", &yamlDecoder{node: node.Content[0]}))
+}
@@ -30,12 +30,14 @@ import (
 
 	"trpc.group/trpc-go/trpc-go/client"
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/config"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	"trpc.group/trpc-go/trpc-go/internal/rand"
 	"trpc.group/trpc-go/trpc-go/overloadctrl"
 	"trpc.group/trpc-go/trpc-go/plugin"
 	"trpc.group/trpc-go/trpc-go/rpcz"
+	"trpc.group/trpc-go/trpc-go/transport"
 )
 
 // ServerConfigPath is the file path of trpc server config file.
@@ -542,6 +544,40 @@ type ServiceConfig struct {
 	MaxRoutines int    `yaml:"max_routines"`
 	Writev      *bool  `yaml:"writev,omitempty"` // Whether to enable writev.
 	Transport   string `yaml:"transport"`        // Transport type.
+	// ZeroCopy is whether to read unary requests into pooled buffers, which are released after the handler
+	// returns. Request bodies and attachments must not be referenced after that.
+	ZeroCopy bool `yaml:"zero_copy"`
+
+	// UDPFragment enables the fragmentation of large UDP messages if not nil.
+	// It's not supported by the tnet transport, which fails to serve UDP with it.
+	UDPFragment *transport.UDPFragmentOptions `yaml:"udp_fragment,omitempty"`
+
+	// MaxConnections is the max number of concurrent connections, zero means no limit.
+	MaxConnections int `yaml:"max_connections"`
+	// MaxConnectionsPerIP is the max number of concurrent connections of a remote IP, zero means no limit.
+	MaxConnectionsPerIP int `yaml:"max_connections_per_ip"`
+	// ConnectionLimitWait is the time in milliseconds a new connection waits for a free slot when
+	// the connection limits are reached. Zero means the connection is rejected immediately.
+	// The tnet transport always rejects immediately, since it must not block its pollers.
+	ConnectionLimitWait int `yaml:"connection_limit_wait"`
+	// MaxRequestSize is the max size of requests in bytes, zero means no limit.
+	MaxRequestSize int `yaml:"max_request_size"`
+	// MaxResponseSize is the max size of encoded responses in bytes, zero means no limit.
+	MaxResponseSize int `yaml:"max_response_size"`
+	// MaxConcurrentStreams is the max number of concurrent streams of a connection, zero means no limit.
+	MaxConcurrentStreams int `yaml:"max_concurrent_streams"`
+
+	// CompressMinSize is the min size of response bodies to compress, smaller ones are sent uncompressed.
+	CompressMinSize int `yaml:"compress_min_size"`
+	// CompressMaxRatio is the max ratio of the compressed size to the original size, response bodies
+	// which can't be compressed to it are sent uncompressed. Zero disables the check.
+	CompressMaxRatio float64 `yaml:"compress_max_ratio"`
+	// ZstdDictionary is the id of the registered zstd dictionary to compress responses of zstd compression.
+	ZstdDictionary uint32 `yaml:"zstd_dictionary"`
+	// Checksum is whether response frames carry checksums.
+	Checksum bool `yaml:"checksum"`
+	// Encryption is the name of the registered key provider to decrypt payloads.
+	Encryption string `yaml:"encryption"`
 
 	OverloadCtrl overloadctrl.Impl `yaml:"overload_ctrl,omitempty"` // Overload control.
 	// OverloadCtrls is retained for compatibility with older configuration.
@@ -590,6 +626,10 @@ var globalConfig atomic.Value
 
 func init() {
 	globalConfig.Store(defaultConfig())
+	// Binary serializations are registered here rather than by package config, which would otherwise
+	// depend on codec and all its serialization libraries.
+	config.RegisterUnmarshaler("msgpack", &codec.MsgPackSerialization{})
+	config.RegisterUnmarshaler("cbor", &codec.CBORSerialization{})
 }
 
 func defaultConfig() *Config {
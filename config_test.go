@@ -26,6 +26,8 @@ import (
 	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v3"
 
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/config"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/overloadctrl"
 	"trpc.group/trpc-go/trpc-go/rpcz"
@@ -936,3 +938,22 @@ server:
 		require.Equal(t, 1500, cfg.Server.Service[0].Idletime)
 	})
 }
+
+func TestBinaryConfigUnmarshalers(t *testing.T) {
+	type value struct {
+		Age  int
+		Name string
+	}
+	for name, serializationType := range map[string]int{
+		"msgpack": codec.SerializationTypeMsgPack,
+		"cbor":    codec.SerializationTypeCBOR,
+	} {
+		data, err := codec.Marshal(serializationType, &value{Age: 20, Name: "foo"})
+		require.Nil(t, err)
+		u := config.GetUnmarshaler(name)
+		require.NotNil(t, u, name)
+		var v value
+		require.Nil(t, u.Unmarshal(data, &v))
+		require.Equal(t, value{Age: 20, Name: "foo"}, v)
+	}
+}
@@ -0,0 +1,174 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+// Package encryption provides keys of the application-level payload encryption of trpc protocol.
+// The bodies and attachments of requests and responses are encrypted with AEAD ciphers,
+// and the key IDs are carried in trans info, so that keys can be rotated without downtime.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Algorithm is the AEAD algorithm of a key.
+type Algorithm string
+
+// Supported algorithms.
+const (
+	// AESGCM is AES in GCM mode, the length of secret decides AES-128, AES-192 or AES-256.
+	AESGCM Algorithm = "aes-gcm"
+	// ChaCha20Poly1305 is ChaCha20-Poly1305, whose secret must be 32 bytes.
+	ChaCha20Poly1305 Algorithm = "chacha20-poly1305"
+)
+
+// ErrKeyNotFound is returned by KeyProvider if the key is not found.
+var ErrKeyNotFound = errors.New("encryption key not found")
+
+// Key is a key to encrypt and decrypt payloads.
+// Its fields must not be modified once it's used, as the AEAD cipher is built only once.
+type Key struct {
+	ID        string    // ID is carried along with the encrypted payload to find the key for decryption.
+	Algorithm Algorithm // Algorithm is the AEAD algorithm.
+	Secret    []byte    // Secret is the secret key of the algorithm.
+
+	once sync.Once
+	aead cipher.AEAD
+	err  error
+}
+
+// AEAD returns the AEAD cipher of the key, which is safe for concurrent use.
+func (k *Key) AEAD() (cipher.AEAD, error) {
+	k.once.Do(func() {
+		k.aead, k.err = newAEAD(k.Algorithm, k.Secret)
+	})
+	return k.aead, k.err
+}
+
+// newAEAD builds the AEAD cipher of the algorithm with the secret.
+func newAEAD(algorithm Algorithm, secret []byte) (cipher.AEAD, error) {
+	switch algorithm {
+	case AESGCM:
+		block, err := aes.NewCipher(secret)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case ChaCha20Poly1305:
+		return chacha20poly1305.New(secret)
+	default:
+		return nil, fmt.Errorf("unknown encryption algorithm %q", algorithm)
+	}
+}
+
+// Seal encrypts and authenticates plaintext, and returns the random nonce followed by the ciphertext.
+// The key ID followed by additionalData is authenticated, so that the ciphertext can only be opened
+// with the same additionalData, which binds it to its context.
+func (k *Key) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := k.AEAD()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, k.additionalData(additionalData)), nil
+}
+
+// Open decrypts and authenticates the output of Seal with the same additionalData.
+func (k *Key) Open(ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := k.AEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("ciphertext len %d is too short", len(ciphertext))
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, k.additionalData(additionalData))
+}
+
+// additionalData returns the key ID followed by ad.
+func (k *Key) additionalData(ad []byte) []byte {
+	return append([]byte(k.ID), ad...)
+}
+
+// KeyProvider provides keys for encryption and decryption.
+type KeyProvider interface {
+	// CurrentKey returns the key to encrypt new requests.
+	CurrentKey() (*Key, error)
+	// Key returns the key of id. To rotate keys, a key should still be returned after it's no longer
+	// the current key, until all payloads encrypted with it have been decrypted.
+	Key(id string) (*Key, error)
+}
+
+// StaticKeyProvider is a KeyProvider of a fixed set of keys.
+type StaticKeyProvider struct {
+	current *Key
+	keys    map[string]*Key
+}
+
+// NewStaticKeyProvider creates a StaticKeyProvider, which encrypts with current, and decrypts with
+// current and others.
+func NewStaticKeyProvider(current *Key, others ...*Key) (*StaticKeyProvider, error) {
+	p := &StaticKeyProvider{current: current, keys: make(map[string]*Key)}
+	for _, k := range append([]*Key{current}, others...) {
+		if _, err := k.AEAD(); err != nil {
+			return nil, fmt.Errorf("invalid encryption key %s: %w", k.ID, err)
+		}
+		if _, ok := p.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicated encryption key %s", k.ID)
+		}
+		p.keys[k.ID] = k
+	}
+	return p, nil
+}
+
+// CurrentKey implements KeyProvider.
+func (p *StaticKeyProvider) CurrentKey() (*Key, error) {
+	return p.current, nil
+}
+
+// Key implements KeyProvider.
+func (p *StaticKeyProvider) Key(id string) (*Key, error) {
+	if k, ok := p.keys[id]; ok {
+		return k, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+var (
+	providers = make(map[string]KeyProvider)
+	lock      = sync.RWMutex{}
+)
+
+// RegisterKeyProvider registers a KeyProvider by name, which can be referenced in config.
+func RegisterKeyProvider(name string, p KeyProvider) {
+	lock.Lock()
+	defer lock.Unlock()
+	providers[name] = p
+}
+
+// GetKeyProvider returns the KeyProvider of name.
+func GetKeyProvider(name string) KeyProvider {
+	lock.RLock()
+	defer lock.RUnlock()
+	return providers[name]
+}
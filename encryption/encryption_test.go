@@ -0,0 +1,99 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package encryption_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/encryption"
+)
+
+func TestKeySealOpen(t *testing.T) {
+	for _, k := range []*encryption.Key{
+		{ID: "aes-128", Algorithm: encryption.AESGCM, Secret: bytes.Repeat([]byte{1}, 16)},
+		{ID: "aes-256", Algorithm: encryption.AESGCM, Secret: bytes.Repeat([]byte{2}, 32)},
+		{ID: "chacha", Algorithm: encryption.ChaCha20Poly1305, Secret: bytes.Repeat([]byte{3}, 32)},
+	} {
+		t.Run(k.ID, func(t *testing.T) {
+			aead1, err := k.AEAD()
+			require.Nil(t, err)
+			aead2, err := k.AEAD()
+			require.Nil(t, err)
+			require.True(t, aead1 == aead2, "AEAD should be built once")
+
+			plaintext := []byte("hello")
+			sealed1, err := k.Seal(plaintext, []byte("ad"))
+			require.Nil(t, err)
+			sealed2, err := k.Seal(plaintext, []byte("ad"))
+			require.Nil(t, err)
+			require.NotEqual(t, sealed1, sealed2, "nonce should be random")
+			opened, err := k.Open(sealed1, []byte("ad"))
+			require.Nil(t, err)
+			require.Equal(t, plaintext, opened)
+
+			empty, err := k.Seal(nil, nil)
+			require.Nil(t, err)
+			opened, err = k.Open(empty, nil)
+			require.Nil(t, err)
+			require.Empty(t, opened)
+
+			sealed1[len(sealed1)-1] ^= 1
+			_, err = k.Open(sealed1, []byte("ad"))
+			require.NotNil(t, err)
+			_, err = k.Open(sealed2[:3], []byte("ad"))
+			require.NotNil(t, err)
+
+			// the key id is authenticated
+			renamed := &encryption.Key{ID: "renamed", Algorithm: k.Algorithm, Secret: k.Secret}
+			_, err = renamed.Open(sealed2, []byte("ad"))
+			require.NotNil(t, err)
+			// the additional data is authenticated
+			_, err = k.Open(sealed2, []byte("other"))
+			require.NotNil(t, err)
+		})
+	}
+
+	_, err := (&encryption.Key{ID: "a", Algorithm: "unknown"}).Seal(nil, nil)
+	require.NotNil(t, err)
+	_, err = (&encryption.Key{ID: "a", Algorithm: encryption.AESGCM, Secret: []byte("short")}).Seal(nil, nil)
+	require.NotNil(t, err)
+}
+
+func TestStaticKeyProvider(t *testing.T) {
+	old := &encryption.Key{ID: "1", Algorithm: encryption.AESGCM, Secret: bytes.Repeat([]byte{1}, 32)}
+	current := &encryption.Key{ID: "2", Algorithm: encryption.ChaCha20Poly1305, Secret: bytes.Repeat([]byte{2}, 32)}
+	p, err := encryption.NewStaticKeyProvider(current, old)
+	require.Nil(t, err)
+
+	k, err := p.CurrentKey()
+	require.Nil(t, err)
+	require.Same(t, current, k)
+	k, err = p.Key("1")
+	require.Nil(t, err)
+	require.Same(t, old, k)
+	_, err = p.Key("3")
+	require.ErrorIs(t, err, encryption.ErrKeyNotFound)
+
+	_, err = encryption.NewStaticKeyProvider(current, current)
+	require.NotNil(t, err)
+	_, err = encryption.NewStaticKeyProvider(&encryption.Key{ID: "3", Algorithm: encryption.ChaCha20Poly1305})
+	require.NotNil(t, err)
+
+	encryption.RegisterKeyProvider("static", p)
+	require.Equal(t, p, encryption.GetKeyProvider("static"))
+	require.Nil(t, encryption.GetKeyProvider("not-exist"))
+}
@@ -30,6 +30,9 @@ const (
 
 	// RetServerDecodeFail is the error code of the server decoding error.
 	RetServerDecodeFail = trpcpb.TrpcRetCode_TRPC_SERVER_DECODE_ERR
+	// RetServerChecksumFail is the error code of the request frame failing its checksum.
+	// It's not defined by trpc protocol yet, and is reported by the server with checksums enabled only.
+	RetServerChecksumFail = trpcpb.TrpcRetCode(3)
 	// RetServerEncodeFail is the error code of the server encoding error.
 	RetServerEncodeFail = trpcpb.TrpcRetCode_TRPC_SERVER_ENCODE_ERR
 	// RetServerNoService is the error code that the server does not call the corresponding service implementation.
@@ -50,6 +53,11 @@ const (
 	RetServerAuthFail = trpcpb.TrpcRetCode_TRPC_SERVER_AUTH_ERR
 	// RetServerValidateFail is the error code for the failure of automatic validation of request parameters.
 	RetServerValidateFail = trpcpb.TrpcRetCode_TRPC_SERVER_VALIDATE_ERR
+	// RetServerStreamReadTimeout is the error code of the server stream receiving nothing for the max idle time.
+	RetServerStreamReadTimeout = trpcpb.TrpcRetCode_TRPC_STREAM_SERVER_READ_TIMEOUT_ERR
+	// RetServerMsgExceedLimit is the error code of the request exceeding the max request size of the server,
+	// or the response exceeding the max response size of the server.
+	RetServerMsgExceedLimit = trpcpb.TrpcRetCode_TRPC_STREAM_SERVER_MSG_EXCEED_LIMIT_ERR
 
 	// RetClientTimeout is the error code that the request timed out on the client side.
 	RetClientTimeout = trpcpb.TrpcRetCode_TRPC_CLIENT_INVOKE_TIMEOUT_ERR
@@ -61,6 +69,9 @@ const (
 	RetClientEncodeFail = trpcpb.TrpcRetCode_TRPC_CLIENT_ENCODE_ERR
 	// RetClientDecodeFail is the error code of the client decoding error.
 	RetClientDecodeFail = trpcpb.TrpcRetCode_TRPC_CLIENT_DECODE_ERR
+	// RetClientChecksumFail is the error code of the response frame failing its checksum.
+	// It's not defined by trpc protocol yet, and is reported by the client with checksums enabled only.
+	RetClientChecksumFail = trpcpb.TrpcRetCode(125)
 	// RetClientThrottled is the error code of the client's current limit.
 	RetClientThrottled = trpcpb.TrpcRetCode_TRPC_CLIENT_LIMITED_ERR
 	// RetClientOverload is the error code for client overload.
@@ -79,6 +90,10 @@ const (
 	RetClientStreamQueueFull = trpcpb.TrpcRetCode_TRPC_STREAM_SERVER_NETWORK_ERR
 	// RetClientStreamReadEnd is the error code of the client stream end error while receiving data.
 	RetClientStreamReadEnd = trpcpb.TrpcRetCode_TRPC_STREAM_CLIENT_READ_END
+	// RetClientStreamReadTimeout is the error code of the client stream receiving nothing for the max idle time.
+	RetClientStreamReadTimeout = trpcpb.TrpcRetCode_TRPC_STREAM_CLIENT_READ_TIMEOUT_ERR
+	// RetClientMsgExceedLimit is the error code of the response exceeding the max response size of the client.
+	RetClientMsgExceedLimit = trpcpb.TrpcRetCode_TRPC_STREAM_CLIENT_MSG_EXCEED_LIMIT_ERR
 
 	// RetUnknown is the error code for unspecified errors.
 	RetUnknown = trpcpb.TrpcRetCode_TRPC_INVOKE_UNKNOWN_ERR
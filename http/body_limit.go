@@ -0,0 +1,79 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package http
+
+import (
+	"fmt"
+	"io"
+	stdhttp "net/http"
+
+	"trpc.group/trpc-go/trpc-go/errs"
+)
+
+// limitRequestBody limits the body of r to n bytes, reading more fails with errs.RetServerMsgExceedLimit.
+// Zero n means no limit.
+func limitRequestBody(r *stdhttp.Request, n int) {
+	if n <= 0 || r.Body == nil {
+		return
+	}
+	r.Body = newLimitedBody(r.Body, r.ContentLength, n, errs.NewFrameError(errs.RetServerMsgExceedLimit,
+		fmt.Sprintf("http request body exceeds the max request size %d", n)))
+}
+
+// limitResponseBody limits the body of rsp to n bytes, reading more fails with errs.RetClientMsgExceedLimit.
+// Zero n means no limit.
+func limitResponseBody(rsp *stdhttp.Response, n int) io.Reader {
+	if n <= 0 {
+		return rsp.Body
+	}
+	return newLimitedBody(rsp.Body, rsp.ContentLength, n, errs.NewFrameError(errs.RetClientMsgExceedLimit,
+		fmt.Sprintf("http response body exceeds the max response size %d", n)))
+}
+
+// limitedBody limits the bytes read from an http body, and fails with err once the limit is exceeded.
+// Unlike http.MaxBytesReader, the connection is not closed, so that the error can still be responded.
+type limitedBody struct {
+	io.ReadCloser
+	remain   int64
+	exceeded bool
+	err      error
+}
+
+// newLimitedBody returns a body which allows reading at most n bytes from body.
+// A body whose content length is already known to exceed n fails at the first read.
+func newLimitedBody(body io.ReadCloser, contentLength int64, n int, err error) *limitedBody {
+	return &limitedBody{
+		ReadCloser: body,
+		remain:     int64(n),
+		exceeded:   contentLength > int64(n),
+		err:        err,
+	}
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, b.err
+	}
+	if int64(len(p)) > b.remain+1 {
+		p = p[:b.remain+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remain {
+		b.remain -= int64(n)
+		return n, err
+	}
+	b.exceeded = true
+	return int(b.remain), b.err
+}
@@ -35,6 +35,7 @@ import (
 	"trpc.group/trpc-go/trpc-go/errs"
 	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
+	"trpc.group/trpc-go/trpc-go/internal/report"
 )
 
 // Constants of header keys related to trpc.
@@ -94,6 +95,9 @@ var contentTypeSerializationType = map[string]int{
 	"application/xml":                   codec.SerializationTypeXML,
 	"text/xml":                          codec.SerializationTypeTextXML,
 	"multipart/form-data":               codec.SerializationTypeFormData,
+	"application/msgpack":               codec.SerializationTypeMsgPack,
+	"application/x-msgpack":             codec.SerializationTypeMsgPack,
+	"application/cbor":                  codec.SerializationTypeCBOR,
 }
 
 var serializationTypeContentType = map[int]string{
@@ -105,14 +109,18 @@ var serializationTypeContentType = map[int]string{
 	codec.SerializationTypeXML:        "application/xml",
 	codec.SerializationTypeTextXML:    "text/xml",
 	codec.SerializationTypeFormData:   "multipart/form-data",
+	codec.SerializationTypeMsgPack:    "application/msgpack",
+	codec.SerializationTypeCBOR:       "application/cbor",
 }
 
 var contentEncodingCompressType = map[string]int{
 	"gzip": codec.CompressTypeGzip,
+	"zstd": codec.CompressTypeZstd,
 }
 
 var compressTypeContentEncoding = map[int]string{
 	codec.CompressTypeGzip: "gzip",
+	codec.CompressTypeZstd: "zstd",
 }
 
 // RegisterSerializer registers a new custom serialization method,
@@ -271,6 +279,10 @@ type ClientRspHeader struct {
 	// SSEHandler handles server-sent event callbacks.
 	// It only takes effect when ManualReadBody is false.
 	SSEHandler SSEHandler
+
+	// maxBodySize is the max size of the response body read by the framework, zero means no limit.
+	// It's set by the transport from transport.RoundTripOptions.MaxResponseSize.
+	maxBodySize int
 }
 
 // RspHandler handles common HTTP responses.
@@ -285,16 +297,17 @@ type SSEHandler interface {
 
 // ErrsToHTTPStatus maps from framework errs retcode to http status code.
 var ErrsToHTTPStatus = map[trpcpb.TrpcRetCode]int{
-	errs.RetServerDecodeFail:   http.StatusBadRequest,
-	errs.RetServerEncodeFail:   http.StatusInternalServerError,
-	errs.RetServerNoService:    http.StatusNotFound,
-	errs.RetServerNoFunc:       http.StatusNotFound,
-	errs.RetServerTimeout:      http.StatusGatewayTimeout,
-	errs.RetServerOverload:     http.StatusTooManyRequests,
-	errs.RetServerSystemErr:    http.StatusInternalServerError,
-	errs.RetServerAuthFail:     http.StatusUnauthorized,
-	errs.RetServerValidateFail: http.StatusBadRequest,
-	errs.RetUnknown:            http.StatusInternalServerError,
+	errs.RetServerDecodeFail:     http.StatusBadRequest,
+	errs.RetServerEncodeFail:     http.StatusInternalServerError,
+	errs.RetServerNoService:      http.StatusNotFound,
+	errs.RetServerNoFunc:         http.StatusNotFound,
+	errs.RetServerTimeout:        http.StatusGatewayTimeout,
+	errs.RetServerOverload:       http.StatusTooManyRequests,
+	errs.RetServerSystemErr:      http.StatusInternalServerError,
+	errs.RetServerAuthFail:       http.StatusUnauthorized,
+	errs.RetServerValidateFail:   http.StatusBadRequest,
+	errs.RetServerMsgExceedLimit: http.StatusRequestEntityTooLarge,
+	errs.RetUnknown:              http.StatusInternalServerError,
 }
 
 // Head gets the corresponding http header from context.
@@ -507,6 +520,13 @@ func (sc *ServerCodec) Decode(msg codec.Msg, _ []byte) ([]byte, error) {
 	head.reqContentType = head.Request.Header.Get("Content-Type")
 
 	reqBody, err := sc.getReqbody(head, msg)
+	var e *errs.Error
+	if errors.As(err, &e) && e.Code == errs.RetServerMsgExceedLimit {
+		report.ServiceRequestTooLarge.Incr()
+		// the error is responded to client by the ErrorHandler.
+		msg.WithServerRspErr(e)
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -701,7 +721,7 @@ func handleResponseBody(rspHeader *ClientRspHeader, msg codec.Msg) ([]byte, erro
 		return nil, nil
 	}
 
-	body, err := io.ReadAll(rsp.Body)
+	body, err := io.ReadAll(limitResponseBody(rsp, rspHeader.maxBodySize))
 	if err != nil {
 		return nil, fmt.Errorf("readall http body fail: %w", err)
 	}
@@ -724,6 +744,12 @@ func (c *ClientCodec) Decode(msg codec.Msg, _ []byte) ([]byte, error) {
 	)
 	rsp := rspHeader.Response
 	if body, err = handleResponseBody(rspHeader, msg); err != nil {
+		var e *errs.Error
+		if errors.As(err, &e) && e.Code == errs.RetClientMsgExceedLimit {
+			report.ClientResponseTooLarge.Incr()
+			msg.WithClientRspErr(e)
+			return nil, nil
+		}
 		return nil, err
 	}
 
@@ -308,6 +308,29 @@ func TestServerCodecEncodeUsesDecodedContentType(t *testing.T) {
 	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
 }
 
+func TestServerCodecDecodeMsgPackAndCBOR(t *testing.T) {
+	for contentType, serializationType := range map[string]int{
+		"application/msgpack":   codec.SerializationTypeMsgPack,
+		"application/x-msgpack": codec.SerializationTypeMsgPack,
+		"application/cbor":      codec.SerializationTypeCBOR,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "http://www.qq.com/trpc.http.test.helloworld/SayHello",
+			bytes.NewReader(nil))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		ctx := thttp.WithHeader(context.Background(), &thttp.Header{Request: r, Response: w})
+		msg := codec.Message(ctx)
+
+		_, err := thttp.DefaultServerCodec.Decode(msg, nil)
+		require.Nil(t, err)
+		require.Equal(t, serializationType, msg.SerializationType())
+		_, err = thttp.DefaultServerCodec.Encode(msg, nil)
+		require.Nil(t, err)
+		require.NotEmpty(t, w.Header().Get("Content-Type"))
+		require.Contains(t, contentType, strings.TrimPrefix(w.Header().Get("Content-Type"), "application/"))
+	}
+}
+
 func TestServerDecode(t *testing.T) {
 	r, _ := http.NewRequest("GET", "www.qq.com/xyz=abc", bytes.NewReader([]byte("")))
 	w := &httptest.ResponseRecorder{}
@@ -29,6 +29,7 @@ import (
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
 	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
+	"trpc.group/trpc-go/trpc-go/internal/connlimit"
 	"trpc.group/trpc-go/trpc-go/internal/reuseport"
 	itls "trpc.group/trpc-go/trpc-go/internal/tls"
 	"trpc.group/trpc-go/trpc-go/log"
@@ -132,6 +133,10 @@ func (st *FastHTTPServerTransport) configureFastHTTPServer(
 	if st.Server != nil {
 		copyServerConfig(server, st.Server)
 	}
+	if opts.MaxRequestSize > 0 {
+		// fasthttp responds 413 to larger requests by itself.
+		server.MaxRequestBodySize = opts.MaxRequestSize
+	}
 
 	// Wrap opts.Handler for server.Handler.
 	server.Handler = func(requestCtx *fasthttp.RequestCtx) {
@@ -240,6 +245,7 @@ func (st *FastHTTPServerTransport) serve(ctx context.Context, server *fasthttp.S
 	if err := transport.SaveListener(ln); err != nil {
 		return fmt.Errorf("save listener error: %w", err)
 	}
+	limiter := connlimit.New(opts.MaxConnections, opts.MaxConnectionsPerIP, opts.ConnectionLimitWait)
 
 	// ServeTLS will only be invoked if TLSKeyFile and TLSCertFile are configured.
 	if len(opts.TLSKeyFile) != 0 && len(opts.TLSCertFile) != 0 {
@@ -258,14 +264,16 @@ func (st *FastHTTPServerTransport) serve(ctx context.Context, server *fasthttp.S
 		go func() {
 			// The TLSConfig has been initialized, including ClientCAs and Certificates.
 			// Therefore, it is only necessary to pass empty cert and key files to ServeTLS.
-			if err := server.ServeTLS(tcpKeepAliveListener{TCPListener: ln.(*net.TCPListener)},
+			if err := server.ServeTLS(
+				connlimit.NewListener(tcpKeepAliveListener{TCPListener: ln.(*net.TCPListener)}, limiter),
 				"", ""); err != nil {
 				log.Errorf("serve TLS failed: %v", err)
 			}
 		}()
 	} else {
 		go func() {
-			if err := server.Serve(tcpKeepAliveListener{TCPListener: ln.(*net.TCPListener)}); err != nil {
+			if err := server.Serve(
+				connlimit.NewListener(tcpKeepAliveListener{TCPListener: ln.(*net.TCPListener)}, limiter)); err != nil {
 				log.Errorf("serve err: %w", err)
 			}
 		}()
@@ -14,10 +14,12 @@
 package http_test
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/valyala/fasthttp"
@@ -64,6 +66,45 @@ func TestFastHTTPServerTransportServe(t *testing.T) {
 	require.Equal(t, "ok", string(body))
 }
 
+func TestFastHTTPServerTransportMaxConnections(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := thttp.NewFastHTTPServerTransport(transport.WithReusePort(false))
+	err = st.ListenAndServe(ctx,
+		transport.WithListener(ln),
+		transport.WithHandler(transportHandlerFunc(func(ctx context.Context, _ []byte) ([]byte, error) {
+			return nil, nil
+		})),
+		transport.WithMaxConnections(1),
+	)
+	require.NoError(t, err)
+
+	c1, err := fasthttp.DialTimeout(ln.Addr().String(), time.Second)
+	require.NoError(t, err)
+	defer c1.Close()
+	require.NoError(t, c1.SetDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, getOverConn(c1))
+
+	c2, err := fasthttp.DialTimeout(ln.Addr().String(), time.Second)
+	require.NoError(t, err)
+	defer c2.Close()
+	require.NoError(t, c2.SetDeadline(time.Now().Add(time.Second)))
+	require.Error(t, getOverConn(c2))
+}
+
+// getOverConn sends a GET request over conn and reads its response.
+func getOverConn(conn net.Conn) error {
+	if _, err := conn.Write([]byte("GET /ping HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		return err
+	}
+	var rsp fasthttp.Response
+	return rsp.Read(bufio.NewReader(conn))
+}
+
 func TestFastHTTPClientTransportRoundTrip(t *testing.T) {
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	require.NoError(t, err)
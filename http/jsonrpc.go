@@ -0,0 +1,327 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	stdhttp "net/http"
+	"strings"
+
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/protocol"
+	"trpc.group/trpc-go/trpc-go/log"
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+func init() {
+	DefaultJSONRPCServerTransport = NewJSONRPCServerTransport(
+		NewServerTransport(func() *stdhttp.Server { return &stdhttp.Server{} }))
+	codec.Register(protocol.JSONRPC, DefaultJSONRPCServerCodec, nil)
+	transport.RegisterServerTransport(protocol.JSONRPC, DefaultJSONRPCServerTransport)
+}
+
+// jsonrpcVersion is the only supported version of JSON-RPC.
+const jsonrpcVersion = "2.0"
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// ErrsToJSONRPCCode maps from framework errs retcode to JSON-RPC error code.
+// Framework errors not in it are mapped to JSONRPCInternalError,
+// and business errors keep their own codes.
+var ErrsToJSONRPCCode = map[trpcpb.TrpcRetCode]int{
+	errs.RetServerDecodeFail:   JSONRPCInvalidParams,
+	errs.RetServerValidateFail: JSONRPCInvalidParams,
+	errs.RetServerNoService:    JSONRPCMethodNotFound,
+	errs.RetServerNoFunc:       JSONRPCMethodNotFound,
+}
+
+var (
+	// DefaultJSONRPCServerCodec is the default JSON-RPC server codec.
+	DefaultJSONRPCServerCodec = &JSONRPCServerCodec{}
+
+	// DefaultJSONRPCServerTransport is the default JSON-RPC server transport.
+	DefaultJSONRPCServerTransport transport.ServerTransport
+)
+
+// JSONRPCRequest is a JSON-RPC 2.0 request object. It's set as the server request head of msg.
+type JSONRPCRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// ID is nil for notifications, which are not responded.
+	ID json.RawMessage `json:"id,omitempty"`
+}
+
+// JSONRPCResponse is a JSON-RPC 2.0 response object. It's set as the server response head of msg.
+type JSONRPCResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// JSONRPCError is a JSON-RPC 2.0 error object.
+type JSONRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// JSONRPCErrorData is the data of the error object of a framework error, which carries the original retcode.
+type JSONRPCErrorData struct {
+	Ret int32 `json:"trpc_ret"`
+}
+
+// newJSONRPCError converts an errs error to a JSON-RPC error object.
+func newJSONRPCError(e *errs.Error) *JSONRPCError {
+	if e.Type != errs.ErrorTypeFramework {
+		return &JSONRPCError{Code: int(e.Code), Message: e.Msg}
+	}
+	code, ok := ErrsToJSONRPCCode[e.Code]
+	if !ok {
+		code = JSONRPCInternalError
+	}
+	return &JSONRPCError{Code: code, Message: e.Msg, Data: &JSONRPCErrorData{Ret: int32(e.Code)}}
+}
+
+// JSONRPCServerCodec is the server codec of a single JSON-RPC call. The call is parsed and set as
+// the server request head by JSONRPCServerTransport before decoding.
+type JSONRPCServerCodec struct{}
+
+// Decode implements codec.Codec. It dispatches the method to the rpc name, and returns the params
+// as the request body, which is decoded by jsonpb.
+func (*JSONRPCServerCodec) Decode(msg codec.Msg, _ []byte) ([]byte, error) {
+	req, ok := msg.ServerReqHead().(*JSONRPCRequest)
+	if !ok {
+		return nil, errors.New("jsonrpc server decode: no request head")
+	}
+	rpcName := req.Method
+	if !strings.HasPrefix(rpcName, "/") {
+		rpcName = "/" + rpcName
+	}
+	msg.WithServerRPCName(rpcName)
+	msg.WithCalleeMethod(rpcName)
+	msg.WithSerializationType(codec.SerializationTypeJSON)
+	msg.WithCompressType(codec.CompressTypeNoop)
+	if req.ID == nil {
+		msg.WithCallType(codec.SendOnly)
+	}
+	return jsonrpcParams(req.Params)
+}
+
+// jsonrpcParams returns the params of by-name, or the only element of by-position.
+func jsonrpcParams(params json.RawMessage) ([]byte, error) {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return []byte("{}"), nil
+	}
+	switch params[0] {
+	case '{':
+		return params, nil
+	case '[':
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil {
+			return nil, err
+		}
+		if len(positional) != 1 {
+			return nil, fmt.Errorf("want 1 positional param, got %d", len(positional))
+		}
+		return positional[0], nil
+	default:
+		return nil, errors.New("params must be an object or an array")
+	}
+}
+
+// Encode implements codec.Codec. It returns the response object of the result or the error of msg.
+func (*JSONRPCServerCodec) Encode(msg codec.Msg, rspBody []byte) ([]byte, error) {
+	req, ok := msg.ServerReqHead().(*JSONRPCRequest)
+	if !ok {
+		return nil, errors.New("jsonrpc server encode: no request head")
+	}
+	rsp := &JSONRPCResponse{Version: jsonrpcVersion, ID: req.ID}
+	if e := msg.ServerRspErr(); e != nil {
+		rsp.Error = newJSONRPCError(e)
+	} else if len(rspBody) == 0 {
+		rsp.Result = json.RawMessage("null")
+	} else {
+		rsp.Result = rspBody
+	}
+	msg.WithServerRspHead(rsp)
+	return json.Marshal(rsp)
+}
+
+// JSONRPCServerTransport is the server transport of JSON-RPC 2.0 over http.
+// Each http POST request carries a single call or a batch of calls,
+// and each call is handled as a separate request by the service, including its filter chain.
+type JSONRPCServerTransport struct {
+	transport.ServerTransport
+}
+
+// NewJSONRPCServerTransport creates a JSON-RPC server transport on the http server transport st.
+func NewJSONRPCServerTransport(st transport.ServerTransport) *JSONRPCServerTransport {
+	return &JSONRPCServerTransport{ServerTransport: st}
+}
+
+// ListenAndServe implements transport.ServerTransport.
+func (t *JSONRPCServerTransport) ListenAndServe(ctx context.Context, opt ...transport.ListenServeOption) error {
+	opts := &transport.ListenServeOptions{}
+	for _, o := range opt {
+		o(opts)
+	}
+	if opts.Handler == nil {
+		return errors.New("jsonrpc server transport handler empty")
+	}
+	return t.ServerTransport.ListenAndServe(ctx,
+		append(opt, transport.WithHandler(&jsonrpcHandler{handler: opts.Handler}))...)
+}
+
+// jsonrpcHandler handles http requests of JSON-RPC by calling handler for each call.
+type jsonrpcHandler struct {
+	handler transport.Handler
+}
+
+// Handle implements transport.Handler. It writes the http response itself.
+func (h *jsonrpcHandler) Handle(ctx context.Context, _ []byte) ([]byte, error) {
+	head := Head(ctx)
+	if head == nil {
+		return nil, ErrEncodeMissingHeader
+	}
+	w := head.Response
+	if head.Request.Method != stdhttp.MethodPost {
+		w.Header().Set("Allow", stdhttp.MethodPost)
+		w.WriteHeader(stdhttp.StatusMethodNotAllowed)
+		return nil, nil
+	}
+	body, err := io.ReadAll(head.Request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("jsonrpc read body: %w", err)
+	}
+	head.ReqBody = body
+
+	var rsp []byte
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		rsp = h.handleBatch(ctx, body)
+	} else {
+		rsp = h.handleCall(ctx, body)
+	}
+	if rsp == nil {
+		w.WriteHeader(stdhttp.StatusNoContent)
+		return nil, nil
+	}
+	w.Header().Set(canonicalContentType, serializationTypeContentType[codec.SerializationTypeJSON])
+	_, err = w.Write(rsp)
+	return nil, err
+}
+
+// handleBatch handles calls in order, and returns the array of their responses,
+// or nil if all of them are notifications.
+func (h *jsonrpcHandler) handleBatch(ctx context.Context, body []byte) []byte {
+	var calls []json.RawMessage
+	if err := json.Unmarshal(body, &calls); err != nil {
+		return marshalJSONRPCError(nil, JSONRPCParseError, err.Error())
+	}
+	if len(calls) == 0 {
+		return marshalJSONRPCError(nil, JSONRPCInvalidRequest, "empty batch")
+	}
+	var rsps [][]byte
+	for _, call := range calls {
+		if rsp := h.handleCall(ctx, call); rsp != nil {
+			rsps = append(rsps, rsp)
+		}
+	}
+	if len(rsps) == 0 {
+		return nil
+	}
+	return append(append([]byte{'['}, bytes.Join(rsps, []byte{','})...), ']')
+}
+
+// handleCall handles a single call, and returns its response, or nil if it's a notification.
+func (h *jsonrpcHandler) handleCall(ctx context.Context, call []byte) []byte {
+	if !json.Valid(call) {
+		return marshalJSONRPCError(nil, JSONRPCParseError, "invalid json")
+	}
+	req := &JSONRPCRequest{}
+	if err := json.Unmarshal(call, req); err != nil {
+		return marshalJSONRPCError(nil, JSONRPCInvalidRequest, err.Error())
+	}
+	if !validJSONRPCID(req.ID) {
+		return marshalJSONRPCError(nil, JSONRPCInvalidRequest, "id must be a string, number or null")
+	}
+	if req.Version != jsonrpcVersion || req.Method == "" {
+		return marshalJSONRPCError(req.ID, JSONRPCInvalidRequest, "invalid jsonrpc version or empty method")
+	}
+
+	// Every call has its own message, so that calls in a batch are independent of each other.
+	outer := codec.Message(ctx)
+	ctx, msg := codec.WithNewMessage(ctx)
+	defer codec.PutBackMessage(msg)
+	msg.WithLocalAddr(outer.LocalAddr())
+	msg.WithRemoteAddr(outer.RemoteAddr())
+	msg.WithServerReqHead(req)
+
+	rsp, err := h.handler.Handle(ctx, nil)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		log.ErrorContextf(ctx, "jsonrpc server handle %s fail: %v", req.Method, err)
+		return marshalJSONRPCError(req.ID, JSONRPCInternalError, err.Error())
+	}
+	return rsp
+}
+
+// validJSONRPCID reports whether id is absent, a string, a number or null.
+func validJSONRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return false
+	}
+	switch v.(type) {
+	case nil, string, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func marshalJSONRPCError(id json.RawMessage, code int, message string) []byte {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	rsp, _ := json.Marshal(&JSONRPCResponse{
+		Version: jsonrpcVersion,
+		Error:   &JSONRPCError{Code: code, Message: message},
+		ID:      id,
+	})
+	return rsp
+}
@@ -0,0 +1,134 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package http_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/filter"
+	"trpc.group/trpc-go/trpc-go/server"
+	"trpc.group/trpc-go/trpc-go/testdata/restful/helloworld"
+)
+
+func TestJSONRPC(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+	notified := make(chan string, 1)
+	s := server.New(
+		server.WithServiceName("trpc.test.jsonrpc.Greeter"),
+		server.WithListener(ln),
+		server.WithProtocol("jsonrpc"),
+		server.WithFilter(func(ctx context.Context, req interface{}, next filter.ServerHandleFunc) (interface{}, error) {
+			switch name := req.(*helloworld.HelloRequest).Name; name {
+			case "error":
+				return nil, errs.New(10001, "business error")
+			case "notify":
+				notified <- name
+			}
+			return next(ctx, req)
+		}),
+	)
+	helloworld.RegisterGreeterService(s, &greeterImpl{})
+	go s.Serve()
+	defer s.Close(nil)
+	url := "http://" + ln.Addr().String() + "/"
+
+	post := func(body string) (int, string) {
+		rsp, err := http.Post(url, "application/json", strings.NewReader(body))
+		require.Nil(t, err)
+		defer rsp.Body.Close()
+		b, err := io.ReadAll(rsp.Body)
+		require.Nil(t, err)
+		return rsp.StatusCode, string(b)
+	}
+
+	for _, tt := range []struct {
+		name string
+		req  string
+		rsp  string
+	}{
+		{
+			name: "by-name params",
+			req:  `{"jsonrpc":"2.0","method":"trpc.examples.restful.helloworld.Greeter/SayHello","params":{"name":"a"},"id":1}`,
+			rsp:  `{"jsonrpc":"2.0","result":{"message":"a"},"id":1}`,
+		},
+		{
+			name: "by-position params",
+			req:  `{"jsonrpc":"2.0","method":"/trpc.examples.restful.helloworld.Greeter/SayHello","params":[{"name":"b"}],"id":"x"}`,
+			rsp:  `{"jsonrpc":"2.0","result":{"message":"b"},"id":"x"}`,
+		},
+		{
+			name: "method not found",
+			req:  `{"jsonrpc":"2.0","method":"trpc.examples.restful.helloworld.Greeter/SayHi","id":2}`,
+			rsp:  `"error":{"code":-32601,`,
+		},
+		{
+			name: "invalid params",
+			req:  `{"jsonrpc":"2.0","method":"trpc.examples.restful.helloworld.Greeter/SayHello","params":{"name":1},"id":3}`,
+			rsp:  `"error":{"code":-32602,`,
+		},
+		{
+			name: "business error",
+			req:  `{"jsonrpc":"2.0","method":"trpc.examples.restful.helloworld.Greeter/SayHello","params":{"name":"error"},"id":null}`,
+			rsp:  `{"jsonrpc":"2.0","error":{"code":10001,"message":"business error"},"id":null}`,
+		},
+		{
+			name: "parse error",
+			req:  `{"jsonrpc":`,
+			rsp:  `{"jsonrpc":"2.0","error":{"code":-32700,"message":"invalid json"},"id":null}`,
+		},
+		{
+			name: "invalid request",
+			req:  `{"jsonrpc":"1.0","method":"m","id":4}`,
+			rsp:  `"error":{"code":-32600,`,
+		},
+		{
+			name: "empty batch",
+			req:  `[]`,
+			rsp:  `{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"},"id":null}`,
+		},
+		{
+			name: "batch",
+			req: `[{"jsonrpc":"2.0","method":"trpc.examples.restful.helloworld.Greeter/SayHello","params":{"name":"c"},"id":5},` +
+				`{"jsonrpc":"2.0","method":"trpc.examples.restful.helloworld.Greeter/SayHello","params":{"name":"d"}},` +
+				`1]`,
+			rsp: `[{"jsonrpc":"2.0","result":{"message":"c"},"id":5},{"jsonrpc":"2.0","error":{"code":-32600,`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			code, rsp := post(tt.req)
+			require.Equal(t, http.StatusOK, code)
+			require.Contains(t, rsp, tt.rsp)
+		})
+	}
+
+	code, rsp := post(`{"jsonrpc":"2.0","method":"trpc.examples.restful.helloworld.Greeter/SayHello","params":{"name":"notify"}}`)
+	require.Equal(t, http.StatusNoContent, code)
+	require.Empty(t, rsp)
+	require.Equal(t, "notify", <-notified)
+
+	r, err := http.Get(url)
+	require.Nil(t, err)
+	r.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, r.StatusCode)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/valyala/fasthttp"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
+	"trpc.group/trpc-go/trpc-go/internal/connlimit"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	"trpc.group/trpc-go/trpc-go/internal/reuseport"
 	itls "trpc.group/trpc-go/trpc-go/internal/tls"
@@ -156,6 +157,9 @@ func (st *RESTServerTransport) ListenAndServe(ctx context.Context, opt ...transp
 	if tcpln, ok := ln.(*net.TCPListener); ok {
 		ln = tcpKeepAliveListener{tcpln}
 	}
+	// Limit connections before the tls handshake.
+	limiter := connlimit.New(opts.MaxConnections, opts.MaxConnectionsPerIP, opts.ConnectionLimitWait)
+	ln = connlimit.NewListener(ln, limiter)
 	// Config tls.
 	if len(opts.TLSKeyFile) != 0 && len(opts.TLSCertFile) != 0 {
 		tlsConf, err := generateTLSConfig(opts)
@@ -195,6 +199,10 @@ func (st *RESTServerTransport) serve(
 			return errReplaceRouter
 		}
 		server := &fasthttp.Server{Handler: r.HandleRequestCtx}
+		if opts.MaxRequestSize > 0 {
+			// fasthttp responds 413 to larger requests by itself.
+			server.MaxRequestBodySize = opts.MaxRequestSize
+		}
 		go func() {
 			_ = server.Serve(ln)
 		}()
@@ -207,6 +215,13 @@ func (st *RESTServerTransport) serve(
 		return nil
 	}
 	// Based on net/http.
+	if opts.MaxRequestSize > 0 {
+		h := router
+		router = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limitRequestBody(r, opts.MaxRequestSize)
+			h.ServeHTTP(w, r)
+		})
+	}
 	server := &http.Server{Addr: opts.Address, Handler: router}
 	if st.opts.EnableH2C && (len(opts.TLSKeyFile) != 0 || len(opts.TLSCertFile) != 0) {
 		return errors.New("restful server transport h2c and tls cannot be enabled at the same time")
@@ -14,6 +14,7 @@
 package http_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
@@ -251,6 +252,46 @@ func TestRESTfulListenAndServeEmptyRouterFastHTTP(t *testing.T) {
 	))
 }
 
+func TestRESTfulListenAndServeMaxConnections(t *testing.T) {
+	for _, basedOnFastHTTP := range []bool{false, true} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.Nil(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		st := thttp.NewRESTServerTransport(basedOnFastHTTP, transport.WithReusePort(false))
+		require.Nil(t, st.ListenAndServe(ctx,
+			transport.WithListener(ln),
+			transport.WithServiceName(t.Name()),
+			transport.WithMaxConnections(1),
+		))
+
+		c1, err := net.Dial("tcp", ln.Addr().String())
+		require.Nil(t, err)
+		defer c1.Close()
+		require.Nil(t, c1.SetDeadline(time.Now().Add(time.Second)))
+		require.Nil(t, roundTripRaw(c1))
+
+		c2, err := net.Dial("tcp", ln.Addr().String())
+		require.Nil(t, err)
+		defer c2.Close()
+		require.Nil(t, c2.SetDeadline(time.Now().Add(time.Second)))
+		require.NotNil(t, roundTripRaw(c2), "basedOnFastHTTP: %v", basedOnFastHTTP)
+		ln.Close()
+	}
+}
+
+// roundTripRaw sends a GET request over conn and reads its response.
+func roundTripRaw(conn net.Conn) error {
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		return err
+	}
+	rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return err
+	}
+	return rsp.Body.Close()
+}
+
 var (
 	headerMatcherTransInfo, _ = json.Marshal(map[string]string{
 		"kfuin": base64.StdEncoding.EncodeToString([]byte("3009025887")),
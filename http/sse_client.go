@@ -0,0 +1,241 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/r3labs/sse/v2"
+
+	"trpc.group/trpc-go/trpc-go/client"
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+const (
+	// headerLastEventID is the header carrying the id of the last event received on reconnect.
+	headerLastEventID = "Last-Event-ID"
+
+	defaultSSEReconnectDelay    = 3 * time.Second
+	defaultSSEMaxReconnectDelay = 30 * time.Second
+)
+
+// SSEClient subscribes to Server-Sent Events, and reconnects automatically once the connection is broken.
+type SSEClient interface {
+	// Subscribe sends a GET request to path and handles the events by handler.
+	// Once the connection is broken or fails to be established, it reconnects after the delay, which is the
+	// latest retry field sent by the server and doubles for each consecutive reconnect without any event
+	// received. Each reconnect selects the node by the selector again, and sends the id of the last event
+	// received in the Last-Event-ID header.
+	// It returns when ctx is done, handler returns an error, the server responds 204 No Content or
+	// a 4xx status code, or reconnects are exhausted.
+	// The request head and response head are set by Subscribe, use WithSSERequestHeader to add headers.
+	Subscribe(ctx context.Context, path string, handler SSEHandler, opts ...client.Option) error
+}
+
+// OptSSEClient sets options of SSEClient.
+type OptSSEClient func(*sseClient)
+
+// WithSSEClientOptions sets the client options of all the requests.
+func WithSSEClientOptions(opts ...client.Option) OptSSEClient {
+	return func(c *sseClient) {
+		c.clientOpts = append(c.clientOpts, opts...)
+	}
+}
+
+// WithSSERequestHeader sets the headers of all the requests.
+func WithSSERequestHeader(h http.Header) OptSSEClient {
+	return func(c *sseClient) {
+		c.header = h
+	}
+}
+
+// WithSSEReconnectDelay sets the delay of reconnecting before the server sends the retry field, default is 3s.
+func WithSSEReconnectDelay(d time.Duration) OptSSEClient {
+	return func(c *sseClient) {
+		c.reconnectDelay = d
+	}
+}
+
+// WithSSEMaxReconnectDelay sets the max delay of reconnecting with backoff, default is 30s.
+func WithSSEMaxReconnectDelay(d time.Duration) OptSSEClient {
+	return func(c *sseClient) {
+		c.maxReconnectDelay = d
+	}
+}
+
+// WithSSEMaxReconnects sets the max number of consecutive reconnects without any event received,
+// default is 0, which means no limit.
+func WithSSEMaxReconnects(n int) OptSSEClient {
+	return func(c *sseClient) {
+		c.maxReconnects = n
+	}
+}
+
+// sseClient implements SSEClient.
+type sseClient struct {
+	serviceName       string
+	clientOpts        []client.Option
+	header            http.Header
+	reconnectDelay    time.Duration
+	maxReconnectDelay time.Duration
+	maxReconnects     int
+}
+
+// NewSSEClientProxy creates a new SSE client proxy.
+// Parameter name means the name of backend http service, the same as NewClientProxy.
+var NewSSEClientProxy = func(name string, opts ...OptSSEClient) SSEClient {
+	c := &sseClient{
+		serviceName:       name,
+		reconnectDelay:    defaultSSEReconnectDelay,
+		maxReconnectDelay: defaultSSEMaxReconnectDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Subscribe implements SSEClient.
+func (c *sseClient) Subscribe(ctx context.Context, path string, handler SSEHandler, opts ...client.Option) error {
+	cli := NewClientProxy(c.serviceName, c.clientOpts...)
+	s := &sseSubscription{handler: handler, retry: c.reconnectDelay}
+	for reconnects := 0; ; reconnects++ {
+		received, stop, err := s.subscribe(ctx, cli, path, c.header, opts...)
+		if stop {
+			return err
+		}
+		if received {
+			reconnects = 0
+		}
+		if c.maxReconnects > 0 && reconnects >= c.maxReconnects {
+			return fmt.Errorf("sse client reconnects exhausted after %d times, last error: %v", reconnects, err)
+		}
+		delay := sseBackoff(s.retry, reconnects, c.maxReconnectDelay)
+		log.DebugContextf(ctx, "sse client reconnects to %s after %s, last event id %q, error: %v",
+			path, delay, s.lastEventID, err)
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// sseBackoff returns the delay of the n-th consecutive reconnect, counting from 0.
+func sseBackoff(delay time.Duration, n int, max time.Duration) time.Duration {
+	for i := 0; i < n && delay < max; i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+// sseSubscription records the state of the events received across reconnects.
+type sseSubscription struct {
+	handler     SSEHandler
+	lastEventID string
+	retry       time.Duration
+	received    bool
+	handleErr   error
+}
+
+// subscribe sends a request and handles the events until the connection ends.
+// It reports whether any event is received, and whether to stop reconnecting.
+func (s *sseSubscription) subscribe(
+	ctx context.Context,
+	cli Client,
+	path string,
+	header http.Header,
+	opts ...client.Option,
+) (bool, bool, error) {
+	reqHeader := &ClientReqHeader{Method: http.MethodGet, Header: header.Clone()}
+	if s.lastEventID != "" {
+		reqHeader.AddHeader(headerLastEventID, s.lastEventID)
+	}
+	rspHeader := &ClientRspHeader{SSEHandler: s}
+	s.received = false
+	err := cli.Get(ctx, path, nil, append(opts, client.WithReqHead(reqHeader), client.WithRspHead(rspHeader))...)
+	if s.handleErr != nil {
+		return s.received, true, s.handleErr
+	}
+	if ctx.Err() != nil {
+		return s.received, true, ctx.Err()
+	}
+	if rsp := rspHeader.Response; rsp != nil {
+		if rsp.StatusCode == http.StatusNoContent {
+			return s.received, true, nil
+		}
+		// Requests rejected by the server are not retried.
+		if rsp.StatusCode >= http.StatusBadRequest && rsp.StatusCode < http.StatusInternalServerError {
+			return s.received, true, err
+		}
+	}
+	if err == nil {
+		err = errors.New("sse connection closed by the server")
+	}
+	return s.received, false, err
+}
+
+// Handle implements SSEHandler, it records the id and retry of the event before handling it.
+func (s *sseSubscription) Handle(e *sse.Event) error {
+	s.received = true
+	if len(e.ID) > 0 {
+		s.lastEventID = string(e.ID)
+	}
+	if ms, err := strconv.Atoi(string(e.Retry)); err == nil && ms >= 0 {
+		s.retry = time.Duration(ms) * time.Millisecond
+	}
+	if err := s.handler.Handle(e); err != nil {
+		s.handleErr = err
+		return err
+	}
+	return nil
+}
+
+// NewSSEDecodeHandler returns an SSEHandler which decodes the data of each event into a target created by
+// newTarget, and handles it. Proto messages are decoded by jsonpb, and others by json.
+// Events without data, such as those only carrying the retry field, are ignored.
+func NewSSEDecodeHandler(
+	newTarget func() interface{},
+	handle func(e *sse.Event, target interface{}) error,
+) SSEHandler {
+	return sseDecodeHandler{newTarget: newTarget, handle: handle}
+}
+
+type sseDecodeHandler struct {
+	newTarget func() interface{}
+	handle    func(e *sse.Event, target interface{}) error
+}
+
+// Handle implements SSEHandler.
+func (h sseDecodeHandler) Handle(e *sse.Event) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	target := h.newTarget()
+	if err := codec.Unmarshal(codec.SerializationTypeJSON, e.Data, target); err != nil {
+		return fmt.Errorf("sse decode event data error: %w", err)
+	}
+	return h.handle(e, target)
+}
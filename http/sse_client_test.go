@@ -0,0 +1,137 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/r3labs/sse/v2"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/client"
+	thttp "trpc.group/trpc-go/trpc-go/http"
+	"trpc.group/trpc-go/trpc-go/testdata/restful/helloworld"
+)
+
+type sseHandlerFunc func(*sse.Event) error
+
+func (f sseHandlerFunc) Handle(e *sse.Event) error {
+	return f(e)
+}
+
+func newSSEClient(ts *httptest.Server, opts ...thttp.OptSSEClient) thttp.SSEClient {
+	return thttp.NewSSEClientProxy("trpc.http.sse.client.test", append([]thttp.OptSSEClient{
+		thttp.WithSSEClientOptions(client.WithTarget("ip://" + ts.Listener.Addr().String())),
+		thttp.WithSSEReconnectDelay(time.Millisecond),
+	}, opts...)...)
+}
+
+func TestSSEClientReconnect(t *testing.T) {
+	var conns int32
+	lastEventIDs := make(chan string, 3)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token", r.Header.Get("Authorization"))
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		switch atomic.AddInt32(&conns, 1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: 1\nretry: 10\ndata: {\"message\":\"a\"}\n\n")
+		case 2:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "retry: 20\n\nid: 2\ndata: {\"message\":\"b\"}\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	var messages []string
+	err := newSSEClient(ts, thttp.WithSSERequestHeader(http.Header{"Authorization": {"token"}})).Subscribe(
+		context.Background(), "/events", thttp.NewSSEDecodeHandler(
+			func() interface{} { return &helloworld.HelloReply{} },
+			func(e *sse.Event, target interface{}) error {
+				messages = append(messages, string(e.ID)+":"+target.(*helloworld.HelloReply).Message)
+				return nil
+			}))
+	require.Nil(t, err)
+	require.Equal(t, []string{"1:a", "2:b"}, messages)
+	require.Equal(t, "", <-lastEventIDs)
+	require.Equal(t, "1", <-lastEventIDs)
+	require.Equal(t, "2", <-lastEventIDs)
+}
+
+func TestSSEClientStop(t *testing.T) {
+	t.Run("handler error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "data: {\"message\":\"a\"}\n\n")
+		}))
+		defer ts.Close()
+		handleErr := errors.New("handle error")
+		err := newSSEClient(ts).Subscribe(context.Background(), "/events",
+			sseHandlerFunc(func(*sse.Event) error { return handleErr }))
+		require.True(t, errors.Is(err, handleErr))
+	})
+	t.Run("decode error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "data: not json\n\n")
+		}))
+		defer ts.Close()
+		err := newSSEClient(ts).Subscribe(context.Background(), "/events", thttp.NewSSEDecodeHandler(
+			func() interface{} { return &map[string]string{} },
+			func(*sse.Event, interface{}) error { return nil }))
+		require.Contains(t, err.Error(), "decode")
+	})
+	t.Run("client error status", func(t *testing.T) {
+		var conns int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&conns, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer ts.Close()
+		err := newSSEClient(ts).Subscribe(context.Background(), "/events",
+			sseHandlerFunc(func(*sse.Event) error { return nil }))
+		require.NotNil(t, err)
+		require.Equal(t, int32(1), atomic.LoadInt32(&conns))
+	})
+	t.Run("reconnects exhausted", func(t *testing.T) {
+		var conns int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&conns, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+		err := newSSEClient(ts, thttp.WithSSEMaxReconnects(2)).Subscribe(context.Background(), "/events",
+			sseHandlerFunc(func(*sse.Event) error { return nil }))
+		require.Contains(t, err.Error(), "exhausted")
+		require.Equal(t, int32(3), atomic.LoadInt32(&conns))
+	})
+	t.Run("context canceled", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := newSSEClient(ts, thttp.WithSSEReconnectDelay(time.Hour)).Subscribe(ctx, "/events",
+			sseHandlerFunc(func(*sse.Event) error { return nil }))
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
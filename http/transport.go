@@ -37,6 +37,7 @@ import (
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
+	"trpc.group/trpc-go/trpc-go/internal/connlimit"
 	icontext "trpc.group/trpc-go/trpc-go/internal/context"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	"trpc.group/trpc-go/trpc-go/internal/reuseport"
@@ -114,6 +115,7 @@ var emptyBuf []byte
 func (t *ServerTransport) listenAndServeHTTP(ctx context.Context, opts *transport.ListenServeOptions) error {
 	// All trpc-go http server transport only register this http.Handler.
 	serveFunc := func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		limitRequestBody(r, opts.MaxRequestSize)
 		h := &Header{Request: r, Response: w}
 		ctx := WithHeader(r.Context(), h)
 
@@ -186,10 +188,11 @@ func (t *ServerTransport) serve(ctx context.Context, s *stdhttp.Server, opts *tr
 		}
 	}
 
+	limiter := connlimit.New(opts.MaxConnections, opts.MaxConnectionsPerIP, opts.ConnectionLimitWait)
 	if len(opts.TLSKeyFile) != 0 && len(opts.TLSCertFile) != 0 {
 		go func() {
 			if err := s.ServeTLS(
-				tcpKeepAliveListener{ln.(*net.TCPListener)},
+				connlimit.NewListener(tcpKeepAliveListener{ln.(*net.TCPListener)}, limiter),
 				"",
 				"",
 			); err != stdhttp.ErrServerClosed {
@@ -198,7 +201,7 @@ func (t *ServerTransport) serve(ctx context.Context, s *stdhttp.Server, opts *tr
 		}()
 	} else {
 		go func() {
-			_ = s.Serve(tcpKeepAliveListener{ln.(*net.TCPListener)})
+			_ = s.Serve(connlimit.NewListener(tcpKeepAliveListener{ln.(*net.TCPListener)}, limiter))
 		}()
 	}
 
@@ -580,6 +583,7 @@ func (ct *ClientTransport) RoundTrip(
 			"http client transport RoundTrip: "+err.Error())
 	}
 	decorateWithCancel(rspHeader, cancel)
+	rspHeader.maxBodySize = opts.MaxResponseSize
 	return emptyBuf, nil
 }
 
@@ -15,11 +15,15 @@ package http
 
 import (
 	"context"
+	"io"
 	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/transport"
 )
 
@@ -39,3 +43,30 @@ func TestClientTransportGetRequestDoesNotSetTLSServerNameFromHost(t *testing.T)
 	require.Equal(t, "example.com", req.Host)
 	require.Empty(t, opts.TLSServerName)
 }
+
+func TestServerCodecDecodeRequestTooLarge(t *testing.T) {
+	sc := &ServerCodec{AutoReadBody: true}
+	for _, tt := range []struct {
+		body     string
+		tooLarge bool
+	}{
+		{body: "0123456789"},
+		{body: "0123456789a", tooLarge: true},
+	} {
+		r := httptest.NewRequest(stdhttp.MethodPost, "/path", io.NopCloser(strings.NewReader(tt.body)))
+		r.Header.Set("Content-Type", "application/json")
+		r.ContentLength = -1
+		limitRequestBody(r, 10)
+		ctx := WithHeader(context.Background(), &Header{Request: r, Response: httptest.NewRecorder()})
+		_, msg := codec.WithNewMessage(ctx)
+		reqBody, err := sc.Decode(msg, nil)
+		require.Nil(t, err)
+		if tt.tooLarge {
+			require.Nil(t, reqBody)
+			require.Equal(t, errs.RetServerMsgExceedLimit, errs.Code(msg.ServerRspErr()))
+		} else {
+			require.Equal(t, tt.body, string(reqBody))
+			require.Nil(t, msg.ServerRspErr())
+		}
+	}
+}
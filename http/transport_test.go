@@ -1782,3 +1782,58 @@ func TestHTTPErrHandlerContextIsLatest(t *testing.T) {
 		t.Fatal("timeout waiting for ErrHandler span name")
 	}
 }
+
+func TestHTTPMaxRequestResponseSize(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+	const serviceName = "trpc.http.max.size"
+	svc := server.New(
+		server.WithListener(ln),
+		server.WithServiceName(serviceName),
+		server.WithProtocol("http"),
+		server.WithMaxRequestSize(10),
+	)
+
+	oldMethods := thttp.ServiceDesc.Methods
+	defer func() { thttp.ServiceDesc.Methods = oldMethods }()
+	thttp.HandleFunc("/max-size", func(w http.ResponseWriter, r *http.Request) error {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		_, _ = w.Write(bytes.Repeat([]byte("a"), 20))
+		return nil
+	})
+	thttp.RegisterDefaultService(svc)
+
+	s := &server.Server{}
+	s.AddService(serviceName, svc)
+	go func() { _ = s.Serve() }()
+	defer func() { _ = s.Close(nil) }()
+	time.Sleep(100 * time.Millisecond)
+
+	target := "http://" + ln.Addr().String() + "/max-size"
+	for _, body := range []io.Reader{
+		bytes.NewReader(bytes.Repeat([]byte("a"), 20)),
+		// chunked body without content length.
+		io.MultiReader(strings.NewReader("aaaaaaaaaa"), strings.NewReader("aaaaaaaaaa")),
+	} {
+		rsp, err := http.Post(target, "application/json", body)
+		require.Nil(t, err)
+		_ = rsp.Body.Close()
+		require.Equal(t, http.StatusRequestEntityTooLarge, rsp.StatusCode)
+		require.Equal(t, strconv.Itoa(int(errs.RetServerMsgExceedLimit)), rsp.Header.Get(thttp.TrpcFrameworkErrorCode))
+	}
+
+	proxy := thttp.NewClientProxy(serviceName, client.WithTarget("ip://"+ln.Addr().String()))
+	rsp := &codec.Body{}
+	require.Nil(t, proxy.Post(context.Background(), "/max-size", &codec.Body{Data: []byte("{}")}, rsp,
+		client.WithSerializationType(codec.SerializationTypeNoop),
+		client.WithCurrentSerializationType(codec.SerializationTypeNoop)))
+	require.Len(t, rsp.Data, 20)
+	err = proxy.Post(context.Background(), "/max-size", &codec.Body{Data: []byte("{}")}, rsp,
+		client.WithSerializationType(codec.SerializationTypeNoop),
+		client.WithCurrentSerializationType(codec.SerializationTypeNoop),
+		client.WithMaxResponseSize(10))
+	require.Equal(t, errs.RetClientMsgExceedLimit, errs.Code(err))
+}
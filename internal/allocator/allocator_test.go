@@ -57,3 +57,21 @@ func TestClassAllocator_InvalidFree(t *testing.T) {
 		a.Free(make([]byte, 9))
 	})
 }
+
+func TestRefBuffer(t *testing.T) {
+	b := MallocRef(10)
+	require.Equal(t, 10, len(b.Bytes()))
+	b.Retain()
+	b.Release()
+	require.Equal(t, 10, len(b.Bytes()))
+	b.Release()
+	require.Nil(t, b.Bytes())
+
+	var nilBuf *RefBuffer
+	require.NotPanics(t, nilBuf.Release)
+}
+
+func TestRefBuffer_ReleaseFreed(t *testing.T) {
+	b := &RefBuffer{}
+	require.Panics(t, b.Release)
+}
@@ -0,0 +1,64 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package allocator
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RefBuffer is a reference counted []byte from the pool.
+// The bytes are freed to the pool once the last reference is released.
+type RefBuffer struct {
+	bts  []byte
+	free interface{}
+	refs int32
+}
+
+var refBufferPool = sync.Pool{New: func() interface{} { return &RefBuffer{} }}
+
+// MallocRef gets a RefBuffer of size from the pool, which holds one reference.
+func MallocRef(size int) *RefBuffer {
+	bts, free := Malloc(size)
+	b := refBufferPool.Get().(*RefBuffer)
+	b.bts, b.free, b.refs = bts, free, 1
+	return b
+}
+
+// Bytes returns the underlying []byte, which is only valid until the last reference is released.
+func (b *RefBuffer) Bytes() []byte {
+	return b.bts
+}
+
+// Retain adds a reference to the RefBuffer.
+func (b *RefBuffer) Retain() {
+	atomic.AddInt32(&b.refs, 1)
+}
+
+// Release drops a reference, and frees the bytes if it's the last one. Releasing a nil RefBuffer is a no-op.
+func (b *RefBuffer) Release() {
+	if b == nil {
+		return
+	}
+	refs := atomic.AddInt32(&b.refs, -1)
+	if refs > 0 {
+		return
+	}
+	if refs < 0 {
+		panic("release a freed RefBuffer")
+	}
+	Free(b.free)
+	b.bts, b.free = nil, nil
+	refBufferPool.Put(b)
+}
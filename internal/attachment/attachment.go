@@ -62,17 +62,35 @@ func ServerResponseAttachment(msg codec.Msg) (io.Reader, bool) {
 // which means that the user has explicitly ignored the att returned by the server.
 // For performance reasons, there is no need to set the response attachment into msg.
 func SetClientResponseAttachment(msg codec.Msg, attachment []byte) {
+	SetClientResponseAttachmentReader(msg, bytes.NewReader(attachment))
+}
+
+// SetClientResponseAttachmentReader sets client's Response attachment reader to msg.
+func SetClientResponseAttachmentReader(msg codec.Msg, r io.Reader) {
 	if a, _ := msg.CommonMeta()[ClientAttachmentKey{}].(*Attachment); a != nil {
-		a.Response = bytes.NewReader(attachment)
+		a.Response = r
 	}
 }
 
 // SetServerRequestAttachment sets server's Request Attachment to msg.
 func SetServerRequestAttachment(m codec.Msg, attachment []byte) {
+	SetServerRequestAttachmentReader(m, bytes.NewReader(attachment))
+}
+
+// SetServerRequestAttachmentReader sets server's Request Attachment reader to msg.
+func SetServerRequestAttachmentReader(m codec.Msg, r io.Reader) {
 	cm := m.CommonMeta()
 	if cm == nil {
 		cm = make(codec.CommonMeta)
 		m.WithCommonMeta(cm)
 	}
-	cm[ServerAttachmentKey{}] = &Attachment{Request: bytes.NewReader(attachment), Response: NoopAttachment{}}
+	cm[ServerAttachmentKey{}] = &Attachment{Request: r, Response: NoopAttachment{}}
+}
+
+// ResetServerResponseAttachment drops server's Response Attachment of msg, including the deferred one.
+func ResetServerResponseAttachment(m codec.Msg) {
+	if a, _ := m.CommonMeta()[ServerAttachmentKey{}].(*Attachment); a != nil {
+		a.Response = NoopAttachment{}
+	}
+	ClearDeferred(m)
 }
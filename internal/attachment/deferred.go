@@ -0,0 +1,147 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+)
+
+// Sized is an attachment of known size. If the transport of the message writes deferred attachments,
+// the codec only counts its size in the frame, and the transport copies it to the connection in chunks
+// right after the frame. Otherwise, it's read into the frame like other attachments.
+type Sized struct {
+	io.Reader
+	Size int64
+}
+
+// DeferredWriter is implemented by client transports which write deferred attachments of requests
+// after the frames returned by the codec.
+type DeferredWriter interface {
+	// WritesDeferredAttachment is a marker method.
+	WritesDeferredAttachment()
+}
+
+// clientDeferrableKey is the key of the mark in common meta that the client transport of msg writes
+// deferred attachments of requests. Client and server are marked separately, since common meta of
+// a server msg is copied into the msgs of the calls made by its handler, whose transports may not
+// write deferred attachments.
+type clientDeferrableKey struct{}
+
+// serverDeferrableKey is the key of the mark in common meta that the server transport of msg writes
+// deferred attachments of responses.
+type serverDeferrableKey struct{}
+
+// deferredKey is the key of the deferred attachment in common meta of msg.
+type deferredKey struct{}
+
+// errShortAttachment is returned if the sized attachment ends before its size.
+var errShortAttachment = errors.New("attachment is shorter than its size")
+
+// AllowClientDeferred sets whether the client transport of msg writes deferred attachments of requests.
+func AllowClientDeferred(msg codec.Msg, allowed bool) {
+	cm := msg.CommonMeta()
+	if !allowed {
+		delete(cm, clientDeferrableKey{})
+		return
+	}
+	if cm == nil {
+		cm = make(codec.CommonMeta)
+		msg.WithCommonMeta(cm)
+	}
+	cm[clientDeferrableKey{}] = struct{}{}
+}
+
+// ClientDeferredAllowed returns whether the client transport of msg writes deferred attachments of requests.
+func ClientDeferredAllowed(msg codec.Msg) bool {
+	_, ok := msg.CommonMeta()[clientDeferrableKey{}]
+	return ok
+}
+
+// AllowServerDeferred marks that the server transport of msg writes deferred attachments of responses.
+func AllowServerDeferred(msg codec.Msg) {
+	cm := msg.CommonMeta()
+	if cm == nil {
+		cm = make(codec.CommonMeta)
+		msg.WithCommonMeta(cm)
+	}
+	cm[serverDeferrableKey{}] = struct{}{}
+}
+
+// ServerDeferredAllowed returns whether the server transport of msg writes deferred attachments of responses.
+func ServerDeferredAllowed(msg codec.Msg) bool {
+	_, ok := msg.CommonMeta()[serverDeferrableKey{}]
+	return ok
+}
+
+// SetDeferred sets the attachment to be written after the frame encoded from msg.
+func SetDeferred(msg codec.Msg, a *Sized) {
+	// common meta always exists, as it's marked by AllowClientDeferred or AllowServerDeferred.
+	msg.CommonMeta()[deferredKey{}] = a
+}
+
+// DeferredSize returns the size of the deferred attachment of msg, or zero if there is none.
+func DeferredSize(msg codec.Msg) int64 {
+	if a, _ := msg.CommonMeta()[deferredKey{}].(*Sized); a != nil {
+		return a.Size
+	}
+	return 0
+}
+
+// ClearDeferred drops the deferred attachment of msg.
+func ClearDeferred(msg codec.Msg) {
+	if cm := msg.CommonMeta(); cm != nil {
+		delete(cm, deferredKey{})
+	}
+}
+
+// WriteDeferred writes the deferred attachment of msg to w, and drops it from msg.
+// The frame is broken if an error is returned, and the connection should be closed.
+func WriteDeferred(msg codec.Msg, w io.Writer) error {
+	a, _ := msg.CommonMeta()[deferredKey{}].(*Sized)
+	if a == nil {
+		return nil
+	}
+	ClearDeferred(msg)
+	n, err := io.CopyN(w, a.Reader, a.Size)
+	if err == io.EOF {
+		err = errShortAttachment
+	}
+	if err != nil {
+		return fmt.Errorf("writing attachment of size %d, written %d: %w", a.Size, n, err)
+	}
+	return nil
+}
+
+// AppendDeferred reads the deferred attachment of msg into buf, and drops it from msg.
+// It's used by the paths of transports which can't write attachments after frames.
+func AppendDeferred(msg codec.Msg, buf []byte) ([]byte, error) {
+	a, _ := msg.CommonMeta()[deferredKey{}].(*Sized)
+	if a == nil {
+		return buf, nil
+	}
+	ClearDeferred(msg)
+	n := len(buf)
+	buf = append(buf, make([]byte, a.Size)...)
+	if _, err := io.ReadFull(a.Reader, buf[n:]); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			err = errShortAttachment
+		}
+		return nil, fmt.Errorf("reading attachment of size %d: %w", a.Size, err)
+	}
+	return buf, nil
+}
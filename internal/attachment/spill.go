@@ -0,0 +1,117 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package attachment
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpillClaimTimeout is the time a registered Spill waits to be claimed by the codec.
+// Spills of frames which are never decoded are released after it.
+var SpillClaimTimeout = time.Minute
+
+// Spill is an attachment spilled to a temp file by the framer, instead of being kept in memory.
+// The file is removed once the Spill is read to the end, closed or garbage collected.
+type Spill struct {
+	f         *os.File
+	name      string // name of the file to remove on close, if it can't be unlinked while open.
+	size      int64
+	timer     *time.Timer
+	closeOnce sync.Once
+}
+
+var (
+	spills  sync.Map // spill id => *Spill
+	spillID uint64
+)
+
+// NewSpill copies size bytes from r to a new temp file in dir.
+// The default directory for temporary files is used if dir is empty.
+func NewSpill(dir string, r io.Reader, size int64) (*Spill, error) {
+	f, err := os.CreateTemp(dir, "trpc-attachment-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating attachment spill file: %w", err)
+	}
+	s := &Spill{f: f, size: size}
+	// the file is unlinked right away where it's allowed, so that it never outlives the process.
+	if err := os.Remove(f.Name()); err != nil {
+		s.name = f.Name()
+	}
+	if _, err := io.CopyN(f, r, size); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("spilling attachment of size %d: %w", size, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("rewinding attachment spill file: %w", err)
+	}
+	return s, nil
+}
+
+// Size returns the size of the attachment.
+func (s *Spill) Size() int64 {
+	return s.size
+}
+
+// Read implements io.Reader. The Spill is closed at EOF.
+func (s *Spill) Read(p []byte) (int, error) {
+	n, err := s.f.Read(p)
+	if err == io.EOF {
+		s.Close()
+	}
+	return n, err
+}
+
+// Close implements io.Closer, and removes the temp file.
+func (s *Spill) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.f.Close()
+		if s.name != "" {
+			os.Remove(s.name)
+		}
+	})
+	return err
+}
+
+// Register registers the Spill, and returns the id by which it's claimed.
+// The Spill is closed if it's not claimed in SpillClaimTimeout.
+func (s *Spill) Register() uint64 {
+	id := atomic.AddUint64(&spillID, 1)
+	s.timer = time.AfterFunc(SpillClaimTimeout, func() {
+		if _, ok := spills.LoadAndDelete(id); ok {
+			s.Close()
+		}
+	})
+	spills.Store(id, s)
+	return id
+}
+
+// ClaimSpill returns the Spill registered with id, and unregisters it.
+func ClaimSpill(id uint64) (*Spill, bool) {
+	v, ok := spills.LoadAndDelete(id)
+	if !ok {
+		return nil, false
+	}
+	s := v.(*Spill)
+	s.timer.Stop()
+	runtime.SetFinalizer(s, (*Spill).Close)
+	return s, true
+}
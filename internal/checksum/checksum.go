@@ -0,0 +1,56 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+// Package checksum provides a internal implementation of marking messages whose frames carry checksums.
+package checksum
+
+import "trpc.group/trpc-go/trpc-go/codec"
+
+// clientKey is the key of the checksum mark of client in common meta of msg.
+// Client and server are marked separately, since common meta of a server msg is copied into the msgs
+// of the calls made by its handler, whose backends may not enable checksums.
+type clientKey struct{}
+
+// serverKey is the key of the checksum mark of server in common meta of msg.
+type serverKey struct{}
+
+// EnableClient marks that the request frames of msg sent out by client should carry checksums.
+func EnableClient(msg codec.Msg) {
+	enable(msg, clientKey{})
+}
+
+// ClientEnabled returns whether the request frames of msg sent out by client should carry checksums.
+func ClientEnabled(msg codec.Msg) bool {
+	enabled, _ := msg.CommonMeta()[clientKey{}].(bool)
+	return enabled
+}
+
+// EnableServer marks that the response frames of msg sent out by server should carry checksums.
+func EnableServer(msg codec.Msg) {
+	enable(msg, serverKey{})
+}
+
+// ServerEnabled returns whether the response frames of msg sent out by server should carry checksums.
+func ServerEnabled(msg codec.Msg) bool {
+	enabled, _ := msg.CommonMeta()[serverKey{}].(bool)
+	return enabled
+}
+
+func enable(msg codec.Msg, key interface{}) {
+	cm := msg.CommonMeta()
+	if cm == nil {
+		cm = make(codec.CommonMeta)
+		msg.WithCommonMeta(cm)
+	}
+	cm[key] = true
+}
@@ -0,0 +1,24 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import "trpc.group/trpc-go/trpc-go/internal/allocator"
+
+// PooledFramer is implemented by framers which can read frames into pooled buffers,
+// so that transports hand frames to handlers without allocating them.
+type PooledFramer interface {
+	// ReadPooledFrame reads a frame like ReadFrame. If buf is not nil, frame is in buf, and is only
+	// valid until buf is released. Frames which may outlive the handler are not pooled.
+	ReadPooledFrame() (frame []byte, buf *allocator.RefBuffer, err error)
+}
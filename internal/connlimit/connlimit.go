@@ -0,0 +1,229 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+// Package connlimit limits the number of concurrent connections accepted by server transports,
+// both globally and per remote IP.
+package connlimit
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/internal/report"
+)
+
+// Limiter limits the number of concurrent connections. A nil Limiter accepts all connections.
+type Limiter struct {
+	maxConns      int
+	maxConnsPerIP int
+	waitTimeout   time.Duration
+
+	mu        sync.Mutex
+	conns     int
+	connsOfIP map[string]int
+	// released is closed and replaced each time a connection is released to wake up the waiters.
+	released chan struct{}
+}
+
+// New creates a Limiter. Zero or negative maxConns and maxConnsPerIP mean no limit, and New returns
+// nil if there is no limit at all. If waitTimeout is positive, Acquire waits for at most waitTimeout
+// for a free slot, otherwise it rejects immediately when a limit is reached.
+func New(maxConns, maxConnsPerIP int, waitTimeout time.Duration) *Limiter {
+	if maxConns <= 0 && maxConnsPerIP <= 0 {
+		return nil
+	}
+	return &Limiter{
+		maxConns:      maxConns,
+		maxConnsPerIP: maxConnsPerIP,
+		waitTimeout:   waitTimeout,
+		connsOfIP:     make(map[string]int),
+		released:      make(chan struct{}),
+	}
+}
+
+// Waitable returns whether Acquire may block.
+func (l *Limiter) Waitable() bool {
+	return l != nil && l.waitTimeout > 0
+}
+
+// Acquire acquires a slot for a connection from addr, it returns false if the connection
+// should be rejected. Each successful Acquire must be paired with a Release.
+func (l *Limiter) Acquire(addr net.Addr) bool {
+	if l == nil {
+		return true
+	}
+	ip := ipOf(addr)
+	var timer *time.Timer
+	for {
+		l.mu.Lock()
+		if l.allow(ip) {
+			l.conns++
+			l.connsOfIP[ip]++
+			l.mu.Unlock()
+			report.ServerConnectionAccepted.Incr()
+			return true
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		if l.waitTimeout <= 0 {
+			report.ServerConnectionRejected.Incr()
+			return false
+		}
+		if timer == nil {
+			timer = time.NewTimer(l.waitTimeout)
+			defer timer.Stop()
+		}
+		select {
+		case <-released:
+		case <-timer.C:
+			report.ServerConnectionRejected.Incr()
+			return false
+		}
+	}
+}
+
+// Release releases the slot of a connection from addr.
+func (l *Limiter) Release(addr net.Addr) {
+	if l == nil {
+		return
+	}
+	ip := ipOf(addr)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.conns--
+	if l.connsOfIP[ip]--; l.connsOfIP[ip] <= 0 {
+		delete(l.connsOfIP, ip)
+	}
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+func (l *Limiter) allow(ip string) bool {
+	if l.maxConns > 0 && l.conns >= l.maxConns {
+		return false
+	}
+	return l.maxConnsPerIP <= 0 || l.connsOfIP[ip] < l.maxConnsPerIP
+}
+
+func ipOf(addr net.Addr) string {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP.String()
+	case nil:
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// NewListener wraps ln so that connections beyond the limits are closed right after being accepted.
+// Connections returned by the listener release their slots on Close. If l is nil, ln is returned.
+func NewListener(ln net.Listener, l *Limiter) net.Listener {
+	if l == nil {
+		return ln
+	}
+	return &listener{
+		Listener: ln,
+		limiter:  l,
+		conns:    make(chan net.Conn),
+		errs:     make(chan error),
+		done:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+type listener struct {
+	net.Listener
+	limiter *Limiter
+
+	once      sync.Once
+	conns     chan net.Conn
+	errs      chan error // temporary accept errors
+	err       error      // the permanent accept error, valid after done is closed
+	done      chan struct{}
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Accept accepts the next connection within the limits. Connections are admitted in their own
+// goroutines, so that waiting for a slot never blocks accepting others.
+func (ln *listener) Accept() (net.Conn, error) {
+	ln.once.Do(func() { go ln.accept() })
+	select {
+	case c := <-ln.conns:
+		return c, nil
+	case err := <-ln.errs:
+		return nil, err
+	case <-ln.done:
+		return nil, ln.err
+	}
+}
+
+// Close closes the listener.
+func (ln *listener) Close() error {
+	ln.closeOnce.Do(func() { close(ln.closed) })
+	return ln.Listener.Close()
+}
+
+func (ln *listener) accept() {
+	for {
+		c, err := ln.Listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				select {
+				case ln.errs <- err:
+					continue
+				case <-ln.closed:
+				}
+			}
+			ln.err = err
+			close(ln.done)
+			return
+		}
+		go ln.admit(c)
+	}
+}
+
+func (ln *listener) admit(c net.Conn) {
+	if !ln.limiter.Acquire(c.RemoteAddr()) {
+		c.Close()
+		return
+	}
+	lc := &conn{Conn: c, limiter: ln.limiter}
+	select {
+	case ln.conns <- lc:
+	case <-ln.done:
+		lc.Close()
+	case <-ln.closed:
+		lc.Close()
+	}
+}
+
+// conn releases its slot of the limiter on Close.
+type conn struct {
+	net.Conn
+	limiter *Limiter
+	once    sync.Once
+}
+
+// Close closes the connection and releases its slot.
+func (c *conn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { c.limiter.Release(c.Conn.RemoteAddr()) })
+	return err
+}
@@ -0,0 +1,122 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package connlimit_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/internal/connlimit"
+)
+
+func TestNoLimit(t *testing.T) {
+	l := connlimit.New(0, 0, time.Second)
+	require.Nil(t, l)
+	require.False(t, l.Waitable())
+	require.True(t, l.Acquire(&net.TCPAddr{}))
+	l.Release(&net.TCPAddr{})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+	require.Equal(t, ln, connlimit.NewListener(ln, nil))
+}
+
+func TestMaxConnections(t *testing.T) {
+	l := connlimit.New(2, 0, 0)
+	a := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	b := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 1}
+	require.True(t, l.Acquire(a))
+	require.True(t, l.Acquire(b))
+	require.False(t, l.Acquire(a))
+	l.Release(b)
+	require.True(t, l.Acquire(a))
+}
+
+func TestMaxConnectionsPerIP(t *testing.T) {
+	l := connlimit.New(0, 1, 0)
+	a1 := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	a2 := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2}
+	b := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 1}
+	require.True(t, l.Acquire(a1))
+	require.False(t, l.Acquire(a2))
+	require.True(t, l.Acquire(b))
+	l.Release(a1)
+	require.True(t, l.Acquire(a2))
+}
+
+func TestWait(t *testing.T) {
+	l := connlimit.New(1, 0, 200*time.Millisecond)
+	require.True(t, l.Waitable())
+	a := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	require.True(t, l.Acquire(a))
+
+	start := time.Now()
+	require.False(t, l.Acquire(a))
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l.Release(a)
+	}()
+	require.True(t, l.Acquire(a))
+}
+
+func TestListener(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	ln := connlimit.NewListener(inner, connlimit.New(1, 0, 0))
+	defer ln.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	c1, err := net.Dial("tcp", inner.Addr().String())
+	require.Nil(t, err)
+	defer c1.Close()
+	s1 := <-accepted
+
+	// The second connection is closed by the server.
+	c2, err := net.Dial("tcp", inner.Addr().String())
+	require.Nil(t, err)
+	defer c2.Close()
+	require.Nil(t, c2.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = c2.Read(make([]byte, 1))
+	require.Equal(t, io.EOF, err)
+
+	// The slot is released after the first connection is closed.
+	require.Nil(t, s1.Close())
+	c3, err := net.Dial("tcp", inner.Addr().String())
+	require.Nil(t, err)
+	defer c3.Close()
+	s3 := <-accepted
+	require.NotNil(t, s3)
+
+	require.Nil(t, ln.Close())
+	_, ok := <-accepted
+	require.False(t, ok)
+}
@@ -0,0 +1,66 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+// Package envelope provides a internal implementation of the payload encryption state of messages.
+package envelope
+
+import (
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
+)
+
+// clientKey is the key of client envelope in common meta of msg.
+type clientKey struct{}
+
+// serverKey is the key of server envelope in common meta of msg.
+type serverKey struct{}
+
+// Envelope is the payload encryption state of a message. It's stored as a pointer, so that messages
+// cloned from the same one, such as messages of a stream, share the key.
+type Envelope struct {
+	Provider encryption.KeyProvider
+	// Key is the key of the request, which is used for both the request and its responses.
+	// It's nil if the request is not encrypted.
+	Key *encryption.Key
+}
+
+// SetClient sets the client envelope of msg, whose requests are encrypted with keys of p.
+func SetClient(msg codec.Msg, p encryption.KeyProvider) {
+	set(msg, clientKey{}, &Envelope{Provider: p})
+}
+
+// Client returns the client envelope of msg, or nil if requests are not encrypted.
+func Client(msg codec.Msg) *Envelope {
+	e, _ := msg.CommonMeta()[clientKey{}].(*Envelope)
+	return e
+}
+
+// SetServer sets the server envelope of msg, whose requests are decrypted with keys of p.
+func SetServer(msg codec.Msg, p encryption.KeyProvider) {
+	set(msg, serverKey{}, &Envelope{Provider: p})
+}
+
+// Server returns the server envelope of msg, or nil if the service doesn't support encryption.
+func Server(msg codec.Msg) *Envelope {
+	e, _ := msg.CommonMeta()[serverKey{}].(*Envelope)
+	return e
+}
+
+func set(msg codec.Msg, key interface{}, e *Envelope) {
+	cm := msg.CommonMeta()
+	if cm == nil {
+		cm = make(codec.CommonMeta)
+		msg.WithCommonMeta(cm)
+	}
+	cm[key] = e
+}
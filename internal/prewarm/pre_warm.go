@@ -199,7 +199,8 @@ func (p *connPool) Get(
 ) (net.Conn, error) {
 	getOpts := connpool.NewGetOptions()
 	getOpts.WithContext(ctx)
-	getOpts.WithFramerBuilder(opts.FramerBuilder)
+	getOpts.WithFramerBuilder(opts.LimitedFramerBuilder())
+	getOpts.WithFramerKey(opts.FramerKey())
 	getOpts.WithDialTLS(opts.TLSCertFile, opts.TLSKeyFile, opts.CACertFile, opts.TLSServerName)
 	getOpts.WithCertProvider(opts.TLSCertProvider)
 	getOpts.WithLocalAddr(opts.LocalAddr)
@@ -230,11 +231,12 @@ func (p *muxPool) Get(
 ) (muxConn, error) {
 	getOpts := multiplexed.NewGetOptions()
 	getOpts.WithVID(atomic.AddUint32(&p.vid, 1))
-	fp, ok := opts.FramerBuilder.(multiplexed.FrameParser)
+	fp, ok := opts.LimitedFramerBuilder().(multiplexed.FrameParser)
 	if !ok {
 		return muxConn{}, errors.New("frame builder does not implement multiplexed.FrameParser")
 	}
 	getOpts.WithFrameParser(fp)
+	getOpts.WithFramerKey(opts.FramerKey())
 	getOpts.WithDialTLS(opts.TLSCertFile, opts.TLSKeyFile, opts.CACertFile, opts.TLSServerName)
 	getOpts.WithCertProvider(opts.TLSCertProvider)
 	getOpts.WithLocalAddr(opts.LocalAddr)
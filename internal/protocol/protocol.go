@@ -18,10 +18,16 @@ const (
 	FastHTTP = "fasthttp"
 	// FastHTTPNoProtocol is the standard FastHTTP service protocol name.
 	FastHTTPNoProtocol = "fasthttp_no_protocol"
+	// JSONRPC is the JSON-RPC 2.0 over HTTP protocol name.
+	JSONRPC = "jsonrpc"
 	// TRPC is the tRPC protocol name.
 	TRPC = "trpc"
+	// Thrift is the Thrift protocol name over framed transport.
+	Thrift = "thrift"
 	// TNET is the tnet transport name.
 	TNET = "tnet"
+	// WebSocket is the WebSocket stream transport name.
+	WebSocket = "websocket"
 )
 
 const (
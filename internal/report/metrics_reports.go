@@ -18,6 +18,13 @@ import (
 	"trpc.group/trpc-go/trpc-go/metrics"
 )
 
+var (
+	// streamMsgSizeBounds are the buckets of the size of stream messages, in bytes.
+	streamMsgSizeBounds = metrics.NewValueBounds(64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304)
+	// streamTimeBounds are the buckets of the time of stream messages, in microseconds.
+	streamTimeBounds = metrics.NewValueBounds(10, 100, 1000, 10000, 100000, 1000000, 10000000)
+)
+
 // Unified all metrics report inside the framework. Every property starts with "trpc.".
 var (
 	// -----------------------------server----------------------------- //
@@ -29,6 +36,12 @@ var (
 	ServiceHandleFail = metrics.Counter("trpc.ServiceHandleFail")
 	// fails to decode request, usually happens when the package is illegal.
 	ServiceCodecDecodeFail = metrics.Counter("trpc.ServiceCodecDecodeFail")
+	// the checksum of request frame mismatches, usually happens when the package is corrupted on the way.
+	ServiceCodecChecksumFail = metrics.Counter("trpc.ServiceCodecChecksumFail")
+	// fails to decrypt request, usually happens when the key is not found or the key is not agreed.
+	ServiceCodecDecryptFail = metrics.Counter("trpc.ServiceCodecDecryptFail")
+	// the request exceeds the max request size of service, and is discarded without being decoded.
+	ServiceRequestTooLarge = metrics.Counter("trpc.ServiceRequestTooLarge")
 	// fails to encode reply, usually happens when there is a bug in the codec plugin.
 	ServiceCodecEncodeFail = metrics.Counter("trpc.ServiceCodecEncodeFail")
 	// invalid handle rpc name, usually happens when the caller fills an incorrect parameter.
@@ -72,6 +85,12 @@ var (
 	UDPClientTransportReadFail = metrics.Counter("trpc.UdpClientTransportReadFail")
 	// the auxiliary data after udp client has already read for a complete frame.
 	UDPClientTransportUnRead = metrics.Counter("trpc.UdpClientTransportUnRead")
+	// udp fragments of a message fail to arrive within the reassembly timeout.
+	UDPFragmentReassemblyTimeout = metrics.Counter("trpc.UdpFragmentReassemblyTimeout")
+	// udp fragments are dropped because the reassembly buffer is full.
+	UDPFragmentReassemblyOverflow = metrics.Counter("trpc.UdpFragmentReassemblyOverflow")
+	// udp fragments are retransmitted on the request of the peer.
+	UDPFragmentRetransmit = metrics.Counter("trpc.UdpFragmentRetransmit")
 	// request package size received by udp server.
 	UDPServerTransportReceiveSize = metrics.Gauge("trpc.UdpServerTransportReceiveSize")
 	// response package size sent by udp server.
@@ -83,6 +102,10 @@ var (
 	// TCPServerAsyncGoroutineScheduleDelay is the schedule delay of goroutine pool when async is on.
 	// DO NOT change the name, as the overload control algorithm depends on it.
 	TCPServerAsyncGoroutineScheduleDelay = metrics.Gauge("trpc.TcpServerAsyncGoroutineScheduleDelay_us")
+	// connection is accepted by the server connection limiter.
+	ServerConnectionAccepted = metrics.Counter("trpc.ServerConnectionAccepted")
+	// connection is rejected because max_connections or max_connections_per_ip is reached.
+	ServerConnectionRejected = metrics.Counter("trpc.ServerConnectionRejected")
 
 	// -----------------------------log----------------------------- //
 	// log is dropped because the queue is full.
@@ -95,6 +118,12 @@ var (
 	SelectNodeFail = metrics.Counter("trpc.SelectNodeFail")
 	// client has not configured the protocol.
 	ClientCodecEmpty = metrics.Counter("trpc.ClientCodecEmpty")
+	// the checksum of response frame mismatches, usually happens when the package is corrupted on the way.
+	ClientCodecChecksumFail = metrics.Counter("trpc.ClientCodecChecksumFail")
+	// fails to decrypt response, usually happens when the server doesn't support encryption.
+	ClientCodecDecryptFail = metrics.Counter("trpc.ClientCodecDecryptFail")
+	// the response exceeds the max response size of backend, and is discarded without being decoded.
+	ClientResponseTooLarge = metrics.Counter("trpc.ClientResponseTooLarge")
 	// fails to load client config, usually happens when the client is not configured properly.
 	LoadClientConfigFail = metrics.Counter("trpc.LoadClientConfigFail")
 	// fails to load the client filter config, usually happens when client filer array is configured with a
@@ -130,6 +159,30 @@ var (
 	MultiplexedTCPReconnectErr        = metrics.Counter("trpc.MultiplexedReconnectErr")
 	MultiplexedTCPReconnectOnReadErr  = metrics.Counter("trpc.MultiplexedReconnectOnReadErr")
 	MultiplexedTCPReconnectOnWriteErr = metrics.Counter("trpc.MultiplexedReconnectOnWriteErr")
+	// the connection is closed because of too many missed heartbeat pongs.
+	MultiplexedTCPHeartbeatTimeout = metrics.Counter("trpc.MultiplexedHeartbeatTimeout")
+	// a new connection is established because existing connections are too busy.
+	MultiplexedTCPScaleUp = metrics.Counter("trpc.MultiplexedScaleUp")
+	// an idle connection is closed because it is above the expected connection number.
+	MultiplexedTCPScaleDown = metrics.Counter("trpc.MultiplexedScaleDown")
+
+	// -----------------------------stream----------------------------- //
+	// the size of messages sent by client streams.
+	ClientStreamSendMsgSize = metrics.Histogram("trpc.ClientStreamSendMsgSize", streamMsgSizeBounds)
+	// the size of messages received by client streams.
+	ClientStreamRecvMsgSize = metrics.Histogram("trpc.ClientStreamRecvMsgSize", streamMsgSizeBounds)
+	// the interval between messages received by client streams.
+	ClientStreamRecvInterval = metrics.Histogram("trpc.ClientStreamRecvInterval_us", streamTimeBounds)
+	// the time client streams are blocked on the flow-control window before sending a message.
+	ClientStreamWindowBlockedTime = metrics.Histogram("trpc.ClientStreamWindowBlockedTime_us", streamTimeBounds)
+	// the size of messages sent by server streams.
+	ServerStreamSendMsgSize = metrics.Histogram("trpc.ServerStreamSendMsgSize", streamMsgSizeBounds)
+	// the size of messages received by server streams.
+	ServerStreamRecvMsgSize = metrics.Histogram("trpc.ServerStreamRecvMsgSize", streamMsgSizeBounds)
+	// the interval between messages received by server streams.
+	ServerStreamRecvInterval = metrics.Histogram("trpc.ServerStreamRecvInterval_us", streamTimeBounds)
+	// the time server streams are blocked on the flow-control window before sending a message.
+	ServerStreamWindowBlockedTime = metrics.Histogram("trpc.ServerStreamWindowBlockedTime_us", streamTimeBounds)
 
 	// -----------------------------other----------------------------- //
 	// panic number of trpc.GoAndWait.
@@ -0,0 +1,98 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+// Package wfq provides weighted fair queueing of the frames written to a connection.
+package wfq
+
+// Quantum is the number of bytes a priority of weight 1 may write in each round.
+const Quantum = 4 << 10
+
+// Scheduler schedules items of different priorities by deficit round robin, so that the bytes of
+// the items popped of each priority with items queued are in proportion to its weight, and items of
+// the same priority are popped in order. It is not safe for concurrent use.
+type Scheduler[T any] struct {
+	weights  []int
+	queues   [][]item[T]
+	deficits []int
+	current  int
+	credited bool // Whether the current priority has got its quantum of the round.
+	n        int
+}
+
+type item[T any] struct {
+	v    T
+	size int
+}
+
+// New creates a Scheduler, where priority i has weights[i]. Weights less than 1 are treated as 1.
+func New[T any](weights ...int) *Scheduler[T] {
+	if len(weights) == 0 {
+		weights = []int{1}
+	}
+	w := make([]int, len(weights))
+	for i, weight := range weights {
+		if w[i] = weight; w[i] < 1 {
+			w[i] = 1
+		}
+	}
+	return &Scheduler[T]{
+		weights:  w,
+		queues:   make([][]item[T], len(w)),
+		deficits: make([]int, len(w)),
+	}
+}
+
+// Len returns the number of items queued.
+func (s *Scheduler[T]) Len() int {
+	return s.n
+}
+
+// Push queues an item of size bytes. Items of unknown priorities are queued with priority 0.
+func (s *Scheduler[T]) Push(priority, size int, v T) {
+	if priority < 0 || priority >= len(s.queues) {
+		priority = 0
+	}
+	s.queues[priority] = append(s.queues[priority], item[T]{v: v, size: size})
+	s.n++
+}
+
+// Pop removes and returns the next item, it returns false if no item is queued.
+func (s *Scheduler[T]) Pop() (T, bool) {
+	if s.n == 0 {
+		var zero T
+		return zero, false
+	}
+	for {
+		q := s.queues[s.current]
+		if len(q) > 0 {
+			if !s.credited {
+				s.deficits[s.current] += s.weights[s.current] * Quantum
+				s.credited = true
+			}
+			if it := q[0]; it.size <= s.deficits[s.current] {
+				q[0] = item[T]{}
+				s.queues[s.current] = q[1:]
+				s.n--
+				s.deficits[s.current] -= it.size
+				if len(q) == 1 {
+					// The credit left is not carried over once the queue is drained.
+					s.queues[s.current] = nil
+					s.deficits[s.current] = 0
+				}
+				return it.v, true
+			}
+		}
+		s.current = (s.current + 1) % len(s.queues)
+		s.credited = false
+	}
+}
@@ -0,0 +1,82 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package wfq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func popAll(s *Scheduler[byte]) string {
+	var tags []byte
+	for {
+		tag, ok := s.Pop()
+		if !ok {
+			return string(tags)
+		}
+		tags = append(tags, tag)
+	}
+}
+
+func TestSchedulerFIFO(t *testing.T) {
+	s := New[byte]()
+	for _, tag := range []byte("abcde") {
+		s.Push(0, 100*Quantum, tag)
+	}
+	require.Equal(t, 5, s.Len())
+	require.Equal(t, "abcde", popAll(s))
+	require.Equal(t, 0, s.Len())
+	_, ok := s.Pop()
+	require.False(t, ok)
+}
+
+func TestSchedulerWeights(t *testing.T) {
+	s := New[byte](1, 3)
+	for i := 0; i < 4; i++ {
+		s.Push(0, Quantum, 'l')
+	}
+	for i := 0; i < 6; i++ {
+		s.Push(1, Quantum, 'h')
+	}
+	require.Equal(t, "lhhhlhhhll", popAll(s))
+}
+
+func TestSchedulerLargeFrames(t *testing.T) {
+	s := New[byte](1, 2)
+	// Credit is accumulated across rounds until the large frame fits.
+	s.Push(0, 3*Quantum, 'l')
+	for i := 0; i < 4; i++ {
+		s.Push(1, Quantum, 'h')
+	}
+	require.Equal(t, "hhhhl", popAll(s))
+}
+
+func TestSchedulerUnknownPriority(t *testing.T) {
+	s := New[byte](0, 1)
+	s.Push(5, 1, 'a')
+	s.Push(-1, 1, 'b')
+	require.Equal(t, "ab", popAll(s))
+}
+
+func TestSchedulerNoCarryOver(t *testing.T) {
+	s := New[byte](1, 1)
+	s.Push(0, 1, 'a')
+	require.Equal(t, "a", popAll(s))
+	// The credit left by a is not used by the frames pushed later.
+	s.Push(0, Quantum, 'b')
+	s.Push(0, Quantum, 'c')
+	s.Push(1, Quantum, 'd')
+	require.Equal(t, "dbc", popAll(s))
+}
@@ -121,7 +121,7 @@ func (p *pool) Get(network string, address string, opts GetOptions) (net.Conn, e
 	if cancel != nil {
 		defer cancel()
 	}
-	key := getNodeKey(network, address, opts.Protocol)
+	key := getNodeKey(network, address, opts.Protocol, opts.FramerKey)
 	if v, ok := p.connectionPools.Load(key); ok {
 		return v.(*ConnectionPool).Get(ctx)
 	}
@@ -693,14 +693,18 @@ func (l *connList) popTail() {
 	pc.inPool = false
 }
 
-func getNodeKey(network, address, protocol string) string {
+func getNodeKey(network, address, protocol, framerKey string) string {
 	const underline = "_"
 	var key strings.Builder
-	key.Grow(len(network) + len(address) + len(protocol) + 2)
+	key.Grow(len(network) + len(address) + len(protocol) + len(framerKey) + 3)
 	key.WriteString(network)
 	key.WriteString(underline)
 	key.WriteString(address)
 	key.WriteString(underline)
 	key.WriteString(protocol)
+	if framerKey != "" {
+		key.WriteString(underline)
+		key.WriteString(framerKey)
+	}
 	return key.String()
 }
@@ -765,7 +765,7 @@ func closePool(t *testing.T, p Pool) {
 	if !ok {
 		return
 	}
-	key := getNodeKey(t.Name(), t.Name(), "")
+	key := getNodeKey(t.Name(), t.Name(), "", "")
 	if pool, ok := v.connectionPools.Load(key); ok {
 		pool.(*ConnectionPool).Close()
 	}
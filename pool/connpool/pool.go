@@ -42,6 +42,9 @@ type GetOptions struct {
 	LocalAddr   string        // The local address when establishing a connection, which is randomly selected by default.
 	DialTimeout time.Duration // Connection establishment timeout.
 	Protocol    string        // protocol type.
+	// FramerKey distinguishes the connections whose framers are built differently by FramerBuilder,
+	// such as with different max frame sizes, which are not shared.
+	FramerKey string
 }
 
 func (o *GetOptions) getDialCtx(dialTimeout time.Duration) (context.Context, context.CancelFunc) {
@@ -119,6 +122,11 @@ func (o *GetOptions) WithDialTimeout(dur time.Duration) {
 	o.DialTimeout = dur
 }
 
+// WithFramerKey returns an Option which sets the key of the framers of the connections.
+func (o *GetOptions) WithFramerKey(key string) {
+	o.FramerKey = key
+}
+
 // WithProtocol returns an Option which sets the backend service protocol name.
 func (o *GetOptions) WithProtocol(s string) {
 	o.Protocol = s
@@ -20,3 +20,12 @@ type FrameParser interface {
 	// Parse parses vid and frame from io.ReadCloser. rc.Close must be called before Parse return.
 	Parse(rc io.Reader) (vid uint32, buf []byte, err error)
 }
+
+// Heartbeater is an optional interface of FrameParser. Connections periodically send
+// ping frames and expect pong frames back only if the FrameParser implements it.
+type Heartbeater interface {
+	// Ping returns a heartbeat ping frame carrying seq.
+	Ping(seq uint32) []byte
+	// IsPong reports whether the parsed frame buf is a heartbeat pong frame.
+	IsPong(buf []byte) bool
+}
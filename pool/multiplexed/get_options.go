@@ -26,6 +26,14 @@ type GetOptions struct {
 	// if not filled in, it defaults to the http hostname.
 
 	LocalAddr string
+	// ExcludedAddrs are the local addresses of the concrete connections which must not be picked.
+	ExcludedAddrs []string
+	// Priority is the priority of the frames written by the virtual connection,
+	// see WithPriorityWeights.
+	Priority int
+	// FramerKey distinguishes the concrete connections whose frames are parsed differently by FP,
+	// such as with different max frame sizes, which are not shared.
+	FramerKey string
 
 	network  string
 	address  string
@@ -68,6 +76,22 @@ func (o *GetOptions) WithLocalAddr(addr string) {
 	o.LocalAddr = addr
 }
 
+// WithExcludedAddrs returns an Option which excludes the concrete connections of the local addresses,
+// for example, those refusing new streams. A new concrete connection is established if all are excluded.
+func (o *GetOptions) WithExcludedAddrs(addrs ...string) {
+	o.ExcludedAddrs = addrs
+}
+
+// WithFramerKey returns an Option which sets the key of the frame parser of the concrete connections.
+func (o *GetOptions) WithFramerKey(key string) {
+	o.FramerKey = key
+}
+
+// WithPriority returns an Option which sets the priority of the frames written by the virtual connection.
+func (o *GetOptions) WithPriority(priority int) {
+	o.Priority = priority
+}
+
 func (o *GetOptions) update(network, address string) error {
 	if o.FP == nil {
 		return ErrFrameParserNil
@@ -80,5 +104,8 @@ func (o *GetOptions) update(network, address string) error {
 	o.address = address
 	o.network = network
 	o.nodeKey = makeNodeKey(o.network, o.address)
+	if o.FramerKey != "" {
+		o.nodeKey += "_" + o.FramerKey
+	}
 	return nil
 }
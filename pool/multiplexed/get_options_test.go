@@ -37,6 +37,7 @@ func TestGetOptions(t *testing.T) {
 	opts.WithDialTLS(certFile, keyFile, caFile, serverName)
 	opts.WithCertProvider(providerName)
 	opts.WithLocalAddr(localAddr)
+	opts.WithExcludedAddrs(localAddr)
 
 	assert.Equal(t, opts.FP, fp)
 	assert.Equal(t, opts.VID, id)
@@ -46,6 +47,7 @@ func TestGetOptions(t *testing.T) {
 	assert.Equal(t, opts.TLSCertFile, certFile)
 	assert.Equal(t, opts.TLSCertProvider, providerName)
 	assert.Equal(t, opts.LocalAddr, localAddr)
+	assert.Equal(t, opts.ExcludedAddrs, []string{localAddr})
 }
 
 type emptyFrameParser struct{}
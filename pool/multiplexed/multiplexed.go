@@ -28,6 +28,7 @@ import (
 	"trpc.group/trpc-go/trpc-go/internal/packetbuffer"
 	"trpc.group/trpc-go/trpc-go/internal/queue"
 	"trpc.group/trpc-go/trpc-go/internal/report"
+	"trpc.group/trpc-go/trpc-go/internal/wfq"
 	"trpc.group/trpc-go/trpc-go/log"
 	"trpc.group/trpc-go/trpc-go/pool/connpool"
 )
@@ -40,6 +41,9 @@ const (
 	defaultConnNumberPerHost = 2
 	defaultSendQueueSize     = 1024
 	defaultDialTimeout       = time.Second
+	defaultMaxMissedPongs    = 3
+	defaultScaleUpThreshold  = 64
+	defaultScaleDownIdle     = time.Minute
 	maxBufferSize            = 65535
 )
 
@@ -74,6 +78,8 @@ var (
 	ErrNetworkNotSupport = errors.New("network not support")
 	// ErrConnectionsHaveBeenExpelled denotes that the connections to a certain ip:port have been expelled.
 	ErrConnectionsHaveBeenExpelled = errors.New("connections have been expelled")
+	// ErrHeartbeatTimeout denotes that the connection is considered dead because of too many missed pongs.
+	ErrHeartbeatTimeout = errors.New("heartbeat timeout, too many missed pongs")
 )
 
 // Pool is a connection pool for multiplexing.
@@ -96,6 +102,20 @@ func New(opt ...PoolOption) *Multiplexed {
 	if opts.maxIdleConnsPerHost != 0 && opts.maxIdleConnsPerHost < opts.connectNumberPerHost {
 		opts.maxIdleConnsPerHost = opts.connectNumberPerHost
 	}
+	if opts.heartbeatInterval > 0 && opts.maxMissedPongs <= 0 {
+		opts.maxMissedPongs = defaultMaxMissedPongs
+	}
+	if opts.maxConnectNumber > 0 {
+		if opts.maxConnectNumber < opts.connectNumberPerHost {
+			opts.maxConnectNumber = opts.connectNumberPerHost
+		}
+		if opts.scaleUpThreshold <= 0 {
+			opts.scaleUpThreshold = defaultScaleUpThreshold
+		}
+		if opts.scaleDownIdleTimeout <= 0 {
+			opts.scaleDownIdleTimeout = defaultScaleDownIdle
+		}
+	}
 	return &Multiplexed{
 		concreteConns: new(sync.Map),
 		opts:          opts,
@@ -152,7 +172,9 @@ func (p *Multiplexed) get(ctx context.Context, opts *GetOptions) (*VirtualConnec
 			"multiplexed pick single concreate connection with node key %s err: %w", opts.nodeKey, err)
 	}
 	// Step 3: single concrete connection => virtual connection.
-	return conn.newVirConn(ctx, opts.VID), nil
+	vc := conn.newVirConn(ctx, opts.VID)
+	vc.priority = opts.Priority
+	return vc, nil
 }
 
 func (p *Multiplexed) initPoolForNode(opts *GetOptions) {
@@ -176,27 +198,39 @@ func (p *Multiplexed) newConcreteConnections(opts *GetOptions) *Connections {
 		},
 	}
 	conns.initialize(opts)
+	if conns.autoScale() {
+		go conns.scaleDown()
+	}
 	return conns
 }
 
 func (cs *Connections) newConn(opts *GetOptions) *Connection {
 	c := &Connection{
-		network:          opts.network,
-		address:          opts.address,
-		virConns:         make(map[uint32]*VirtualConnection),
-		done:             make(chan struct{}),
-		dropFull:         cs.opts.dropFull,
-		maxVirConns:      cs.opts.maxVirConnsPerConn,
-		writeBuffer:      make(chan []byte, cs.opts.sendQueueSize),
-		isStream:         opts.isStream,
-		isIdle:           true,
-		enableIdleRemove: cs.maxIdle > 0 && cs.opts.maxVirConnsPerConn > 0,
-		connsAddIdle:     func() { cs.addIdle() },
-		connsSubIdle:     func() { cs.subIdle() },
+		network:           opts.network,
+		address:           opts.address,
+		virConns:          make(map[uint32]*VirtualConnection),
+		done:              make(chan struct{}),
+		dropFull:          cs.opts.dropFull,
+		maxVirConns:       cs.opts.maxVirConnsPerConn,
+		writeBuffer:       make(chan frame, cs.opts.sendQueueSize),
+		scheduler:         wfq.New[[]byte](cs.opts.priorityWeights...),
+		weighted:          len(cs.opts.priorityWeights) > 1,
+		isStream:          opts.isStream,
+		isIdle:            true,
+		enableIdleRemove:  cs.maxIdle > 0 && cs.opts.maxVirConnsPerConn > 0,
+		heartbeatInterval: cs.opts.heartbeatInterval,
+		dialFunc:          cs.opts.dialFunc,
+		maxMissedPongs:    cs.opts.maxMissedPongs,
+		idleSince:         time.Now(),
+		connsAddIdle:      func() { cs.addIdle() },
+		connsSubIdle:      func() { cs.subIdle() },
 		connsNeedIdleRemove: func() bool {
 			return int(atomic.LoadInt32(&cs.currentIdle)) > cs.maxIdle
 		},
 	}
+	if c.weighted {
+		c.slots = make(chan struct{}, cs.opts.sendQueueSize)
+	}
 	c.destroy = func() { cs.expel(c) }
 	cs.conns = append(cs.conns, c)
 	cs.addIdle()
@@ -204,7 +238,7 @@ func (cs *Connections) newConn(opts *GetOptions) *Connection {
 	return c
 }
 
-func dialTCP(timeout time.Duration, opts *GetOptions) (net.Conn, *connpool.DialOptions, error) {
+func dialTCP(dial connpool.DialFunc, timeout time.Duration, opts *GetOptions) (net.Conn, *connpool.DialOptions, error) {
 	dialOpts := &connpool.DialOptions{
 		Network:         opts.network,
 		Address:         opts.address,
@@ -216,7 +250,7 @@ func dialTCP(timeout time.Duration, opts *GetOptions) (net.Conn, *connpool.DialO
 		TLSServerName:   opts.TLSServerName,
 		LocalAddr:       opts.LocalAddr,
 	}
-	conn, err := tryConnect(dialOpts)
+	conn, err := tryConnect(dial, dialOpts)
 	return conn, dialOpts, err
 }
 
@@ -248,6 +282,13 @@ func (cs *Connections) pickSingleConcrete(ctx context.Context, opts *GetOptions)
 		return nil, fmt.Errorf("node key: %s, err: %w, caused by sub errors on conns: %+v",
 			cs.nodeKey, ErrConnectionsHaveBeenExpelled, cs.err)
 	}
+	if len(opts.ExcludedAddrs) > 0 {
+		return cs.pickNotExcluded(opts), nil
+	}
+	if cs.opts.maxVirConnsPerConn == 0 && cs.opts.selectStrategy != RoundRobin {
+		// The number of virtual connections on each concrete connection is unlimited, pick by load.
+		return cs.pickLeastLoaded(opts), nil
+	}
 	if cs.opts.maxVirConnsPerConn == 0 {
 		// The number of virtual connections on each concrete connection is unlimited, do round robin.
 		cs.roundRobinIndex = (cs.roundRobinIndex + 1) % cs.opts.connectNumberPerHost
@@ -267,6 +308,136 @@ func (cs *Connections) pickSingleConcrete(ctx context.Context, opts *GetOptions)
 	return cs.newConn(opts), nil
 }
 
+// pickLeastLoaded picks the connection with the least load according to the select strategy,
+// and establishes a new connection instead if auto scaling is enabled and the least loaded one is too busy.
+// cs.mu must be held by the caller.
+func (cs *Connections) pickLeastLoaded(opts *GetOptions) *Connection {
+	if len(cs.conns) < cs.opts.connectNumberPerHost {
+		// Current concrete connections have been reduced below the expected number.
+		return cs.newConn(opts)
+	}
+	var (
+		picked     *Connection
+		pickedLoad int64
+	)
+	for _, c := range cs.conns {
+		if load := c.load(cs.opts.selectStrategy); picked == nil || load < pickedLoad {
+			picked, pickedLoad = c, load
+		}
+	}
+	if len(cs.conns) < cs.opts.maxConnectNumber && picked.inFlight() >= cs.opts.scaleUpThreshold {
+		report.MultiplexedTCPScaleUp.Incr()
+		return cs.newConn(opts)
+	}
+	return picked
+}
+
+// pickNotExcluded picks the least loaded connection which is not excluded and can get a virtual connection,
+// and establishes a new connection instead if there is none.
+// cs.mu must be held by the caller.
+func (cs *Connections) pickNotExcluded(opts *GetOptions) *Connection {
+	var (
+		picked     *Connection
+		pickedLoad int64
+	)
+	for _, c := range cs.conns {
+		if c.isExcluded(opts.ExcludedAddrs) || !c.canGetVirConn() {
+			continue
+		}
+		if load := c.load(cs.opts.selectStrategy); picked == nil || load < pickedLoad {
+			picked, pickedLoad = c, load
+		}
+	}
+	if picked == nil {
+		return cs.newConn(opts)
+	}
+	return picked
+}
+
+func (cs *Connections) autoScale() bool {
+	return cs.opts.maxVirConnsPerConn == 0 &&
+		cs.opts.selectStrategy != RoundRobin &&
+		cs.opts.maxConnectNumber > cs.opts.connectNumberPerHost
+}
+
+// scaleDown periodically closes idle connections above ConnectNumber until the connections are expelled.
+func (cs *Connections) scaleDown() {
+	timeout := cs.opts.scaleDownIdleTimeout
+	ticker := time.NewTicker(timeout)
+	defer ticker.Stop()
+	for range ticker.C {
+		cs.mu.Lock()
+		if cs.expelled {
+			cs.mu.Unlock()
+			return
+		}
+		var idle []*Connection
+		for i := len(cs.conns) - 1; i >= 0 && len(cs.conns)-len(idle) > cs.opts.connectNumberPerHost; i-- {
+			if cs.conns[i].isIdleFor(timeout) {
+				idle = append(idle, cs.conns[i])
+			}
+		}
+		cs.mu.Unlock()
+		for _, c := range idle {
+			if c.closeIdle(timeout) {
+				report.MultiplexedTCPScaleDown.Incr()
+				c.destroy()
+			}
+		}
+	}
+}
+
+// load returns the load of the connection measured by the select strategy.
+func (c *Connection) load(strategy SelectStrategy) int64 {
+	if strategy == LeastPendingBytes {
+		return atomic.LoadInt64(&c.pendingBytes)
+	}
+	return int64(c.inFlight())
+}
+
+// inFlight returns the number of in-flight virtual connections.
+func (c *Connection) inFlight() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.virConns)
+}
+
+func (c *Connection) isIdleFor(d time.Duration) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.virConns) == 0 && !c.closed && time.Since(c.idleSince) >= d
+}
+
+// closeIdle closes the connection if it has no virtual connections for at least d.
+func (c *Connection) closeIdle(d time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.virConns) != 0 || c.closed || time.Since(c.idleSince) < d {
+		return false
+	}
+	c.closed = true
+	close(c.done)
+	if conn := c.getRawConn(); conn != nil {
+		conn.Close()
+	}
+	return true
+}
+
+// isExcluded returns whether the local address of the connection is one of addrs.
+func (c *Connection) isExcluded(addrs []string) bool {
+	conn := c.getRawConn()
+	if conn == nil {
+		return false
+	}
+	local := conn.LocalAddr().String()
+	for _, addr := range addrs {
+		if addr == local {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Connection) canGetVirConn() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -285,11 +456,12 @@ func (c *Connection) startConnect(opts *GetOptions, dialTimeout time.Duration) {
 	}
 	go c.reading()
 	go c.writing()
+	c.startHeartbeat()
 }
 
 func (c *Connection) dial(timeout time.Duration, opts *GetOptions) error {
 	if c.isStream {
-		conn, dialOpts, err := dialTCP(timeout, opts)
+		conn, dialOpts, err := dialTCP(c.dialFunc, timeout, opts)
 		c.dialOpts = dialOpts
 		if err != nil {
 			return err
@@ -321,6 +493,10 @@ func (c *Connection) reading() {
 			// all subsequent parsing, so it is necessary to close the reconnection.
 			if c.isStream {
 				lastErr = err
+				if atomic.CompareAndSwapUint32(&c.heartbeatTimeout, 1, 0) {
+					// The read error is caused by heartbeat closing the dead connection.
+					lastErr = ErrHeartbeatTimeout
+				}
 				report.MultiplexedTCPReconnectOnReadErr.Incr()
 				log.Tracef("reconnect on read err: %+v", err)
 				break
@@ -330,6 +506,10 @@ func (c *Connection) reading() {
 			log.Tracef("decode packet err: %s", err)
 			continue
 		}
+		if c.isPong(buf) {
+			atomic.StoreInt32(&c.missedPongs, 0)
+			continue
+		}
 
 		c.mu.RLock()
 		vc, ok := c.virConns[vid]
@@ -344,28 +524,63 @@ func (c *Connection) reading() {
 
 func (c *Connection) writing() {
 	var lastErr error
-L:
 	for {
-		select {
-		case <-c.done:
+		b, ok := c.next()
+		if !ok {
 			return
-		case it := <-c.writeBuffer:
-			if err := c.writeAll(it); err != nil {
-				if c.isStream { // If tcp fails to write data, it will cause the peer to close the connection.
-					lastErr = err
-					report.MultiplexedTCPReconnectOnWriteErr.Incr()
-					log.Tracef("reconnect on write err: %+v", err)
-					break L
-				}
-				// udp failed to send packets, you can continue to send packets.
-				log.Tracef("multiplexed send UDP packet failed: %v", err)
-				continue
+		}
+		err := c.writeAll(b)
+		atomic.AddInt64(&c.pendingBytes, -int64(len(b)))
+		if c.slots != nil {
+			<-c.slots
+		}
+		if err != nil {
+			if c.isStream { // If tcp fails to write data, it will cause the peer to close the connection.
+				lastErr = err
+				report.MultiplexedTCPReconnectOnWriteErr.Incr()
+				log.Tracef("reconnect on write err: %+v", err)
+				break
 			}
+			// udp failed to send packets, you can continue to send packets.
+			log.Tracef("multiplexed send UDP packet failed: %v", err)
 		}
 	}
 	c.close(lastErr, true)
 }
 
+// next returns the next frame to be written, it returns false once the connection is closed.
+// Once prioritized, the frames waiting in writeBuffer are moved into the scheduler, so that they are
+// written by priority rather than in the order they are sent. The frames in both of them are limited
+// by slots, so the senders are blocked once the send queue size is reached.
+func (c *Connection) next() ([]byte, bool) {
+	select {
+	case <-c.done:
+		return nil, false
+	default:
+	}
+	if c.scheduler.Len() == 0 {
+		select {
+		case <-c.done:
+			return nil, false
+		case f := <-c.writeBuffer:
+			if atomic.LoadUint32(&c.prioritized) == 0 {
+				return f.b, true
+			}
+			c.scheduler.Push(f.priority, len(f.b), f.b)
+		}
+	}
+	for drained := false; !drained; {
+		select {
+		case f := <-c.writeBuffer:
+			c.scheduler.Push(f.priority, len(f.b), f.b)
+		default:
+			drained = true
+		}
+	}
+	b, _ := c.scheduler.Pop()
+	return b, true
+}
+
 func (c *Connection) parse() (vid uint32, buf []byte, err error) {
 	if c.isStream {
 		return c.fp.Parse(c.getRawConn())
@@ -384,6 +599,12 @@ func (c *Connection) parse() (vid uint32, buf []byte, err error) {
 	return c.fp.Parse(c.packetBuffer)
 }
 
+// frame is a frame waiting to be written with its priority.
+type frame struct {
+	priority int
+	b        []byte
+}
+
 // Connection represents the underlying tcp connection.
 type Connection struct {
 	err                 error
@@ -408,10 +629,32 @@ type Connection struct {
 
 	fp          FrameParser
 	done        chan struct{} // closed when underlying connection closed.
-	writeBuffer chan []byte
+	writeBuffer chan frame
 	dropFull    bool
 	maxVirConns int
 
+	// scheduler orders the frames taken from writeBuffer by priority, it is only accessed by
+	// the writing goroutine, and keeps the frames not yet written across reconnects.
+	scheduler *wfq.Scheduler[[]byte]
+	// weighted denotes whether there are multiple priorities to be scheduled.
+	weighted bool
+	// prioritized is set to 1 once a frame of a priority other than 0 is sent, until which the frames
+	// are written in the order they are sent, without the scheduler.
+	prioritized uint32
+	// slots limits the frames in writeBuffer and scheduler to the send queue size, nil if not weighted.
+	slots chan struct{}
+
+	// pendingBytes is the number of bytes in writeBuffer and scheduler waiting to be written.
+	pendingBytes int64
+	// idleSince denotes the time at which the connection has no virtual connections.
+	idleSince time.Time
+
+	// heartbeat, tcp/unix stream only
+	heartbeatInterval time.Duration
+	maxMissedPongs    int
+	missedPongs       int32
+	heartbeatTimeout  uint32 // set to 1 when heartbeat closes the underlying connection.
+
 	// udp only
 	packetBuffer *packetbuffer.PacketBuffer
 	addr         *net.UDPAddr
@@ -421,6 +664,7 @@ type Connection struct {
 	conn       net.Conn // the underlying tcp connection.
 	connLocker sync.RWMutex
 	dialOpts   *connpool.DialOptions
+	dialFunc   connpool.DialFunc
 	isStream   bool
 	closed     bool
 }
@@ -514,18 +758,52 @@ func (c *Connection) newVirConn(ctx context.Context, virConnID uint32) *VirtualC
 	return vc
 }
 
-func (c *Connection) send(b []byte) error {
+func (c *Connection) send(priority int, b []byte) error {
+	atomic.AddInt64(&c.pendingBytes, int64(len(b)))
+	f := frame{priority: priority, b: b}
+	if c.slots != nil {
+		if priority != 0 && atomic.LoadUint32(&c.prioritized) == 0 {
+			atomic.StoreUint32(&c.prioritized, 1)
+		}
+		// writeBuffer is never full while a slot is held.
+		if err := c.acquireSlot(); err != nil {
+			atomic.AddInt64(&c.pendingBytes, -int64(len(b)))
+			return err
+		}
+		c.writeBuffer <- f
+		return nil
+	}
 	// If dropfull is set, the queue is full, then discard.
 	if c.dropFull {
 		select {
-		case c.writeBuffer <- b:
+		case c.writeBuffer <- f:
 			return nil
 		default:
+			atomic.AddInt64(&c.pendingBytes, -int64(len(b)))
 			return ErrSendQueueFull
 		}
 	}
 	select {
-	case c.writeBuffer <- b:
+	case c.writeBuffer <- f:
+		return nil
+	case <-c.done:
+		atomic.AddInt64(&c.pendingBytes, -int64(len(b)))
+		return c.err
+	}
+}
+
+// acquireSlot acquires a slot of the send queue, which is released once the frame is written.
+func (c *Connection) acquireSlot() error {
+	if c.dropFull {
+		select {
+		case c.slots <- struct{}{}:
+			return nil
+		default:
+			return ErrSendQueueFull
+		}
+	}
+	select {
+	case c.slots <- struct{}{}:
 		return nil
 	case <-c.done:
 		return c.err
@@ -620,8 +898,11 @@ func (c *Connection) doClose(lastErr error, reconnect bool) (needDestroy bool) {
 	return true
 }
 
-func tryConnect(opts *connpool.DialOptions) (net.Conn, error) {
-	conn, err := connpool.Dial(opts)
+func tryConnect(dial connpool.DialFunc, opts *connpool.DialOptions) (net.Conn, error) {
+	if dial == nil {
+		dial = connpool.Dial
+	}
+	conn, err := dial(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -633,7 +914,7 @@ func tryConnect(opts *connpool.DialOptions) (net.Conn, error) {
 
 func (c *Connection) reconnect() (success bool) {
 	for {
-		conn, err := tryConnect(c.dialOpts)
+		conn, err := tryConnect(c.dialFunc, c.dialOpts)
 		if err != nil {
 			report.MultiplexedTCPReconnectErr.Incr()
 			log.Tracef("reconnect fail: %+v", err)
@@ -654,10 +935,59 @@ func (c *Connection) reconnect() (success bool) {
 		c.closed = false
 		go c.reading()
 		go c.writing()
+		c.startHeartbeat()
 		return true
 	}
 }
 
+// startHeartbeat starts sending heartbeat pings on the current underlying connection
+// if heartbeat is enabled and supported by the frame parser.
+func (c *Connection) startHeartbeat() {
+	if !c.isStream || c.heartbeatInterval <= 0 {
+		return
+	}
+	hb, ok := c.fp.(Heartbeater)
+	if !ok {
+		return
+	}
+	atomic.StoreInt32(&c.missedPongs, 0)
+	go c.heartbeat(hb, c.getRawConn(), c.done)
+}
+
+// heartbeat sends a ping every heartbeat interval. If more than maxMissedPongs pings in a row are
+// left unanswered, the connection is closed, which makes reading fail fast and triggers reconnection.
+func (c *Connection) heartbeat(hb Heartbeater, conn net.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+	var seq uint32
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		if int(atomic.AddInt32(&c.missedPongs, 1)) > c.maxMissedPongs {
+			report.MultiplexedTCPHeartbeatTimeout.Incr()
+			log.Tracef("multiplexed connection to %s missed %d pongs, close it", c.address, c.maxMissedPongs)
+			atomic.StoreUint32(&c.heartbeatTimeout, 1)
+			conn.Close()
+			return
+		}
+		seq++
+		if err := c.send(0, hb.Ping(seq)); err != nil {
+			log.Tracef("multiplexed send heartbeat ping failed: %v", err)
+		}
+	}
+}
+
+func (c *Connection) isPong(buf []byte) bool {
+	if c.heartbeatInterval <= 0 {
+		return false
+	}
+	hb, ok := c.fp.(Heartbeater)
+	return ok && hb.IsPong(buf)
+}
+
 func (c *Connection) doReconnectBackoff() bool {
 	cur := time.Now()
 	if !c.lastReconnectTime.IsZero() && c.lastReconnectTime.Add(reconnectCountResetInterval).Before(cur) {
@@ -688,6 +1018,9 @@ func (c *Connection) doRemove(virConnID uint32) (needDestroy bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.virConns, virConnID)
+	if len(c.virConns) == 0 {
+		c.idleSince = time.Now()
+	}
 	if c.enableIdleRemove {
 		return c.idleRemove()
 	}
@@ -745,6 +1078,7 @@ type MuxConn interface {
 // VirtualConnection multiplexes virtual connections.
 type VirtualConnection struct {
 	id        uint32
+	priority  int
 	conn      *Connection
 	recvQueue *queue.Queue[[]byte]
 
@@ -796,7 +1130,7 @@ func (vc *VirtualConnection) Write(b []byte) error {
 		return vc.ctx.Err()
 	default:
 	}
-	if err := vc.conn.send(b); err != nil {
+	if err := vc.conn.send(vc.priority, b); err != nil {
 		// clean the virtual connection when send fail.
 		vc.Close()
 		return err
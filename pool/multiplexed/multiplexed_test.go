@@ -31,6 +31,7 @@ import (
 
 	"golang.org/x/sync/errgroup"
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/pool/connpool"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -628,6 +629,151 @@ func (s *msuite) TestWithLocalAddr() {
 	}
 }
 
+func (s *msuite) TestWithDialFunc() {
+	var dialed int32
+	m := New(WithDialFunc(func(opts *connpool.DialOptions) (net.Conn, error) {
+		atomic.AddInt32(&dialed, 1)
+		return connpool.Dial(opts)
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	id := atomic.AddUint32(&s.requestID, 1)
+	ld := &lengthDelimitedFramer{}
+	opts := NewGetOptions()
+	opts.WithVID(id)
+	opts.WithFrameParser(ld)
+	vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+
+	body := []byte("hello world")
+	buf, err := ld.Encode(&delimitedRequest{
+		body:      body,
+		requestID: id,
+	})
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), vc.Write(buf))
+	rsp, err := vc.Read()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), body, rsp)
+	assert.Equal(s.T(), int32(defaultConnNumberPerHost), atomic.LoadInt32(&dialed))
+}
+
+// gatedConn blocks the first write until the gate is opened, and records the frames written.
+type gatedConn struct {
+	net.Conn
+	entered chan struct{}
+	gate    chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	written [][]byte
+}
+
+func (c *gatedConn) Write(b []byte) (int, error) {
+	c.once.Do(func() {
+		close(c.entered)
+		<-c.gate
+	})
+	c.mu.Lock()
+	c.written = append(c.written, append([]byte(nil), b...))
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
+
+func (s *msuite) TestPriorityWeights() {
+	gc := &gatedConn{entered: make(chan struct{}), gate: make(chan struct{})}
+	m := New(WithConnectNumber(1), WithPriorityWeights(1, 8), WithQueueSize(100),
+		WithDialFunc(func(opts *connpool.DialOptions) (net.Conn, error) {
+			conn, err := connpool.Dial(opts)
+			gc.Conn = conn
+			return gc, err
+		}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ld := &lengthDelimitedFramer{}
+	getConn := func(priority int) (MuxConn, uint32) {
+		id := atomic.AddUint32(&s.requestID, 1)
+		opts := NewGetOptions()
+		opts.WithVID(id)
+		opts.WithFrameParser(ld)
+		opts.WithPriority(priority)
+		vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+		require.Nil(s.T(), err)
+		return vc, id
+	}
+	low, lowID := getConn(0)
+	high, highID := getConn(1)
+	frame := func(id uint32, i int) []byte {
+		buf, err := ld.Encode(&delimitedRequest{
+			body:      bytes.Repeat([]byte{byte(i)}, 4088),
+			requestID: id,
+		})
+		require.Nil(s.T(), err)
+		return buf
+	}
+
+	// The first frame blocks the writing, so that the following frames are scheduled together.
+	require.Nil(s.T(), low.Write(frame(lowID, 0)))
+	<-gc.entered
+	for i := 1; i <= 4; i++ {
+		require.Nil(s.T(), low.Write(frame(lowID, i)))
+	}
+	// The frames are scheduled once the high priority is sent. In each round, the quantum of the low
+	// priority fits one frame, and that of the high priority fits all of them.
+	want := [][]byte{frame(lowID, 1)}
+	for i := 1; i <= 4; i++ {
+		f := frame(highID, i)
+		want = append(want, f)
+		require.Nil(s.T(), high.Write(f))
+	}
+	for i := 2; i <= 4; i++ {
+		want = append(want, frame(lowID, i))
+	}
+	close(gc.gate)
+
+	// Wait for all the frames to be echoed.
+	for i := 0; i < 5; i++ {
+		_, err := low.Read()
+		require.Nil(s.T(), err)
+	}
+	for i := 0; i < 4; i++ {
+		_, err := high.Read()
+		require.Nil(s.T(), err)
+	}
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	require.Len(s.T(), gc.written, 9)
+	require.Equal(s.T(), want, gc.written[1:])
+}
+
+func (s *msuite) TestPriorityQueueSize() {
+	gc := &gatedConn{entered: make(chan struct{}), gate: make(chan struct{})}
+	defer close(gc.gate)
+	m := New(WithConnectNumber(1), WithPriorityWeights(1, 8), WithQueueSize(2), WithDropFull(true),
+		WithDialFunc(func(opts *connpool.DialOptions) (net.Conn, error) {
+			conn, err := connpool.Dial(opts)
+			gc.Conn = conn
+			return gc, err
+		}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ld := &lengthDelimitedFramer{}
+	id := atomic.AddUint32(&s.requestID, 1)
+	opts := NewGetOptions()
+	opts.WithVID(id)
+	opts.WithFrameParser(ld)
+	opts.WithPriority(1)
+	vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+	buf, err := ld.Encode(&delimitedRequest{body: []byte("hello"), requestID: id})
+	require.Nil(s.T(), err)
+
+	// The frame being written and the one scheduled reach the queue size together.
+	require.Nil(s.T(), vc.Write(buf))
+	<-gc.entered
+	require.Nil(s.T(), vc.Write(buf))
+	require.Equal(s.T(), ErrSendQueueFull, vc.Write(buf))
+}
+
 func (s *msuite) TestTCPReconnect() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
@@ -672,6 +818,173 @@ func (s *msuite) TestTCPReconnect() {
 	assert.ErrorIs(s.T(), err, context.DeadlineExceeded)
 }
 
+const heartbeatRequestID = math.MaxUint32
+
+// heartbeatFramer sends pings which are echoed back by the test server as pongs.
+type heartbeatFramer struct {
+	lengthDelimitedFramer
+	ignorePong bool
+}
+
+func (f *heartbeatFramer) Ping(seq uint32) []byte {
+	buf, _ := f.Encode(&delimitedRequest{requestID: heartbeatRequestID, body: []byte("ping")})
+	return buf
+}
+
+func (f *heartbeatFramer) IsPong(buf []byte) bool {
+	return !f.ignorePong && bytes.Equal(buf, []byte("ping"))
+}
+
+func (s *msuite) TestHeartbeatKeepsConnectionAlive() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m := New(WithConnectNumber(1), WithHeartbeatInterval(10*time.Millisecond), WithMaxMissedPongs(2))
+	hf := &heartbeatFramer{}
+	opts := NewGetOptions()
+	opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+	opts.WithFrameParser(hf)
+	vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+	time.Sleep(100 * time.Millisecond)
+
+	buf, err := hf.Encode(&delimitedRequest{body: []byte("hello world"), requestID: s.requestID})
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), vc.Write(buf))
+	rsp, err := vc.Read()
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), []byte("hello world"), rsp)
+}
+
+func (s *msuite) TestHeartbeatTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m := New(WithConnectNumber(1), WithHeartbeatInterval(10*time.Millisecond), WithMaxMissedPongs(2))
+	opts := NewGetOptions()
+	opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+	opts.WithFrameParser(&heartbeatFramer{ignorePong: true})
+	vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+
+	start := time.Now()
+	_, err = vc.Read()
+	require.ErrorIs(s.T(), err, ErrHeartbeatTimeout)
+	require.Less(s.T(), time.Since(start), 500*time.Millisecond)
+
+	// The dead connection is re-dialed, new virtual connections still work.
+	vc, err = m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), vc.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0}))
+}
+
+func (s *msuite) TestLeastInFlightSelection() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m := New(WithConnectNumber(2), WithSelectStrategy(LeastInFlight))
+	opts := NewGetOptions()
+	opts.WithFrameParser(&lengthDelimitedFramer{})
+	conns := make(map[*Connection]int)
+	for i := 0; i < 4; i++ {
+		opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+		vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+		require.Nil(s.T(), err)
+		defer vc.Close()
+		conns[vc.(*VirtualConnection).conn]++
+	}
+	require.Len(s.T(), conns, 2)
+	for _, n := range conns {
+		require.Equal(s.T(), 2, n)
+	}
+}
+
+func (s *msuite) TestLeastPendingBytesSelection() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m := New(WithConnectNumber(2), WithSelectStrategy(LeastPendingBytes))
+	opts := NewGetOptions()
+	opts.WithFrameParser(&lengthDelimitedFramer{})
+	opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+	vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+	vc.Close()
+
+	val, ok := m.concreteConns.Load(makeNodeKey(s.network, s.address))
+	require.True(s.T(), ok)
+	cs := val.(*Connections)
+	require.Len(s.T(), cs.conns, 2)
+	atomic.StoreInt64(&cs.conns[0].pendingBytes, 1024)
+	cs.mu.Lock()
+	picked := cs.pickLeastLoaded(&opts)
+	cs.mu.Unlock()
+	require.Equal(s.T(), cs.conns[1], picked)
+}
+
+func (s *msuite) TestExcludedAddrs() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m := New(WithConnectNumber(1))
+	ld := &lengthDelimitedFramer{}
+	opts := NewGetOptions()
+	opts.WithFrameParser(ld)
+	opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+	vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+	defer vc.Close()
+	// Make sure the connection is established.
+	buf, err := ld.Encode(&delimitedRequest{body: []byte("hello world"), requestID: opts.VID})
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), vc.Write(buf))
+	_, err = vc.Read()
+	require.Nil(s.T(), err)
+
+	opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+	opts.WithExcludedAddrs(vc.LocalAddr().String())
+	excluding, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+	defer excluding.Close()
+	require.NotEqual(s.T(), vc.(*VirtualConnection).conn, excluding.(*VirtualConnection).conn)
+
+	opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+	opts.WithExcludedAddrs()
+	picked, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+	require.Nil(s.T(), err)
+	defer picked.Close()
+	require.Equal(s.T(), vc.(*VirtualConnection).conn, picked.(*VirtualConnection).conn)
+}
+
+func (s *msuite) TestAutoScale() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m := New(
+		WithConnectNumber(1),
+		WithSelectStrategy(LeastInFlight),
+		WithAutoScale(3, 2, 50*time.Millisecond),
+	)
+	opts := NewGetOptions()
+	opts.WithFrameParser(&lengthDelimitedFramer{})
+	var vcs []MuxConn
+	for i := 0; i < 10; i++ {
+		opts.WithVID(atomic.AddUint32(&s.requestID, 1))
+		vc, err := m.GetMuxConn(ctx, s.network, s.address, opts)
+		require.Nil(s.T(), err)
+		vcs = append(vcs, vc)
+	}
+	val, ok := m.concreteConns.Load(makeNodeKey(s.network, s.address))
+	require.True(s.T(), ok)
+	cs := val.(*Connections)
+	cs.mu.Lock()
+	require.Len(s.T(), cs.conns, 3)
+	cs.mu.Unlock()
+
+	for _, vc := range vcs {
+		vc.Close()
+	}
+	require.Eventually(s.T(), func() bool {
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+		return len(cs.conns) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
 func (s *msuite) TestTCPReconnectMaxReconnectCount() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
@@ -13,18 +13,42 @@
 
 package multiplexed
 
-import "time"
+import (
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/pool/connpool"
+)
 
 // PoolOptions represents some settings for the connection pool.
 type PoolOptions struct {
-	connectNumberPerHost int           // Set the number of connections per address.
-	sendQueueSize        int           // Set the length of each Connection send queue.
-	dropFull             bool          // Whether the queue is full or not.
-	dialTimeout          time.Duration // Connection timeout, default 1s.
-	maxVirConnsPerConn   int           // Max number of virtual connections per real connection, 0 means no limit.
-	maxIdleConnsPerHost  int           // Set the maximum number of idle connections for each peer ip:port.
+	connectNumberPerHost int               // Set the number of connections per address.
+	sendQueueSize        int               // Set the length of each Connection send queue.
+	dropFull             bool              // Whether the queue is full or not.
+	dialTimeout          time.Duration     // Connection timeout, default 1s.
+	maxVirConnsPerConn   int               // Max number of virtual connections per real connection, 0 means no limit.
+	maxIdleConnsPerHost  int               // Set the maximum number of idle connections for each peer ip:port.
+	heartbeatInterval    time.Duration     // Interval of heartbeat pings, 0 means heartbeat is disabled.
+	maxMissedPongs       int               // Max number of unanswered pings before a connection is considered dead.
+	selectStrategy       SelectStrategy    // Strategy of selecting a concrete connection.
+	maxConnectNumber     int               // Max number of connections per address when scaling automatically.
+	scaleUpThreshold     int               // Number of in-flight virtual connections per connection to scale up.
+	scaleDownIdleTimeout time.Duration     // Idle time after which a scaled up connection is closed.
+	dialFunc             connpool.DialFunc // Dial function of stream connections, default is connpool.Dial.
+	priorityWeights      []int             // Weights of the priorities of frames written to each connection.
 }
 
+// SelectStrategy is the strategy of selecting a concrete connection among the connections to an address.
+type SelectStrategy int
+
+const (
+	// RoundRobin selects connections in turn, this is the default strategy.
+	RoundRobin SelectStrategy = iota
+	// LeastInFlight selects the connection with the least in-flight virtual connections.
+	LeastInFlight
+	// LeastPendingBytes selects the connection with the least bytes waiting to be written.
+	LeastPendingBytes
+)
+
 // PoolOption is the Options helper.
 type PoolOption func(*PoolOptions)
 
@@ -74,3 +98,64 @@ func WithMaxIdleConnsPerHost(n int) PoolOption {
 		opts.maxIdleConnsPerHost = n
 	}
 }
+
+// WithHeartbeatInterval returns an Option which sets the interval of application-level heartbeat pings
+// on stream connections, 0 means heartbeat is disabled.
+// Heartbeat takes effect only if the FrameParser implements Heartbeater, and the server must be able
+// to answer pings, otherwise the connection will be closed after several missed pongs.
+func WithHeartbeatInterval(d time.Duration) PoolOption {
+	return func(opts *PoolOptions) {
+		opts.heartbeatInterval = d
+	}
+}
+
+// WithMaxMissedPongs returns an Option which sets the maximum number of consecutive unanswered pings.
+// Once exceeded, the connection is considered dead, all of its virtual connections fail with
+// ErrHeartbeatTimeout and the connection is re-dialed. Default is 3.
+func WithMaxMissedPongs(n int) PoolOption {
+	return func(opts *PoolOptions) {
+		opts.maxMissedPongs = n
+	}
+}
+
+// WithSelectStrategy returns an Option which sets the strategy of selecting a concrete connection.
+// It only takes effect when MaxVirConnsPerConn is not set.
+func WithSelectStrategy(strategy SelectStrategy) PoolOption {
+	return func(opts *PoolOptions) {
+		opts.selectStrategy = strategy
+	}
+}
+
+// WithAutoScale returns an Option which enables the number of connections per address to grow
+// from ConnectNumber up to maxConnectNumber. A new connection is established when the selected
+// connection has at least scaleUpThreshold in-flight virtual connections, and connections above
+// ConnectNumber are closed after being idle for idleTimeout.
+// It only takes effect with load-aware strategies, i.e. LeastInFlight and LeastPendingBytes.
+func WithAutoScale(maxConnectNumber, scaleUpThreshold int, idleTimeout time.Duration) PoolOption {
+	return func(opts *PoolOptions) {
+		opts.maxConnectNumber = maxConnectNumber
+		opts.scaleUpThreshold = scaleUpThreshold
+		opts.scaleDownIdleTimeout = idleTimeout
+	}
+}
+
+// WithDialFunc returns an Option which sets the function to dial stream connections, default is connpool.Dial.
+// It allows frames to be carried by another protocol over the connection, such as WebSocket.
+func WithDialFunc(d connpool.DialFunc) PoolOption {
+	return func(opts *PoolOptions) {
+		opts.dialFunc = d
+	}
+}
+
+// WithPriorityWeights returns an Option which sets the weights of the priorities of virtual connections,
+// where priority i has weights[i]. Frames waiting to be written to a connection are scheduled by weighted
+// fair queueing, so that the bytes written by each priority are in proportion to its weight, and frames of
+// the same priority are written in order. Frames of unknown priorities are written with priority 0.
+// A connection writes frames in the order they are sent until a frame of a priority other than 0 is sent.
+// Frames waiting to be scheduled are limited by the queue size as well.
+// By default there is only one priority, and frames are written in the order they are sent.
+func WithPriorityWeights(weights ...int) PoolOption {
+	return func(opts *PoolOptions) {
+		opts.priorityWeights = weights
+	}
+}
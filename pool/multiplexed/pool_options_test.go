@@ -15,6 +15,7 @@ package multiplexed
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,7 +26,17 @@ func TestPoolOptions(t *testing.T) {
 	WithConnectNumber(50000)(opts)
 	WithQueueSize(20000)(opts)
 	WithDropFull(true)(opts)
+	WithHeartbeatInterval(time.Second)(opts)
+	WithMaxMissedPongs(5)(opts)
+	WithSelectStrategy(LeastPendingBytes)(opts)
+	WithAutoScale(8, 100, time.Minute)(opts)
 	assert.Equal(t, opts.connectNumberPerHost, 50000)
 	assert.Equal(t, opts.sendQueueSize, 20000)
 	assert.Equal(t, opts.dropFull, true)
+	assert.Equal(t, opts.heartbeatInterval, time.Second)
+	assert.Equal(t, opts.maxMissedPongs, 5)
+	assert.Equal(t, opts.selectStrategy, LeastPendingBytes)
+	assert.Equal(t, opts.maxConnectNumber, 8)
+	assert.Equal(t, opts.scaleUpThreshold, 100)
+	assert.Equal(t, opts.scaleDownIdleTimeout, time.Minute)
 }
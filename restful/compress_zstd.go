@@ -0,0 +1,99 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package restful
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	RegisterCompressor(&ZSTDCompressor{})
+}
+
+var zstdReaderPool sync.Pool
+var zstdWriterPool sync.Pool
+
+// ZSTDCompressor is the compressor for Content-Encoding: zstd.
+type ZSTDCompressor struct{}
+
+// wrappedZSTDWriter wraps zstd.Encoder for pooling.
+type wrappedZSTDWriter struct {
+	*zstd.Encoder
+}
+
+// Close rewrites the underlying zstd.Encoder's Close method.
+// The wrapped writer will be put back to the pool after its underlying zstd.Encoder is closed.
+func (w *wrappedZSTDWriter) Close() error {
+	defer zstdWriterPool.Put(w)
+	return w.Encoder.Close()
+}
+
+// wrappedZSTDReader wraps zstd.Decoder for pooling.
+type wrappedZSTDReader struct {
+	*zstd.Decoder
+}
+
+// Read rewrites the underlying zstd.Decoder's Read method.
+// The wrapped reader will be put back to the pool after its underlying zstd.Decoder is read.
+func (r *wrappedZSTDReader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		zstdReaderPool.Put(r)
+	}
+	return n, err
+}
+
+// Compress implements Compressor.
+func (*ZSTDCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	z, ok := zstdWriterPool.Get().(*wrappedZSTDWriter)
+	if !ok {
+		encoder, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedZSTDWriter{Encoder: encoder}, nil
+	}
+	z.Encoder.Reset(w)
+	return z, nil
+}
+
+// Decompress implements Compressor.
+func (*ZSTDCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	z, ok := zstdReaderPool.Get().(*wrappedZSTDReader)
+	if !ok {
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedZSTDReader{Decoder: decoder}, nil
+	}
+	if err := z.Decoder.Reset(r); err != nil {
+		zstdReaderPool.Put(z)
+		return nil, err
+	}
+	return z, nil
+}
+
+// Name implements Compressor.
+func (*ZSTDCompressor) Name() string {
+	return "zstd"
+}
+
+// ContentEncoding implements Compressor.
+func (*ZSTDCompressor) ContentEncoding() string {
+	return "zstd"
+}
@@ -105,3 +105,26 @@ func TestGZIPCompressor(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, input, out)
 }
+
+func TestZSTDCompressor(t *testing.T) {
+	z := &restful.ZSTDCompressor{}
+
+	require.Equal(t, "zstd", z.Name())
+	require.Equal(t, "zstd", z.ContentEncoding())
+	require.Equal(t, z, restful.GetCompressor("zstd"))
+
+	input := []byte("foobar foo bar baz")
+	for i := 0; i < 2; i++ {
+		buf := new(bytes.Buffer)
+		w, err := z.Compress(buf)
+		require.Nil(t, err)
+		_, err = w.Write(input)
+		require.Nil(t, err)
+		require.Nil(t, w.Close())
+		r, err := z.Decompress(buf)
+		require.Nil(t, err)
+		out, err := io.ReadAll(r)
+		require.Nil(t, err)
+		require.Equal(t, input, out)
+	}
+}
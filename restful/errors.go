@@ -53,16 +53,17 @@ func (w *WithStatusCode) Unwrap() error {
 
 // tRPC error code => http status code
 var httpStatusMap = map[trpcpb.TrpcRetCode]int{
-	errs.RetServerDecodeFail:   http.StatusBadRequest,
-	errs.RetServerEncodeFail:   http.StatusInternalServerError,
-	errs.RetServerNoService:    http.StatusNotFound,
-	errs.RetServerNoFunc:       http.StatusNotFound,
-	errs.RetServerTimeout:      http.StatusGatewayTimeout,
-	errs.RetServerOverload:     http.StatusTooManyRequests,
-	errs.RetServerSystemErr:    http.StatusInternalServerError,
-	errs.RetServerAuthFail:     http.StatusUnauthorized,
-	errs.RetServerValidateFail: http.StatusBadRequest,
-	errs.RetUnknown:            http.StatusInternalServerError,
+	errs.RetServerDecodeFail:     http.StatusBadRequest,
+	errs.RetServerEncodeFail:     http.StatusInternalServerError,
+	errs.RetServerNoService:      http.StatusNotFound,
+	errs.RetServerNoFunc:         http.StatusNotFound,
+	errs.RetServerTimeout:        http.StatusGatewayTimeout,
+	errs.RetServerOverload:       http.StatusTooManyRequests,
+	errs.RetServerSystemErr:      http.StatusInternalServerError,
+	errs.RetServerAuthFail:       http.StatusUnauthorized,
+	errs.RetServerValidateFail:   http.StatusBadRequest,
+	errs.RetServerMsgExceedLimit: http.StatusRequestEntityTooLarge,
+	errs.RetUnknown:              http.StatusInternalServerError,
 }
 
 // marshalError marshals an error.
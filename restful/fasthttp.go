@@ -98,6 +98,12 @@ func (r *Router) HandleRequestCtx(ctx *fasthttp.RequestCtx) {
 	for _, tr := range r.transcoders[bytes2str(ctx.Method())] {
 		fieldValues, err := tr.pat.Match(bytes2str(ctx.Path()))
 		if err == nil {
+			if tr.stream != nil {
+				r.opts.FastHTTPErrHandler(newCtx, ctx,
+					errs.New(errs.RetServerNoFunc, "server streaming is not supported by fasthttp"))
+				return
+			}
+
 			// header matching
 			stubCtx, err := r.opts.FastHTTPHeaderMatcher(newCtx, ctx,
 				r.opts.ServiceName, tr.name)
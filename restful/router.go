@@ -166,10 +166,13 @@ type ExtractFilterFunc func() filter.ServerChain
 
 // Binding is the binding of tRPC method and HttpRule.
 type Binding struct {
-	Name         string
-	Input        Initializer
-	Output       Initializer
-	Filter       HandleFunc
+	Name   string
+	Input  Initializer
+	Output Initializer
+	Filter HandleFunc
+	// Stream handles the server-streaming method, whose responses are sent as newline-delimited JSON
+	// or Server-Sent Events. Filter is ignored if Stream is set.
+	Stream       StreamHandleFunc
 	HTTPMethod   string
 	Pattern      *Pattern
 	Body         BodyLocator
@@ -201,6 +204,7 @@ func (r *Router) newTranscoder(binding *Binding, serviceImpl interface{}) (*tran
 		input:                binding.Input,
 		output:               binding.Output,
 		handler:              binding.Filter,
+		stream:               binding.Stream,
 		httpMethod:           binding.HTTPMethod,
 		pat:                  binding.Pattern,
 		body:                 binding.Body,
@@ -405,6 +409,11 @@ func (r *Router) handle(
 	ctx = modifiedCtx
 	defer putBackCtxMessage(ctx)
 
+	if tr.stream != nil {
+		r.handleStream(ctx, w, req, tr, fieldValues)
+		return
+	}
+
 	timeout := r.opts.Timeout
 	requestTimeout := codec.Message(ctx).RequestTimeout()
 	if requestTimeout > 0 && (requestTimeout < timeout || timeout == 0) {
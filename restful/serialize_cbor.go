@@ -0,0 +1,53 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package restful
+
+import (
+	"trpc.group/trpc-go/trpc-go/codec"
+)
+
+func init() {
+	RegisterSerializer(&CBORSerializer{})
+}
+
+// CBORSerializer is used for Content-Type: application/cbor.
+// It's based on codec.CBORSerialization, see it for how proto messages are serialized.
+type CBORSerializer struct{}
+
+// Marshal implements Serializer.
+func (*CBORSerializer) Marshal(v interface{}) ([]byte, error) {
+	return (&codec.CBORSerialization{}).Marshal(v)
+}
+
+// Unmarshal implements Serializer.
+func (*CBORSerializer) Unmarshal(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	// unmarshal the tRPC message itself or a message field of it
+	if msg, ok := assertProtoMessage(v); ok {
+		return (&codec.CBORSerialization{}).Unmarshal(data, msg)
+	}
+	return (&codec.CBORSerialization{}).Unmarshal(data, v)
+}
+
+// Name implements Serializer.
+func (*CBORSerializer) Name() string {
+	return "application/cbor"
+}
+
+// ContentType implements Serializer.
+func (*CBORSerializer) ContentType() string {
+	return "application/cbor"
+}
@@ -14,10 +14,12 @@
 package restful_test
 
 import (
+	"math"
 	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"trpc.group/trpc-go/trpc-go/restful"
 	"trpc.group/trpc-go/trpc-go/testdata/restful/bookstore"
@@ -163,6 +165,61 @@ func TestProtoSerializer(t *testing.T) {
 	require.True(t, proto.Equal(input, output))
 }
 
+func TestMsgPackAndCBORSerializer(t *testing.T) {
+	input := &helloworld.HelloRequest{
+		Name:                   "nobody",
+		SingleNested:           &helloworld.NestedOuter{Name: "anybody", Ok: helloworld.NestedOuter_TRUE},
+		PrimitiveBytesValue:    []byte{0, 1, 2},
+		PrimitiveBoolValue:     true,
+		PrimitiveFloatValue:    1.5,
+		PrimitiveDoubleValue:   -2.5,
+		PrimitiveInt32Value:    math.MinInt32,
+		PrimitiveUint32Value:   math.MaxUint32,
+		PrimitiveInt64Value:    math.MinInt64,
+		PrimitiveUint64Value:   math.MaxUint64,
+		PrimitiveFixed32Value:  1,
+		PrimitiveFixed64Value:  2,
+		PrimitiveSint32Value:   -3,
+		PrimitiveSint64Value:   -4,
+		PrimitiveSfixed32Value: -5,
+		PrimitiveSfixed64Value: -6,
+		EnumValue:              helloworld.NumericEnum_ONE,
+		OneofValue:             &helloworld.HelloRequest_OneofEmpty{OneofEmpty: &emptypb.Empty{}},
+		RepeatedStringValue:    []string{"a", "b"},
+		RepeatedEnumValue:      []helloworld.NumericEnum{helloworld.NumericEnum_ONE, helloworld.NumericEnum_ZERO},
+		RepeatedNestedValue:    []*helloworld.NestedOuter{{Name: "a"}, {Inner: &helloworld.NestedInner{A: true}}},
+		MappedStringValue:      map[string]string{"foo": "bar"},
+		MappedEnumValue:        map[string]helloworld.NumericEnum{"foo": helloworld.NumericEnum_ONE},
+		MappedNestedValue:      map[string]*helloworld.NestedOuter{"foo": {Amount: 2}},
+		Time:                   &timestamppb.Timestamp{Seconds: 111111111, Nanos: 1},
+		Duration:               durationpb.New(time.Second),
+		WrappedStrValue:        wrapperspb.String("wrapped"),
+		WrappedUint64Value:     wrapperspb.UInt64(1),
+	}
+	for _, s := range []restful.Serializer{&restful.MsgPackSerializer{}, &restful.CBORSerializer{}} {
+		require.Equal(t, s, restful.GetSerializer(s.Name()))
+		require.Equal(t, s.Name(), s.ContentType())
+
+		buf, err := s.Marshal(input)
+		require.Nil(t, err)
+		output := &helloworld.HelloRequest{}
+		require.Nil(t, s.Unmarshal(buf, output))
+		require.True(t, proto.Equal(input, output), "got %v", output)
+		require.Nil(t, s.Unmarshal(nil, output))
+
+		// marshal and unmarshal a field of the tRPC message
+		buf, err = s.Marshal(input.SingleNested)
+		require.Nil(t, err)
+		output = &helloworld.HelloRequest{}
+		require.Nil(t, s.Unmarshal(buf, &output.SingleNested))
+		require.True(t, proto.Equal(input.SingleNested, output.SingleNested))
+		buf, err = s.Marshal(input.Name)
+		require.Nil(t, err)
+		require.Nil(t, s.Unmarshal(buf, &output.Name))
+		require.Equal(t, input.Name, output.Name)
+	}
+}
+
 func TestJSONPBSerializer(t *testing.T) {
 	input := &helloworld.HelloRequest{
 		Name: "nobody",
@@ -0,0 +1,227 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/r3labs/sse/v2"
+	"google.golang.org/protobuf/proto"
+
+	"trpc.group/trpc-go/trpc-go/errs"
+	isse "trpc.group/trpc-go/trpc-go/internal/sse"
+)
+
+const (
+	// contentTypeNDJSON is the content type of newline-delimited JSON responses.
+	contentTypeNDJSON = "application/x-ndjson"
+	// contentTypeSSE is the content type of Server-Sent Events responses.
+	contentTypeSSE = "text/event-stream"
+)
+
+// ServerStream is the server stream of a server-streaming method served by RESTful.
+// It has the same method set as server.Stream.
+type ServerStream interface {
+	// Context returns the context of the stream, which is canceled once the client disconnects.
+	Context() context.Context
+	// SendMsg sends a response to the client, and the response is flushed immediately.
+	SendMsg(m interface{}) error
+	// RecvMsg receives the request transcoded from the http request once, and then returns io.EOF.
+	RecvMsg(m interface{}) error
+}
+
+// StreamHandleFunc is tRPC server-streaming method handle function.
+type StreamHandleFunc func(svc interface{}, stream ServerStream) error
+
+// handleStream serves the server-streaming method.
+// The responses are written as Server-Sent Events if the client accepts text/event-stream,
+// or newline-delimited JSON otherwise.
+func (r *Router) handleStream(
+	ctx context.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	tr *transcoder,
+	fieldValues map[string]string,
+) {
+	reqCompressor, _ := compressorForTranscoding(req.Header[headerContentEncoding],
+		req.Header[headerAcceptEncoding])
+	reqSerializer, _ := serializerForTranscoding(req.Header[headerContentType],
+		req.Header[headerAccept])
+
+	params, _ := paramsPool.Get().(*transcodeParams)
+	params.reqCompressor = reqCompressor
+	params.reqSerializer = reqSerializer
+	params.body = req.Body
+	params.fieldValues = fieldValues
+	params.form = req.URL.Query()
+	protoReq, err := tr.transcodeRequest(params)
+	putBackParams(params)
+	if err != nil {
+		r.opts.ErrorHandler(ctx, w, req, err)
+		return
+	}
+
+	// The context of the http request is canceled once the client disconnects.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ss := &serverStream{
+		ctx: ctx,
+		w:   w,
+		tr:  tr,
+		req: protoReq,
+		sse: acceptsSSE(req.Header.Values(headerAccept)),
+	}
+	if err := tr.stream(tr.serviceImpl, ss); err != nil {
+		if !ss.headerWritten {
+			r.opts.ErrorHandler(ctx, w, req, err)
+			return
+		}
+		ss.writeError(err)
+		return
+	}
+	// Write the header for the stream without responses.
+	ss.writeHeader()
+}
+
+// serverStream implements ServerStream.
+type serverStream struct {
+	ctx           context.Context
+	w             http.ResponseWriter
+	tr            *transcoder
+	req           proto.Message
+	sse           bool
+	recvDone      bool
+	headerWritten bool
+}
+
+// Context implements ServerStream.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg implements ServerStream.
+func (s *serverStream) RecvMsg(m interface{}) error {
+	if s.recvDone {
+		return io.EOF
+	}
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return errs.NewFrameError(errs.RetServerDecodeFail, "restful stream: request is not a proto message")
+	}
+	proto.Merge(msg, s.req)
+	s.recvDone = true
+	return nil
+}
+
+// SendMsg implements ServerStream.
+func (s *serverStream) SendMsg(m interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return errs.NewFrameError(errs.RetServerSystemErr, "restful stream: "+err.Error())
+	}
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return errs.NewFrameError(errs.RetServerEncodeFail, "restful stream: response is not a proto message")
+	}
+	buf, err := s.tr.transcodeResp(msg, streamSerializer())
+	if err != nil {
+		return errs.NewFrameError(errs.RetServerEncodeFail, "restful stream: "+err.Error())
+	}
+	if err := s.write("", compactJSON(buf)); err != nil {
+		return errs.NewFrameError(errs.RetServerSystemErr, "restful stream: "+err.Error())
+	}
+	return nil
+}
+
+// writeHeader writes the header of the response once.
+func (s *serverStream) writeHeader() {
+	if s.headerWritten {
+		return
+	}
+	s.headerWritten = true
+	if s.sse {
+		s.w.Header().Set(headerContentType, contentTypeSSE)
+		s.w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		s.w.Header().Set(headerContentType, contentTypeNDJSON)
+	}
+	s.w.WriteHeader(GetStatusCodeOnSucceed(s.ctx))
+}
+
+// write writes an event or a line of data, and flushes it.
+func (s *serverStream) write(event string, data []byte) error {
+	s.writeHeader()
+	var err error
+	if s.sse {
+		err = isse.Write(s.w, sse.Event{Event: []byte(event), Data: data})
+	} else {
+		_, err = s.w.Write(append(data, '\n'))
+	}
+	if err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// writeError writes the error returned after the header has been written, which is
+// an event named "error" of Server-Sent Events, or a line of {"error":{"code":..,"message":..}}.
+func (s *serverStream) writeError(err error) {
+	buf, merr := marshalError(err, streamSerializer())
+	if merr != nil {
+		buf = []byte(MarshalErrorContent)
+	}
+	buf = compactJSON(buf)
+	if s.sse {
+		s.write("error", buf)
+		return
+	}
+	s.write("", append(append([]byte(`{"error":`), buf...), '}'))
+}
+
+// streamSerializer returns the serializer of responses of streams.
+func streamSerializer() Serializer {
+	return GetSerializer("application/json")
+}
+
+// acceptsSSE reports whether the client accepts Server-Sent Events.
+func acceptsSSE(accept []string) bool {
+	for _, v := range accept {
+		for _, t := range strings.Split(v, ",") {
+			mediaType := strings.TrimSpace(strings.SplitN(t, ";", 2)[0])
+			if strings.EqualFold(mediaType, contentTypeSSE) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compactJSON removes the newlines of the JSON, which would break the lines of streams.
+func compactJSON(b []byte) []byte {
+	if !bytes.ContainsAny(b, "\r\n") {
+		return b
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return bytes.ReplaceAll(bytes.ReplaceAll(b, []byte("\r"), nil), []byte("\n"), nil)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,236 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package restful_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/restful"
+	"trpc.group/trpc-go/trpc-go/server"
+	"trpc.group/trpc-go/trpc-go/testdata/restful/helloworld"
+)
+
+// streamGreeter replies Name-0, Name-1, ... Name-(PrimitiveInt32Value-1).
+// It fails before replying if Name is "fail", and after replying if Name is "fail-after".
+// It blocks until the client disconnects if Name is "block".
+type streamGreeter struct {
+	canceled chan struct{}
+}
+
+func (g *streamGreeter) SayHellos(stream server.Stream) error {
+	req := &helloworld.HelloRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	if err := stream.RecvMsg(&helloworld.HelloRequest{}); err != io.EOF {
+		return fmt.Errorf("want io.EOF after the request, got %v", err)
+	}
+	switch req.Name {
+	case "fail":
+		return errs.New(errs.RetServerValidateFail, "invalid name")
+	case "block":
+		if err := stream.SendMsg(&helloworld.HelloReply{Message: "blocking"}); err != nil {
+			return err
+		}
+		<-stream.Context().Done()
+		close(g.canceled)
+		return stream.SendMsg(&helloworld.HelloReply{Message: "unreachable"})
+	}
+	for i := 0; i < int(req.PrimitiveInt32Value); i++ {
+		if err := stream.SendMsg(&helloworld.HelloReply{Message: fmt.Sprintf("%s-%d", req.Name, i)}); err != nil {
+			return err
+		}
+	}
+	if req.Name == "fail-after" {
+		return errs.New(10001, "failed after replies")
+	}
+	return nil
+}
+
+var streamGreeterServiceDesc = server.ServiceDesc{
+	ServiceName: "trpc.examples.restful.helloworld.StreamGreeter",
+	HandlerType: (*interface{ SayHellos(server.Stream) error })(nil),
+	Streams: []server.StreamDesc{
+		{
+			StreamName: "/trpc.examples.restful.helloworld.StreamGreeter/SayHellos",
+			Handler: func(srv interface{}, stream server.Stream) error {
+				return srv.(*streamGreeter).SayHellos(stream)
+			},
+			ServerStreams: true,
+			Bindings: []*restful.Binding{
+				{
+					Name:       "/trpc.examples.restful.helloworld.StreamGreeter/SayHellos",
+					Input:      func() restful.ProtoMessage { return new(helloworld.HelloRequest) },
+					Output:     func() restful.ProtoMessage { return new(helloworld.HelloReply) },
+					HTTPMethod: "GET",
+					Pattern:    restful.Enforce("/v1/hellos/{name}"),
+				},
+			},
+		},
+	},
+}
+
+func startStreamGreeter(t *testing.T, opts ...server.Option) (string, *streamGreeter) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	s := server.New(append([]server.Option{
+		server.WithListener(ln),
+		server.WithServiceName(t.Name()),
+		server.WithNetwork("tcp"),
+		server.WithProtocol("restful"),
+	}, opts...)...)
+	g := &streamGreeter{canceled: make(chan struct{})}
+	require.Nil(t, s.Register(&streamGreeterServiceDesc, g))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Serve() }()
+	select {
+	case err := <-errCh:
+		require.FailNow(t, "serve failed", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	t.Cleanup(func() {
+		s.Close(nil)
+		_ = ln.Close()
+	})
+	return "http://" + ln.Addr().String(), g
+}
+
+func getStream(t *testing.T, url, accept string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.Nil(t, err)
+	// Other tests may change the default serializer.
+	req.Header.Set("Content-Type", "application/json")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rsp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	t.Cleanup(func() { rsp.Body.Close() })
+	return rsp
+}
+
+func readAll(t *testing.T, rsp *http.Response) string {
+	t.Helper()
+	body, err := io.ReadAll(rsp.Body)
+	require.Nil(t, err)
+	return string(body)
+}
+
+func TestServerStreamingNDJSON(t *testing.T) {
+	var filtered int32
+	endpoint, _ := startStreamGreeter(t, server.WithStreamFilter(
+		func(ss server.Stream, info *server.StreamServerInfo, handler server.StreamHandler) error {
+			require.Equal(t, "/trpc.examples.restful.helloworld.StreamGreeter/SayHellos", info.FullMethod)
+			require.True(t, info.IsServerStream)
+			atomic.AddInt32(&filtered, 1)
+			return handler(ss)
+		}))
+
+	rsp := getStream(t, endpoint+"/v1/hellos/world?primitive_int32_value=3", "")
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, "application/x-ndjson", rsp.Header.Get("Content-Type"))
+	require.Equal(t, `{"message":"world-0"}
+{"message":"world-1"}
+{"message":"world-2"}
+`, readAll(t, rsp))
+	require.Equal(t, int32(1), atomic.LoadInt32(&filtered))
+
+	t.Run("no responses", func(t *testing.T) {
+		rsp := getStream(t, endpoint+"/v1/hellos/world", "")
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		require.Equal(t, "application/x-ndjson", rsp.Header.Get("Content-Type"))
+		require.Equal(t, "", readAll(t, rsp))
+	})
+	t.Run("error before responses", func(t *testing.T) {
+		rsp := getStream(t, endpoint+"/v1/hellos/fail", "")
+		require.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+		require.Contains(t, readAll(t, rsp), "invalid name")
+	})
+	t.Run("error after responses", func(t *testing.T) {
+		rsp := getStream(t, endpoint+"/v1/hellos/fail-after?primitive_int32_value=1", "")
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		require.Equal(t, `{"message":"fail-after-0"}
+{"error":{"code":10001,"message":"failed after replies"}}
+`, readAll(t, rsp))
+	})
+	t.Run("decode error", func(t *testing.T) {
+		rsp := getStream(t, endpoint+"/v1/hellos/world?primitive_int32_value=x", "")
+		require.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+	})
+}
+
+func TestServerStreamingSSE(t *testing.T) {
+	endpoint, _ := startStreamGreeter(t)
+
+	rsp := getStream(t, endpoint+"/v1/hellos/world?primitive_int32_value=2", "text/event-stream")
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, "text/event-stream", rsp.Header.Get("Content-Type"))
+	require.Equal(t, "no-cache", rsp.Header.Get("Cache-Control"))
+	require.Equal(t, "data:{\"message\":\"world-0\"}\n\ndata:{\"message\":\"world-1\"}\n\n", readAll(t, rsp))
+
+	rsp = getStream(t, endpoint+"/v1/hellos/fail-after?primitive_int32_value=1",
+		"application/json;q=0.9, text/event-stream")
+	require.Equal(t, "data:{\"message\":\"fail-after-0\"}\n\n"+
+		"event:error\ndata:{\"code\":10001,\"message\":\"failed after replies\"}\n\n", readAll(t, rsp))
+}
+
+func TestServerStreamingFlushAndDisconnect(t *testing.T) {
+	endpoint, g := startStreamGreeter(t)
+
+	req, err := http.NewRequest(http.MethodGet, endpoint+"/v1/hellos/block", nil)
+	require.Nil(t, err)
+	rsp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	// The first response is flushed while the handler is blocking.
+	line, err := bufio.NewReader(rsp.Body).ReadString('\n')
+	require.Nil(t, err)
+	require.Equal(t, "{\"message\":\"blocking\"}\n", line)
+
+	// Disconnecting cancels the context of the stream.
+	rsp.Body.Close()
+	select {
+	case <-g.canceled:
+	case <-time.After(time.Second):
+		require.FailNow(t, "stream context is not canceled after the client disconnects")
+	}
+}
+
+func TestServerStreamingBindingErrors(t *testing.T) {
+	desc := streamGreeterServiceDesc
+	desc.Streams = []server.StreamDesc{desc.Streams[0]}
+	desc.Streams[0].ClientStreams = true
+	s := server.New(server.WithServiceName(t.Name()), server.WithProtocol("restful"))
+	err := s.Register(&desc, &streamGreeter{})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "client streaming")
+}
+
+func TestServerStreamingFastHTTP(t *testing.T) {
+	endpoint, _ := startStreamGreeter(t, server.WithProtocol("restful_based_on_fasthttp"))
+	rsp := getStream(t, endpoint+"/v1/hellos/world?primitive_int32_value=1", "")
+	require.Equal(t, http.StatusNotFound, rsp.StatusCode)
+	require.Contains(t, readAll(t, rsp), "server streaming is not supported by fasthttp")
+}
@@ -42,6 +42,7 @@ type transcoder struct {
 	input                func() ProtoMessage
 	output               func() ProtoMessage
 	handler              HandleFunc
+	stream               StreamHandleFunc
 	httpMethod           string
 	pat                  *Pattern
 	body                 BodyLocator
@@ -87,23 +88,9 @@ func (tr *transcoder) transcode(
 	stubCtx context.Context,
 	params *transcodeParams,
 ) (proto.Message, []byte, error) {
-	// init tRPC request
-	protoReq := tr.input()
-
-	// transcode body
-	if err := tr.transcodeBody(protoReq, params.body, params.reqCompressor,
-		params.reqSerializer); err != nil {
-		return nil, nil, errs.New(errs.RetServerDecodeFail, err.Error())
-	}
-
-	// transcode fieldValues from url path matching
-	if err := tr.transcodeFieldValues(protoReq, params.fieldValues); err != nil {
-		return nil, nil, errs.New(errs.RetServerDecodeFail, err.Error())
-	}
-
-	// transcode query params
-	if err := tr.transcodeQueryParams(protoReq, params.form); err != nil {
-		return nil, nil, errs.New(errs.RetServerDecodeFail, err.Error())
+	protoReq, err := tr.transcodeRequest(params)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// tRPC Stub handling
@@ -128,6 +115,33 @@ func (tr *transcoder) transcode(
 	return protoResp, buf, nil
 }
 
+// transcodeRequest transcodes the http request to a tRPC request.
+func (tr *transcoder) transcodeRequest(params *transcodeParams) (proto.Message, error) {
+	// init tRPC request
+	protoReq := tr.input()
+
+	// transcode body
+	if err := tr.transcodeBody(protoReq, params.body, params.reqCompressor,
+		params.reqSerializer); err != nil {
+		var e *errs.Error
+		if errors.As(err, &e) && e.Code == errs.RetServerMsgExceedLimit {
+			return nil, e
+		}
+		return nil, errs.New(errs.RetServerDecodeFail, err.Error())
+	}
+
+	// transcode fieldValues from url path matching
+	if err := tr.transcodeFieldValues(protoReq, params.fieldValues); err != nil {
+		return nil, errs.New(errs.RetServerDecodeFail, err.Error())
+	}
+
+	// transcode query params
+	if err := tr.transcodeQueryParams(protoReq, params.form); err != nil {
+		return nil, errs.New(errs.RetServerDecodeFail, err.Error())
+	}
+	return protoReq, nil
+}
+
 // bodyBufferPool is the pool of http request body buffer.
 var bodyBufferPool = sync.Pool{
 	New: func() interface{} {
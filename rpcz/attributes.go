@@ -34,6 +34,18 @@ const (
 	TRPCAttributeRequestSize = "__@*TRPCAttribute(RequestSize)*@__"
 	// TRPCAttributeFilterNames is used to set the FilterNames attribute of span.
 	TRPCAttributeFilterNames = "__@*TRPCAttribute(FilterNames)*@__"
+	// TRPCAttributeSentMessages is used to set the number of messages sent by a stream.
+	TRPCAttributeSentMessages = "__@*TRPCAttribute(SentMessages)*@__"
+	// TRPCAttributeSentBytes is used to set the bytes of messages sent by a stream.
+	TRPCAttributeSentBytes = "__@*TRPCAttribute(SentBytes)*@__"
+	// TRPCAttributeReceivedMessages is used to set the number of messages received by a stream.
+	TRPCAttributeReceivedMessages = "__@*TRPCAttribute(ReceivedMessages)*@__"
+	// TRPCAttributeReceivedBytes is used to set the bytes of messages received by a stream.
+	TRPCAttributeReceivedBytes = "__@*TRPCAttribute(ReceivedBytes)*@__"
+	// TRPCAttributeFlowControlStalls is used to set the number of times a stream is blocked on its window.
+	TRPCAttributeFlowControlStalls = "__@*TRPCAttribute(FlowControlStalls)*@__"
+	// TRPCAttributeFlowControlBlockedTime is used to set the total time a stream is blocked on its window.
+	TRPCAttributeFlowControlBlockedTime = "__@*TRPCAttribute(FlowControlBlockedTime)*@__"
 
 	// HTTPAttributeURL is used to set the URL attribute of span.
 	HTTPAttributeURL = "__@*HTTPAttribute(URL)*@__"
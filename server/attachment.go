@@ -26,6 +26,8 @@ type Attachment struct {
 }
 
 // Request returns Request Attachment.
+// A request attachment spilled to a temp file by WithAttachmentSpill is an io.ReadCloser,
+// and the file is removed once it's read to the end or closed.
 func (a *Attachment) Request() io.Reader {
 	return a.attachment.Request
 }
@@ -35,6 +37,13 @@ func (a *Attachment) SetResponse(attachment io.Reader) {
 	a.attachment.Response = attachment
 }
 
+// SetStreamingResponse sets Response attachment of size bytes streamed from r. The default tcp transport
+// writes it to the connection in chunks right after the frame, instead of reading it into memory.
+// Other transports, writev, encryption and checksums read it into memory as other attachments.
+func (a *Attachment) SetStreamingResponse(r io.Reader, size int64) {
+	a.attachment.Response = &attachment.Sized{Reader: r, Size: size}
+}
+
 // GetAttachment returns Attachment from msg.
 // If there is no Attachment in the msg, an empty attachment bound to the msg will be returned.
 func GetAttachment(msg codec.Msg) *Attachment {
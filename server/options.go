@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/filter"
 	"trpc.group/trpc-go/trpc-go/naming/registry"
 	"trpc.group/trpc-go/trpc-go/overloadctrl"
@@ -44,6 +45,11 @@ type Options struct {
 	DisableKeepAlives        bool          // disables keep-alives
 	CurrentSerializationType int
 	CurrentCompressType      int
+	CompressPolicy           codec.CompressPolicy   // decides whether a response body is worth compressing
+	ZstdDictionary           uint32                 // id of the zstd dictionary to compress responses, 0 means none
+	Checksum                 bool                   // whether response frames carry checksums
+	KeyProvider              encryption.KeyProvider // provides keys to decrypt requests, nil disables encryption
+	MaxResponseSize          int                    // max size of responses in bytes, zero means no limit
 
 	protocol   string // protocol like "trpc", "http" etc.
 	network    string // network like "tcp", "udp" etc.
@@ -57,13 +63,20 @@ type Options struct {
 	Registry registry.Registry
 	Codec    codec.Codec
 
-	Filters          filter.ServerChain              // filter chain
-	FilterNames      []string                        // the name of filters
-	StreamHandle     StreamHandle                    // server stream processing
-	StreamTransport  transport.ServerStreamTransport // server stream transport plugin
-	MaxWindowSize    uint32                          // max window size for server stream
-	CloseWaitTime    time.Duration                   // min waiting time when closing server for wait deregister finish
-	MaxCloseWaitTime time.Duration                   // max waiting time when closing server for wait requests finish
+	Filters           filter.ServerChain              // filter chain
+	FilterNames       []string                        // the name of filters
+	StreamHandle      StreamHandle                    // server stream processing
+	StreamTransport   transport.ServerStreamTransport // server stream transport plugin
+	MaxWindowSize     uint32                          // max window size for server stream
+	MaxAdaptiveWindow uint32                          // upper limit of adaptive window for server stream
+	StreamKeepalive   time.Duration                   // interval of keepalive pings for server stream
+	StreamMaxIdleTime time.Duration                   // max time of server stream receiving nothing
+	StreamResumeSize  int                             // max messages buffered for resuming server stream
+	StreamResumeWait  time.Duration                   // max time of waiting for server stream to be resumed
+	CloseWaitTime     time.Duration                   // min waiting time when closing server for wait deregister finish
+	MaxCloseWaitTime  time.Duration                   // max waiting time when closing server for wait requests finish
+
+	MaxConcurrentStreams int // max concurrent streams per connection, 0 means no limit
 
 	RESTOptions   []restful.Option // RESTful router options
 	StreamFilters StreamFilterChain
@@ -246,6 +259,79 @@ func WithWritev(writev bool) Option {
 	}
 }
 
+// WithZeroCopy returns an Option that sets whether to read unary requests into pooled buffers, which
+// are unmarshaled without copying and released after the handler returns. The request attachment, and
+// request bodies kept by serializations which don't copy, such as noop and flatbuffers, must not be
+// referenced after the handler returns. It takes effect on tcp transports with the trpc protocol.
+func WithZeroCopy(zeroCopy bool) Option {
+	return func(o *Options) {
+		o.ServeOptions = append(o.ServeOptions, transport.WithZeroCopy(zeroCopy))
+	}
+}
+
+// WithMaxConnections returns an Option that sets the max number of concurrent connections.
+// Zero means no limit.
+func WithMaxConnections(n int) Option {
+	return func(o *Options) {
+		o.ServeOptions = append(o.ServeOptions, transport.WithMaxConnections(n))
+	}
+}
+
+// WithMaxConnectionsPerIP returns an Option that sets the max number of concurrent connections
+// of a single remote IP. Zero means no limit.
+func WithMaxConnectionsPerIP(n int) Option {
+	return func(o *Options) {
+		o.ServeOptions = append(o.ServeOptions, transport.WithMaxConnectionsPerIP(n))
+	}
+}
+
+// WithConnectionLimitWait returns an Option that sets the time a new connection waits for a free
+// slot when the connection limits are reached. Zero means the connection is rejected immediately.
+// The tnet transport always rejects immediately, since it must not block its pollers.
+func WithConnectionLimitWait(d time.Duration) Option {
+	return func(o *Options) {
+		o.ServeOptions = append(o.ServeOptions, transport.WithConnectionLimitWait(d))
+	}
+}
+
+// WithMaxRequestSize returns an Option that sets the max size of requests in bytes. Zero means no limit.
+// Larger requests are discarded before their bodies are allocated, and are responded with
+// errs.RetServerMsgExceedLimit. It applies to frames of trpc protocol, including streaming, and
+// bodies of http protocols.
+func WithMaxRequestSize(n int) Option {
+	return func(o *Options) {
+		o.ServeOptions = append(o.ServeOptions, transport.WithServeMaxRequestSize(n))
+	}
+}
+
+// WithMaxResponseSize returns an Option that sets the max size of encoded responses in bytes.
+// Zero means no limit. A larger unary response is replaced by an error of errs.RetServerMsgExceedLimit,
+// and sending a larger stream message fails with it.
+func WithMaxResponseSize(n int) Option {
+	return func(o *Options) {
+		o.MaxResponseSize = n
+	}
+}
+
+// WithAttachmentSpill returns an Option that spills request attachments larger than threshold bytes
+// to temp files in dir, instead of reading them into memory. Zero threshold means never, and the default
+// directory for temporary files is used if dir is empty. Spilled attachments don't count towards
+// trpc.DefaultMaxFrameSize.
+func WithAttachmentSpill(threshold int, dir string) Option {
+	return func(o *Options) {
+		o.ServeOptions = append(o.ServeOptions, transport.WithServeAttachmentSpill(threshold, dir))
+	}
+}
+
+// WithUDPFragment returns an Option that enables the fragmentation of large UDP messages.
+// The client must enable it too.
+// It's not supported by the tnet transport, which fails with the option rather than falling back to gonet.
+func WithUDPFragment(opts *transport.UDPFragmentOptions) Option {
+	return func(o *Options) {
+		o.ServeOptions = append(o.ServeOptions, transport.WithServerUDPFragment(opts))
+	}
+}
+
 // WithMaxRoutines returns an Option that sets max number of goroutines.
 // It only works for server async mode.
 // MaxRoutines should be set to twice as expected number of routines (can be calculated by expected QPS),
@@ -332,6 +418,53 @@ func WithCurrentCompressType(t int) Option {
 	}
 }
 
+// WithCompressMinSize returns an Option that sets the min size of response bodies to compress.
+// Smaller bodies are sent uncompressed, and the client is told by the compress type of the frame.
+// It doesn't apply to streaming, or when the current compress type is set.
+func WithCompressMinSize(n int) Option {
+	return func(o *Options) {
+		o.CompressPolicy.MinSize = n
+	}
+}
+
+// WithZstdDictionary returns an Option that sets the id of the registered zstd dictionary,
+// with which response bodies of codec.CompressTypeZstd are compressed.
+// The client decompresses them as long as the dictionary is registered there too.
+func WithZstdDictionary(id uint32) Option {
+	return func(o *Options) {
+		o.ZstdDictionary = id
+	}
+}
+
+// WithCompressMaxRatio returns an Option that sets the max ratio of the compressed size to the
+// original size. Response bodies which can't be compressed to it are sent uncompressed.
+// It doesn't apply to streaming, or when the current compress type is set.
+func WithCompressMaxRatio(r float64) Option {
+	return func(o *Options) {
+		o.CompressPolicy.MaxRatio = r
+	}
+}
+
+// WithChecksum returns an Option that sets whether response frames carry checksums, which are
+// verified by clients supporting them and ignored by others. Regardless of it, checksums of requests
+// are always verified, and responses to them carry checksums. A request failing its checksum is
+// responded with errs.RetServerChecksumFail.
+// Only trpc protocol supports checksums for now, and it doesn't apply to streaming.
+func WithChecksum(enable bool) Option {
+	return func(o *Options) {
+		o.Checksum = enable
+	}
+}
+
+// WithEncryption returns an Option that sets the key provider of payload encryption. Encrypted requests
+// are decrypted with keys of p, and responses to them are encrypted with the same keys, while plaintext
+// requests are still accepted. Only trpc protocol supports encryption for now.
+func WithEncryption(p encryption.KeyProvider) Option {
+	return func(o *Options) {
+		o.KeyProvider = p
+	}
+}
+
 // WithMaxWindowSize returns an Option that sets max window size for server stream.
 func WithMaxWindowSize(w uint32) Option {
 	return func(o *Options) {
@@ -339,6 +472,53 @@ func WithMaxWindowSize(w uint32) Option {
 	}
 }
 
+// WithAdaptiveWindow returns an Option that makes the receive window of server stream adaptive.
+// The window starts at the size set by WithMaxWindowSize, grows up to max when the estimated
+// bandwidth-delay product of the stream exceeds it, and shrinks back when the stream is idle.
+func WithAdaptiveWindow(max uint32) Option {
+	return func(o *Options) {
+		o.MaxAdaptiveWindow = max
+	}
+}
+
+// WithStreamKeepalive returns an Option that makes server streams ping the client
+// when they have sent nothing for interval, which keeps them from being idle on the client.
+// Pings are ignored by clients without keepalive.
+func WithStreamKeepalive(interval time.Duration) Option {
+	return func(o *Options) {
+		o.StreamKeepalive = interval
+	}
+}
+
+// WithStreamMaxIdleTime returns an Option that closes server streams which have received nothing,
+// including keepalive pings, for d. Recv and Send of the stream fail with errs.RetServerStreamReadTimeout,
+// and the client is notified by a reset close frame of the same code when the handler returns the error.
+func WithStreamMaxIdleTime(d time.Duration) Option {
+	return func(o *Options) {
+		o.StreamMaxIdleTime = d
+	}
+}
+
+// WithResumableStream returns an Option that makes server streams resumable for clients which enable it.
+// The last size messages sent by a stream are buffered. When the connection of the stream breaks,
+// the stream handler keeps running, and the client may resume the stream on a new connection within wait,
+// which replays the buffered messages the client missed. Otherwise, Recv and Send of the stream fail.
+func WithResumableStream(size int, wait time.Duration) Option {
+	return func(o *Options) {
+		o.StreamResumeSize = size
+		o.StreamResumeWait = wait
+	}
+}
+
+// WithMaxConcurrentStreams returns an Option that limits the number of concurrent streams of each connection.
+// A new stream over the limit is refused with errs.RetServerThrottled, and is marked in the Init frame responded
+// so that the client applies its stream limit policy. 0 means no limit.
+func WithMaxConcurrentStreams(n int) Option {
+	return func(o *Options) {
+		o.MaxConcurrentStreams = n
+	}
+}
+
 // WithCloseWaitTime returns an Option that sets min waiting time when close service.
 // It's used for service's graceful restart.
 // Default: 0ms, max: 10s.
@@ -26,6 +26,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/filter"
 	"trpc.group/trpc-go/trpc-go/naming/registry"
 	"trpc.group/trpc-go/trpc-go/overloadctrl"
@@ -173,6 +174,33 @@ func TestMoreOptions(t *testing.T) {
 	o(opts)
 	assert.Equal(t, opts.CurrentCompressType, codec.CompressTypeSnappy)
 
+	server.WithCompressMinSize(1024)(opts)
+	server.WithCompressMaxRatio(0.8)(opts)
+	assert.Equal(t, codec.CompressPolicy{MinSize: 1024, MaxRatio: 0.8}, opts.CompressPolicy)
+
+	server.WithZstdDictionary(1001)(opts)
+	assert.Equal(t, uint32(1001), opts.ZstdDictionary)
+
+	server.WithChecksum(true)(opts)
+	assert.True(t, opts.Checksum)
+
+	keys := &encryption.StaticKeyProvider{}
+	server.WithEncryption(keys)(opts)
+	assert.Equal(t, keys, opts.KeyProvider)
+
+	server.WithMaxResponseSize(1024)(opts)
+	assert.Equal(t, 1024, opts.MaxResponseSize)
+	server.WithMaxRequestSize(2048)(opts)
+	server.WithAttachmentSpill(4096, "spill")(opts)
+	server.WithZeroCopy(true)(opts)
+	for _, o := range opts.ServeOptions {
+		o(transportOpts)
+	}
+	assert.True(t, transportOpts.ZeroCopy)
+	assert.Equal(t, 2048, transportOpts.MaxRequestSize)
+	assert.Equal(t, 4096, transportOpts.AttachmentSpillThreshold)
+	assert.Equal(t, "spill", transportOpts.AttachmentSpillDir)
+
 	// WithFilter
 	o = server.WithFilter(filter.NoopServerFilter)
 	o(opts)
@@ -316,6 +344,32 @@ func TestMoreOptions(t *testing.T) {
 	o = server.WithMaxWindowSize(maxWindowSize)
 	o(opts)
 	assert.Equal(t, maxWindowSize, opts.MaxWindowSize)
+
+	// WithAdaptiveWindow
+	o = server.WithAdaptiveWindow(1 << 20)
+	o(opts)
+	assert.Equal(t, uint32(1<<20), opts.MaxAdaptiveWindow)
+
+	// WithStreamKeepalive
+	o = server.WithStreamKeepalive(time.Second)
+	o(opts)
+	assert.Equal(t, time.Second, opts.StreamKeepalive)
+
+	// WithStreamMaxIdleTime
+	o = server.WithStreamMaxIdleTime(time.Minute)
+	o(opts)
+	assert.Equal(t, time.Minute, opts.StreamMaxIdleTime)
+
+	// WithResumableStream
+	o = server.WithResumableStream(16, time.Second)
+	o(opts)
+	assert.Equal(t, 16, opts.StreamResumeSize)
+	assert.Equal(t, time.Second, opts.StreamResumeWait)
+
+	// WithMaxConcurrentStreams
+	o = server.WithMaxConcurrentStreams(8)
+	o(opts)
+	assert.Equal(t, 8, opts.MaxConcurrentStreams)
 }
 
 type serverTestOrderedGroups struct{}
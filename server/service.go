@@ -23,10 +23,15 @@ import (
 	"sync/atomic"
 	"time"
 
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/filter"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
+	"trpc.group/trpc-go/trpc-go/internal/checksum"
 	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
+	"trpc.group/trpc-go/trpc-go/internal/envelope"
 	ikeeporder "trpc.group/trpc-go/trpc-go/internal/keeporder"
 	"trpc.group/trpc-go/trpc-go/internal/report"
 	"trpc.group/trpc-go/trpc-go/log"
@@ -81,6 +86,9 @@ type StreamDesc struct {
 	ServerStreams bool
 	// ClientStreams indicates whether it's client streaming.
 	ClientStreams bool
+	// Bindings are the RESTful bindings of the server-streaming method, whose responses are sent as
+	// newline-delimited JSON or Server-Sent Events.
+	Bindings []*restful.Binding
 }
 
 // Handler is the default handler.
@@ -267,7 +275,8 @@ func (s *service) Handle(ctx context.Context, reqBuf []byte) (rspBuf []byte, err
 	}
 	// ServerRspErr is already set,
 	// since RequestID is acquired, just respond to client.
-	if err := msg.ServerRspErr(); err != nil {
+	// Except for the stream close frame, whose ServerRspErr is the reset of client to be handled by stream.
+	if err := msg.ServerRspErr(); err != nil && !isStreamClose(msg) {
 		return s.encode(ctx, msg, nil, err)
 	}
 
@@ -300,6 +309,12 @@ func (s *service) Handle(ctx context.Context, reqBuf []byte) (rspBuf []byte, err
 	return s.handleResponse(ctx, msg, rspbody)
 }
 
+// isStreamClose returns whether msg is a stream close frame.
+func isStreamClose(msg codec.Msg) bool {
+	_, ok := msg.StreamFrame().(*trpcpb.TrpcStreamCloseMeta)
+	return ok
+}
+
 // HandleClose is called when conn is closed.
 // Currently, only used for server stream.
 func (s *service) HandleClose(ctx context.Context) error {
@@ -325,6 +340,14 @@ func (s *service) encode(ctx context.Context, msg codec.Msg, rspBodyBuf []byte,
 		log.ErrorContextf(ctx, "service:%s encode fail:%v", s.opts.ServiceName, err)
 		return nil, err
 	}
+	rspSize := int64(len(rspBuf)) + attachment.DeferredSize(msg)
+	if s.opts.MaxResponseSize > 0 && rspSize > int64(s.opts.MaxResponseSize) && e == nil {
+		report.ServiceCodecEncodeFail.Incr()
+		attachment.ResetServerResponseAttachment(msg)
+		return s.encode(ctx, msg, nil, errs.NewFrameError(errs.RetServerMsgExceedLimit,
+			fmt.Sprintf("service codec Encode: response size %d exceeds the max response size %d",
+				rspSize, s.opts.MaxResponseSize)))
+	}
 	return rspBuf, nil
 }
 
@@ -340,6 +363,12 @@ func (s *service) handleStream(ctx context.Context, msg codec.Msg, reqBuf []byte
 
 func (s *service) decode(ctx context.Context, msg codec.Msg, reqBuf []byte) ([]byte, error) {
 	s.setOpt(msg)
+	if s.opts.Checksum {
+		checksum.EnableServer(msg)
+	}
+	if s.opts.KeyProvider != nil {
+		envelope.SetServer(msg, s.opts.KeyProvider)
+	}
 	reqBodyBuf, err := s.opts.Codec.Decode(msg, reqBuf)
 	if err != nil {
 		report.ServiceCodecDecodeFail.Incr()
@@ -349,9 +378,44 @@ func (s *service) decode(ctx context.Context, msg codec.Msg, reqBuf []byte) ([]b
 	// call setOpt again to avoid some msg infos (namespace, env name, etc.)
 	// being modified by request decoding.
 	s.setOpt(msg)
+	takeRspCompressType(msg)
 	return reqBodyBuf, nil
 }
 
+// rspCompressTypeKey is the key of the compress type of the response in common meta of msg.
+type rspCompressTypeKey struct{}
+
+// takeRspCompressType takes the compress type of the response, which is sent by the client whose
+// request is sent uncompressed by its compress policy, out of the metadata, so that it's not passed
+// to the downstream.
+func takeRspCompressType(msg codec.Msg) {
+	md := msg.ServerMetaData()
+	v, ok := md[codec.RspCompressTypeKey]
+	if !ok {
+		return
+	}
+	delete(md, codec.RspCompressTypeKey)
+	compressType, err := strconv.Atoi(string(v))
+	if err != nil || !icodec.IsValidCompressType(compressType) {
+		return
+	}
+	cm := msg.CommonMeta()
+	if cm == nil {
+		cm = make(codec.CommonMeta)
+		msg.WithCommonMeta(cm)
+	}
+	cm[rspCompressTypeKey{}] = compressType
+}
+
+// rspCompressType returns the compress type of the response, which is the compress type of the request
+// by default.
+func rspCompressType(msg codec.Msg) int {
+	if compressType, ok := msg.CommonMeta()[rspCompressTypeKey{}].(int); ok {
+		return compressType
+	}
+	return msg.CompressType()
+}
+
 func (s *service) setOpt(msg codec.Msg) {
 	msg.WithNamespace(s.opts.Namespace)           // service namespace
 	msg.WithEnvName(s.opts.EnvName)               // service environment
@@ -433,14 +497,29 @@ func (s *service) handleResponse(ctx context.Context, msg codec.Msg, rspBody int
 	}
 
 	// compress response body
-	compressType := msg.CompressType()
-	if icodec.IsValidCompressType(s.opts.CurrentCompressType) {
+	compressType := rspCompressType(msg)
+	policy := s.opts.CompressPolicy
+	forced := icodec.IsValidCompressType(s.opts.CurrentCompressType)
+	if forced {
 		compressType = s.opts.CurrentCompressType
+		// The client is expected to force the same compress type, the body is always compressed.
+		policy = codec.CompressPolicy{}
 	}
 
 	_, end = span.NewChild("Compress")
-	rspBodyBuf, err = codec.Compress(compressType, rspBodyBuf)
+	if compressType == codec.CompressTypeZstd {
+		rspBodyBuf, err = codec.CompressZstd(s.opts.ZstdDictionary, rspBodyBuf, policy)
+	} else {
+		rspBodyBuf, err = codec.Compress(compressType, rspBodyBuf, policy)
+	}
 	end.End()
+	if errors.Is(err, codec.ErrCompressSkipped) {
+		// Tell the client that the body is sent uncompressed.
+		compressType, err = codec.CompressTypeNoop, nil
+	}
+	if !forced {
+		msg.WithCompressType(compressType)
+	}
 
 	if err != nil {
 		report.ServiceCodecCompressFail.Incr()
@@ -605,14 +684,27 @@ func (s *service) Register(serviceDesc interface{}, serviceImpl interface{}) err
 			return fmt.Errorf("duplicate stream name: %s", n)
 		}
 		h := stream.Handler
-		s.streamInfo[stream.StreamName] = &StreamServerInfo{
+		info := &StreamServerInfo{
 			FullMethod:     stream.StreamName,
 			IsClientStream: stream.ClientStreams,
 			IsServerStream: stream.ServerStreams,
 		}
+		s.streamInfo[stream.StreamName] = info
 		s.streamHandlers[stream.StreamName] = func(stream Stream) error {
 			return h(serviceImpl, stream)
 		}
+		if len(stream.Bindings) > 0 && stream.ClientStreams {
+			return fmt.Errorf("client streaming %s can not be bound to RESTful", n)
+		}
+		for _, binding := range stream.Bindings {
+			b := *binding
+			b.Stream = func(svc interface{}, ss restful.ServerStream) error {
+				return s.opts.StreamFilters.Filter(ss, info, func(ss Stream) error {
+					return h(svc, ss)
+				})
+			}
+			bindings = append(bindings, &b)
+		}
 	}
 	return s.createOrUpdateRouter(bindings, serviceImpl)
 }
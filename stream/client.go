@@ -21,6 +21,7 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
 
@@ -30,6 +31,8 @@ import (
 	"trpc.group/trpc-go/trpc-go/errs"
 	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
 	"trpc.group/trpc-go/trpc-go/internal/queue"
+	"trpc.group/trpc-go/trpc-go/log"
+	"trpc.group/trpc-go/trpc-go/rpcz"
 	"trpc.group/trpc-go/trpc-go/transport"
 )
 
@@ -68,6 +71,16 @@ type clientStream struct {
 	closed    uint32
 	closeCh   chan struct{}
 	closeOnce sync.Once
+	ka        *keepalive
+	resume    *clientResume // State of resumable stream, nil if the stream is not resumable.
+	stats     *streamStats
+
+	monitorOnce sync.Once
+
+	// mu guards stream, cancelConn and conn, which are replaced when the stream is resumed on a new connection.
+	mu         sync.RWMutex
+	cancelConn context.CancelFunc // Breaks the connection of a resumable stream.
+	conn       string             // Key of the connection, where the stream is counted as an active stream.
 }
 
 // NewStream creates a new stream through which users send and receive messages.
@@ -80,6 +93,7 @@ func (c *streamClient) NewStream(ctx context.Context, desc *client.ClientStreamD
 func (c *streamClient) newStream(ctx context.Context, desc *client.ClientStreamDesc,
 	method string, opt ...client.Option) (client.ClientStream, error) {
 	ctx, _ = codec.EnsureMessage(ctx)
+	span, end, ctx := rpcz.NewSpanContext(ctx, "client-stream")
 	cs := &clientStream{
 		desc:      desc,
 		method:    method,
@@ -89,12 +103,18 @@ func (c *streamClient) newStream(ctx context.Context, desc *client.ClientStreamD
 		closeCh:   make(chan struct{}, 1),
 		recvQueue: queue.New[*response](ctx.Done()),
 		stream:    client.NewStream(),
+		stats:     newClientStreamStats(span, end),
 	}
 	if err := cs.prepare(opt...); err != nil {
+		cs.stats.finish(method, err)
 		return nil, err
 	}
 	if cs.opts.StreamFilters != nil {
-		return cs.opts.StreamFilters.Filter(cs.ctx, cs.desc, cs.invoke)
+		s, err := cs.opts.StreamFilters.Filter(cs.ctx, cs.desc, cs.invoke)
+		if err != nil {
+			cs.stats.finish(method, err)
+		}
+		return s, err
 	}
 	return cs.invoke(cs.ctx, cs.desc)
 }
@@ -139,17 +159,32 @@ func (cs *clientStream) recv(m interface{}) error {
 	if err := cs.recvFlowCtl(len(resp.data)); err != nil {
 		return err
 	}
+	data := resp.data
+	if cs.resume != nil {
+		// The sequence is checked by the dispatch goroutine.
+		data = data[seqLen:]
+	}
 
-	serializationType := codec.Message(cs.ctx).SerializationType()
-	if icodec.IsValidSerializationType(cs.opts.CurrentSerializationType) {
-		serializationType = cs.opts.CurrentSerializationType
+	if c, ok := m.(*correlated); ok {
+		if err := c.unmarshal(cs.serializationType(), data); err != nil {
+			return errs.NewFrameError(errs.RetClientDecodeFail, "client codec Unmarshal: "+err.Error())
+		}
+		return nil
 	}
-	if err := codec.Unmarshal(serializationType, resp.data, m); err != nil {
+	if err := codec.Unmarshal(cs.serializationType(), data, m); err != nil {
 		return errs.NewFrameError(errs.RetClientDecodeFail, "client codec Unmarshal: "+err.Error())
 	}
 	return nil
 }
 
+// serializationType returns the serialization type of the messages of the stream.
+func (cs *clientStream) serializationType() int {
+	if icodec.IsValidSerializationType(cs.opts.CurrentSerializationType) {
+		return cs.opts.CurrentSerializationType
+	}
+	return codec.Message(cs.ctx).SerializationType()
+}
+
 func (cs *clientStream) recvFlowCtl(n int) error {
 	if cs.opts.RControl == nil {
 		return nil
@@ -189,7 +224,43 @@ func (cs *clientStream) SendMsg(m interface{}) error {
 	msg.WithStreamID(cs.streamID)
 	msg.WithClientRPCName(cs.method)
 	msg.WithCompressType(codec.Message(cs.ctx).CompressType())
-	return cs.stream.Send(ctx, m)
+	if c, ok := m.(*correlated); ok {
+		body, err := cs.marshalCorrelated(c)
+		if err != nil {
+			return err
+		}
+		// The message prefixed with the correlation header is sent as bytes.
+		msg.WithSerializationType(codec.SerializationTypeNoop)
+		m = &codec.Body{Data: body}
+	}
+	n, err := sendSized(ctx, cs.getStream(), m)
+	if err != nil {
+		return err
+	}
+	cs.ka.sent()
+	cs.stats.sent(n)
+	return nil
+}
+
+// sendSized sends m through s, and returns the size of the body sent, which is 0 if s doesn't report it.
+func sendSized(ctx context.Context, s client.Stream, m interface{}) (int, error) {
+	if ss, ok := s.(client.SizedSender); ok {
+		return ss.SendSized(ctx, m)
+	}
+	return 0, s.Send(ctx, m)
+}
+
+// marshalCorrelated serializes the correlated message and prefixes the correlation header.
+func (cs *clientStream) marshalCorrelated(c *correlated) ([]byte, error) {
+	if t := cs.opts.CurrentSerializationType; icodec.IsValidSerializationType(t) && t != codec.SerializationTypeNoop {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail,
+			fmt.Sprintf("client codec Marshal: correlated calls don't support current serialization type %d", t))
+	}
+	body, err := c.marshal(cs.serializationType())
+	if err != nil {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail, "client codec Marshal: "+err.Error())
+	}
+	return body, nil
 }
 
 func newFrameHead(t trpcpb.TrpcStreamFrameType, id uint32) *trpc.FrameHead {
@@ -210,7 +281,37 @@ func (cs *clientStream) CloseSend() error {
 		CloseType: int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_CLOSE),
 		Ret:       0,
 	})
-	return cs.stream.Send(ctx, nil)
+	if err := cs.getStream().Send(ctx, nil); err != nil {
+		return err
+	}
+	cs.ka.sent()
+	cs.stats.event(eventSendClose)
+	return nil
+}
+
+// reset sends a reset close frame to the server, where ret and message represent the error.
+func (cs *clientStream) reset(ret int32, message string) error {
+	ctx, msg := codec.WithCloneContextAndMessage(cs.ctx)
+	defer codec.PutBackMessage(msg)
+	msg.WithFrameHead(newFrameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_CLOSE, cs.streamID))
+	msg.WithStreamID(cs.streamID)
+	msg.WithStreamFrame(&trpcpb.TrpcStreamCloseMeta{
+		CloseType: int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_RESET),
+		Ret:       ret,
+		Msg:       []byte(message),
+	})
+	if err := cs.getStream().Send(ctx, nil); err != nil {
+		return err
+	}
+	cs.stats.event(eventSendClose)
+	return nil
+}
+
+// getStream returns the stream of the current connection.
+func (cs *clientStream) getStream() client.Stream {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.stream
 }
 
 func (cs *clientStream) prepare(opt ...client.Option) error {
@@ -227,20 +328,134 @@ func (cs *clientStream) prepare(opt ...client.Option) error {
 	if cs.opts.EnableStreamSelectInFilter {
 		cs.ctx = client.ContextWithOptions(cs.ctx, cs.opts)
 	}
+	// Only server streams are resumable, whose client has sent all messages before the stream is resumed.
+	if cs.opts.StreamResumeTimeout > 0 && cs.desc.ServerStreams && !cs.desc.ClientStreams &&
+		!cs.opts.EnableStreamSelectInFilter {
+		token, err := newResumeToken()
+		if err != nil {
+			return err
+		}
+		cs.resume = &clientResume{token: token, opt: opt}
+	}
 	return nil
 }
 
-func (cs *clientStream) invoke(ctx context.Context, _ *client.ClientStreamDesc) (client.ClientStream, error) {
-	if err := cs.stream.Invoke(ctx); err != nil {
-		return nil, err
+func (cs *clientStream) invoke(ctx context.Context, _ *client.ClientStreamDesc) (_ client.ClientStream, err error) {
+	defer func() {
+		if err != nil {
+			cs.stats.finish(cs.method, err)
+		}
+	}()
+	if cs.resume != nil {
+		// The connection of a resumable stream can be broken alone to be resumed.
+		ctx, cs.cancelConn = context.WithCancel(ctx)
+		defer func() {
+			if err != nil {
+				cs.cancelConn()
+			}
+		}()
 	}
 	var w uint32
 	if !cs.opts.DisabledFlowControl {
 		w = getWindowSize(cs.opts.MaxWindowSize)
 	}
-	newCtx, newMsg := codec.WithCloneContextAndMessage(ctx)
+	if cs.opts.RControl == nil {
+		cs.opts.RControl = newRecvControl(w, cs.opts.MaxAdaptiveWindow, cs.feedback)
+	}
+	initRspMeta, err := cs.open(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	cs.track(codec.Message(cs.ctx))
+	if cs.resume != nil && !cs.resume.isConfirmed(initRspMeta) {
+		// The server doesn't enable resumable streams.
+		cs.resume = nil
+	}
+	initWindowSize := initRspMeta.GetInitWindowSize()
+	cs.configSendControl(initWindowSize)
+	cs.ka = newKeepalive(cs.opts.StreamKeepalive, cs.opts.StreamMaxIdleTime,
+		func() error { return cs.feedback(0) }, cs.onIdle, cs.closeCh)
+	cs.ka.start()
+
+	// Start the dispatch goroutine loop to send packets.
+	go cs.dispatch()
+	return cs, nil
+}
+
+// open opens the stream by sending the Init frame with the initial window w. If the stream is refused by
+// the max concurrent streams of the connection, the stream is opened again according to the stream limit policy.
+// Other refusals with errs.RetServerThrottled, such as those of rate limiters, fail the stream.
+func (cs *clientStream) open(ctx context.Context, w uint32) (*trpcpb.TrpcStreamInitMeta, error) {
+	callOpts := cs.opts.CallOptions
+	var excluded []string
+	for {
+		initRspMeta, err := cs.openOnce(ctx, w)
+		if err == nil || !isStreamLimited(initRspMeta, err) {
+			return initRspMeta, err
+		}
+		msg := codec.Message(cs.ctx)
+		switch cs.opts.StreamLimitPolicy {
+		case client.StreamLimitNewConn:
+			if len(excluded) >= maxExcludedConns || msg.LocalAddr() == nil {
+				return nil, err
+			}
+			excluded = append(excluded, msg.LocalAddr().String())
+			cs.opts.CallOptions = append(callOpts[:len(callOpts):len(callOpts)],
+				transport.WithExcludedAddrs(excluded...))
+		case client.StreamLimitQueue:
+			if err := activeStreams.wait(ctx, connKey(msg)); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+		log.Tracef("stream: client stream (method = %s, streamID = %d) is opened again: %v",
+			cs.method, cs.streamID, err)
+	}
+}
+
+// openOnce sends the Init frame with the initial window w, and receives the Init frame responded.
+func (cs *clientStream) openOnce(ctx context.Context, w uint32) (*trpcpb.TrpcStreamInitMeta, error) {
+	if err := cs.stream.Invoke(ctx); err != nil {
+		return nil, err
+	}
+	newCtx, newMsg := cs.newInitMsg(ctx, w)
 	defer codec.PutBackMessage(newMsg)
+	// Send the init message out.
+	if err := cs.stream.Send(newCtx, nil); err != nil {
+		return nil, err
+	}
+	cs.stats.event(eventSendInit)
+	cs.monitorOnce.Do(func() { go cs.monitorContextCancellation() })
+	// After init is sent, the server will return directly.
+	return cs.recvInit(newCtx, cs.stream)
+}
+
+// track counts the stream as an active stream of the connection of msg.
+func (cs *clientStream) track(msg codec.Msg) {
+	conn := connKey(msg)
+	activeStreams.add(conn, msg.CalleeServiceName())
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.conn = conn
+}
+
+// untrack removes the stream from the active streams of its connection.
+func (cs *clientStream) untrack() {
+	cs.mu.RLock()
+	conn := cs.conn
+	cs.mu.RUnlock()
+	activeStreams.done(conn, codec.Message(cs.ctx).CalleeServiceName())
+}
+
+// newInitMsg returns the message of the Init frame, where w is the initial window.
+func (cs *clientStream) newInitMsg(ctx context.Context, w uint32) (context.Context, codec.Msg) {
+	newCtx, newMsg := codec.WithCloneContextAndMessage(ctx)
 	copyMetaData(newMsg, codec.Message(cs.ctx))
+	if cs.resume != nil {
+		cs.resume.withMeta(newMsg)
+	}
+	withPriorityMeta(newMsg, cs.opts.StreamPriority)
 	newMsg.WithFrameHead(newFrameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_INIT, cs.streamID))
 	newMsg.WithClientRPCName(cs.method)
 	newMsg.WithStreamID(cs.streamID)
@@ -249,30 +464,113 @@ func (cs *clientStream) invoke(ctx context.Context, _ *client.ClientStreamDesc)
 		RequestMeta:    &trpcpb.TrpcStreamInitRequestMeta{},
 		InitWindowSize: w,
 	})
-	if cs.opts.RControl == nil {
-		cs.opts.RControl = newReceiveControl(w, cs.feedback)
-	}
-	// Send the init message out.
-	if err := cs.stream.Send(newCtx, nil); err != nil {
-		return nil, err
-	}
-	go cs.monitorContextCancellation()
-	// After init is sent, the server will return directly.
-	if _, err := cs.stream.Recv(newCtx); err != nil {
-		return nil, err
+	return newCtx, newMsg
+}
+
+// recvInit receives the Init frame responded by the server through s.
+func (cs *clientStream) recvInit(ctx context.Context, s client.Stream) (*trpcpb.TrpcStreamInitMeta, error) {
+	if _, err := s.Recv(ctx); err != nil {
+		// The Init frame refusing the stream is returned along with the error.
+		initRspMeta, _ := codec.Message(ctx).StreamFrame().(*trpcpb.TrpcStreamInitMeta)
+		return initRspMeta, err
 	}
-	initRspMeta, ok := newMsg.StreamFrame().(*trpcpb.TrpcStreamInitMeta)
+	cs.stats.event(eventRecvInit)
+	msg := codec.Message(ctx)
+	initRspMeta, ok := msg.StreamFrame().(*trpcpb.TrpcStreamInitMeta)
 	if !ok {
 		return nil, fmt.Errorf("client stream (method = %s, streamID = %d) recv "+
 			"unexpected frame type: %T, expected: %T",
-			cs.method, cs.streamID, newMsg.StreamFrame(), (*trpcpb.TrpcStreamInitMeta)(nil))
+			cs.method, cs.streamID, msg.StreamFrame(), (*trpcpb.TrpcStreamInitMeta)(nil))
 	}
-	initWindowSize := initRspMeta.GetInitWindowSize()
-	cs.configSendControl(initWindowSize)
+	return initRspMeta, nil
+}
 
-	// Start the dispatch goroutine loop to send packets.
-	go cs.dispatch()
-	return cs, nil
+// resumeStream resumes the stream on a new connection, and retries until the resume timeout elapses.
+func (cs *clientStream) resumeStream() error {
+	deadline := time.Now().Add(cs.opts.StreamResumeTimeout)
+	for backoff := minResumeBackoff; ; {
+		err := cs.reconnect()
+		if err == nil {
+			cs.ka.received()
+			return nil
+		}
+		log.Tracef("stream: client resume stream (method = %s, streamID = %d) fail: %v", cs.method, cs.streamID, err)
+		var e *errs.Error
+		if errors.As(err, &e) && e.Type == errs.ErrorTypeCalleeFramework || errors.Is(err, errResumeUnconfirmed) {
+			// The server refuses to resume the stream.
+			return err
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-cs.closeCh:
+			return err
+		}
+		if backoff *= 2; backoff > maxResumeBackoff {
+			backoff = maxResumeBackoff
+		}
+	}
+}
+
+// reconnect selects a node, connects to it, and resumes the stream from the last message received.
+func (cs *clientStream) reconnect() error {
+	ctx, cancel := context.WithCancel(cs.ctx)
+	ctx, msg := codec.WithNewMessage(ctx)
+	codec.CopyMsg(msg, codec.Message(cs.ctx))
+	// The node is selected again.
+	msg.WithRemoteAddr(nil)
+	msg.WithLocalAddr(nil)
+	s := client.NewStream()
+	if _, err := s.Init(ctx, cs.resume.opt...); err != nil {
+		cancel()
+		return err
+	}
+	if err := s.Invoke(ctx); err != nil {
+		cancel()
+		return err
+	}
+	var w uint32
+	if cs.opts.RControl != nil {
+		w = getWindowSize(cs.opts.MaxWindowSize)
+	}
+	newCtx, newMsg := cs.newInitMsg(ctx, w)
+	defer codec.PutBackMessage(newMsg)
+	initRspMeta, err := func() (*trpcpb.TrpcStreamInitMeta, error) {
+		if err := s.Send(newCtx, nil); err != nil {
+			return nil, err
+		}
+		cs.stats.event(eventSendInit)
+		return cs.recvInit(newCtx, s)
+	}()
+	if err == nil && !cs.resume.isConfirmed(initRspMeta) {
+		err = errResumeUnconfirmed
+	}
+	if err == nil && atomic.LoadUint32(&cs.closed) == 1 {
+		err = errors.New(streamClosed)
+	}
+	if err != nil {
+		cs.opts.StreamTransport.Close(ctx)
+		cancel()
+		return err
+	}
+	cs.untrack()
+	cs.track(msg)
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.stream, cs.cancelConn = s, cancel
+	return nil
+}
+
+// breakConn breaks the connection of a resumable stream, then the stream is resumed on a new connection
+// unless it's closed.
+func (cs *clientStream) breakConn() {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if cs.cancelConn != nil {
+		cs.cancelConn()
+	}
 }
 
 func (cs *clientStream) monitorContextCancellation() {
@@ -293,11 +591,28 @@ func (cs *clientStream) configSendControl(initWindowSize uint32) {
 		return
 	}
 	if cs.opts.SControl == nil {
-		cs.opts.SControl = newSendControl(initWindowSize, cs.ctx.Done(), cs.closeCh)
+		sc := newSendControl(initWindowSize, cs.ctx.Done(), cs.closeCh)
+		sc.stats = cs.stats
+		cs.opts.SControl = sc
 	}
 }
 
-// feedback send feedback frame.
+// onIdle resets the stream which has received nothing for the max idle time.
+func (cs *clientStream) onIdle() {
+	if cs.resume != nil {
+		cs.breakConn()
+		return
+	}
+	message := fmt.Sprintf("client stream received nothing for %v", cs.opts.StreamMaxIdleTime)
+	cs.recvQueue.Put(&response{err: errs.NewFrameError(errs.RetClientStreamReadTimeout, message)})
+	if err := cs.reset(int32(errs.RetClientStreamReadTimeout), message); err != nil {
+		log.Trace("stream: client reset idle stream fail", err)
+	}
+	cs.opts.StreamTransport.Close(cs.ctx)
+	cs.close()
+}
+
+// feedback send feedback frame, a zero increment is a keepalive ping.
 func (cs *clientStream) feedback(i uint32) error {
 	ctx, msg := codec.WithCloneContextAndMessage(cs.ctx)
 	defer codec.PutBackMessage(msg)
@@ -305,21 +620,50 @@ func (cs *clientStream) feedback(i uint32) error {
 	msg.WithStreamID(cs.streamID)
 	msg.WithClientRPCName(cs.method)
 	msg.WithStreamFrame(&trpcpb.TrpcStreamFeedBackMeta{WindowSizeIncrement: i})
-	return cs.stream.Send(ctx, nil)
+	if err := cs.getStream().Send(ctx, nil); err != nil {
+		if cs.resume != nil {
+			// The window is reset when the stream is resumed.
+			log.Trace("stream: client feedback on resumable stream fail", err)
+			return nil
+		}
+		return err
+	}
+	cs.ka.sent()
+	return nil
 }
 
 // handleFrame performs different logical processing according to the type of frame.
 func (cs *clientStream) handleFrame(ctx context.Context, resp *response,
 	respData []byte, frameHead *trpc.FrameHead) error {
 	msg := codec.Message(ctx)
+	cs.ka.received()
 	switch trpcpb.TrpcStreamFrameType(frameHead.StreamFrameType) {
 	case trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA:
+		if cs.resume != nil {
+			seq, err := parseSeq(respData)
+			if err != nil {
+				cs.recvQueue.Put(&response{err: errs.NewFrameError(errs.RetClientDecodeFail, err.Error())})
+				return err
+			}
+			if seq <= cs.resume.seq {
+				// Replayed again after the stream is resumed.
+				return nil
+			}
+			cs.resume.seq = seq
+			cs.stats.received(len(respData) - seqLen)
+		} else {
+			cs.stats.received(len(respData))
+		}
 		// Get the data and return it to the client.
 		resp.data = respData
 		resp.err = nil
+		if o, ok := cs.opts.RControl.(arrivalObserver); ok {
+			o.onArrive(uint32(len(respData)))
+		}
 		cs.recvQueue.Put(resp)
 		return nil
 	case trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_CLOSE:
+		cs.stats.event(eventRecvClose)
 		// Close, it should be judged as Reset or Close.
 		resp.data = nil
 		var err error
@@ -350,15 +694,24 @@ func (cs *clientStream) handleFeedback(msg codec.Msg) {
 // dispatch is used to distribute the received data packets, receive them in a loop,
 // and then distribute the data packets according to different data types.
 func (cs *clientStream) dispatch() {
+	var err error
 	defer func() {
 		cs.opts.StreamTransport.Close(cs.ctx)
 		cs.close()
+		cs.untrack()
+		cs.stats.finish(cs.method, err)
 	}()
 	for {
 		ctx, msg := codec.WithCloneContextAndMessage(cs.ctx)
 		msg.WithCompressType(codec.Message(cs.ctx).CompressType())
 		msg.WithStreamID(cs.streamID)
-		respData, err := cs.stream.Recv(ctx)
+		var respData []byte
+		respData, err = cs.getStream().Recv(ctx)
+		if err != nil && cs.canResume(msg, err) {
+			if err = cs.resumeStream(); err == nil {
+				continue
+			}
+		}
 		if err != nil {
 			// return to client on error.
 			cs.recvQueue.Put(&response{
@@ -369,23 +722,32 @@ func (cs *clientStream) dispatch() {
 
 		frameHead, ok := msg.FrameHead().(*trpc.FrameHead)
 		if !ok {
+			err = errors.New(frameHeadInvalid)
 			cs.recvQueue.Put(&response{
-				err: errors.New(frameHeadInvalid),
+				err: err,
 			})
 			return
 		}
 
-		if err := cs.handleFrame(ctx, &response{}, respData, frameHead); err != nil {
+		if err = cs.handleFrame(ctx, &response{}, respData, frameHead); err != nil {
 			// If there is a Close frame, the dispatch goroutine ends.
 			return
 		}
 	}
 }
 
+// canResume returns whether the stream can be resumed after failing to receive msg with err.
+// A stream reset by the server or with broken frames is not resumable.
+func (cs *clientStream) canResume(msg codec.Msg, err error) bool {
+	return cs.resume != nil && msg.ClientRspErr() == nil && errs.Code(err) != errs.RetClientDecodeFail &&
+		atomic.LoadUint32(&cs.closed) == 0 && cs.ctx.Err() == nil
+}
+
 func (cs *clientStream) close() {
 	cs.closeOnce.Do(func() {
 		atomic.StoreUint32(&cs.closed, 1)
 		close(cs.closeCh)
+		cs.breakConn()
 	})
 }
 
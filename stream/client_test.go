@@ -904,6 +904,53 @@ func TestClientNewStreamFail(t *testing.T) {
 	})
 }
 
+func TestClientStreamLimitPolicy(t *testing.T) {
+	codec.Register("stream_limit_policy", nil, &fakeCodec{})
+	throttled := func(limited bool) recvExpect {
+		return func(_ *trpc.FrameHead, msg codec.Msg) ([]byte, error) {
+			initMeta := &trpcpb.TrpcStreamInitMeta{}
+			if limited {
+				// The mark of the refusal over the max concurrent streams of the connection.
+				initMeta.RequestMeta = &trpcpb.TrpcStreamInitRequestMeta{
+					TransInfo: map[string][]byte{"trpc-stream-limited": []byte("1")},
+				}
+			}
+			msg.WithStreamFrame(initMeta)
+			msg.WithClientRspErr(errs.NewFrameError(errs.RetServerThrottled, "throttled"))
+			return nil, nil
+		}
+	}
+	opened := func(_ *trpc.FrameHead, msg codec.Msg) ([]byte, error) {
+		msg.WithStreamFrame(&trpcpb.TrpcStreamInitMeta{})
+		return nil, nil
+	}
+	newStream := func(tp *fakeTransport) error {
+		_, err := stream.NewStreamClient().NewStream(ctx, bidiDesc, "/trpc.test.helloworld.Greeter/SayHello",
+			client.WithProtocol("stream_limit_policy"),
+			client.WithTarget("ip://127.0.0.1:8000"),
+			client.WithCurrentSerializationType(codec.SerializationTypeNoop),
+			client.WithStreamTransport(tp),
+			client.WithStreamLimitPolicy(client.StreamLimitQueue),
+		)
+		return err
+	}
+	t.Run("queued over max concurrent streams", func(t *testing.T) {
+		tp := &fakeTransport{expectChan: make(chan recvExpect, 2)}
+		tp.expectChan <- throttled(true)
+		tp.expectChan <- opened
+		assert.Nil(t, newStream(tp))
+		assert.Empty(t, tp.expectChan)
+	})
+	t.Run("fail by other throttling", func(t *testing.T) {
+		tp := &fakeTransport{expectChan: make(chan recvExpect, 2)}
+		tp.expectChan <- throttled(false)
+		tp.expectChan <- opened
+		err := newStream(tp)
+		assert.Equal(t, errs.RetServerThrottled, errs.Code(err))
+		assert.Len(t, tp.expectChan, 1)
+	})
+}
+
 func TestClientNewStreamCloseTransportWhenInitRecvBlocks(t *testing.T) {
 	codec.Register("blocking_stream_timeout", nil, &fakeCodec{})
 	tp := newBlockingStreamTransport()
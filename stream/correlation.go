@@ -0,0 +1,527 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/client"
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
+	"trpc.group/trpc-go/trpc-go/server"
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+)
+
+// Correlated calls multiplex requests and replies over a bidirectional stream. Since Data frames carry
+// no metadata, the serialized message of each Data frame is prefixed with a correlation header before
+// compression, so that peers in other languages may implement it. All integers are big-endian:
+//
+//	+------+----+-------+----------+------------+--------+---------+
+//	| kind | id | value | err type | method len | method | message |
+//	+------+----+-------+----------+------------+--------+---------+
+//	|  1   | 8  |   4   |    1     |     2      |  len   |   ...   |
+//	+------+----+-------+----------+------------+--------+---------+
+//
+// The fields are:
+//   - kind is 1 for a request, 2 for a reply and 3 for an error reply.
+//   - id is the correlation id of the call, chosen by the client and unique among its outstanding calls.
+//   - value is the timeout in milliseconds of a request, 0 if it has none, or the error code (int32)
+//     of an error reply, 0 otherwise.
+//   - err type of an error reply is 1 for a framework error and 2 for a business error, like ret and
+//     func_ret of the trpc unary protocol. It's 0 otherwise.
+//   - method of a request is the name by which the server dispatches it, which may be empty.
+//     Its length is 0 otherwise.
+//   - message is the serialized request or reply, or the error message of an error reply.
+const (
+	correlationHeaderLen = 16
+	maxCallMethodLen     = math.MaxUint16
+
+	callRequest byte = iota + 1
+	callReply
+	callError
+)
+
+var errInvalidCorrelationHeader = errors.New("stream: data frame is shorter than the correlation header")
+
+// correlated is a message of correlated calls, which is recognized by SendMsg and RecvMsg of the streams
+// of this package.
+type correlated struct {
+	kind   byte
+	id     uint64
+	value  uint32
+	method string
+	err    error // Error of the error reply.
+	msg    interface{}
+	// target returns the message which a request or a reply received is unmarshalled into,
+	// once the header is parsed. The message is dropped if it returns nil.
+	target func(*correlated) interface{}
+}
+
+// marshal serializes the message, and prefixes the correlation header.
+func (c *correlated) marshal(serializationType int) ([]byte, error) {
+	var (
+		body    []byte
+		errType byte
+		err     error
+	)
+	switch {
+	case c.kind == callError:
+		body = []byte(errs.Msg(c.err))
+		errType = errs.ErrorTypeBusiness
+		if e := (*errs.Error)(nil); errors.As(c.err, &e) && e.Type == errs.ErrorTypeFramework {
+			errType = errs.ErrorTypeFramework
+		}
+	case c.msg != nil && icodec.IsValidSerializationType(serializationType):
+		if body, err = codec.Marshal(serializationType, c.msg); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.method) > maxCallMethodLen {
+		return nil, fmt.Errorf("stream: method of correlated call is longer than %d", maxCallMethodLen)
+	}
+	b := make([]byte, correlationHeaderLen+len(c.method)+len(body))
+	b[0] = c.kind
+	binary.BigEndian.PutUint64(b[1:], c.id)
+	binary.BigEndian.PutUint32(b[9:], c.value)
+	b[13] = errType
+	binary.BigEndian.PutUint16(b[14:], uint16(len(c.method)))
+	n := copy(b[correlationHeaderLen:], c.method)
+	copy(b[correlationHeaderLen+n:], body)
+	return b, nil
+}
+
+// unmarshal parses the correlation header, and deserializes the message.
+func (c *correlated) unmarshal(serializationType int, data []byte) error {
+	if len(data) < correlationHeaderLen {
+		return errInvalidCorrelationHeader
+	}
+	c.kind = data[0]
+	c.id = binary.BigEndian.Uint64(data[1:])
+	c.value = binary.BigEndian.Uint32(data[9:])
+	errType := data[13]
+	methodLen := int(binary.BigEndian.Uint16(data[14:]))
+	if len(data) < correlationHeaderLen+methodLen {
+		return errInvalidCorrelationHeader
+	}
+	c.method = string(data[correlationHeaderLen : correlationHeaderLen+methodLen])
+	body := data[correlationHeaderLen+methodLen:]
+	switch c.kind {
+	case callError:
+		c.err = correlatedError(errType, int32(c.value), string(body))
+		return nil
+	case callRequest, callReply:
+		if c.target != nil {
+			c.msg = c.target(c)
+		}
+		if c.msg != nil && icodec.IsValidSerializationType(serializationType) {
+			return codec.Unmarshal(serializationType, body, c.msg)
+		}
+		return nil
+	default:
+		return fmt.Errorf("stream: unknown kind %d of correlated message", c.kind)
+	}
+}
+
+// correlatedError returns the error of an error reply. Framework errors of the server are
+// callee framework errors to the client, like those of unary calls.
+func correlatedError(errType byte, code int32, msg string) error {
+	if errType == errs.ErrorTypeFramework {
+		return &errs.Error{
+			Type: errs.ErrorTypeCalleeFramework,
+			Code: trpcpb.TrpcRetCode(code),
+			Msg:  msg,
+		}
+	}
+	return errs.New(int(code), msg)
+}
+
+// CallOption sets options of correlated calls.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout       time.Duration
+	maxConcurrent int
+}
+
+// WithCallTimeout returns a CallOption which sets the timeout of each call of Caller whose context has
+// no deadline, default is 0, which means no timeout.
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithMaxConcurrentCalls returns a CallOption which sets the max number of requests handled concurrently
+// by CallMux, default is 0, which means no limit. Once reached, no more requests are received until
+// a handler returns, and the client is blocked by flow control.
+func WithMaxConcurrentCalls(n int) CallOption {
+	return func(o *callOptions) {
+		o.maxConcurrent = n
+	}
+}
+
+// pendingCall is an outstanding call of Caller.
+type pendingCall struct {
+	rsp    interface{} // Target of the reply.
+	result chan error  // Receives nil once rsp is filled, or the error of the call.
+}
+
+// Caller makes request-reply calls over a bidirectional client stream, whose server serves the calls
+// by CallMux or ServeCalls. Each request is sent with a correlation id, by which the reply is matched
+// to the call, so that calls are outstanding concurrently and replied in any order.
+// Caller receives all the messages of the stream, RecvMsg of the stream must not be called by others.
+type Caller struct {
+	cs   client.ClientStream
+	opts callOptions
+
+	sendMu  sync.Mutex // SendMsg of the stream is not concurrency safe.
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]*pendingCall
+	closed  bool
+	done    chan struct{} // Closed once the stream ends.
+	err     error         // Error by which the stream ends.
+}
+
+// NewCaller creates a Caller on the stream, which must be created by this package.
+func NewCaller(cs client.ClientStream, opts ...CallOption) *Caller {
+	c := &Caller{
+		cs:      cs,
+		pending: make(map[uint64]*pendingCall),
+		done:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(&c.opts)
+	}
+	go c.receive()
+	return c
+}
+
+// Call sends req of method by c and waits for its reply until ctx is done. It's safe to call Call
+// concurrently, with different methods and types. The remaining time of ctx is sent along with req,
+// so that the server stops handling it in time. The error returned by the handler of the server is
+// returned with the same code and message.
+func Call[Req, Rsp any](ctx context.Context, c *Caller, method string, req *Req) (*Rsp, error) {
+	rsp := new(Rsp)
+	if err := c.invoke(ctx, method, req, rsp); err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+// invoke sends req and waits for its reply, which is unmarshalled into rsp.
+// rsp must not be accessed after invoke returns an error.
+func (c *Caller) invoke(ctx context.Context, method string, req, rsp interface{}) error {
+	if _, ok := ctx.Deadline(); !ok && c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+	id, call, err := c.register(rsp)
+	if err != nil {
+		return err
+	}
+	var timeout uint32
+	if deadline, ok := ctx.Deadline(); ok {
+		d := time.Until(deadline)
+		if d <= 0 {
+			c.unregister(id)
+			return callContextError(ctx)
+		}
+		// Round up, so that the timeout is never sent as 0.
+		if ms := (d + time.Millisecond - 1) / time.Millisecond; ms < math.MaxUint32 {
+			timeout = uint32(ms)
+		} else {
+			timeout = math.MaxUint32
+		}
+	}
+
+	c.sendMu.Lock()
+	err = c.cs.SendMsg(&correlated{kind: callRequest, id: id, value: timeout, method: method, msg: req})
+	c.sendMu.Unlock()
+	if err != nil {
+		c.unregister(id)
+		return err
+	}
+	select {
+	case err := <-call.result:
+		return err
+	case <-ctx.Done():
+		c.unregister(id)
+		return callContextError(ctx)
+	case <-c.done:
+		// The reply may be received right before the stream ends.
+		select {
+		case err := <-call.result:
+			return err
+		default:
+			return c.err
+		}
+	}
+}
+
+// Close closes sending of the stream, and waits for the replies of the outstanding calls
+// until the server ends the stream. Calls after Close fail.
+func (c *Caller) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		<-c.done
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.sendMu.Lock()
+	err := c.cs.CloseSend()
+	c.sendMu.Unlock()
+	if err != nil {
+		return err
+	}
+	<-c.done
+	if errs.Code(c.err) == errs.RetClientStreamReadEnd {
+		return nil
+	}
+	return c.err
+}
+
+// register allocates a correlation id for a new call.
+func (c *Caller) register(rsp interface{}) (uint64, *pendingCall, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, nil, errs.NewFrameError(errs.RetClientStreamReadEnd, "stream: caller is closed")
+	}
+	select {
+	case <-c.done:
+		return 0, nil, c.err
+	default:
+	}
+	c.nextID++
+	call := &pendingCall{rsp: rsp, result: make(chan error, 1)}
+	c.pending[c.nextID] = call
+	return c.nextID, call, nil
+}
+
+// unregister removes the call, whose reply received later is dropped.
+func (c *Caller) unregister(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// take removes the call of id and returns it, nil if it has timed out or been canceled.
+func (c *Caller) take(id uint64) *pendingCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call := c.pending[id]
+	delete(c.pending, id)
+	return call
+}
+
+// receive dispatches the replies to their calls until the stream ends.
+func (c *Caller) receive() {
+	for {
+		var call *pendingCall
+		m := &correlated{target: func(m *correlated) interface{} {
+			// The reply is unmarshalled only if the call is still waiting, which owns rsp.
+			if call = c.take(m.id); call != nil {
+				return call.rsp
+			}
+			return nil
+		}}
+		if err := c.cs.RecvMsg(m); err != nil {
+			if err == io.EOF {
+				err = errs.NewFrameError(errs.RetClientStreamReadEnd, "stream: server ends the stream")
+			}
+			if call != nil {
+				call.result <- err
+			}
+			c.mu.Lock()
+			c.err = err
+			c.pending = nil
+			close(c.done)
+			c.mu.Unlock()
+			return
+		}
+		if m.kind == callError {
+			call = c.take(m.id)
+		}
+		if call == nil {
+			// The call has timed out or been canceled.
+			continue
+		}
+		call.result <- m.err
+	}
+}
+
+// callContextError returns the error of the call whose ctx is done.
+func callContextError(ctx context.Context) error {
+	if ctx.Err() == context.Canceled {
+		return errs.NewFrameError(errs.RetClientCanceled, "stream: call canceled: "+ctx.Err().Error())
+	}
+	return errs.NewFrameError(errs.RetClientTimeout, "stream: call timeout: "+context.DeadlineExceeded.Error())
+}
+
+// CallHandler handles a request of correlated calls. ctx is derived from the context of the stream,
+// with the timeout of the call if any, and is canceled once the handler returns.
+type CallHandler[Req, Rsp any] func(ctx context.Context, req *Req) (*Rsp, error)
+
+// callHandler is a CallHandler of any types.
+type callHandler struct {
+	newReq func() interface{}
+	handle func(ctx context.Context, req interface{}) (interface{}, error)
+}
+
+// CallMux serves the correlated calls made by Caller over bidirectional server streams,
+// and dispatches the requests to the handlers registered by their methods.
+type CallMux struct {
+	handlers map[string]*callHandler
+}
+
+// NewCallMux creates a CallMux without handlers.
+func NewCallMux() *CallMux {
+	return &CallMux{handlers: make(map[string]*callHandler)}
+}
+
+// HandleCall registers the handler of method to m. The handler registered with the empty method
+// handles the requests whose methods are not registered, which fail with errs.RetServerNoFunc otherwise.
+// Handlers must be registered before m serves streams.
+func HandleCall[Req, Rsp any](m *CallMux, method string, handler CallHandler[Req, Rsp]) {
+	m.handlers[method] = &callHandler{
+		newReq: func() interface{} { return new(Req) },
+		handle: func(ctx context.Context, req interface{}) (interface{}, error) {
+			rsp, err := handler(ctx, req.(*Req))
+			if err != nil || rsp == nil {
+				return nil, err
+			}
+			return rsp, nil
+		},
+	}
+}
+
+// handler returns the handler of method, nil if it's not registered.
+func (m *CallMux) handler(method string) *callHandler {
+	if h, ok := m.handlers[method]; ok {
+		return h
+	}
+	return m.handlers[""]
+}
+
+// ServeCalls serves the correlated calls over the bidirectional server stream by a single handler
+// of all the methods, see CallMux.Serve.
+func ServeCalls[Req, Rsp any](ss server.Stream, handler CallHandler[Req, Rsp], opts ...CallOption) error {
+	m := NewCallMux()
+	HandleCall(m, "", handler)
+	return m.Serve(ss, opts...)
+}
+
+// Serve serves the correlated calls over the bidirectional server stream.
+// Each request is handled by the handler of its method in a new goroutine, and the reply or the error
+// returned is sent with the correlation id of the request. It returns nil after the client closes sending
+// and all the requests received are replied, or the error of the stream, in which case the contexts of
+// the handlers running are canceled. Serve receives all the messages of the stream, and is usually the
+// only call of the stream handler.
+func (m *CallMux) Serve(ss server.Stream, opts ...CallOption) error {
+	var o callOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx, cancel := context.WithCancel(ss.Context())
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		sendMu  sync.Mutex
+		sendErr error
+		limit   chan struct{}
+	)
+	if o.maxConcurrent > 0 {
+		limit = make(chan struct{}, o.maxConcurrent)
+	}
+	reply := func(m *correlated) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if sendErr != nil {
+			return
+		}
+		if err := ss.SendMsg(m); err != nil {
+			sendErr = err
+			cancel()
+		}
+	}
+	for {
+		var h *callHandler
+		req := &correlated{target: func(c *correlated) interface{} {
+			if h = m.handler(c.method); h != nil {
+				return h.newReq()
+			}
+			return nil
+		}}
+		if err := ss.RecvMsg(req); err != nil {
+			if err != io.EOF {
+				cancel()
+			}
+			wg.Wait()
+			if err == io.EOF {
+				sendMu.Lock()
+				defer sendMu.Unlock()
+				return sendErr
+			}
+			return err
+		}
+		if req.kind != callRequest {
+			cancel()
+			wg.Wait()
+			return errs.NewFrameError(errs.RetServerDecodeFail,
+				fmt.Sprintf("stream: received kind %d of correlated message, want request", req.kind))
+		}
+		if h == nil {
+			reply(&correlated{kind: callError, id: req.id, value: uint32(errs.RetServerNoFunc),
+				err: errs.NewFrameError(errs.RetServerNoFunc, "stream: no handler of method "+req.method)})
+			continue
+		}
+		if limit != nil {
+			limit <- struct{}{}
+		}
+		wg.Add(1)
+		go func(req *correlated) {
+			defer func() {
+				if limit != nil {
+					<-limit
+				}
+				wg.Done()
+			}()
+			callCtx, callCancel := ctx, context.CancelFunc(func() {})
+			if req.value > 0 {
+				callCtx, callCancel = context.WithTimeout(ctx, time.Duration(req.value)*time.Millisecond)
+			}
+			rsp, err := h.handle(callCtx, req.msg)
+			callCancel()
+			if err != nil {
+				reply(&correlated{kind: callError, id: req.id, value: uint32(errs.Code(err)), err: err})
+				return
+			}
+			reply(&correlated{kind: callReply, id: req.id, msg: rsp})
+		}(req)
+	}
+}
@@ -0,0 +1,86 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+)
+
+func TestCorrelated(t *testing.T) {
+	data, err := (&correlated{
+		kind:   callRequest,
+		id:     42,
+		value:  100,
+		method: "Echo",
+		msg:    map[string]string{"name": "hello"},
+	}).marshal(codec.SerializationTypeJSON)
+	require.Nil(t, err)
+	assert.Equal(t, "Echo", string(data[correlationHeaderLen:correlationHeaderLen+4]))
+	assert.Equal(t, `{"name":"hello"}`, string(data[correlationHeaderLen+4:]))
+
+	req := map[string]string{}
+	c := &correlated{target: func(c *correlated) interface{} {
+		assert.Equal(t, "Echo", c.method)
+		return &req
+	}}
+	require.Nil(t, c.unmarshal(codec.SerializationTypeJSON, data))
+	assert.Equal(t, callRequest, c.kind)
+	assert.Equal(t, uint64(42), c.id)
+	assert.Equal(t, uint32(100), c.value)
+	assert.Equal(t, map[string]string{"name": "hello"}, req)
+
+	// The message is dropped without a target.
+	c = &correlated{target: func(*correlated) interface{} { return nil }}
+	require.Nil(t, c.unmarshal(codec.SerializationTypeJSON, data))
+	assert.Nil(t, c.msg)
+
+	for _, tt := range []struct {
+		err     error
+		errType int
+	}{
+		{errs.New(10001, "failed"), errs.ErrorTypeBusiness},
+		{errors.New("failed"), errs.ErrorTypeBusiness},
+		{errs.NewFrameError(errs.RetServerNoFunc, "failed"), errs.ErrorTypeCalleeFramework},
+	} {
+		data, err = (&correlated{
+			kind:  callError,
+			id:    43,
+			value: uint32(errs.Code(tt.err)),
+			err:   tt.err,
+		}).marshal(codec.SerializationTypeJSON)
+		require.Nil(t, err)
+		c = &correlated{}
+		require.Nil(t, c.unmarshal(codec.SerializationTypeJSON, data))
+		assert.Equal(t, callError, c.kind)
+		assert.Equal(t, uint64(43), c.id)
+		assert.Equal(t, errs.Code(tt.err), errs.Code(c.err))
+		assert.Equal(t, "failed", errs.Msg(c.err))
+		var e *errs.Error
+		require.True(t, errors.As(c.err, &e))
+		assert.Equal(t, tt.errType, e.Type)
+	}
+
+	assert.Equal(t, errInvalidCorrelationHeader, c.unmarshal(codec.SerializationTypeJSON, []byte("short")))
+	data[0] = 0
+	assert.NotNil(t, c.unmarshal(codec.SerializationTypeJSON, data))
+	data[0], data[15] = callReply, 0xff
+	assert.Equal(t, errInvalidCorrelationHeader, c.unmarshal(codec.SerializationTypeJSON, data))
+}
@@ -15,8 +15,13 @@ package stream
 
 import (
 	"errors"
+	"math"
 	"reflect"
+	"sync"
 	"sync/atomic"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/client"
 )
 
 // sendControl is the behavior control of the sender.
@@ -27,6 +32,8 @@ type sendControl struct {
 	ch chan struct{}
 	// waits wait for data to arrive or the stream to end.
 	waits []reflect.SelectCase
+	// stats records the time blocked on the window, nil if it's not recorded.
+	stats *streamStats
 }
 
 // feedback is the feedback type.
@@ -47,14 +54,22 @@ func newSendControl(window uint32, dones ...<-chan struct{}) *sendControl {
 // GetWindow gets the sending window of a certain size, if it can't get it, it will block.
 // precision is not guaranteed, may be negative.
 func (s *sendControl) GetWindow(w uint32) error {
+	var stalled time.Time
 	for w := int64(w); ; {
 		// First determine the currently available port, if the available window is <= 0, wait for the window to update.
 		// If it is greater than 0, subtract this window and return.
 		// Note that it may become a negative number after subtraction.
 		if atomic.LoadInt64(&s.window) > 0 {
 			atomic.AddInt64(&s.window, -w)
+			if !stalled.IsZero() {
+				s.stats.unblocked(time.Since(stalled))
+			}
 			return nil
 		}
+		if stalled.IsZero() {
+			stalled = time.Now()
+			s.stats.stalled()
+		}
 		if chosen, _, _ := reflect.Select(s.waits); chosen == 0 {
 			// received data
 			continue
@@ -77,6 +92,15 @@ func (s *sendControl) UpdateWindow(increment uint32) {
 	}
 }
 
+// reset resets the window to w, which is the initial window of the new connection of a resumed stream.
+func (s *sendControl) reset(w uint32) {
+	atomic.StoreInt64(&s.window, int64(w))
+	select {
+	case s.ch <- struct{}{}:
+	default:
+	}
+}
+
 func checkUpdate(updatedWindow, increment int64) bool {
 	return (updatedWindow-increment <= 0) && (updatedWindow > 0)
 }
@@ -108,3 +132,173 @@ func (r *receiveControl) OnRecv(n uint32) error {
 	}
 	return nil
 }
+
+// adaptiveWindowIdle is the idle period after which the adaptive window is halved.
+const adaptiveWindowIdle = time.Second
+
+// newRecvControl returns an adaptive receive control if maxWindow is larger than window,
+// otherwise a receive control of the fixed window.
+func newRecvControl(window, maxWindow uint32, fb feedback) client.RecvControl {
+	if window > 0 && maxWindow > window {
+		return newAdaptiveReceiveControl(window, maxWindow, fb)
+	}
+	return newReceiveControl(window, fb)
+}
+
+// arrivalObserver is implemented by receive controls which observe data frames as soon as they arrive,
+// before they are consumed by the application.
+type arrivalObserver interface {
+	onArrive(n uint32)
+}
+
+// adaptiveReceiveControl is a receive control whose window adapts to the bandwidth-delay product (BDP)
+// of the stream, like the BDP estimation of HTTP/2.
+//
+// A probe starts when data arrives, and the next window update is its marker: data beyond the window
+// granted before the update can only be sent after the update reaches the sender, so its arrival ends
+// the probe after a round trip. The throughput during the probe, multiplied by the min round trip time,
+// is a BDP sample. If the sample nears the window while the application keeps up with the arrived data,
+// the window is what limits the throughput, and it is grown to twice the sample, up to the max window.
+// For each idle period, the window is halved down to the initial window by withholding window updates.
+// The sender is not aware of the adaptation, it only sees larger or smaller window updates.
+type adaptiveReceiveControl struct {
+	mu        sync.Mutex
+	window    uint32 // Current window.
+	minWindow uint32 // Initial window, the window never shrinks below it.
+	maxWindow uint32 // Upper limit of the window.
+	fb        feedback
+	now       func() time.Time
+
+	unUpdated  uint64 // Consumed, no window update sent.
+	grown      uint64 // Window grown, no window update sent.
+	withheld   uint64 // Window shrunk, to be withheld from window updates.
+	granted    uint64 // Total window granted to the sender, including the initial window.
+	arrived    uint64 // Total bytes arrived.
+	consumed   uint64 // Total bytes consumed.
+	lastActive time.Time
+
+	probing      bool      // Whether a probe is started.
+	probeStart   time.Time // When the probe is started.
+	probeArrived uint64    // Bytes arrived when the probe is started.
+	probeMarked  bool      // Whether the marker update of the probe is sent.
+	probeMark    uint64    // The window granted before the marker update.
+	probeSent    time.Time // When the marker update is sent.
+	minRTT       time.Duration
+}
+
+func newAdaptiveReceiveControl(window, maxWindow uint32, fb feedback) *adaptiveReceiveControl {
+	return &adaptiveReceiveControl{
+		window:     window,
+		minWindow:  window,
+		maxWindow:  maxWindow,
+		fb:         fb,
+		now:        time.Now,
+		granted:    uint64(window),
+		lastActive: time.Now(),
+	}
+}
+
+// OnRecv is called when data is consumed by the application, and the window is updated.
+func (r *adaptiveReceiveControl) OnRecv(n uint32) error {
+	r.mu.Lock()
+	now := r.now()
+	r.checkIdle(now)
+	r.consumed += uint64(n)
+	r.unUpdated += uint64(n)
+	increment := r.takeIncrement(now)
+	r.mu.Unlock()
+	if increment == 0 || r.fb == nil {
+		return nil
+	}
+	return r.fb(increment)
+}
+
+// onArrive is called when data arrives. It ends the probe with a BDP sample if the data starts beyond
+// the marker, and starts a new probe if there is none in progress.
+// The grown window is granted by the next window update.
+func (r *adaptiveReceiveControl) onArrive(n uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.checkIdle(now)
+	// The sender may overdraw its window by a frame, so only a frame starting beyond the marker
+	// is sent after the marker update.
+	if r.probing && r.probeMarked && r.arrived >= r.probeMark {
+		r.probing = false
+		r.sample(now.Sub(r.probeSent), now.Sub(r.probeStart), r.arrived-r.probeArrived)
+	}
+	if !r.probing {
+		r.probing, r.probeMarked, r.probeStart, r.probeArrived = true, false, now, r.arrived
+	}
+	r.arrived += uint64(n)
+}
+
+// checkIdle halves the window for each idle period since the stream was last active.
+func (r *adaptiveReceiveControl) checkIdle(now time.Time) {
+	idle := now.Sub(r.lastActive)
+	r.lastActive = now
+	if idle < adaptiveWindowIdle {
+		return
+	}
+	// A probe which spans an idle period does not measure the link.
+	r.probing = false
+	window := r.window
+	for ; idle >= adaptiveWindowIdle && window > r.minWindow; idle -= adaptiveWindowIdle {
+		window /= 2
+	}
+	if window < r.minWindow {
+		window = r.minWindow
+	}
+	r.withheld += uint64(r.window - window)
+	r.window = window
+}
+
+// sample grows the window if the BDP, sampled from the bytes arrived during the probe lasting elapsed,
+// nears the window.
+func (r *adaptiveReceiveControl) sample(rtt, elapsed time.Duration, bytes uint64) {
+	if r.minRTT == 0 || rtt < r.minRTT {
+		r.minRTT = rtt
+	}
+	if r.arrived-r.consumed >= uint64(r.window/4) {
+		// The application falls behind, a larger window only buffers more data.
+		return
+	}
+	bdp := float64(bytes)
+	if elapsed > r.minRTT {
+		bdp = bdp * float64(r.minRTT) / float64(elapsed)
+	}
+	if bdp < float64(r.window)*2/3 {
+		return
+	}
+	window := uint64(math.Min(bdp*2, float64(r.maxWindow)))
+	if window <= uint64(r.window) {
+		return
+	}
+	r.grown += window - uint64(r.window)
+	r.window = uint32(window)
+}
+
+// takeIncrement returns the window update to be sent, which is zero if no update is due.
+// The update is the marker of the probe in progress if it has none.
+func (r *adaptiveReceiveControl) takeIncrement(now time.Time) uint32 {
+	if r.grown == 0 && r.unUpdated < uint64(r.window/4) {
+		return 0
+	}
+	increment := r.unUpdated + r.grown
+	r.unUpdated, r.grown = 0, 0
+	if increment <= r.withheld {
+		r.withheld -= increment
+		return 0
+	}
+	increment -= r.withheld
+	r.withheld = 0
+	if increment > math.MaxUint32 {
+		r.unUpdated = increment - math.MaxUint32
+		increment = math.MaxUint32
+	}
+	if r.probing && !r.probeMarked {
+		r.probeMarked, r.probeMark, r.probeSent = true, r.granted, now
+	}
+	r.granted += increment
+	return uint32(increment)
+}
@@ -68,3 +68,129 @@ func TestReceiveControl(t *testing.T) {
 	err = rc.OnRecv(defaultInitWindowSize / 4)
 	assert.NotNil(t, err)
 }
+
+func TestAdaptiveReceiveControl(t *testing.T) {
+	const (
+		initWindow = defaultInitWindowSize
+		maxWindow  = 16 << 20
+	)
+	t.Run("fixed window if max is not larger", func(t *testing.T) {
+		assert.IsType(t, &receiveControl{}, newRecvControl(initWindow, initWindow, nil))
+		assert.IsType(t, &receiveControl{}, newRecvControl(0, maxWindow, nil))
+		assert.IsType(t, &adaptiveReceiveControl{}, newRecvControl(initWindow, maxWindow, nil))
+	})
+	t.Run("grow on high latency link", func(t *testing.T) {
+		// BDP of the link is 64KB/ms * 50ms = 3.2MB.
+		fixed := newAdaptiveSim(newAdaptiveReceiveControl(initWindow, initWindow, nil), 50*time.Millisecond, 64<<10, 0)
+		fixed.run(t, 5*time.Second)
+		adaptive := newAdaptiveSim(newAdaptiveReceiveControl(initWindow, maxWindow, nil), 50*time.Millisecond, 64<<10, 0)
+		adaptive.run(t, 5*time.Second)
+		assert.Greater(t, adaptive.rc.window, uint32(3200<<10))
+		assert.Less(t, adaptive.rc.window, uint32(4*3200<<10))
+		assert.Greater(t, adaptive.consumed, 20*fixed.consumed)
+	})
+	t.Run("grow up to max", func(t *testing.T) {
+		sim := newAdaptiveSim(newAdaptiveReceiveControl(initWindow, 256<<10, nil), 50*time.Millisecond, 64<<10, 0)
+		sim.run(t, 5*time.Second)
+		assert.Equal(t, uint32(256<<10), sim.rc.window)
+	})
+	t.Run("no growth for slow consumer", func(t *testing.T) {
+		sim := newAdaptiveSim(newAdaptiveReceiveControl(initWindow, maxWindow, nil), 50*time.Millisecond, 64<<10, 128)
+		sim.run(t, 5*time.Second)
+		assert.Equal(t, uint32(initWindow), sim.rc.window)
+		assert.LessOrEqual(t, sim.queued, int(initWindow))
+	})
+	t.Run("shrink on idle", func(t *testing.T) {
+		sim := newAdaptiveSim(newAdaptiveReceiveControl(initWindow, maxWindow, nil), 50*time.Millisecond, 64<<10, 0)
+		sim.run(t, 5*time.Second)
+		grown := sim.rc.window
+		assert.Greater(t, grown, uint32(initWindow))
+
+		sim.clock = sim.clock.Add(10 * time.Second)
+		sim.rc.checkIdle(sim.clock)
+		assert.Equal(t, uint32(initWindow), sim.rc.window)
+		assert.Equal(t, uint64(grown-initWindow), sim.rc.withheld)
+
+		// The stream is not stalled by the withheld window, and grows again.
+		consumed := sim.consumed
+		sim.run(t, 5*time.Second)
+		assert.Greater(t, sim.consumed, consumed)
+		assert.Greater(t, sim.rc.window, uint32(initWindow))
+	})
+	t.Run("feedback error", func(t *testing.T) {
+		rc := newAdaptiveReceiveControl(initWindow, maxWindow, func(uint32) error {
+			return errors.New("feedback error")
+		})
+		assert.Nil(t, rc.OnRecv(100))
+		assert.NotNil(t, rc.OnRecv(initWindow/4))
+	})
+}
+
+// adaptiveSim simulates a stream whose sender always has data to send, over a link of rtt and bandwidth,
+// to a receiver consuming at the consume rate, which is unlimited if zero. Rates are in bytes per ms.
+type adaptiveSim struct {
+	rc        *adaptiveReceiveControl
+	rtt       time.Duration
+	bandwidth int
+	consume   int
+
+	clock    time.Time
+	credit   int64
+	data     []adaptiveSimPacket
+	updates  []adaptiveSimPacket
+	budget   int
+	queued   int
+	consumed int
+}
+
+type adaptiveSimPacket struct {
+	at time.Time
+	n  int
+}
+
+func newAdaptiveSim(rc *adaptiveReceiveControl, rtt time.Duration, bandwidth, consume int) *adaptiveSim {
+	s := &adaptiveSim{
+		rc:        rc,
+		rtt:       rtt,
+		bandwidth: bandwidth,
+		consume:   consume,
+		clock:     time.Unix(0, 0),
+		credit:    int64(rc.window),
+	}
+	rc.now = func() time.Time { return s.clock }
+	rc.lastActive = s.clock
+	rc.fb = func(increment uint32) error {
+		s.updates = append(s.updates, adaptiveSimPacket{at: s.clock.Add(s.rtt / 2), n: int(increment)})
+		return nil
+	}
+	return s
+}
+
+func (s *adaptiveSim) run(t *testing.T, d time.Duration) {
+	const frameSize = 1024
+	for end := s.clock.Add(d); s.clock.Before(end); s.clock = s.clock.Add(time.Millisecond) {
+		for len(s.updates) > 0 && !s.updates[0].at.After(s.clock) {
+			s.credit += int64(s.updates[0].n)
+			s.updates = s.updates[1:]
+		}
+		for sent := 0; s.credit > 0 && sent+frameSize <= s.bandwidth; sent += frameSize {
+			s.credit -= frameSize
+			s.data = append(s.data, adaptiveSimPacket{at: s.clock.Add(s.rtt / 2), n: frameSize})
+		}
+		for len(s.data) > 0 && !s.data[0].at.After(s.clock) {
+			s.rc.onArrive(uint32(s.data[0].n))
+			s.queued += s.data[0].n
+			s.data = s.data[1:]
+		}
+		s.budget += s.consume
+		for s.queued > 0 && (s.consume == 0 || s.budget >= frameSize) {
+			s.queued -= frameSize
+			s.consumed += frameSize
+			s.budget -= frameSize
+			assert.Nil(t, s.rc.OnRecv(frameSize))
+		}
+		if s.queued == 0 {
+			s.budget = 0
+		}
+	}
+}
@@ -0,0 +1,121 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"sync/atomic"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// keepalive pings the peer when the stream has sent nothing for interval, and closes the stream
+// when it has received nothing for maxIdle. Either of them is disabled if it's zero.
+//
+// A ping is a feedback frame with zero window increment, which is ignored by the flow control
+// of the peer, so that it's compatible with peers without keepalive. Any frame received,
+// including pings, keeps the stream from being idle.
+type keepalive struct {
+	interval time.Duration
+	maxIdle  time.Duration
+	ping     func() error
+	onIdle   func()
+	done     <-chan struct{}
+
+	lastSent int64 // Unix nano of the last frame sent.
+	lastRecv int64 // Unix nano of the last frame received.
+}
+
+// newKeepalive returns nil if both interval and maxIdle are zero.
+func newKeepalive(interval, maxIdle time.Duration, ping func() error,
+	onIdle func(), done <-chan struct{}) *keepalive {
+	if interval <= 0 && maxIdle <= 0 {
+		return nil
+	}
+	now := time.Now().UnixNano()
+	return &keepalive{
+		interval: interval,
+		maxIdle:  maxIdle,
+		ping:     ping,
+		onIdle:   onIdle,
+		done:     done,
+		lastSent: now,
+		lastRecv: now,
+	}
+}
+
+// start starts the goroutine of keepalive, which exits when done is closed.
+func (k *keepalive) start() {
+	if k != nil {
+		go k.run()
+	}
+}
+
+// sent records that a frame is sent.
+func (k *keepalive) sent() {
+	if k != nil {
+		atomic.StoreInt64(&k.lastSent, time.Now().UnixNano())
+	}
+}
+
+// received records that a frame is received.
+func (k *keepalive) received() {
+	if k != nil {
+		atomic.StoreInt64(&k.lastRecv, time.Now().UnixNano())
+	}
+}
+
+func (k *keepalive) run() {
+	timer := time.NewTimer(k.wait(time.Now()))
+	defer timer.Stop()
+	for {
+		select {
+		case <-k.done:
+			return
+		case <-timer.C:
+		}
+		now := time.Now()
+		if k.maxIdle > 0 && now.Sub(time.Unix(0, atomic.LoadInt64(&k.lastRecv))) >= k.maxIdle {
+			k.onIdle()
+			// A resumable stream is resumed on a new connection instead of being closed,
+			// which is watched from now on.
+			k.received()
+		} else if k.interval > 0 && now.Sub(time.Unix(0, atomic.LoadInt64(&k.lastSent))) >= k.interval {
+			if err := k.ping(); err != nil {
+				log.Trace("stream: keepalive ping fail", err)
+			}
+			k.sent()
+		}
+		timer.Reset(k.wait(now))
+	}
+}
+
+// wait returns the duration until the next ping or idle check.
+func (k *keepalive) wait(now time.Time) time.Duration {
+	var wait time.Duration
+	if k.interval > 0 {
+		wait = time.Unix(0, atomic.LoadInt64(&k.lastSent)).Add(k.interval).Sub(now)
+	}
+	if k.maxIdle > 0 {
+		idle := time.Unix(0, atomic.LoadInt64(&k.lastRecv)).Add(k.maxIdle).Sub(now)
+		if k.interval <= 0 || idle < wait {
+			wait = idle
+		}
+	}
+	if wait < time.Millisecond {
+		// The deadline is reached while checking, check again soon.
+		return time.Millisecond
+	}
+	return wait
+}
@@ -0,0 +1,84 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepalive(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		k := newKeepalive(0, 0, nil, nil, nil)
+		assert.Nil(t, k)
+		k.start()
+		k.sent()
+		k.received()
+	})
+	t.Run("ping when nothing is sent", func(t *testing.T) {
+		done := make(chan struct{})
+		defer close(done)
+		var pings int32
+		k := newKeepalive(20*time.Millisecond, 0, func() error {
+			atomic.AddInt32(&pings, 1)
+			return errors.New("ping error")
+		}, nil, done)
+		k.start()
+		time.Sleep(110 * time.Millisecond)
+		assert.GreaterOrEqual(t, atomic.LoadInt32(&pings), int32(3))
+
+		atomic.StoreInt32(&pings, 0)
+		for i := 0; i < 10; i++ {
+			k.sent()
+			time.Sleep(5 * time.Millisecond)
+		}
+		assert.Zero(t, atomic.LoadInt32(&pings))
+	})
+	t.Run("idle when nothing is received", func(t *testing.T) {
+		done := make(chan struct{})
+		defer close(done)
+		idle := make(chan struct{})
+		var once sync.Once
+		k := newKeepalive(0, 50*time.Millisecond, nil, func() { once.Do(func() { close(idle) }) }, done)
+		k.start()
+		for i := 0; i < 20; i++ {
+			k.received()
+			time.Sleep(5 * time.Millisecond)
+		}
+		select {
+		case <-idle:
+			t.Fatal("stream is idle while receiving")
+		default:
+		}
+		select {
+		case <-idle:
+		case <-time.After(time.Second):
+			t.Fatal("stream is not idle")
+		}
+	})
+	t.Run("stop when done", func(t *testing.T) {
+		done := make(chan struct{})
+		var idle int32
+		k := newKeepalive(0, 20*time.Millisecond, nil, func() { atomic.StoreInt32(&idle, 1) }, done)
+		k.start()
+		close(done)
+		time.Sleep(50 * time.Millisecond)
+		assert.Zero(t, atomic.LoadInt32(&idle))
+	})
+}
@@ -0,0 +1,164 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/addrutil"
+	"trpc.group/trpc-go/trpc-go/metrics"
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+)
+
+const (
+	// maxExcludedConns is the max number of connections refusing a new stream,
+	// which are excluded when the stream is opened on another connection.
+	maxExcludedConns = 8
+	// queuedStreamRetryInterval is the max interval between attempts of opening a queued stream.
+	// The stream is opened again as soon as a stream of the connection finishes,
+	// but the server may not have found it finished yet, or the streams may be of another client.
+	queuedStreamRetryInterval = 100 * time.Millisecond
+)
+
+// A new stream over the max concurrent streams of the connection is refused with errs.RetServerThrottled,
+// the code of trpc protocol for server limits, which is also used by others such as rate limiters.
+// The refusal is marked by streamLimitKey in the trans info of the Init frame responded, so that
+// the client applies its stream limit policy to the streams refused by the max concurrent streams only.
+const streamLimitKey = "trpc-stream-limited"
+
+// errTooManyStreams is the cause of the refusal of a new stream over the max concurrent streams.
+var errTooManyStreams = errors.New("stream: connection has reached the max concurrent streams")
+
+// tooManyStreams returns the error refusing a new stream over the max concurrent streams n.
+func tooManyStreams(n int) error {
+	return errs.WrapFrameError(errTooManyStreams, errs.RetServerThrottled,
+		fmt.Sprintf("connection has reached the max concurrent streams %d", n))
+}
+
+// streamLimitMark returns the request meta which marks the refusal of a new stream over the max concurrent
+// streams. Like resumeConfirmation, the request meta is borrowed by the Init frame responded.
+func streamLimitMark() *trpcpb.TrpcStreamInitRequestMeta {
+	return &trpcpb.TrpcStreamInitRequestMeta{TransInfo: map[string][]byte{streamLimitKey: []byte("1")}}
+}
+
+// isStreamLimited returns whether err refuses the stream over the max concurrent streams,
+// where initMeta is the Init frame responded with err.
+func isStreamLimited(initMeta *trpcpb.TrpcStreamInitMeta, err error) bool {
+	if errs.Code(err) != errs.RetServerThrottled {
+		return false
+	}
+	_, ok := initMeta.GetRequestMeta().GetTransInfo()[streamLimitKey]
+	return ok
+}
+
+// connKey returns the key of the connection of msg sent by the client stream.
+func connKey(msg codec.Msg) string {
+	if msg.LocalAddr() == nil || msg.RemoteAddr() == nil {
+		return ""
+	}
+	return addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr())
+}
+
+// activeStreams is the active client streams.
+var activeStreams = newConnStreams()
+
+// connStreams counts the active client streams of each connection to queue the new streams refused
+// by the max concurrent streams of the connection, and reports the active streams of each callee service.
+type connStreams struct {
+	mu       sync.Mutex
+	conns    map[string]*connStreamCount // key of connection => streams of the connection
+	services map[string]int              // callee service => number of streams
+}
+
+type connStreamCount struct {
+	n        int
+	released chan struct{} // Closed when a stream of the connection finishes.
+}
+
+func newConnStreams() *connStreams {
+	return &connStreams{
+		conns:    make(map[string]*connStreamCount),
+		services: make(map[string]int),
+	}
+}
+
+// add adds a stream of the callee service on the connection.
+func (c *connStreams) add(conn, service string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cc, ok := c.conns[conn]
+	if !ok {
+		cc = &connStreamCount{released: make(chan struct{})}
+		c.conns[conn] = cc
+	}
+	cc.n++
+	c.services[service]++
+	c.report(service)
+}
+
+// done removes a finished stream of the callee service from the connection,
+// and wakes up the streams queued for the connection.
+func (c *connStreams) done(conn, service string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cc, ok := c.conns[conn]; ok {
+		close(cc.released)
+		cc.released = make(chan struct{})
+		if cc.n--; cc.n == 0 {
+			delete(c.conns, conn)
+		}
+	}
+	if c.services[service]--; c.services[service] <= 0 {
+		delete(c.services, service)
+	}
+	c.report(service)
+}
+
+// wait waits until a stream of the connection finishes, or the retry interval elapses.
+func (c *connStreams) wait(ctx context.Context, conn string) error {
+	c.mu.Lock()
+	var released <-chan struct{}
+	if cc, ok := c.conns[conn]; ok {
+		released = cc.released
+	}
+	c.mu.Unlock()
+
+	timer := time.NewTimer(queuedStreamRetryInterval)
+	defer timer.Stop()
+	select {
+	case <-released:
+	case <-timer.C:
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return errs.NewFrameError(errs.RetClientTimeout,
+				"client stream timeout while queued for the max concurrent streams: "+ctx.Err().Error())
+		}
+		return errs.NewFrameError(errs.RetClientCanceled,
+			"client stream canceled while queued for the max concurrent streams: "+ctx.Err().Error())
+	}
+	return nil
+}
+
+// report reports the number of active streams of the callee service. c.mu must be held.
+func (c *connStreams) report(service string) {
+	metrics.Gauge(strings.Join([]string{"trpc.ClientActiveStreams", service}, ".")).
+		Set(float64(c.services[service]))
+}
@@ -0,0 +1,87 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/errs"
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStreamLimited(t *testing.T) {
+	err := tooManyStreams(1)
+	require.Equal(t, errs.RetServerThrottled, errs.Code(err))
+	require.True(t, errors.Is(err, errTooManyStreams))
+
+	marked := &trpcpb.TrpcStreamInitMeta{RequestMeta: streamLimitMark()}
+	assert.True(t, isStreamLimited(marked, err))
+	// Refusals of rate limiters are not marked.
+	assert.False(t, isStreamLimited(&trpcpb.TrpcStreamInitMeta{},
+		errs.NewFrameError(errs.RetServerThrottled, "rate limited")))
+	assert.False(t, isStreamLimited(nil, err))
+	assert.False(t, isStreamLimited(marked, errs.NewFrameError(errs.RetServerSystemErr, "")))
+}
+
+func TestConnStreams(t *testing.T) {
+	t.Run("count streams", func(t *testing.T) {
+		c := newConnStreams()
+		c.add("conn1", "service")
+		c.add("conn1", "service")
+		c.add("conn2", "service")
+		assert.Equal(t, 2, c.conns["conn1"].n)
+		assert.Equal(t, 3, c.services["service"])
+
+		c.done("conn1", "service")
+		c.done("conn2", "service")
+		assert.Equal(t, 1, c.conns["conn1"].n)
+		assert.NotContains(t, c.conns, "conn2")
+		assert.Equal(t, 1, c.services["service"])
+	})
+	t.Run("wake up when stream finishes", func(t *testing.T) {
+		c := newConnStreams()
+		c.add("conn", "service")
+		waited := make(chan error, 1)
+		go func() { waited <- c.wait(context.Background(), "conn") }()
+		time.Sleep(10 * time.Millisecond)
+		c.done("conn", "service")
+		select {
+		case err := <-waited:
+			require.Nil(t, err)
+		case <-time.After(queuedStreamRetryInterval / 2):
+			t.Fatal("queued stream should be waked up when a stream of the connection finishes")
+		}
+	})
+	t.Run("retry after interval", func(t *testing.T) {
+		start := time.Now()
+		require.Nil(t, newConnStreams().wait(context.Background(), "conn"))
+		assert.GreaterOrEqual(t, time.Since(start), queuedStreamRetryInterval)
+	})
+	t.Run("context done", func(t *testing.T) {
+		c := newConnStreams()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Equal(t, errs.RetClientCanceled, errs.Code(c.wait(ctx, "conn")))
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		assert.Equal(t, errs.RetClientTimeout, errs.Code(c.wait(ctx, "conn")))
+	})
+}
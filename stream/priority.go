@@ -0,0 +1,53 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"strconv"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+// priorityKey is the key of the priority of a stream in trans info of the Init frame.
+// The server writes the frames of the stream by the priority.
+const priorityKey = "trpc-stream-priority"
+
+// withPriorityMeta sets the priority into the metadata of msg, the default priority is not sent.
+func withPriorityMeta(msg codec.Msg, p transport.StreamPriority) {
+	if p == transport.StreamPriorityNormal {
+		return
+	}
+	md := msg.ClientMetaData()
+	if md == nil {
+		md = codec.MetaData{}
+	}
+	md[priorityKey] = []byte(strconv.Itoa(int(p)))
+	msg.WithClientMetaData(md)
+}
+
+// priorityMeta takes the priority out of the metadata of msg.
+func priorityMeta(msg codec.Msg) transport.StreamPriority {
+	md := msg.ServerMetaData()
+	v, ok := md[priorityKey]
+	if !ok {
+		return transport.StreamPriorityNormal
+	}
+	delete(md, priorityKey)
+	p, err := strconv.ParseUint(string(v), 10, 8)
+	if err != nil {
+		return transport.StreamPriorityNormal
+	}
+	return transport.StreamPriority(p)
+}
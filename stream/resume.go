@@ -0,0 +1,268 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	"trpc.group/trpc-go/trpc-go/client"
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/internal/addrutil"
+)
+
+// A resumable server stream is identified by a token generated by the client, which is carried by
+// the trans info of Init frames, together with the sequence of the last message received by the client.
+// The server confirms that the stream is resumable by echoing the token in the Init frame responded.
+// The payload of each Data frame sent by the server is prefixed with its sequence, starting from 1,
+// before compression. When the connection breaks, the client sends the Init frame again on a new connection,
+// and the server replays the buffered messages after the sequence, then the stream goes on.
+const (
+	// resumeTokenKey is the key of the token of a resumable stream in trans info.
+	resumeTokenKey = "trpc-stream-resume-token"
+	// resumeSeqKey is the key of the sequence of the last message received by the client in trans info.
+	resumeSeqKey = "trpc-stream-resume-seq"
+	// seqLen is the length of the sequence prefixed to the payload of Data frames.
+	seqLen = 8
+)
+
+const (
+	// minResumeBackoff is the initial backoff between attempts of resuming a client stream.
+	minResumeBackoff = 10 * time.Millisecond
+	// maxResumeBackoff is the max backoff between attempts of resuming a client stream.
+	maxResumeBackoff = time.Second
+)
+
+var (
+	errInvalidSeq        = errors.New("stream: data frame of resumable stream is shorter than its sequence")
+	errResumeUnconfirmed = errors.New("stream: server doesn't confirm to resume the stream")
+)
+
+// newResumeToken returns a random token of a resumable stream.
+func newResumeToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// withSeq returns data prefixed with seq.
+func withSeq(seq uint64, data []byte) []byte {
+	b := make([]byte, seqLen+len(data))
+	binary.BigEndian.PutUint64(b, seq)
+	copy(b[seqLen:], data)
+	return b
+}
+
+// parseSeq returns the sequence prefixed to data.
+func parseSeq(data []byte) (uint64, error) {
+	if len(data) < seqLen {
+		return 0, errInvalidSeq
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// clientResume is the state of a resumable client stream.
+type clientResume struct {
+	token string
+	seq   uint64          // Sequence of the last message received, only accessed by the dispatch goroutine.
+	opt   []client.Option // Options to select the node again.
+}
+
+// withMeta sets the token and the sequence of the last message received into the metadata of msg.
+func (r *clientResume) withMeta(msg codec.Msg) {
+	md := msg.ClientMetaData()
+	if md == nil {
+		md = codec.MetaData{}
+	}
+	md[resumeTokenKey] = []byte(r.token)
+	md[resumeSeqKey] = []byte(strconv.FormatUint(r.seq, 10))
+	msg.WithClientMetaData(md)
+}
+
+// isConfirmed returns whether the server confirms that the stream is resumable by initMeta responded.
+func (r *clientResume) isConfirmed(initMeta *trpcpb.TrpcStreamInitMeta) bool {
+	return string(initMeta.GetRequestMeta().GetTransInfo()[resumeTokenKey]) == r.token
+}
+
+// resumeConfirmation returns the request meta which confirms that the stream of token is resumable.
+// The Init frame responded has no trans info of its own, so the request meta is borrowed.
+func resumeConfirmation(token string) *trpcpb.TrpcStreamInitRequestMeta {
+	return &trpcpb.TrpcStreamInitRequestMeta{TransInfo: map[string][]byte{resumeTokenKey: []byte(token)}}
+}
+
+// resumeMeta takes the token and the sequence of a resumable stream out of the metadata of msg.
+func resumeMeta(msg codec.Msg) (token string, seq uint64, ok bool) {
+	md := msg.ServerMetaData()
+	t, ok := md[resumeTokenKey]
+	if !ok {
+		return "", 0, false
+	}
+	s := md[resumeSeqKey]
+	delete(md, resumeTokenKey)
+	delete(md, resumeSeqKey)
+	seq, err := strconv.ParseUint(string(s), 10, 64)
+	if err != nil || len(t) == 0 {
+		return "", 0, false
+	}
+	return string(t), seq, true
+}
+
+// streamConn is the connection which a server stream is attached to.
+type streamConn struct {
+	laddr    net.Addr
+	raddr    net.Addr
+	streamID uint32
+}
+
+// connOf returns the connection where msg is received.
+func connOf(msg codec.Msg) streamConn {
+	return streamConn{laddr: msg.LocalAddr(), raddr: msg.RemoteAddr(), streamID: msg.StreamID()}
+}
+
+// apply sets the connection into msg to be sent.
+func (c streamConn) apply(msg codec.Msg) {
+	msg.WithLocalAddr(c.laddr)
+	msg.WithRemoteAddr(c.raddr)
+	msg.WithStreamID(c.streamID)
+}
+
+// key returns the key of the connection in streamDispatcher.
+func (c streamConn) key() string {
+	return addrutil.AddrToKey(c.laddr, c.raddr)
+}
+
+// serverResume is the state of a resumable server stream, which outlives the connections of the stream.
+type serverResume struct {
+	token  string
+	size   int
+	expire func(gen uint64) // Called when the stream isn't resumed in time, or some time after it's finished.
+	wait   time.Duration
+
+	mu       sync.Mutex
+	conn     streamConn
+	attached bool
+	seq      uint64                      // Sequence of the last message sent.
+	buf      [][]byte                    // Payloads of the last messages sent, the last one is of seq.
+	closed   *trpcpb.TrpcStreamCloseMeta // Close frame sent after the handler returns.
+	gen      uint64                      // Generation of timer, increased when the timer is replaced.
+	timer    *time.Timer
+}
+
+func newServerResume(token string, size int, wait time.Duration, conn streamConn) *serverResume {
+	return &serverResume{
+		token:    token,
+		size:     size,
+		wait:     wait,
+		conn:     conn,
+		attached: true,
+	}
+}
+
+// current returns the connection which the stream is attached to.
+func (r *serverResume) current() streamConn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.conn
+}
+
+// nextSeq returns the sequence of the next message.
+func (r *serverResume) nextSeq() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seq + 1
+}
+
+// push buffers the payload of the next message, and drops the oldest one if the buffer is full.
+// r.mu must be held.
+func (r *serverResume) push(data []byte) {
+	r.seq++
+	r.buf = append(r.buf, data)
+	if len(r.buf) > r.size {
+		r.buf[0] = nil
+		r.buf = r.buf[1:]
+	}
+}
+
+// replayable returns the buffered payloads of the messages after seq, and false if some of them are dropped.
+// r.mu must be held.
+func (r *serverResume) replayable(seq uint64) ([][]byte, bool) {
+	if seq > r.seq || r.seq-seq > uint64(len(r.buf)) {
+		return nil, false
+	}
+	return r.buf[uint64(len(r.buf))-(r.seq-seq):], true
+}
+
+// detach detaches the stream from its broken connection, and waits for it to be resumed.
+// r.mu must be held.
+func (r *serverResume) detach() {
+	if !r.attached {
+		return
+	}
+	r.attached = false
+	if r.closed == nil {
+		r.resetTimer()
+	}
+}
+
+// detachFrom detaches the stream if it's attached to the broken connection of key.
+func (r *serverResume) detachFrom(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn.key() == key {
+		r.detach()
+	}
+}
+
+// attach attaches the stream to a new connection.
+// r.mu must be held.
+func (r *serverResume) attach(conn streamConn) {
+	r.conn = conn
+	r.attached = true
+	if r.closed == nil && r.timer != nil {
+		r.timer.Stop()
+		r.gen++
+	}
+}
+
+// resetTimer replaces the timer by a new one which expires the stream after r.wait.
+// r.mu must be held.
+func (r *serverResume) resetTimer() {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.gen++
+	gen := r.gen
+	r.timer = time.AfterFunc(r.wait, func() { r.expire(gen) })
+}
+
+// isExpired returns whether the timer of gen is not replaced,
+// and the stream is finished or not resumed.
+func (r *serverResume) isExpired(gen uint64) (expired bool, finished bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gen != r.gen {
+		return false, false
+	}
+	return r.closed != nil || !r.attached, r.closed != nil
+}
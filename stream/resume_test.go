@@ -0,0 +1,122 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeq(t *testing.T) {
+	data := withSeq(42, []byte("hello"))
+	seq, err := parseSeq(data)
+	require.Nil(t, err)
+	assert.Equal(t, uint64(42), seq)
+	assert.Equal(t, []byte("hello"), data[seqLen:])
+
+	_, err = parseSeq([]byte("short"))
+	assert.Equal(t, errInvalidSeq, err)
+}
+
+func TestResumeMeta(t *testing.T) {
+	token, err := newResumeToken()
+	require.Nil(t, err)
+	r := &clientResume{token: token, seq: 7}
+
+	_, clientMsg := codec.WithNewMessage(context.Background())
+	r.withMeta(clientMsg)
+	assert.True(t, r.isConfirmed(&trpcpb.TrpcStreamInitMeta{RequestMeta: resumeConfirmation(token)}))
+	assert.False(t, r.isConfirmed(&trpcpb.TrpcStreamInitMeta{}))
+
+	_, serverMsg := codec.WithNewMessage(context.Background())
+	serverMsg.WithServerMetaData(clientMsg.ClientMetaData())
+	got, seq, ok := resumeMeta(serverMsg)
+	assert.True(t, ok)
+	assert.Equal(t, token, got)
+	assert.Equal(t, uint64(7), seq)
+	assert.Empty(t, serverMsg.ServerMetaData())
+
+	_, _, ok = resumeMeta(serverMsg)
+	assert.False(t, ok)
+}
+
+func TestServerResume(t *testing.T) {
+	laddr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}
+	conn := streamConn{laddr: laddr, raddr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9000}, streamID: 100}
+	t.Run("replay buffered messages", func(t *testing.T) {
+		r := newServerResume("token", 2, time.Second, conn)
+		assert.Equal(t, uint64(1), r.nextSeq())
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.push([]byte("1"))
+		r.push([]byte("2"))
+		r.push([]byte("3"))
+
+		replay, ok := r.replayable(3)
+		assert.True(t, ok)
+		assert.Empty(t, replay)
+		replay, ok = r.replayable(1)
+		assert.True(t, ok)
+		assert.Equal(t, [][]byte{[]byte("2"), []byte("3")}, replay)
+		// The first message is dropped.
+		_, ok = r.replayable(0)
+		assert.False(t, ok)
+		_, ok = r.replayable(4)
+		assert.False(t, ok)
+	})
+	t.Run("expire if not resumed", func(t *testing.T) {
+		expired := make(chan uint64, 1)
+		r := newServerResume("token", 2, 10*time.Millisecond, conn)
+		r.expire = func(gen uint64) { expired <- gen }
+
+		r.detachFrom("another connection")
+		r.mu.Lock()
+		assert.True(t, r.attached)
+		r.mu.Unlock()
+
+		r.detachFrom(conn.key())
+		gen := <-expired
+		expire, finished := r.isExpired(gen)
+		assert.True(t, expire)
+		assert.False(t, finished)
+	})
+	t.Run("not expire if resumed", func(t *testing.T) {
+		expired := make(chan uint64, 1)
+		r := newServerResume("token", 2, 10*time.Millisecond, conn)
+		r.expire = func(gen uint64) { expired <- gen }
+
+		r.detachFrom(conn.key())
+		r.mu.Lock()
+		gen := r.gen
+		r.attach(streamConn{laddr: laddr, raddr: conn.raddr, streamID: 101})
+		r.mu.Unlock()
+		expire, _ := r.isExpired(gen)
+		assert.False(t, expire)
+		select {
+		case <-expired:
+			t.Fatal("resumed stream should not expire")
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.Equal(t, uint32(101), r.current().streamID)
+	})
+}
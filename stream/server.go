@@ -16,7 +16,9 @@ package stream
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 	"sync"
 
 	"go.uber.org/atomic"
@@ -24,11 +26,14 @@ import (
 	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
 
 	trpc "trpc.group/trpc-go/trpc-go"
+	"trpc.group/trpc-go/trpc-go/client"
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
 	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
 	"trpc.group/trpc-go/trpc-go/internal/queue"
 	"trpc.group/trpc-go/trpc-go/log"
+	"trpc.group/trpc-go/trpc-go/metrics"
+	"trpc.group/trpc-go/trpc-go/rpcz"
 	"trpc.group/trpc-go/trpc-go/server"
 	"trpc.group/trpc-go/trpc-go/transport"
 )
@@ -43,8 +48,11 @@ type serverStream struct {
 	done      chan struct{}
 	err       atomic.Error // Carry the server tcp failure information.
 	once      sync.Once
-	rControl  *receiveControl // Receiver flow control.
-	sControl  *sendControl    // Sender flow control.
+	rControl  client.RecvControl // Receiver flow control.
+	sControl  *sendControl       // Sender flow control.
+	ka        *keepalive         // Keepalive pings and idle timeout.
+	resume    *serverResume      // State of resumable stream, nil if the stream is not resumable.
+	stats     *streamStats
 }
 
 // SendMsg is the API that users use to send streaming messages.
@@ -52,27 +60,26 @@ func (s *serverStream) SendMsg(m interface{}) error {
 	if err := s.err.Load(); err != nil {
 		return errs.WrapFrameError(err, errs.Code(err), "stream sending error")
 	}
-	msg := codec.Message(s.ctx)
-	ctx, newMsg := codec.WithCloneContextAndMessage(s.ctx)
-	defer codec.PutBackMessage(newMsg)
-	newMsg.WithLocalAddr(msg.LocalAddr())
-	newMsg.WithRemoteAddr(msg.RemoteAddr())
-	newMsg.WithCompressType(msg.CompressType())
-	newMsg.WithStreamID(s.streamID)
-	// Refer to the pb code generated by trpc.proto, common to each language, automatically generated code.
-	newMsg.WithFrameHead(newFrameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA, s.streamID))
-
 	var (
 		err           error
 		reqBodyBuffer []byte
 	)
-	serializationType, compressType := s.serializationAndCompressType(newMsg)
-	if icodec.IsValidSerializationType(serializationType) {
+	serializationType, compressType := s.serializationAndCompressType(codec.Message(s.ctx))
+	if c, ok := m.(*correlated); ok {
+		reqBodyBuffer, err = c.marshal(serializationType)
+		if err != nil {
+			return errs.NewFrameError(errs.RetServerEncodeFail, "server codec Marshal: "+err.Error())
+		}
+	} else if icodec.IsValidSerializationType(serializationType) {
 		reqBodyBuffer, err = codec.Marshal(serializationType, m)
 		if err != nil {
 			return errs.NewFrameError(errs.RetServerEncodeFail, "server codec Marshal: "+err.Error())
 		}
 	}
+	// The sequence is prefixed before compression, so the client gets it after decompression.
+	if s.resume != nil {
+		reqBodyBuffer = withSeq(s.resume.nextSeq(), reqBodyBuffer)
+	}
 
 	// compress
 	if icodec.IsValidCompressType(compressType) && compressType != codec.CompressTypeNoop {
@@ -89,14 +96,90 @@ func (s *serverStream) SendMsg(m interface{}) error {
 		}
 	}
 
-	// encode the entire request.
-	reqBuffer, err := s.opts.Codec.Encode(newMsg, reqBodyBuffer)
+	if s.resume != nil {
+		if err := s.sendResumable(reqBodyBuffer); err != nil {
+			return err
+		}
+		s.stats.sent(len(reqBodyBuffer))
+		return nil
+	}
+	ctx, msg := s.newFrameMsg(s.conn(), trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA)
+	defer codec.PutBackMessage(msg)
+	reqBuffer, err := s.encodeData(msg, reqBodyBuffer)
 	if err != nil {
-		return errs.NewFrameError(errs.RetServerEncodeFail, "server codec Encode: "+err.Error())
+		return err
 	}
-
 	// initiate a backend network request.
-	return s.opts.StreamTransport.Send(ctx, reqBuffer)
+	if err := s.send(ctx, reqBuffer); err != nil {
+		return err
+	}
+	s.stats.sent(len(reqBodyBuffer))
+	return nil
+}
+
+// sendResumable buffers the payload of a Data frame for replaying, and sends it if the stream
+// is attached to a connection. Failing to send detaches the stream, which is not an error of the handler.
+func (s *serverStream) sendResumable(body []byte) error {
+	r := s.resume
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx, msg := s.newFrameMsg(r.conn, trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA)
+	defer codec.PutBackMessage(msg)
+	buf, err := s.encodeData(msg, body)
+	if err != nil {
+		return err
+	}
+	r.push(body)
+	if !r.attached {
+		return nil
+	}
+	if err := s.send(ctx, buf); err != nil {
+		log.Trace("stream: server send on resumable stream fail", err)
+		r.detach()
+	}
+	return nil
+}
+
+// encodeData encodes body as the Data frame of msg.
+func (s *serverStream) encodeData(msg codec.Msg, body []byte) ([]byte, error) {
+	msg.WithCompressType(codec.Message(s.ctx).CompressType())
+	buf, err := s.opts.Codec.Encode(msg, body)
+	if err != nil {
+		return nil, errs.NewFrameError(errs.RetServerEncodeFail, "server codec Encode: "+err.Error())
+	}
+	if s.opts.MaxResponseSize > 0 && len(buf) > s.opts.MaxResponseSize {
+		return nil, errs.NewFrameError(errs.RetServerMsgExceedLimit, fmt.Sprintf(
+			"server codec Encode: message size %d exceeds the max response size %d",
+			len(buf), s.opts.MaxResponseSize))
+	}
+	return buf, nil
+}
+
+// newFrameMsg returns the message of a frame of type t to be sent on conn.
+func (s *serverStream) newFrameMsg(conn streamConn, t trpcpb.TrpcStreamFrameType) (context.Context, codec.Msg) {
+	ctx, msg := codec.WithCloneContextAndMessage(s.ctx)
+	conn.apply(msg)
+	// Refer to the pb code generated by trpc.proto, common to each language, automatically generated code.
+	msg.WithFrameHead(newFrameHead(t, conn.streamID))
+	return ctx, msg
+}
+
+// send sends the encoded frame buf with ctx returned by newFrameMsg.
+func (s *serverStream) send(ctx context.Context, buf []byte) error {
+	if err := s.opts.StreamTransport.Send(ctx, buf); err != nil {
+		return err
+	}
+	s.ka.sent()
+	return nil
+}
+
+// conn returns the connection which the stream is attached to.
+func (s *serverStream) conn() streamConn {
+	if s.resume != nil {
+		return s.resume.current()
+	}
+	msg := codec.Message(s.ctx)
+	return streamConn{laddr: msg.LocalAddr(), raddr: msg.RemoteAddr(), streamID: s.streamID}
 }
 
 func (s *serverStream) newFrameHead(streamFrameType trpcpb.TrpcStreamFrameType) *trpc.FrameHead {
@@ -154,6 +237,12 @@ func (s *serverStream) decompressAndUnmarshal(data []byte, m interface{}) error
 		}
 	}
 
+	if c, ok := m.(*correlated); ok {
+		if err := c.unmarshal(serializationType, data); err != nil {
+			return errs.NewFrameError(errs.RetClientDecodeFail, "server codec Unmarshal: "+err.Error())
+		}
+		return nil
+	}
 	// Deserialize the binary body to a specific body structure.
 	if icodec.IsValidSerializationType(serializationType) {
 		if err := codec.Unmarshal(serializationType, data, m); err != nil {
@@ -167,23 +256,68 @@ func (s *serverStream) decompressAndUnmarshal(data []byte, m interface{}) error
 // which is divided into TRPC_STREAM_CLOSE and TRPC_STREAM_RESET.
 // message represents the returned message, where error messages can be logged.
 func (s *serverStream) CloseSend(closeType, ret int32, message string) error {
-	oldMsg := codec.Message(s.ctx)
-	ctx, msg := codec.WithCloneContextAndMessage(s.ctx)
-	defer codec.PutBackMessage(msg)
-	msg.WithLocalAddr(oldMsg.LocalAddr())
-	msg.WithRemoteAddr(oldMsg.RemoteAddr())
-	msg.WithFrameHead(newFrameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_CLOSE, s.streamID))
-	msg.WithStreamFrame(&trpcpb.TrpcStreamCloseMeta{
+	closeMeta := &trpcpb.TrpcStreamCloseMeta{
 		CloseType: closeType,
 		Ret:       ret,
 		Msg:       []byte(message),
-	})
+	}
+	if s.resume != nil {
+		return s.closeResumable(closeMeta)
+	}
+	return s.sendClose(s.conn(), closeMeta)
+}
+
+// closeResumable keeps the Close frame for replaying, and sends it if the stream is attached to a connection.
+// The stream can still be resumed until the wait time elapses.
+func (s *serverStream) closeResumable(closeMeta *trpcpb.TrpcStreamCloseMeta) error {
+	r := s.resume
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = closeMeta
+	r.resetTimer()
+	if !r.attached {
+		return nil
+	}
+	if err := s.sendClose(r.conn, closeMeta); err != nil {
+		log.Trace("stream: server close resumable stream fail", err)
+		r.detach()
+	}
+	return nil
+}
 
+// sendClose sends the Close frame on conn.
+func (s *serverStream) sendClose(conn streamConn, closeMeta *trpcpb.TrpcStreamCloseMeta) error {
+	ctx, msg := s.newFrameMsg(conn, trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_CLOSE)
+	defer codec.PutBackMessage(msg)
+	msg.WithStreamFrame(closeMeta)
 	rspBuffer, err := s.opts.Codec.Encode(msg, nil)
 	if err != nil {
 		return err
 	}
-	return s.opts.StreamTransport.Send(ctx, rspBuffer)
+	if err := s.send(ctx, rspBuffer); err != nil {
+		return err
+	}
+	s.stats.event(eventSendClose)
+	return nil
+}
+
+// sendInit sends the Init frame responding to the client on conn, err is the error of the response.
+func (s *serverStream) sendInit(conn streamConn, initMeta *trpcpb.TrpcStreamInitMeta, rspErr error) error {
+	ctx, msg := s.newFrameMsg(conn, trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_INIT)
+	defer codec.PutBackMessage(msg)
+	msg.WithStreamFrame(initMeta)
+	if rspErr != nil {
+		msg.WithServerRspErr(rspErr)
+	}
+	rspBuffer, err := s.opts.Codec.Encode(msg, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.opts.StreamTransport.Send(ctx, rspBuffer); err != nil {
+		return err
+	}
+	s.stats.event(eventSendInit)
+	return nil
 }
 
 // newServerStream creates a new server stream, which can send and receive streaming messages.
@@ -198,21 +332,55 @@ func newServerStream(ctx context.Context, streamID uint32, opts *server.Options)
 	return s
 }
 
+// onIdle closes the stream which has received nothing for the max idle time.
+func (s *serverStream) onIdle() {
+	s.err.Store(errs.NewFrameError(errs.RetServerStreamReadTimeout,
+		fmt.Sprintf("server stream received nothing for %v", s.opts.StreamMaxIdleTime)))
+	s.once.Do(func() { close(s.done) })
+}
+
+// feedback sends feedback frame, a zero increment is a keepalive ping.
 func (s *serverStream) feedback(w uint32) error {
-	oldMsg := codec.Message(s.ctx)
-	ctx, msg := codec.WithCloneContextAndMessage(s.ctx)
+	ctx, msg := s.newFrameMsg(s.conn(), trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_FEEDBACK)
 	defer codec.PutBackMessage(msg)
-	msg.WithLocalAddr(oldMsg.LocalAddr())
-	msg.WithRemoteAddr(oldMsg.RemoteAddr())
-	msg.WithStreamID(s.streamID)
-	msg.WithFrameHead(newFrameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_FEEDBACK, s.streamID))
 	msg.WithStreamFrame(&trpcpb.TrpcStreamFeedBackMeta{WindowSizeIncrement: w})
 
 	feedbackBuf, err := s.opts.Codec.Encode(msg, nil)
 	if err != nil {
 		return err
 	}
-	return s.opts.StreamTransport.Send(ctx, feedbackBuf)
+	if err := s.send(ctx, feedbackBuf); err != nil {
+		if s.resume != nil {
+			// The window is reset when the stream is resumed.
+			log.Trace("stream: server feedback on resumable stream fail", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// rejectInit responds to the Init frame received on conn with rspErr.
+func (s *serverStream) rejectInit(conn streamConn, rspErr error) ([]byte, error) {
+	initMeta := &trpcpb.TrpcStreamInitMeta{}
+	if errors.Is(rspErr, errTooManyStreams) {
+		initMeta.RequestMeta = streamLimitMark()
+	}
+	if err := s.sendInit(conn, initMeta, rspErr); err != nil {
+		return nil, err
+	}
+	return nil, errs.ErrServerNoResponse
+}
+
+// replay sends the buffered payload of a Data frame on conn.
+func (s *serverStream) replay(conn streamConn, body []byte) error {
+	ctx, msg := s.newFrameMsg(conn, trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_DATA)
+	defer codec.PutBackMessage(msg)
+	buf, err := s.encodeData(msg, body)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, buf)
 }
 
 // Context returns the context of the serverStream structure.
@@ -227,7 +395,10 @@ type streamDispatcher struct {
 	//  => stream ID
 	//    => serverStream
 	addrToServerStream map[string]map[uint32]*serverStream
-	opts               *server.Options
+	// token => resumable serverStream
+	resumables map[string]*serverStream
+	streams    int // number of streams in addrToServerStream
+	opts       *server.Options
 }
 
 // DefaultStreamDispatcher is the default implementation of the trpc dispatcher,
@@ -238,21 +409,43 @@ var DefaultStreamDispatcher = NewStreamDispatcher()
 func NewStreamDispatcher() server.StreamHandle {
 	return &streamDispatcher{
 		addrToServerStream: make(map[string]map[uint32]*serverStream),
+		resumables:         make(map[string]*serverStream),
 	}
 }
 
-// storeServerStream msg contains the socket address of the client connection,
+// admitServerStream stores the serverStream by the socket address of the client connection,
 // there are multiple streams under each socket address, and map it to serverStream
 // again according to the id of the stream.
-func (sd *streamDispatcher) storeServerStream(addr string, streamID uint32, ss *serverStream) {
+// The serverStream is not stored if the connection has reached the max concurrent streams.
+func (sd *streamDispatcher) admitServerStream(addr string, streamID uint32, ss *serverStream) bool {
 	sd.m.Lock()
 	defer sd.m.Unlock()
-	if addrToStreamID, ok := sd.addrToServerStream[addr]; !ok {
+	if max := sd.opts.MaxConcurrentStreams; max > 0 && len(sd.addrToServerStream[addr]) >= max {
+		return false
+	}
+	sd.store(addr, streamID, ss)
+	return true
+}
+
+// store stores the serverStream. sd.m must be held.
+func (sd *streamDispatcher) store(addr string, streamID uint32, ss *serverStream) {
+	addrToStreamID, ok := sd.addrToServerStream[addr]
+	if !ok {
 		// Does not exist, indicating that a new connection is coming, re-create the structure.
-		sd.addrToServerStream[addr] = map[uint32]*serverStream{streamID: ss}
-	} else {
-		addrToStreamID[streamID] = ss
+		addrToStreamID = make(map[uint32]*serverStream)
+		sd.addrToServerStream[addr] = addrToStreamID
+	}
+	if _, ok := addrToStreamID[streamID]; !ok {
+		sd.streams++
+		sd.reportStreams()
 	}
+	addrToStreamID[streamID] = ss
+}
+
+// reportStreams reports the number of active streams of the service. sd.m must be held.
+func (sd *streamDispatcher) reportStreams() {
+	metrics.Gauge(strings.Join([]string{"trpc.ServerActiveStreams", sd.opts.ServiceName}, ".")).
+		Set(float64(sd.streams))
 }
 
 // deleteServerStream deletes the serverStream from cache.
@@ -262,6 +455,8 @@ func (sd *streamDispatcher) deleteServerStream(addr string, streamID uint32) {
 	if addrToStreamID, ok := sd.addrToServerStream[addr]; ok {
 		if _, ok = addrToStreamID[streamID]; ok {
 			delete(addrToStreamID, streamID)
+			sd.streams--
+			sd.reportStreams()
 		}
 		if len(addrToStreamID) == 0 {
 			delete(sd.addrToServerStream, addr)
@@ -286,6 +481,40 @@ func (sd *streamDispatcher) loadServerStream(addr string, streamID uint32) (*ser
 	return ss, nil
 }
 
+// storeResumable stores the resumable serverStream by its token.
+func (sd *streamDispatcher) storeResumable(ss *serverStream) {
+	sd.m.Lock()
+	defer sd.m.Unlock()
+	sd.resumables[ss.resume.token] = ss
+}
+
+// loadResumable loads the resumable serverStream by its token.
+func (sd *streamDispatcher) loadResumable(token string) *serverStream {
+	sd.m.RLock()
+	defer sd.m.RUnlock()
+	return sd.resumables[token]
+}
+
+// expireResumable deletes the resumable serverStream which is finished or not resumed in time,
+// and closes the stream if it's not finished.
+func (sd *streamDispatcher) expireResumable(ss *serverStream, gen uint64) {
+	expired, finished := ss.resume.isExpired(gen)
+	if !expired {
+		return
+	}
+	sd.m.Lock()
+	if sd.resumables[ss.resume.token] == ss {
+		delete(sd.resumables, ss.resume.token)
+	}
+	sd.m.Unlock()
+	if finished {
+		return
+	}
+	ss.err.Store(errs.NewFrameError(errs.RetServerSystemErr,
+		fmt.Sprintf("server stream is not resumed in %v", ss.resume.wait)))
+	ss.once.Do(func() { close(ss.done) })
+}
+
 // Init initializes some settings of dispatcher.
 func (sd *streamDispatcher) Init(opts *server.Options) error {
 	sd.opts = opts
@@ -304,6 +533,11 @@ func (sd *streamDispatcher) Init(opts *server.Options) error {
 func (sd *streamDispatcher) startStreamHandler(addr string, streamID uint32,
 	ss *serverStream, si *server.StreamServerInfo, sh server.StreamHandler) {
 	defer func() {
+		if ss.resume != nil {
+			// The stream may have been resumed on another connection.
+			conn := ss.resume.current()
+			addr, streamID = conn.key(), conn.streamID
+		}
 		sd.deleteServerStream(addr, streamID)
 		ss.once.Do(func() { close(ss.done) })
 	}()
@@ -316,21 +550,29 @@ func (sd *streamDispatcher) startStreamHandler(addr string, streamID uint32,
 		err = sh(ss)
 	}
 
-	var frameworkError *errs.Error
+	var (
+		frameworkError *errs.Error
+		closeErr       error
+	)
 	switch {
 	case errors.As(err, &frameworkError):
-		err = ss.CloseSend(int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_RESET), int32(frameworkError.Code), frameworkError.Msg)
+		closeErr = ss.CloseSend(int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_RESET),
+			int32(frameworkError.Code), frameworkError.Msg)
 	case err != nil:
 		// return business error.
-		err = ss.CloseSend(int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_RESET), 0, err.Error())
+		closeErr = ss.CloseSend(int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_RESET), 0, err.Error())
 	default:
 		// Stream is normally closed.
-		err = ss.CloseSend(int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_CLOSE), 0, "")
+		closeErr = ss.CloseSend(int32(trpcpb.TrpcStreamCloseType_TRPC_STREAM_CLOSE), 0, "")
 	}
-	if err != nil {
-		ss.err.Store(err)
-		log.Trace(closeSendFail, err)
+	if closeErr != nil {
+		ss.err.Store(closeErr)
+		log.Trace(closeSendFail, closeErr)
+		if err == nil {
+			err = closeErr
+		}
 	}
+	ss.stats.finish(codec.Message(ss.ctx).ServerRPCName(), err)
 }
 
 // setSendControl obtained from the init frame.
@@ -349,6 +591,7 @@ func (s *serverStream) setSendControl(msg codec.Msg) (uint32, error) {
 		return initMeta.InitWindowSize, nil
 	}
 	s.sControl = newSendControl(initMeta.InitWindowSize, s.done)
+	s.sControl.stats = s.stats
 	return initMeta.InitWindowSize, nil
 }
 
@@ -361,11 +604,35 @@ func (sd *streamDispatcher) handleInit(ctx context.Context,
 	ctx, msg := codec.WithNewMessage(ctx)
 	codec.CopyMsg(msg, oldMsg)
 
+	// All the frames of the stream, including the Init frame responded, are written by its priority.
+	ctx = transport.ContextWithStreamPriority(ctx, priorityMeta(msg))
 	streamID := msg.StreamID()
-	ss := newServerStream(ctx, streamID, sd.opts)
+	token, seq, resumable := resumeMeta(msg)
+	resumable = resumable && sd.opts.StreamResumeSize > 0
+	if resumable {
+		if rs := sd.loadResumable(token); rs != nil {
+			return sd.handleResume(ctx, msg, rs, seq, si)
+		}
+	}
+	// The span of the stream is a root span, since the span of the Init frame ends before the stream.
+	span, end := rpcz.GlobalRPCZ.NewChild("server-stream")
+	ss := newServerStream(rpcz.ContextWithSpan(ctx, span), streamID, sd.opts)
+	ss.stats = newServerStreamStats(span, end)
+	ss.stats.event(eventRecvInit)
+	if resumable && seq > 0 {
+		return sd.rejectInit(ss, msg, errs.NewFrameError(errs.RetServerSystemErr, "resumable stream is expired"))
+	}
 	w := getWindowSize(sd.opts.MaxWindowSize)
-	ss.rControl = newReceiveControl(w, ss.feedback)
-	sd.storeServerStream(addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr()), streamID, ss)
+	ss.rControl = newRecvControl(w, sd.opts.MaxAdaptiveWindow, ss.feedback)
+	ss.ka = newKeepalive(sd.opts.StreamKeepalive, sd.opts.StreamMaxIdleTime,
+		func() error { return ss.feedback(0) }, ss.onIdle, ss.done)
+	if resumable {
+		ss.resume = newServerResume(token, sd.opts.StreamResumeSize, sd.opts.StreamResumeWait, connOf(msg))
+		ss.resume.expire = func(gen uint64) { sd.expireResumable(ss, gen) }
+	}
+	if !sd.admitServerStream(addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr()), streamID, ss) {
+		return sd.rejectInit(ss, msg, tooManyStreams(sd.opts.MaxConcurrentStreams))
+	}
 
 	cw, err := ss.setSendControl(msg)
 	if err != nil {
@@ -373,13 +640,6 @@ func (sd *streamDispatcher) handleInit(ctx context.Context,
 	}
 
 	// send init response packet.
-	newCtx, newMsg := codec.WithCloneContextAndMessage(ctx)
-	defer codec.PutBackMessage(newMsg)
-	newMsg.WithLocalAddr(msg.LocalAddr())
-	newMsg.WithRemoteAddr(msg.RemoteAddr())
-	newMsg.WithStreamID(streamID)
-	newMsg.WithFrameHead(newFrameHead(trpcpb.TrpcStreamFrameType_TRPC_STREAM_FRAME_INIT, ss.streamID))
-
 	initMeta := &trpcpb.TrpcStreamInitMeta{ResponseMeta: &trpcpb.TrpcStreamInitResponseMeta{}}
 	// If the client does not set it, the server should not set it to prevent incompatibility.
 	if cw == 0 {
@@ -387,27 +647,120 @@ func (sd *streamDispatcher) handleInit(ctx context.Context,
 	} else {
 		initMeta.InitWindowSize = w
 	}
-	newMsg.WithStreamFrame(initMeta)
-
-	rspBuffer, err := ss.opts.Codec.Encode(newMsg, nil)
-	if err != nil {
-		return nil, err
+	if resumable {
+		initMeta.RequestMeta = resumeConfirmation(token)
 	}
-	if err := ss.opts.StreamTransport.Send(newCtx, rspBuffer); err != nil {
+	if err := ss.sendInit(connOf(msg), initMeta, nil); err != nil {
 		return nil, err
 	}
+	if resumable {
+		sd.storeResumable(ss)
+	}
+	ss.ka.start()
 
 	// Initiate a goroutine to execute specific business logic.
 	go sd.startStreamHandler(addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr()), streamID, ss, si, sh)
 	return nil, errs.ErrServerNoResponse
 }
 
+// rejectInit rejects the new stream ss with rspErr in response to the Init frame msg.
+func (sd *streamDispatcher) rejectInit(ss *serverStream, msg codec.Msg, rspErr error) ([]byte, error) {
+	rsp, err := ss.rejectInit(connOf(msg), rspErr)
+	ss.stats.finish(msg.ServerRPCName(), rspErr)
+	return rsp, err
+}
+
+// handleResume resumes the stream on the connection of the Init frame msg,
+// and replays the messages after seq, which is the sequence of the last message received by the client.
+// Like a new stream, the resumed one must be admitted by the stream filters and the max concurrent streams.
+func (sd *streamDispatcher) handleResume(ctx context.Context, msg codec.Msg, ss *serverStream, seq uint64,
+	si *server.StreamServerInfo) ([]byte, error) {
+	conn := connOf(msg)
+	// The token is not bound to the connection, so it must at least be resumed by the same RPC.
+	if initMsg := codec.Message(ss.ctx); msg.CalleeServiceName() != initMsg.CalleeServiceName() ||
+		msg.ServerRPCName() != initMsg.ServerRPCName() {
+		return ss.rejectInit(conn, errs.NewFrameError(errs.RetServerSystemErr,
+			fmt.Sprintf("resumable stream is of %s %s", initMsg.CalleeServiceName(), initMsg.ServerRPCName())))
+	}
+	if ss.opts.StreamFilters != nil {
+		// The filters see the metadata of the Init frame resuming the stream, but not its messages.
+		err := ss.opts.StreamFilters.Filter(&resumingStream{Stream: ss, ctx: ctx}, si,
+			func(server.Stream) error { return nil })
+		if err != nil {
+			return ss.rejectInit(conn, err)
+		}
+	}
+	r := ss.resume
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	replay, ok := r.replayable(seq)
+	if !ok {
+		return ss.rejectInit(conn, errs.NewFrameError(errs.RetServerSystemErr,
+			fmt.Sprintf("messages after %d of resumable stream are not buffered", seq)))
+	}
+	// The client may find the connection broken before the server.
+	sd.deleteServerStream(r.conn.key(), r.conn.streamID)
+	if !sd.admitServerStream(conn.key(), conn.streamID, ss) {
+		return ss.rejectInit(conn, tooManyStreams(sd.opts.MaxConcurrentStreams))
+	}
+	ss.ka.received()
+	ss.stats.event(eventRecvInit)
+	r.attach(conn)
+
+	initMeta := &trpcpb.TrpcStreamInitMeta{
+		RequestMeta:  resumeConfirmation(r.token),
+		ResponseMeta: &trpcpb.TrpcStreamInitResponseMeta{},
+	}
+	clientInitMeta, _ := msg.StreamFrame().(*trpcpb.TrpcStreamInitMeta)
+	if cw := clientInitMeta.GetInitWindowSize(); cw != 0 && ss.sControl != nil {
+		// Messages lost with the broken connection are replayed regardless of the window.
+		ss.sControl.reset(cw)
+		initMeta.InitWindowSize = getWindowSize(sd.opts.MaxWindowSize)
+	}
+	if err := ss.sendInit(conn, initMeta, nil); err != nil {
+		r.detach()
+		return nil, err
+	}
+	for _, body := range replay {
+		if err := ss.replay(conn, body); err != nil {
+			log.Trace("stream: server replay resumable stream fail", err)
+			r.detach()
+			return nil, errs.ErrServerNoResponse
+		}
+	}
+	if r.closed != nil {
+		if err := ss.sendClose(conn, r.closed); err != nil {
+			log.Trace("stream: server close resumable stream fail", err)
+			r.detach()
+		}
+		sd.deleteServerStream(conn.key(), conn.streamID)
+	}
+	return nil, errs.ErrServerNoResponse
+}
+
+// resumingStream is the stream passed to the stream filters when it's resumed,
+// whose context carries the message of the Init frame resuming it.
+type resumingStream struct {
+	server.Stream
+	ctx context.Context
+}
+
+// Context returns the context of the Init frame resuming the stream.
+func (s *resumingStream) Context() context.Context {
+	return s.ctx
+}
+
 // handleData handles data messages.
 func (sd *streamDispatcher) handleData(msg codec.Msg, req []byte) ([]byte, error) {
 	ss, err := sd.loadServerStream(addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr()), msg.StreamID())
 	if err != nil {
 		return nil, err
 	}
+	ss.ka.received()
+	ss.stats.received(len(req))
+	if o, ok := ss.rControl.(arrivalObserver); ok {
+		o.onArrive(uint32(len(req)))
+	}
 	ss.recvQueue.Put(&response{data: req})
 	return nil, errs.ErrServerNoResponse
 }
@@ -422,6 +775,8 @@ func (sd *streamDispatcher) handleClose(msg codec.Msg) ([]byte, error) {
 		log.Trace("handleClose loadServerStream fail", err)
 		return nil, errs.ErrServerNoResponse
 	}
+	ss.ka.received()
+	ss.stats.event(eventRecvClose)
 	// is Reset message.
 	if msg.ServerRspErr() != nil {
 		ss.recvQueue.Put(&response{err: msg.ServerRspErr()})
@@ -434,20 +789,31 @@ func (sd *streamDispatcher) handleClose(msg codec.Msg) ([]byte, error) {
 
 // handleError When the connection is wrong, handle the error.
 func (sd *streamDispatcher) handleError(msg codec.Msg) ([]byte, error) {
-	sd.m.Lock()
-	defer sd.m.Unlock()
-
 	addr := addrutil.AddrToKey(msg.LocalAddr(), msg.RemoteAddr())
+	sd.m.Lock()
 	addrToStream, ok := sd.addrToServerStream[addr]
 	if !ok {
+		sd.m.Unlock()
 		return nil, errs.NewFrameError(errs.RetServerSystemErr, noSuchAddr)
 	}
-	for streamID, ss := range addrToStream {
+	var resumables []*serverStream
+	for _, ss := range addrToStream {
+		if ss.resume != nil {
+			resumables = append(resumables, ss)
+			continue
+		}
 		ss.err.Store(msg.ServerRspErr())
 		ss.once.Do(func() { close(ss.done) })
-		delete(addrToStream, streamID)
 	}
 	delete(sd.addrToServerStream, addr)
+	sd.streams -= len(addrToStream)
+	sd.reportStreams()
+	sd.m.Unlock()
+	// Resumable streams wait to be resumed instead. They are detached asynchronously, since the transport
+	// may report the broken connection while a message is being sent with the state of the stream locked.
+	for _, ss := range resumables {
+		go ss.resume.detachFrom(addr)
+	}
 	return nil, errs.ErrServerNoResponse
 }
 
@@ -474,6 +840,7 @@ func (sd *streamDispatcher) handleFeedback(msg codec.Msg) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	ss.ka.received()
 	fb, ok := msg.StreamFrame().(*trpcpb.TrpcStreamFeedBackMeta)
 	if !ok {
 		return nil, errors.New(streamFrameInvalid)
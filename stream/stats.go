@@ -0,0 +1,164 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/internal/report"
+	"trpc.group/trpc-go/trpc-go/metrics"
+	"trpc.group/trpc-go/trpc-go/rpcz"
+)
+
+// Events of the rpcz span of a stream.
+const (
+	eventSendInit         = "SendInit"
+	eventRecvInit         = "RecvInit"
+	eventSendClose        = "SendClose"
+	eventRecvClose        = "RecvClose"
+	eventFlowControlStall = "FlowControlStall"
+)
+
+// maxStallEvents is the max number of flow-control stall events of a span,
+// so that a long-lived stream doesn't grow its span without limit. Further stalls are only counted.
+const maxStallEvents = 16
+
+// streamStats records the messages of a stream into its rpcz span, which ends with the stream,
+// and into the histograms of messages.
+type streamStats struct {
+	// mu guards span against events after it ends, since an ended span may be reused by rpcz.
+	mu    sync.RWMutex
+	span  rpcz.Span
+	end   rpcz.Ender
+	ended bool
+
+	sendSize     metrics.IHistogram
+	recvSize     metrics.IHistogram
+	recvInterval metrics.IHistogram
+	blockedTime  metrics.IHistogram
+
+	sentMsgs  uint64
+	sentBytes uint64
+	recvMsgs  uint64
+	recvBytes uint64
+	stalls    uint64
+	blocked   int64 // Total time blocked on the window, in nanoseconds.
+	lastRecv  int64 // Unix nano of the last message received.
+}
+
+func newClientStreamStats(span rpcz.Span, end rpcz.Ender) *streamStats {
+	return &streamStats{
+		span:         span,
+		end:          end,
+		sendSize:     report.ClientStreamSendMsgSize,
+		recvSize:     report.ClientStreamRecvMsgSize,
+		recvInterval: report.ClientStreamRecvInterval,
+		blockedTime:  report.ClientStreamWindowBlockedTime,
+	}
+}
+
+func newServerStreamStats(span rpcz.Span, end rpcz.Ender) *streamStats {
+	return &streamStats{
+		span:         span,
+		end:          end,
+		sendSize:     report.ServerStreamSendMsgSize,
+		recvSize:     report.ServerStreamRecvMsgSize,
+		recvInterval: report.ServerStreamRecvInterval,
+		blockedTime:  report.ServerStreamWindowBlockedTime,
+	}
+}
+
+// event adds an event to the span unless it has ended.
+func (s *streamStats) event(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.ended {
+		s.span.AddEvent(name)
+	}
+}
+
+// sent records a message of n bytes sent.
+func (s *streamStats) sent(n int) {
+	if s == nil {
+		return
+	}
+	atomic.AddUint64(&s.sentMsgs, 1)
+	atomic.AddUint64(&s.sentBytes, uint64(n))
+	s.sendSize.AddSample(float64(n))
+}
+
+// received records a message of n bytes received.
+func (s *streamStats) received(n int) {
+	if s == nil {
+		return
+	}
+	atomic.AddUint64(&s.recvMsgs, 1)
+	atomic.AddUint64(&s.recvBytes, uint64(n))
+	s.recvSize.AddSample(float64(n))
+	now := time.Now().UnixNano()
+	if last := atomic.SwapInt64(&s.lastRecv, now); last != 0 {
+		s.recvInterval.AddSample(float64(time.Duration(now-last) / time.Microsecond))
+	}
+}
+
+// stalled records that sending a message is blocked on the window.
+func (s *streamStats) stalled() {
+	if s == nil {
+		return
+	}
+	if atomic.AddUint64(&s.stalls, 1) <= maxStallEvents {
+		s.event(eventFlowControlStall)
+	}
+}
+
+// unblocked records that sending a message has been blocked on the window for d.
+func (s *streamStats) unblocked(d time.Duration) {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.blocked, int64(d))
+	s.blockedTime.AddSample(float64(d / time.Microsecond))
+}
+
+// finish sets the statistics and err of the stream into the span, and ends the span.
+// A stream finished by io.EOF has no error.
+func (s *streamStats) finish(rpcName string, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	if err == io.EOF {
+		err = nil
+	}
+	s.span.SetAttribute(rpcz.TRPCAttributeRPCName, rpcName)
+	s.span.SetAttribute(rpcz.TRPCAttributeError, err)
+	s.span.SetAttribute(rpcz.TRPCAttributeSentMessages, atomic.LoadUint64(&s.sentMsgs))
+	s.span.SetAttribute(rpcz.TRPCAttributeSentBytes, atomic.LoadUint64(&s.sentBytes))
+	s.span.SetAttribute(rpcz.TRPCAttributeReceivedMessages, atomic.LoadUint64(&s.recvMsgs))
+	s.span.SetAttribute(rpcz.TRPCAttributeReceivedBytes, atomic.LoadUint64(&s.recvBytes))
+	s.span.SetAttribute(rpcz.TRPCAttributeFlowControlStalls, atomic.LoadUint64(&s.stalls))
+	s.span.SetAttribute(rpcz.TRPCAttributeFlowControlBlockedTime, time.Duration(atomic.LoadInt64(&s.blocked)))
+	s.end.End()
+}
@@ -0,0 +1,91 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package stream
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/rpcz"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamStats(t *testing.T) {
+	r := rpcz.NewRPCZ(&rpcz.Config{Fraction: 1, Capacity: 10})
+	span, end := r.NewChild("client-stream")
+	s := newClientStreamStats(span, end)
+
+	s.event(eventSendInit)
+	s.event(eventRecvInit)
+	s.sent(10)
+	s.sent(20)
+	s.received(5)
+	for i := 0; i < maxStallEvents+1; i++ {
+		s.stalled()
+	}
+	s.unblocked(time.Millisecond)
+	s.event(eventRecvClose)
+	s.finish("/trpc.test.helloworld.Greeter/SayHello", io.EOF)
+	// Nothing is recorded after the span ends.
+	s.event(eventSendClose)
+	s.finish("/trpc.test.helloworld.Greeter/SayHello", io.ErrUnexpectedEOF)
+
+	got, ok := r.Query(span.ID())
+	require.True(t, ok)
+	attributes := make(map[string]interface{})
+	for _, a := range got.Attributes {
+		attributes[a.Name] = a.Value
+	}
+	assert.Equal(t, "/trpc.test.helloworld.Greeter/SayHello", attributes[rpcz.TRPCAttributeRPCName])
+	assert.Nil(t, attributes[rpcz.TRPCAttributeError])
+	assert.Equal(t, uint64(2), attributes[rpcz.TRPCAttributeSentMessages])
+	assert.Equal(t, uint64(30), attributes[rpcz.TRPCAttributeSentBytes])
+	assert.Equal(t, uint64(1), attributes[rpcz.TRPCAttributeReceivedMessages])
+	assert.Equal(t, uint64(5), attributes[rpcz.TRPCAttributeReceivedBytes])
+	assert.Equal(t, uint64(maxStallEvents+1), attributes[rpcz.TRPCAttributeFlowControlStalls])
+	assert.Equal(t, time.Millisecond, attributes[rpcz.TRPCAttributeFlowControlBlockedTime])
+
+	events := make(map[string]int)
+	for _, e := range got.Events {
+		events[e.Name]++
+	}
+	assert.Equal(t, map[string]int{
+		eventSendInit:         1,
+		eventRecvInit:         1,
+		eventFlowControlStall: maxStallEvents,
+		eventRecvClose:        1,
+	}, events)
+}
+
+func TestSendControlStats(t *testing.T) {
+	r := rpcz.NewRPCZ(&rpcz.Config{Fraction: 1, Capacity: 10})
+	span, end := r.NewChild("server-stream")
+	s := newServerStreamStats(span, end)
+
+	sc := newSendControl(1)
+	sc.stats = s
+	require.Nil(t, sc.GetWindow(1))
+	assert.Equal(t, uint64(0), s.stalls)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		sc.UpdateWindow(1)
+	}()
+	require.Nil(t, sc.GetWindow(1))
+	assert.Equal(t, uint64(1), s.stalls)
+	assert.GreaterOrEqual(t, time.Duration(s.blocked), 10*time.Millisecond)
+}
@@ -16,9 +16,12 @@ package test
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
+	"os"
 	"testing"
 	"time"
 
@@ -32,6 +35,8 @@ import (
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/server"
 	testpb "trpc.group/trpc-go/trpc-go/test/protocols"
+	"trpc.group/trpc-go/trpc-go/transport"
+	"trpc.group/trpc-go/trpc-go/transport/tnet"
 	trpcpb "trpc.group/trpc/trpc-protocol/pb/go/trpc"
 )
 
@@ -154,6 +159,69 @@ func (s *TestSuite) TestAttachment() {
 	})
 }
 
+func (s *TestSuite) TestStreamingAttachment() {
+	const size = 20 << 20 // larger than trpc.DefaultMaxFrameSize.
+	newPayload := func(seed int64) io.Reader {
+		return io.LimitReader(rand.New(rand.NewSource(seed)), size)
+	}
+	sum := func(t *testing.T, r io.Reader) []byte {
+		h := sha256.New()
+		n, err := io.Copy(h, r)
+		require.Nil(t, err)
+		require.Equal(t, int64(size), n)
+		return h.Sum(nil)
+	}
+	reqSum, rspSum := sum(s.T(), newPayload(1)), sum(s.T(), newPayload(2))
+
+	for _, tt := range []struct {
+		name            string
+		serverTransport string
+		clientTransport transport.ClientTransport
+	}{
+		{"tnet", "tnet", tnet.DefaultClientTransport},
+		{"go-net", "default", transport.DefaultClientTransport},
+	} {
+		s.T().Run(tt.name, func(t *testing.T) {
+			s.tRPCEnv.server.transport = tt.serverTransport
+			serverDir, clientDir := t.TempDir(), t.TempDir()
+			s.startServer(&TRPCService{EmptyCallF: func(ctx context.Context, in *testpb.Empty) (*testpb.Empty, error) {
+				a := server.GetAttachment(trpc.Message(ctx))
+				if _, ok := a.Request().(io.ReadCloser); !ok {
+					return nil, errs.New(1, "request attachment is not spilled")
+				}
+				h := sha256.New()
+				if n, err := io.Copy(h, a.Request()); err != nil || n != size || !bytes.Equal(reqSum, h.Sum(nil)) {
+					return nil, errs.New(2, "request attachment mismatch")
+				}
+				a.SetStreamingResponse(newPayload(2), size)
+				return &testpb.Empty{}, nil
+			}}, server.WithAttachmentSpill(1<<20, serverDir))
+			defer s.closeServer(nil)
+
+			c := s.newTRPCClient(client.WithAttachmentSpill(1<<20, clientDir), client.WithTransport(tt.clientTransport))
+			a := client.NewStreamingAttachment(newPayload(1), size)
+			_, err := c.EmptyCall(trpc.BackgroundContext(), &testpb.Empty{}, client.WithAttachment(a))
+			require.Nil(t, err)
+			require.Equal(t, rspSum, sum(t, a.Response()))
+
+			for _, dir := range []string{serverDir, clientDir} {
+				entries, err := os.ReadDir(dir)
+				require.Nil(t, err)
+				require.Empty(t, entries, "spill files are removed")
+			}
+
+			t.Run("ShortAttachment", func(t *testing.T) {
+				a := client.NewStreamingAttachment(io.LimitReader(newPayload(1), size/2), size)
+				_, err := c.EmptyCall(trpc.BackgroundContext(), &testpb.Empty{}, client.WithAttachment(a))
+				require.Equal(t, errs.RetClientNetErr, errs.Code(err))
+				// the broken connection is not reused.
+				_, err = c.UnaryCall(trpc.BackgroundContext(), s.defaultSimpleRequest)
+				require.Nil(t, err)
+			})
+		})
+	}
+}
+
 // 这里通过测试用例来展示其他可行方法，并讨论各种方法的优点和缺点，包括以下方法：
 // 1. trans_info 字段透传
 // 2. client 指定空序列化方式
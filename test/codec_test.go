@@ -14,13 +14,23 @@
 package test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+
 	"github.com/stretchr/testify/require"
 
 	trpc "trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/client"
 	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/filter"
+	"trpc.group/trpc-go/trpc-go/internal/checksum"
 	"trpc.group/trpc-go/trpc-go/server"
+	testpb "trpc.group/trpc-go/trpc-go/test/protocols"
 )
 
 func (s *TestSuite) TestCompressOkSetByConfig() {
@@ -99,6 +109,249 @@ func (s *TestSuite) TestWithCompressTypeOption() {
 	}
 }
 
+func (s *TestSuite) TestCompressMinSizeOption() {
+	const minSize = 1024
+	var reqCompressType, rspCompressType int
+	s.startServer(
+		&TRPCService{},
+		server.WithCompressMinSize(minSize),
+		server.WithFilter(func(ctx context.Context, req interface{}, next filter.ServerHandleFunc) (interface{}, error) {
+			reqCompressType = codec.Message(ctx).CompressType()
+			return next(ctx, req)
+		}),
+	)
+
+	c := s.newTRPCClient()
+	for _, tt := range []struct {
+		name            string
+		reqSize         int
+		rspSize         int32
+		reqCompressType int
+		rspCompressType int
+	}{
+		{"small request and large response", 10, 4 * minSize, codec.CompressTypeNoop, codec.CompressTypeGzip},
+		{"large request and small response", 4 * minSize, 10, codec.CompressTypeGzip, codec.CompressTypeNoop},
+		{"large request and large response", 4 * minSize, 4 * minSize, codec.CompressTypeGzip, codec.CompressTypeGzip},
+	} {
+		s.Run(tt.name, func() {
+			req := &testpb.SimpleRequest{
+				ResponseType: testpb.PayloadType_COMPRESSIBLE,
+				ResponseSize: tt.rspSize,
+				Payload:      &testpb.Payload{Body: make([]byte, tt.reqSize)},
+			}
+			rsp, err := c.UnaryCall(
+				trpc.BackgroundContext(),
+				req,
+				client.WithCompressType(codec.CompressTypeGzip),
+				client.WithCompressMinSize(minSize),
+				client.WithFilter(func(ctx context.Context, req, rsp interface{}, next filter.ClientHandleFunc) error {
+					err := next(ctx, req, rsp)
+					rspCompressType = codec.Message(ctx).CompressType()
+					return err
+				}),
+			)
+			require.Nil(s.T(), err)
+			require.Len(s.T(), rsp.Payload.Body, int(tt.rspSize))
+			require.Equal(s.T(), tt.reqCompressType, reqCompressType, "content encoding of the request frame")
+			require.Equal(s.T(), tt.rspCompressType, rspCompressType, "content encoding of the response frame")
+		})
+	}
+}
+
+func (s *TestSuite) TestZstdDictionaryOption() {
+	dict, err := os.ReadFile("../../codec/testdata/zstd.dict")
+	require.Nil(s.T(), err)
+	id, err := codec.ZstdDictionaryID(dict)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), codec.RegisterZstdDictionary(id, dict))
+	s.startServer(&TRPCService{}, server.WithZstdDictionary(id))
+
+	c := s.newTRPCClient()
+	rsp, err := c.UnaryCall(
+		trpc.BackgroundContext(),
+		s.defaultSimpleRequest,
+		client.WithCompressType(codec.CompressTypeZstd),
+		client.WithZstdDictionary(id),
+	)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), rsp.Payload.Body, int(s.defaultSimpleRequest.ResponseSize))
+
+	_, err = c.UnaryCall(
+		trpc.BackgroundContext(),
+		s.defaultSimpleRequest,
+		client.WithCompressType(codec.CompressTypeZstd),
+		client.WithZstdDictionary(id+1),
+	)
+	require.Equal(s.T(), errs.RetClientEncodeFail, errs.Code(err), "dictionary not registered")
+}
+
+func (s *TestSuite) TestChecksumOption() {
+	for _, serverChecksum := range []bool{false, true} {
+		s.startServer(&TRPCService{}, server.WithChecksum(serverChecksum))
+		c := s.newTRPCClient()
+		for _, clientChecksum := range []bool{false, true} {
+			rsp, err := c.UnaryCall(
+				trpc.BackgroundContext(),
+				s.defaultSimpleRequest,
+				client.WithChecksum(clientChecksum),
+			)
+			require.Nil(s.T(), err)
+			require.Len(s.T(), rsp.Payload.Body, int(s.defaultSimpleRequest.ResponseSize))
+		}
+		s.closeServer(nil)
+	}
+}
+
+func (s *TestSuite) TestChecksumOptionOfCallsInHandler() {
+	// The handler calls the service itself without checksums, so the checksum of the inbound request
+	// mustn't be carried by the outbound one.
+	var innerChecksum bool
+	s.startServer(&TRPCService{UnaryCallF: func(ctx context.Context, in *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+		if in.GetUsername() == "inner" {
+			innerChecksum = checksum.ServerEnabled(codec.Message(ctx))
+			return &testpb.SimpleResponse{Username: in.GetUsername()}, nil
+		}
+		return s.newTRPCClient().UnaryCall(ctx, &testpb.SimpleRequest{Username: "inner"})
+	}})
+	defer s.closeServer(nil)
+
+	rsp, err := s.newTRPCClient().UnaryCall(
+		trpc.BackgroundContext(),
+		&testpb.SimpleRequest{Username: "outer"},
+		client.WithChecksum(true),
+	)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), "inner", rsp.GetUsername())
+	require.False(s.T(), innerChecksum)
+}
+
+func (s *TestSuite) TestEncryptionOption() {
+	oldKey := &encryption.Key{ID: "old", Algorithm: encryption.AESGCM, Secret: bytes.Repeat([]byte{1}, 32)}
+	newKey := &encryption.Key{ID: "new", Algorithm: encryption.ChaCha20Poly1305, Secret: bytes.Repeat([]byte{2}, 32)}
+	serverKeys, err := encryption.NewStaticKeyProvider(oldKey, newKey)
+	require.Nil(s.T(), err)
+	clientKeys, err := encryption.NewStaticKeyProvider(newKey)
+	require.Nil(s.T(), err)
+
+	s.Run("Unary", func() {
+		s.startServer(&TRPCService{}, server.WithEncryption(serverKeys))
+		defer s.closeServer(nil)
+		c := s.newTRPCClient()
+		for _, opts := range [][]client.Option{nil, {client.WithEncryption(clientKeys)}} {
+			rsp, err := c.UnaryCall(trpc.BackgroundContext(), s.defaultSimpleRequest, opts...)
+			require.Nil(s.T(), err)
+			require.Len(s.T(), rsp.Payload.Body, int(s.defaultSimpleRequest.ResponseSize))
+		}
+	})
+	s.Run("UnaryServerWithoutEncryption", func() {
+		s.startServer(&TRPCService{})
+		defer s.closeServer(nil)
+		_, err := s.newTRPCClient().UnaryCall(
+			trpc.BackgroundContext(),
+			s.defaultSimpleRequest,
+			client.WithEncryption(clientKeys),
+		)
+		require.Equal(s.T(), errs.RetServerDecodeFail, errs.Code(err))
+	})
+	s.Run("Streaming", func() {
+		s.startServer(&StreamingService{}, server.WithEncryption(serverKeys))
+		defer s.closeServer(nil)
+		cs, err := s.newStreamingClient(client.WithEncryption(clientKeys)).FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		const sendNum = 3
+		req := &testpb.StreamingOutputCallRequest{
+			ResponseType:       testpb.PayloadType_COMPRESSIBLE,
+			ResponseParameters: []*testpb.ResponseParameters{{Size: 10}},
+		}
+		for i := 0; i < sendNum; i++ {
+			require.Nil(s.T(), cs.Send(req))
+		}
+		require.Nil(s.T(), cs.CloseSend())
+		for i := 0; i < sendNum; i++ {
+			rsp, err := cs.Recv()
+			require.Nil(s.T(), err)
+			require.Len(s.T(), rsp.GetPayload().GetBody(), 10)
+		}
+		_, err = cs.Recv()
+		require.Equal(s.T(), io.EOF, err)
+	})
+}
+
+func (s *TestSuite) TestMaxRequestResponseSize() {
+	payload, err := newPayload(testpb.PayloadType_COMPRESSIBLE, 2048)
+	require.Nil(s.T(), err)
+	largeRequest := &testpb.SimpleRequest{
+		ResponseType: testpb.PayloadType_COMPRESSIBLE,
+		ResponseSize: 10,
+		Payload:      payload,
+	}
+	largeResponseRequest := &testpb.SimpleRequest{
+		ResponseType: testpb.PayloadType_COMPRESSIBLE,
+		ResponseSize: 2048,
+	}
+
+	s.Run("ServerMaxRequestSize", func() {
+		s.startServer(&TRPCService{}, server.WithMaxRequestSize(1024))
+		defer s.closeServer(nil)
+		c := s.newTRPCClient()
+		_, err := c.UnaryCall(trpc.BackgroundContext(), largeRequest)
+		require.Equal(s.T(), errs.RetServerMsgExceedLimit, errs.Code(err))
+		// the connection is still available.
+		_, err = c.UnaryCall(trpc.BackgroundContext(), s.defaultSimpleRequest)
+		require.Nil(s.T(), err)
+	})
+	s.Run("ServerMaxResponseSize", func() {
+		s.startServer(&TRPCService{}, server.WithMaxResponseSize(1024))
+		defer s.closeServer(nil)
+		c := s.newTRPCClient()
+		_, err := c.UnaryCall(trpc.BackgroundContext(), largeResponseRequest)
+		require.Equal(s.T(), errs.RetServerMsgExceedLimit, errs.Code(err))
+		_, err = c.UnaryCall(trpc.BackgroundContext(), s.defaultSimpleRequest)
+		require.Nil(s.T(), err)
+	})
+	s.Run("ClientMaxRequestSize", func() {
+		s.startServer(&TRPCService{})
+		defer s.closeServer(nil)
+		_, err := s.newTRPCClient(client.WithMaxRequestSize(1024)).UnaryCall(trpc.BackgroundContext(), largeRequest)
+		require.Equal(s.T(), errs.RetClientEncodeFail, errs.Code(err))
+	})
+	s.Run("ClientMaxResponseSize", func() {
+		s.startServer(&TRPCService{})
+		defer s.closeServer(nil)
+		c := s.newTRPCClient(client.WithMaxResponseSize(1024))
+		_, err := c.UnaryCall(trpc.BackgroundContext(), largeResponseRequest)
+		require.Equal(s.T(), errs.RetClientMsgExceedLimit, errs.Code(err))
+		_, err = c.UnaryCall(trpc.BackgroundContext(), s.defaultSimpleRequest)
+		require.Nil(s.T(), err)
+	})
+	s.Run("ClientMaxResponseSizeOfSharedAddress", func() {
+		s.startServer(&TRPCService{})
+		defer s.closeServer(nil)
+		limited := s.newTRPCClient(client.WithMaxResponseSize(1024))
+		unlimited := s.newTRPCClient()
+		for i := 0; i < 2; i++ {
+			// the connections created by either client are not shared with the other.
+			_, err := limited.UnaryCall(trpc.BackgroundContext(), largeResponseRequest)
+			require.Equal(s.T(), errs.RetClientMsgExceedLimit, errs.Code(err))
+			_, err = unlimited.UnaryCall(trpc.BackgroundContext(), largeResponseRequest)
+			require.Nil(s.T(), err)
+		}
+	})
+	s.Run("Streaming", func() {
+		s.startServer(&StreamingService{}, server.WithMaxRequestSize(1024))
+		defer s.closeServer(nil)
+		cs, err := s.newStreamingClient().FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		require.Nil(s.T(), cs.Send(&testpb.StreamingOutputCallRequest{
+			ResponseType: testpb.PayloadType_COMPRESSIBLE,
+			Payload:      payload,
+		}))
+		_, err = cs.Recv()
+		// the error of the reset frame is the cause of the stream error.
+		require.Equal(s.T(), errs.RetServerMsgExceedLimit, errs.Code(errors.Unwrap(err)))
+	})
+}
+
 func (s *TestSuite) TestClientCompressorNotRegistered() {
 	s.startServer(&TRPCService{})
 	s.Run("PositiveCompressType", func() {
@@ -14,8 +14,13 @@
 package test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"sync"
+	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
@@ -25,8 +30,13 @@ import (
 	trpc "trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/client"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/pool/multiplexed"
+	"trpc.group/trpc-go/trpc-go/rpcz"
 	"trpc.group/trpc-go/trpc-go/server"
+	"trpc.group/trpc-go/trpc-go/stream"
 	testpb "trpc.group/trpc-go/trpc-go/test/protocols"
+	"trpc.group/trpc-go/trpc-go/transport"
+	"trpc.group/trpc-go/trpc-go/transport/websocket"
 )
 
 func (s *TestSuite) TestBidirectionalStreamingServerCrashWhenReceivingMessage() {
@@ -296,6 +306,509 @@ func (s *TestSuite) TestFlowControlWindowSizeUpdateOk() {
 	require.Nil(s.T(), cs.CloseSend())
 }
 
+func (s *TestSuite) TestFlowControlAdaptiveWindowOk() {
+	const (
+		initWindowSize = 65535
+		maxWindowSize  = 16 << 20
+	)
+	s.startServer(&StreamingService{}, server.WithAdaptiveWindow(maxWindowSize))
+
+	c := s.newStreamingClient(client.WithAdaptiveWindow(maxWindowSize))
+	cs, err := c.FullDuplexCall(trpc.BackgroundContext())
+	require.Nil(s.T(), err)
+
+	for i := 1; i <= 20; i++ {
+		payload, err := newPayload(testpb.PayloadType_COMPRESSIBLE, int32(initWindowSize*i))
+		require.Nil(s.T(), err)
+		require.Nil(s.T(), cs.Send(&testpb.StreamingOutputCallRequest{
+			ResponseType:       testpb.PayloadType_COMPRESSIBLE,
+			ResponseParameters: []*testpb.ResponseParameters{{Size: int32(initWindowSize * i)}},
+			Payload:            payload,
+		}))
+		rsp, err := cs.Recv()
+		require.Nil(s.T(), err)
+		require.Len(s.T(), rsp.GetPayload().GetBody(), initWindowSize*i)
+	}
+	require.Nil(s.T(), cs.CloseSend())
+}
+
+func (s *TestSuite) TestStreamMaxIdleTime() {
+	const maxIdleTime = 200 * time.Millisecond
+	s.Run("ServerMaxIdleTime", func() {
+		serverErr := make(chan error, 1)
+		s.startServer(&StreamingService{
+			FullDuplexCallF: func(stream testpb.TestStreaming_FullDuplexCallServer) error {
+				_, err := stream.Recv()
+				serverErr <- err
+				return err
+			},
+		}, server.WithStreamMaxIdleTime(maxIdleTime))
+		defer s.closeServer(nil)
+
+		cs, err := s.newStreamingClient().FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		require.Equal(s.T(), errs.RetServerStreamReadTimeout, errs.Code(<-serverErr))
+		_, err = cs.Recv()
+		require.Equal(s.T(), errs.RetClientStreamReadEnd, errs.Code(err))
+		require.Equal(s.T(), errs.RetServerStreamReadTimeout, errs.Code(errors.Unwrap(err)))
+	})
+	s.Run("ClientMaxIdleTime", func() {
+		serverErr := make(chan error, 1)
+		s.startServer(&StreamingService{
+			FullDuplexCallF: func(stream testpb.TestStreaming_FullDuplexCallServer) error {
+				_, err := stream.Recv()
+				serverErr <- err
+				return err
+			},
+		})
+		defer s.closeServer(nil)
+
+		cs, err := s.newStreamingClient(client.WithStreamMaxIdleTime(maxIdleTime)).
+			FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		_, err = cs.Recv()
+		require.Equal(s.T(), errs.RetClientStreamReadTimeout, errs.Code(err))
+		require.Equal(s.T(), errs.RetClientStreamReadTimeout, errs.Code(<-serverErr))
+	})
+	s.Run("KeepaliveOk", func() {
+		s.startServer(&StreamingService{},
+			server.WithStreamKeepalive(maxIdleTime/4), server.WithStreamMaxIdleTime(maxIdleTime))
+		defer s.closeServer(nil)
+
+		cs, err := s.newStreamingClient(
+			client.WithStreamKeepalive(maxIdleTime/4), client.WithStreamMaxIdleTime(maxIdleTime),
+		).FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		time.Sleep(3 * maxIdleTime)
+
+		payload, err := newPayload(testpb.PayloadType_COMPRESSIBLE, 1)
+		require.Nil(s.T(), err)
+		require.Nil(s.T(), cs.Send(&testpb.StreamingOutputCallRequest{
+			ResponseType:       testpb.PayloadType_COMPRESSIBLE,
+			ResponseParameters: []*testpb.ResponseParameters{{Size: 1}},
+			Payload:            payload,
+		}))
+		rsp, err := cs.Recv()
+		require.Nil(s.T(), err)
+		require.Len(s.T(), rsp.GetPayload().GetBody(), 1)
+		require.Nil(s.T(), cs.CloseSend())
+		_, err = cs.Recv()
+		require.Equal(s.T(), io.EOF, err)
+	})
+}
+
+func (s *TestSuite) TestStreamPriority() {
+	priorities := make(chan transport.StreamPriority, 2)
+	s.startServer(&StreamingService{
+		FullDuplexCallF: func(stream testpb.TestStreaming_FullDuplexCallServer) error {
+			priorities <- transport.StreamPriorityFromContext(stream.Context())
+			for {
+				req, err := stream.Recv()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: req.GetPayload()}); err != nil {
+					return err
+				}
+			}
+		},
+	})
+	defer s.closeServer(nil)
+
+	c := s.newStreamingClient()
+	for _, p := range []transport.StreamPriority{transport.StreamPriorityHigh, transport.StreamPriorityNormal} {
+		cs, err := c.FullDuplexCall(trpc.BackgroundContext(), client.WithStreamPriority(p))
+		require.Nil(s.T(), err)
+		payload := &testpb.Payload{Body: []byte("hello")}
+		require.Nil(s.T(), cs.Send(&testpb.StreamingOutputCallRequest{Payload: payload}))
+		rsp, err := cs.Recv()
+		require.Nil(s.T(), err)
+		require.Equal(s.T(), payload.GetBody(), rsp.GetPayload().GetBody())
+		require.Nil(s.T(), cs.CloseSend())
+		_, err = cs.Recv()
+		require.Equal(s.T(), io.EOF, err)
+		require.Equal(s.T(), p, <-priorities)
+	}
+}
+
+func (s *TestSuite) TestStreamCorrelatedCalls() {
+	type (
+		req = testpb.StreamingOutputCallRequest
+		rsp = testpb.StreamingOutputCallResponse
+	)
+	canceled := make(chan struct{})
+	mux := stream.NewCallMux()
+	stream.HandleCall(mux, "Echo", func(ctx context.Context, r *req) (*rsp, error) {
+		switch body := string(r.GetPayload().GetBody()); body {
+		case "fail":
+			return nil, errs.New(10001, "failed")
+		case "block":
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		default:
+			// Later requests are replied earlier.
+			if ps := r.GetResponseParameters(); len(ps) > 0 {
+				time.Sleep(ps[0].GetInterval().AsDuration())
+			}
+			return &rsp{Payload: &testpb.Payload{Body: []byte(body)}}, nil
+		}
+	})
+	stream.HandleCall(mux, "Size", func(ctx context.Context, r *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+		if r.GetResponseSize() < 0 {
+			return nil, errs.NewFrameError(errs.RetServerValidateFail, "negative size")
+		}
+		return &testpb.SimpleResponse{Payload: &testpb.Payload{Body: make([]byte, r.GetResponseSize())}}, nil
+	})
+	s.startServer(&StreamingService{
+		FullDuplexCallF: func(ss testpb.TestStreaming_FullDuplexCallServer) error {
+			return mux.Serve(ss)
+		},
+	})
+	defer s.closeServer(nil)
+
+	cs, err := s.newStreamingClient().FullDuplexCall(trpc.BackgroundContext())
+	require.Nil(s.T(), err)
+	caller := stream.NewCaller(cs)
+	echo := func(ctx context.Context, r *req) (*rsp, error) {
+		return stream.Call[req, rsp](ctx, caller, "Echo", r)
+	}
+
+	s.Run("ConcurrentCalls", func() {
+		const n = 10
+		var eg errgroup.Group
+		for i := 0; i < n; i++ {
+			i := i
+			eg.Go(func() error {
+				body := fmt.Sprintf("hello-%d", i)
+				r, err := echo(context.Background(), &req{
+					ResponseParameters: []*testpb.ResponseParameters{
+						{Interval: durationpb.New(time.Duration(n-i) * 10 * time.Millisecond)},
+					},
+					Payload: &testpb.Payload{Body: []byte(body)},
+				})
+				if err != nil {
+					return err
+				}
+				if got := string(r.GetPayload().GetBody()); got != body {
+					return fmt.Errorf("got reply %s of request %s", got, body)
+				}
+				return nil
+			})
+		}
+		require.Nil(s.T(), eg.Wait())
+	})
+	s.Run("Methods", func() {
+		r, err := stream.Call[testpb.SimpleRequest, testpb.SimpleResponse](
+			context.Background(), caller, "Size", &testpb.SimpleRequest{ResponseSize: 3})
+		require.Nil(s.T(), err)
+		require.Len(s.T(), r.GetPayload().GetBody(), 3)
+
+		_, err = stream.Call[req, rsp](context.Background(), caller, "Unknown", &req{})
+		require.Equal(s.T(), errs.RetServerNoFunc, errs.Code(err))
+	})
+	s.Run("HandlerError", func() {
+		_, err := echo(context.Background(), &req{Payload: &testpb.Payload{Body: []byte("fail")}})
+		require.Equal(s.T(), 10001, int(errs.Code(err)))
+		require.Equal(s.T(), "failed", errs.Msg(err))
+		var e *errs.Error
+		require.True(s.T(), errors.As(err, &e))
+		require.Equal(s.T(), errs.ErrorTypeBusiness, e.Type)
+
+		_, err = stream.Call[testpb.SimpleRequest, testpb.SimpleResponse](
+			context.Background(), caller, "Size", &testpb.SimpleRequest{ResponseSize: -1})
+		require.Equal(s.T(), errs.RetServerValidateFail, errs.Code(err))
+		require.True(s.T(), errors.As(err, &e))
+		require.Equal(s.T(), errs.ErrorTypeCalleeFramework, e.Type)
+	})
+	s.Run("Timeout", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := echo(ctx, &req{Payload: &testpb.Payload{Body: []byte("block")}})
+		require.Equal(s.T(), errs.RetClientTimeout, errs.Code(err))
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			require.FailNow(s.T(), "context of the handler is not canceled after the call timeout")
+		}
+		// The stream goes on after the call fails.
+		r, err := echo(context.Background(), &req{Payload: &testpb.Payload{Body: []byte("hello")}})
+		require.Nil(s.T(), err)
+		require.Equal(s.T(), "hello", string(r.GetPayload().GetBody()))
+	})
+	s.Run("Close", func() {
+		require.Nil(s.T(), caller.Close())
+		_, err := echo(context.Background(), &req{Payload: &testpb.Payload{Body: []byte("hello")}})
+		require.Equal(s.T(), errs.RetClientStreamReadEnd, errs.Code(err))
+	})
+}
+
+func (s *TestSuite) TestResumableServerStream() {
+	const messages = 10
+	broken := make(chan struct{})
+	s.startServer(&StreamingService{
+		StreamingOutputCallF: func(
+			args *testpb.StreamingOutputCallRequest,
+			stream testpb.TestStreaming_StreamingOutputCallServer,
+		) error {
+			for i := 1; i <= messages; i++ {
+				if i == messages/2+1 {
+					<-broken
+				}
+				payload, err := newPayload(testpb.PayloadType_COMPRESSIBLE, int32(i))
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: payload}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, server.WithResumableStream(messages, time.Second))
+	defer s.closeServer(nil)
+
+	p := newBreakableProxy(s.T(), s.listener.Addr().String())
+	defer p.close()
+	c := testpb.NewTestStreamingClientProxy(
+		client.WithTarget("ip://"+p.addr()),
+		client.WithTimeout(time.Second),
+		client.WithResumableStream(time.Second),
+	)
+	cs, err := c.StreamingOutputCall(trpc.BackgroundContext(), &testpb.StreamingOutputCallRequest{})
+	require.Nil(s.T(), err)
+	for i := 1; i <= messages; i++ {
+		if i == messages/2+1 {
+			p.breakConns()
+			close(broken)
+		}
+		rsp, err := cs.Recv()
+		require.Nil(s.T(), err)
+		require.Len(s.T(), rsp.GetPayload().GetBody(), i)
+	}
+	_, err = cs.Recv()
+	require.Equal(s.T(), io.EOF, err)
+}
+
+func (s *TestSuite) TestResumableServerStreamFilter() {
+	var inits int
+	broken := make(chan struct{})
+	s.startServer(&StreamingService{
+		StreamingOutputCallF: func(
+			args *testpb.StreamingOutputCallRequest,
+			stream testpb.TestStreaming_StreamingOutputCallServer,
+		) error {
+			for i := 1; i <= 2; i++ {
+				if i == 2 {
+					<-broken
+				}
+				payload, err := newPayload(testpb.PayloadType_COMPRESSIBLE, int32(i))
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: payload}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+		server.WithResumableStream(2, time.Second),
+		server.WithStreamFilter(func(ss server.Stream, _ *server.StreamServerInfo, next server.StreamHandler) error {
+			// Filters run sequentially for the stream, as it's resumed after the connection is broken.
+			if inits++; inits > 1 {
+				return errs.NewFrameError(errs.RetServerAuthFail, "resuming is not allowed")
+			}
+			return next(ss)
+		}),
+	)
+	defer s.closeServer(nil)
+
+	p := newBreakableProxy(s.T(), s.listener.Addr().String())
+	defer p.close()
+	c := testpb.NewTestStreamingClientProxy(
+		client.WithTarget("ip://"+p.addr()),
+		client.WithTimeout(time.Second),
+		client.WithResumableStream(time.Second),
+	)
+	cs, err := c.StreamingOutputCall(trpc.BackgroundContext(), &testpb.StreamingOutputCallRequest{})
+	require.Nil(s.T(), err)
+	rsp, err := cs.Recv()
+	require.Nil(s.T(), err)
+	require.Len(s.T(), rsp.GetPayload().GetBody(), 1)
+
+	p.breakConns()
+	close(broken)
+	_, err = cs.Recv()
+	require.Equal(s.T(), errs.RetServerAuthFail, errs.Code(errors.Unwrap(err)), "resuming is refused by the stream filter")
+}
+
+// breakableProxy forwards TCP connections to the server, and breaks them on demand.
+type breakableProxy struct {
+	l      net.Listener
+	target string
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newBreakableProxy(t *testing.T, target string) *breakableProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	p := &breakableProxy{l: l, target: target}
+	go p.serve()
+	return p
+}
+
+func (p *breakableProxy) serve() {
+	for {
+		conn, err := p.l.Accept()
+		if err != nil {
+			return
+		}
+		upstream, err := net.Dial("tcp", p.target)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		p.mu.Lock()
+		p.conns = append(p.conns, conn, upstream)
+		p.mu.Unlock()
+		go io.Copy(upstream, conn)
+		go io.Copy(conn, upstream)
+	}
+}
+
+func (p *breakableProxy) addr() string {
+	return p.l.Addr().String()
+}
+
+func (p *breakableProxy) breakConns() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+func (p *breakableProxy) close() {
+	p.l.Close()
+	p.breakConns()
+}
+
+func (s *TestSuite) TestMaxConcurrentStreams() {
+	s.startServer(&StreamingService{}, server.WithMaxConcurrentStreams(1))
+	defer s.closeServer(nil)
+
+	// Streams share a single connection unless a new one is established for the stream limit.
+	newClient := func(opts ...client.Option) testpb.TestStreamingClientProxy {
+		return s.newStreamingClient(append([]client.Option{
+			client.WithMultiplexedPool(multiplexed.New(multiplexed.WithConnectNumber(1))),
+		}, opts...)...)
+	}
+	closeStream := func(cs testpb.TestStreaming_FullDuplexCallClient) {
+		require.Nil(s.T(), cs.CloseSend())
+		_, err := cs.Recv()
+		require.Equal(s.T(), io.EOF, err)
+	}
+	s.Run("Fail", func() {
+		c := newClient()
+		cs, err := c.FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		defer closeStream(cs)
+		_, err = c.FullDuplexCall(trpc.BackgroundContext())
+		require.Equal(s.T(), errs.RetServerThrottled, errs.Code(err))
+	})
+	s.Run("NewConn", func() {
+		c := newClient(client.WithStreamLimitPolicy(client.StreamLimitNewConn))
+		cs1, err := c.FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		cs2, err := c.FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		closeStream(cs2)
+		closeStream(cs1)
+	})
+	s.Run("Queue", func() {
+		c := newClient(client.WithStreamLimitPolicy(client.StreamLimitQueue))
+		cs1, err := c.FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		opened := make(chan testpb.TestStreaming_FullDuplexCallClient, 1)
+		go func() {
+			cs2, err := c.FullDuplexCall(trpc.BackgroundContext())
+			if err != nil {
+				s.T().Error(err)
+			}
+			opened <- cs2
+		}()
+		select {
+		case <-opened:
+			s.T().Fatal("stream should be queued until the other stream of the connection finishes")
+		case <-time.After(200 * time.Millisecond):
+		}
+		closeStream(cs1)
+		cs2 := <-opened
+		require.NotNil(s.T(), cs2)
+		closeStream(cs2)
+	})
+}
+
+func (s *TestSuite) TestStreamRPCZ() {
+	oldRPCZ := rpcz.GlobalRPCZ
+	defer func() { rpcz.GlobalRPCZ = oldRPCZ }()
+	rpcz.GlobalRPCZ = rpcz.NewRPCZ(&rpcz.Config{Fraction: 1, Capacity: 100})
+
+	s.startServer(&StreamingService{})
+	defer s.closeServer(nil)
+
+	const messages = 3
+	cs, err := s.newStreamingClient().FullDuplexCall(trpc.BackgroundContext())
+	require.Nil(s.T(), err)
+	for i := 1; i <= messages; i++ {
+		payload, err := newPayload(testpb.PayloadType_COMPRESSIBLE, int32(i))
+		require.Nil(s.T(), err)
+		require.Nil(s.T(), cs.Send(&testpb.StreamingOutputCallRequest{
+			ResponseType:       testpb.PayloadType_COMPRESSIBLE,
+			ResponseParameters: []*testpb.ResponseParameters{{Size: int32(i)}},
+			Payload:            payload,
+		}))
+		_, err = cs.Recv()
+		require.Nil(s.T(), err)
+	}
+	require.Nil(s.T(), cs.CloseSend())
+	_, err = cs.Recv()
+	require.Equal(s.T(), io.EOF, err)
+
+	spans := make(map[string]*rpcz.ReadOnlySpan)
+	require.Eventually(s.T(), func() bool {
+		for _, span := range rpcz.GlobalRPCZ.BatchQuery(100) {
+			spans[span.Name] = span
+		}
+		return spans["client-stream"] != nil && spans["server-stream"] != nil
+	}, time.Second, 10*time.Millisecond)
+	for _, name := range []string{"client-stream", "server-stream"} {
+		attributes := make(map[string]interface{})
+		for _, a := range spans[name].Attributes {
+			attributes[a.Name] = a.Value
+		}
+		require.Equal(s.T(), "/trpc.testing.end2end.TestStreaming/FullDuplexCall",
+			attributes[rpcz.TRPCAttributeRPCName], name)
+		require.Nil(s.T(), attributes[rpcz.TRPCAttributeError], name)
+		require.Equal(s.T(), uint64(messages), attributes[rpcz.TRPCAttributeSentMessages], name)
+		require.Equal(s.T(), uint64(messages), attributes[rpcz.TRPCAttributeReceivedMessages], name)
+		events := make(map[string]bool)
+		for _, e := range spans[name].Events {
+			events[e.Name] = true
+		}
+		for _, e := range []string{"SendInit", "RecvInit", "SendClose", "RecvClose"} {
+			require.True(s.T(), events[e], "%s has no event %s", name, e)
+		}
+	}
+}
+
 func (s *TestSuite) TestWithMaxWindowSizeNotWorkWhenLessThanDefaultInitWindowSize() {
 	const (
 		defaultInitWindowSize = 65535
@@ -682,3 +1195,38 @@ func (s *TestSuite) testClientStreamingDontSendDataAfterCreatingStreaming() {
 	_, err = cs.CloseAndRecv()
 	require.Nil(s.T(), err)
 }
+
+func (s *TestSuite) TestWebSocketStream() {
+	s.startServer(&StreamingService{},
+		server.WithTransport(websocket.NewServerStreamTransport(websocket.WithPath("/stream"))))
+	defer s.closeServer(nil)
+
+	c := s.newStreamingClient(client.WithStreamTransport(
+		websocket.NewClientStreamTransport(websocket.WithPath("/stream"))))
+	for i := 0; i < 2; i++ {
+		cs, err := c.FullDuplexCall(trpc.BackgroundContext())
+		require.Nil(s.T(), err)
+		for size := int32(1); size <= 3; size++ {
+			payload, err := newPayload(testpb.PayloadType_COMPRESSIBLE, size)
+			require.Nil(s.T(), err)
+			require.Nil(s.T(), cs.Send(&testpb.StreamingOutputCallRequest{
+				ResponseType:       testpb.PayloadType_COMPRESSIBLE,
+				ResponseParameters: []*testpb.ResponseParameters{{Size: size}},
+				Payload:            payload,
+			}))
+			rsp, err := cs.Recv()
+			require.Nil(s.T(), err)
+			require.Len(s.T(), rsp.GetPayload().GetBody(), int(size))
+		}
+		require.Nil(s.T(), cs.CloseSend())
+		_, err = cs.Recv()
+		require.Equal(s.T(), io.EOF, err)
+	}
+
+	// A plain trpc client can't talk to the WebSocket server.
+	cs, err := s.newStreamingClient().FullDuplexCall(trpc.BackgroundContext())
+	if err == nil {
+		_, err = cs.Recv()
+	}
+	require.NotNil(s.T(), err)
+}
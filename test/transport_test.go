@@ -14,8 +14,13 @@
 package test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/stretchr/testify/require"
@@ -138,3 +143,46 @@ func (s *TestSuite) testServerIdleTimeGreaterThanHandleTime() {
 	_, err := c.UnaryCall(trpc.BackgroundContext(), s.defaultSimpleRequest, client.WithTimeout(2*time.Second))
 	require.Nil(s.T(), err)
 }
+
+func (s *TestSuite) TestServerZeroCopy() {
+	for _, e := range allTRPCEnvs {
+		s.tRPCEnv = e
+		s.Run(e.String(), s.testServerZeroCopy)
+	}
+}
+
+func (s *TestSuite) testServerZeroCopy() {
+	s.startServer(&TRPCService{UnaryCallF: func(ctx context.Context, in *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+		a := server.GetAttachment(trpc.Message(ctx))
+		attachment, err := io.ReadAll(a.Request())
+		if err != nil {
+			return nil, err
+		}
+		a.SetResponse(bytes.NewReader(attachment))
+		return &testpb.SimpleResponse{Payload: in.Payload}, nil
+	}}, server.WithZeroCopy(true))
+	defer s.closeServer(nil)
+
+	c := s.newTRPCClient()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				body := bytes.Repeat([]byte(fmt.Sprint(i, j)), 1024)
+				a := client.NewAttachment(bytes.NewReader(body[:len(body)/2]))
+				rsp, err := c.UnaryCall(trpc.BackgroundContext(),
+					&testpb.SimpleRequest{Payload: &testpb.Payload{Body: body}}, client.WithAttachment(a))
+				if !s.Nil(err) {
+					return
+				}
+				s.Equal(body, rsp.GetPayload().GetBody())
+				attachment, err := io.ReadAll(a.Response())
+				s.Nil(err)
+				s.Equal(body[:len(body)/2], attachment)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,263 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package thrift
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	apache "github.com/apache/thrift/lib/go/thrift"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+)
+
+// frameHeadLen is the length of the frame head of framed transport, which is the length of the
+// message in 4 bytes big endian.
+const frameHeadLen = 4
+
+var (
+	// DefaultServerCodec is the default server codec of Thrift protocol.
+	DefaultServerCodec = &ServerCodec{}
+	// DefaultClientCodec is the default client codec of Thrift protocol.
+	DefaultClientCodec = &ClientCodec{}
+	// DefaultFramerBuilder is the default framer builder of Thrift framed transport.
+	DefaultFramerBuilder = &FramerBuilder{}
+
+	// DefaultMaxFrameSize is the default max size of a frame, which is the same as Thrift's.
+	DefaultMaxFrameSize = 16384000
+)
+
+// Head is the head of a Thrift message. It's set as the server request head and the client
+// response head of msg.
+type Head struct {
+	Name  string              // method name
+	Type  apache.TMessageType // message type, such as CALL, REPLY, EXCEPTION and ONEWAY
+	SeqID int32               // sequence id
+}
+
+// FramerBuilder builds framers of Thrift framed transport.
+type FramerBuilder struct{}
+
+// New implements codec.FramerBuilder.
+func (*FramerBuilder) New(reader io.Reader) codec.Framer {
+	return &framer{reader: reader}
+}
+
+// framer reads frames of framed transport, in which each message is prefixed by its length.
+type framer struct {
+	reader io.Reader
+	head   [frameHeadLen]byte
+}
+
+// ReadFrame implements codec.Framer. The frame returned includes the frame head.
+func (f *framer) ReadFrame() ([]byte, error) {
+	if _, err := io.ReadFull(f.reader, f.head[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(f.head[:])
+	if size > uint32(DefaultMaxFrameSize) {
+		return nil, fmt.Errorf("thrift framer: frame size %d > %d, too large", size, DefaultMaxFrameSize)
+	}
+	frame := make([]byte, frameHeadLen+int(size))
+	copy(frame, f.head[:])
+	if _, err := io.ReadFull(f.reader, frame[frameHeadLen:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// IsSafe implements codec.SafeFramer.
+func (*framer) IsSafe() bool {
+	return true
+}
+
+// ServerCodec is the server codec of Thrift protocol.
+type ServerCodec struct{}
+
+// Decode implements codec.Codec. It decodes the head of a call, and returns the args struct as
+// the request body.
+func (*ServerCodec) Decode(msg codec.Msg, reqBuf []byte) ([]byte, error) {
+	head, serializationType, body, err := readMessage(reqBuf)
+	if err != nil {
+		return nil, fmt.Errorf("thrift server decode: %w", err)
+	}
+	msg.WithServerReqHead(head)
+	msg.WithSerializationType(serializationType)
+	msg.WithCompressType(codec.CompressTypeNoop)
+	msg.WithRequestID(uint32(head.SeqID))
+	msg.WithServerRPCName(rpcNameOf(head.Name))
+	switch head.Type {
+	case apache.CALL:
+	case apache.ONEWAY:
+		msg.WithCallType(codec.SendOnly)
+	default:
+		return nil, fmt.Errorf("thrift server decode: invalid message type %d", head.Type)
+	}
+	return body, nil
+}
+
+// Encode implements codec.Codec. It encodes the result struct as a reply, or the error of msg
+// as an application exception.
+func (*ServerCodec) Encode(msg codec.Msg, rspBody []byte) ([]byte, error) {
+	req, ok := msg.ServerReqHead().(*Head)
+	if !ok {
+		return nil, errors.New("thrift server encode: no request head")
+	}
+	head := &Head{Name: req.Name, Type: apache.REPLY, SeqID: req.SeqID}
+	msg.WithServerRspHead(head)
+	var exception apache.TApplicationException
+	if e := msg.ServerRspErr(); e != nil {
+		head.Type = apache.EXCEPTION
+		exception = apache.NewTApplicationException(exceptionTypeOf(e), e.Msg)
+	}
+	return writeMessage(msg.SerializationType(), head, rspBody, exception)
+}
+
+// ClientCodec is the client codec of Thrift protocol.
+type ClientCodec struct {
+	seqID uint32
+}
+
+// Encode implements codec.Codec. It encodes the args struct as a call.
+func (c *ClientCodec) Encode(msg codec.Msg, reqBody []byte) ([]byte, error) {
+	seqID := atomic.AddUint32(&c.seqID, 1)
+	msg.WithRequestID(seqID)
+	head := &Head{Name: thriftMethodOf(msg.ClientRPCName()), Type: apache.CALL, SeqID: int32(seqID)}
+	if msg.CallType() == codec.SendOnly {
+		head.Type = apache.ONEWAY
+	}
+	msg.WithClientReqHead(head)
+	return writeMessage(msg.SerializationType(), head, reqBody, nil)
+}
+
+// Decode implements codec.Codec. It returns the result struct of a reply as the response body,
+// or sets the client response error by an application exception.
+func (c *ClientCodec) Decode(msg codec.Msg, rspBuf []byte) ([]byte, error) {
+	head, serializationType, body, err := readMessage(rspBuf)
+	if err != nil {
+		return nil, fmt.Errorf("thrift client decode: %w", err)
+	}
+	msg.WithClientRspHead(head)
+	msg.WithSerializationType(serializationType)
+	msg.WithCompressType(codec.CompressTypeNoop)
+	if uint32(head.SeqID) != msg.RequestID() {
+		return nil, fmt.Errorf("thrift client decode: sequence id %d != %d", head.SeqID, msg.RequestID())
+	}
+	switch head.Type {
+	case apache.REPLY:
+		return body, nil
+	case apache.EXCEPTION:
+		e := apache.NewTApplicationException(apache.UNKNOWN_APPLICATION_EXCEPTION, "")
+		if err := e.Read(context.Background(), newProtocol(serializationType, newReadBuffer(body))); err != nil {
+			return nil, fmt.Errorf("thrift client decode exception: %w", err)
+		}
+		msg.WithClientRspErr(errorOf(e))
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("thrift client decode: invalid message type %d", head.Type)
+	}
+}
+
+// exceptionTypeOf returns the type of application exception of a server error.
+func exceptionTypeOf(e *errs.Error) int32 {
+	switch e.Code {
+	case errs.RetServerNoService, errs.RetServerNoFunc:
+		return apache.UNKNOWN_METHOD
+	case errs.RetServerDecodeFail:
+		return apache.PROTOCOL_ERROR
+	default:
+		return apache.INTERNAL_ERROR
+	}
+}
+
+// errorOf returns the client error of an application exception.
+func errorOf(e apache.TApplicationException) error {
+	switch e.TypeId() {
+	case apache.UNKNOWN_METHOD:
+		return errs.NewFrameError(errs.RetServerNoFunc, e.Error())
+	case apache.PROTOCOL_ERROR:
+		return errs.NewFrameError(errs.RetServerDecodeFail, e.Error())
+	default:
+		return errs.NewFrameError(errs.RetServerSystemErr, e.Error())
+	}
+}
+
+func newReadBuffer(b []byte) *apache.TMemoryBuffer {
+	return &apache.TMemoryBuffer{Buffer: bytes.NewBuffer(b)}
+}
+
+// readMessage reads the head of a framed message, and returns the rest of it as the body.
+// The serialization type is detected by the first byte of the message.
+func readMessage(frame []byte) (*Head, int, []byte, error) {
+	if len(frame) < frameHeadLen {
+		return nil, 0, nil, errors.New("frame len invalid")
+	}
+	payload := frame[frameHeadLen:]
+	if size := binary.BigEndian.Uint32(frame); size != uint32(len(payload)) {
+		return nil, 0, nil, fmt.Errorf("frame size %d is not actual payload len %d", size, len(payload))
+	}
+	serializationType := codec.SerializationTypeThriftBinary
+	if len(payload) > 0 && payload[0] == apache.COMPACT_PROTOCOL_ID {
+		serializationType = codec.SerializationTypeThriftCompact
+	}
+	buf := newReadBuffer(payload)
+	name, typeID, seqID, err := newProtocol(serializationType, buf).ReadMessageBegin(context.Background())
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("read message head: %w", err)
+	}
+	head := &Head{Name: name, Type: typeID, SeqID: seqID}
+	return head, serializationType, payload[len(payload)-buf.Len():], nil
+}
+
+// writeMessage writes a framed message of the head and the body, or the exception if not nil.
+func writeMessage(
+	serializationType int,
+	head *Head,
+	body []byte,
+	exception apache.TApplicationException,
+) ([]byte, error) {
+	ctx := context.Background()
+	buf := apache.NewTMemoryBufferLen(frameHeadLen + len(body) + 64)
+	buf.Write(make([]byte, frameHeadLen)) // reserved for the frame head
+	p := newProtocol(serializationType, buf)
+	if err := p.WriteMessageBegin(ctx, head.Name, head.Type, head.SeqID); err != nil {
+		return nil, err
+	}
+	if exception != nil {
+		if err := exception.Write(ctx, p); err != nil {
+			return nil, err
+		}
+	} else {
+		buf.Write(body)
+	}
+	if err := p.WriteMessageEnd(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.Flush(ctx); err != nil {
+		return nil, err
+	}
+	frame := buf.Bytes()
+	size := len(frame) - frameHeadLen
+	if size > DefaultMaxFrameSize {
+		return nil, fmt.Errorf("thrift frame size %d > %d, too large", size, DefaultMaxFrameSize)
+	}
+	binary.BigEndian.PutUint32(frame, uint32(size))
+	return frame, nil
+}
@@ -0,0 +1,149 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+// Package thrift provides the "thrift" protocol, which is Thrift over framed transport with
+// binary or compact protocol, so that tRPC clients can call Thrift services and Thrift clients
+// can call tRPC services.
+//
+// Request and response bodies are the args and result structs generated by the Thrift compiler,
+// such as GreeterSayHelloArgs and GreeterSayHelloResult, so that methods with any parameters and
+// declared exceptions are supported. Thrift method names are mapped to tRPC RPC names by
+// RegisterMethod, or by default, "method" to "/method" and "Service:method" of multiplexed
+// protocol to "/Service/method".
+//
+// The server detects the protocol of each request and responds in the same one. The client
+// uses the binary protocol unless the serialization type is codec.SerializationTypeThriftCompact.
+package thrift
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	apache "github.com/apache/thrift/lib/go/thrift"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/internal/protocol"
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+func init() {
+	codec.Register(protocol.Thrift, DefaultServerCodec, DefaultClientCodec)
+	transport.RegisterFramerBuilder(protocol.Thrift, DefaultFramerBuilder)
+	codec.RegisterSerializer(codec.SerializationTypeThriftBinary, &BinarySerialization{})
+	codec.RegisterSerializer(codec.SerializationTypeThriftCompact, &CompactSerialization{})
+}
+
+var methods = struct {
+	sync.RWMutex
+	rpcNames      map[string]string // thrift method name => rpc name
+	thriftMethods map[string]string // rpc name => thrift method name
+}{
+	rpcNames:      make(map[string]string),
+	thriftMethods: make(map[string]string),
+}
+
+// RegisterMethod maps a Thrift method name to a tRPC RPC name in both directions. The Thrift
+// method name is "Service:method" for multiplexed protocol.
+func RegisterMethod(thriftMethod, rpcName string) {
+	methods.Lock()
+	defer methods.Unlock()
+	methods.rpcNames[thriftMethod] = rpcName
+	methods.thriftMethods[rpcName] = thriftMethod
+}
+
+// rpcNameOf returns the tRPC RPC name of a Thrift method name.
+func rpcNameOf(thriftMethod string) string {
+	methods.RLock()
+	rpcName, ok := methods.rpcNames[thriftMethod]
+	methods.RUnlock()
+	if ok {
+		return rpcName
+	}
+	return "/" + strings.Replace(thriftMethod, ":", "/", 1)
+}
+
+// thriftMethodOf returns the Thrift method name of a tRPC RPC name.
+func thriftMethodOf(rpcName string) string {
+	methods.RLock()
+	thriftMethod, ok := methods.thriftMethods[rpcName]
+	methods.RUnlock()
+	if ok {
+		return thriftMethod
+	}
+	return strings.Replace(strings.TrimPrefix(rpcName, "/"), "/", ":", 1)
+}
+
+var (
+	errNotThriftStruct = errors.New("body is not a generated thrift struct")
+	tConfiguration     = &apache.TConfiguration{}
+)
+
+// newProtocol returns the Thrift protocol of the serialization type on trans.
+func newProtocol(serializationType int, trans apache.TTransport) apache.TProtocol {
+	if serializationType == codec.SerializationTypeThriftCompact {
+		return apache.NewTCompactProtocolConf(trans, tConfiguration)
+	}
+	return apache.NewTBinaryProtocolConf(trans, tConfiguration)
+}
+
+// BinarySerialization serializes generated Thrift structs in binary protocol.
+type BinarySerialization struct{}
+
+// Unmarshal deserializes the in bytes into body, which must be a generated Thrift struct.
+func (*BinarySerialization) Unmarshal(in []byte, body interface{}) error {
+	return unmarshal(codec.SerializationTypeThriftBinary, in, body)
+}
+
+// Marshal returns the serialized bytes of body, which must be a generated Thrift struct.
+func (*BinarySerialization) Marshal(body interface{}) ([]byte, error) {
+	return marshal(codec.SerializationTypeThriftBinary, body)
+}
+
+// CompactSerialization serializes generated Thrift structs in compact protocol.
+type CompactSerialization struct{}
+
+// Unmarshal deserializes the in bytes into body, which must be a generated Thrift struct.
+func (*CompactSerialization) Unmarshal(in []byte, body interface{}) error {
+	return unmarshal(codec.SerializationTypeThriftCompact, in, body)
+}
+
+// Marshal returns the serialized bytes of body, which must be a generated Thrift struct.
+func (*CompactSerialization) Marshal(body interface{}) ([]byte, error) {
+	return marshal(codec.SerializationTypeThriftCompact, body)
+}
+
+func unmarshal(serializationType int, in []byte, body interface{}) error {
+	s, ok := body.(apache.TStruct)
+	if !ok {
+		return errNotThriftStruct
+	}
+	return s.Read(context.Background(), newProtocol(serializationType, newReadBuffer(in)))
+}
+
+func marshal(serializationType int, body interface{}) ([]byte, error) {
+	s, ok := body.(apache.TStruct)
+	if !ok {
+		return nil, errNotThriftStruct
+	}
+	buf := apache.NewTMemoryBuffer()
+	p := newProtocol(serializationType, buf)
+	if err := s.Write(context.Background(), p); err != nil {
+		return nil, err
+	}
+	if err := p.Flush(context.Background()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,323 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package thrift_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	apache "github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/client"
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/server"
+	"trpc.group/trpc-go/trpc-go/thrift"
+)
+
+// The following structs are like the ones generated by the Thrift compiler for:
+//
+//	struct Message { 1: string text }
+//	service Greeter { Message sayHello(1: Message req) }
+
+type message struct {
+	Text string
+}
+
+func (p *message) Write(ctx context.Context, oprot apache.TProtocol) error {
+	return writeStruct(ctx, oprot, "Message", func() error {
+		if err := oprot.WriteFieldBegin(ctx, "text", apache.STRING, 1); err != nil {
+			return err
+		}
+		if err := oprot.WriteString(ctx, p.Text); err != nil {
+			return err
+		}
+		return oprot.WriteFieldEnd(ctx)
+	})
+}
+
+func (p *message) Read(ctx context.Context, iprot apache.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typeID apache.TType) (bool, error) {
+		if id != 1 || typeID != apache.STRING {
+			return false, nil
+		}
+		var err error
+		p.Text, err = iprot.ReadString(ctx)
+		return true, err
+	})
+}
+
+type sayHelloArgs struct {
+	Req *message
+}
+
+func (p *sayHelloArgs) Write(ctx context.Context, oprot apache.TProtocol) error {
+	return writeStruct(ctx, oprot, "sayHello_args", func() error {
+		return writeStructField(ctx, oprot, "req", 1, p.Req)
+	})
+}
+
+func (p *sayHelloArgs) Read(ctx context.Context, iprot apache.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typeID apache.TType) (bool, error) {
+		if id != 1 || typeID != apache.STRUCT {
+			return false, nil
+		}
+		p.Req = &message{}
+		return true, p.Req.Read(ctx, iprot)
+	})
+}
+
+type sayHelloResult struct {
+	Success *message
+}
+
+func (p *sayHelloResult) Write(ctx context.Context, oprot apache.TProtocol) error {
+	return writeStruct(ctx, oprot, "sayHello_result", func() error {
+		return writeStructField(ctx, oprot, "success", 0, p.Success)
+	})
+}
+
+func (p *sayHelloResult) Read(ctx context.Context, iprot apache.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typeID apache.TType) (bool, error) {
+		if id != 0 || typeID != apache.STRUCT {
+			return false, nil
+		}
+		p.Success = &message{}
+		return true, p.Success.Read(ctx, iprot)
+	})
+}
+
+func writeStruct(ctx context.Context, oprot apache.TProtocol, name string, writeFields func() error) error {
+	if err := oprot.WriteStructBegin(ctx, name); err != nil {
+		return err
+	}
+	if err := writeFields(); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	return oprot.WriteStructEnd(ctx)
+}
+
+func writeStructField(ctx context.Context, oprot apache.TProtocol, name string, id int16, s apache.TStruct) error {
+	if err := oprot.WriteFieldBegin(ctx, name, apache.STRUCT, id); err != nil {
+		return err
+	}
+	if err := s.Write(ctx, oprot); err != nil {
+		return err
+	}
+	return oprot.WriteFieldEnd(ctx)
+}
+
+func readStruct(
+	ctx context.Context,
+	iprot apache.TProtocol,
+	readField func(id int16, typeID apache.TType) (bool, error),
+) error {
+	if _, err := iprot.ReadStructBegin(ctx); err != nil {
+		return err
+	}
+	for {
+		_, typeID, id, err := iprot.ReadFieldBegin(ctx)
+		if err != nil {
+			return err
+		}
+		if typeID == apache.STOP {
+			break
+		}
+		ok, err := readField(id, typeID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if err := iprot.Skip(ctx, typeID); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+	return iprot.ReadStructEnd(ctx)
+}
+
+type greeter interface {
+	SayHello(ctx context.Context, req *sayHelloArgs) (*sayHelloResult, error)
+}
+
+type greeterImpl struct {
+	oneway chan string
+}
+
+func (g *greeterImpl) SayHello(_ context.Context, req *sayHelloArgs) (*sayHelloResult, error) {
+	switch req.Req.Text {
+	case "error":
+		return nil, errs.New(1000, "business error")
+	case "oneway":
+		g.oneway <- req.Req.Text
+	}
+	return &sayHelloResult{Success: &message{Text: "hello " + req.Req.Text}}, nil
+}
+
+func sayHelloHandler(svr interface{}, ctx context.Context, f server.FilterFunc) (interface{}, error) {
+	req := &sayHelloArgs{}
+	filters, err := f(req)
+	if err != nil {
+		return nil, err
+	}
+	handleFunc := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return svr.(greeter).SayHello(ctx, req.(*sayHelloArgs))
+	}
+	return filters.Filter(ctx, req, handleFunc)
+}
+
+func startServer(t *testing.T, g *greeterImpl) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	s := server.New(
+		server.WithServiceName("trpc.test.thrift.Greeter"),
+		server.WithProtocol("thrift"),
+		server.WithNetwork("tcp"),
+		server.WithListener(ln),
+	)
+	require.Nil(t, s.Register(&server.ServiceDesc{
+		ServiceName: "trpc.test.thrift.Greeter",
+		HandlerType: (*greeter)(nil),
+		Methods: []server.Method{
+			{Name: "/sayHello", Func: sayHelloHandler},
+			{Name: "/trpc.test.thrift.Greeter/SayHello", Func: sayHelloHandler},
+		},
+	}, g))
+	go s.Serve()
+	t.Cleanup(func() { s.Close(nil) })
+	return ln.Addr().String()
+}
+
+func TestThriftClientToServer(t *testing.T) {
+	addr := startServer(t, &greeterImpl{})
+	for _, newProtocol := range []func(apache.TTransport) apache.TProtocol{
+		func(trans apache.TTransport) apache.TProtocol {
+			return apache.NewTBinaryProtocolConf(trans, nil)
+		},
+		func(trans apache.TTransport) apache.TProtocol {
+			return apache.NewTCompactProtocolConf(trans, nil)
+		},
+	} {
+		socket := apache.NewTSocketConf(addr, &apache.TConfiguration{SocketTimeout: time.Second})
+		trans := apache.NewTFramedTransportConf(socket, nil)
+		require.Nil(t, trans.Open())
+		p := newProtocol(trans)
+		c := apache.NewTStandardClient(p, p)
+
+		rsp := &sayHelloResult{}
+		_, err := c.Call(context.Background(), "sayHello", &sayHelloArgs{Req: &message{Text: "thrift"}}, rsp)
+		require.Nil(t, err)
+		require.Equal(t, "hello thrift", rsp.Success.Text)
+
+		_, err = c.Call(context.Background(), "sayHi", &sayHelloArgs{Req: &message{}}, rsp)
+		var e apache.TApplicationException
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, int32(apache.UNKNOWN_METHOD), e.TypeId())
+
+		_, err = c.Call(context.Background(), "sayHello", &sayHelloArgs{Req: &message{Text: "error"}}, rsp)
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, int32(apache.INTERNAL_ERROR), e.TypeId())
+		require.Equal(t, "business error", e.Error())
+		require.Nil(t, trans.Close())
+	}
+}
+
+func TestTRPCClientToServer(t *testing.T) {
+	g := &greeterImpl{oneway: make(chan string, 1)}
+	addr := startServer(t, g)
+	thrift.RegisterMethod("trpc.test.thrift.Greeter:sayHello", "/trpc.test.thrift.Greeter/SayHello")
+
+	for _, serializationType := range []int{codec.SerializationTypeThriftBinary, codec.SerializationTypeThriftCompact} {
+		c := client.New()
+		opts := []client.Option{
+			client.WithTarget("ip://" + addr),
+			client.WithProtocol("thrift"),
+			client.WithNetwork("tcp"),
+			client.WithSerializationType(serializationType),
+		}
+		for _, rpcName := range []string{"/sayHello", "/trpc.test.thrift.Greeter/SayHello"} {
+			ctx, msg := codec.WithCloneMessage(context.Background())
+			msg.WithClientRPCName(rpcName)
+			rsp := &sayHelloResult{}
+			require.Nil(t, c.Invoke(ctx, &sayHelloArgs{Req: &message{Text: "trpc"}}, rsp, opts...))
+			require.Equal(t, "hello trpc", rsp.Success.Text)
+		}
+
+		ctx, msg := codec.WithCloneMessage(context.Background())
+		msg.WithClientRPCName("/sayHi")
+		err := c.Invoke(ctx, &sayHelloArgs{Req: &message{}}, &sayHelloResult{}, opts...)
+		require.Equal(t, errs.RetServerNoFunc, errs.Code(err))
+
+		ctx, msg = codec.WithCloneMessage(context.Background())
+		msg.WithClientRPCName("/sayHello")
+		err = c.Invoke(ctx, &sayHelloArgs{Req: &message{Text: "oneway"}}, nil, append(opts, client.WithSendOnly())...)
+		require.Nil(t, err)
+		require.Equal(t, "oneway", <-g.oneway)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	buf := apache.NewTMemoryBuffer()
+	trans := apache.NewTFramedTransportConf(buf, nil)
+	p := apache.NewTBinaryProtocolConf(trans, nil)
+	args := &sayHelloArgs{Req: &message{Text: "codec"}}
+	require.Nil(t, apache.NewTStandardClient(p, p).Send(context.Background(), p, 7, "Greeter:sayHello", args))
+
+	msg := codec.Message(context.Background())
+	body, err := thrift.DefaultServerCodec.Decode(msg, buf.Bytes())
+	require.Nil(t, err)
+	require.Equal(t, "/Greeter/sayHello", msg.ServerRPCName())
+	require.Equal(t, uint32(7), msg.RequestID())
+	require.Equal(t, codec.SerializationTypeThriftBinary, msg.SerializationType())
+	require.Equal(t, &thrift.Head{Name: "Greeter:sayHello", Type: apache.CALL, SeqID: 7}, msg.ServerReqHead())
+	gotArgs := &sayHelloArgs{}
+	require.Nil(t, codec.Unmarshal(msg.SerializationType(), body, gotArgs))
+	require.Equal(t, args, gotArgs)
+
+	_, err = thrift.DefaultServerCodec.Decode(codec.Message(context.Background()), []byte{0, 0, 0, 1})
+	require.NotNil(t, err)
+	_, err = thrift.DefaultServerCodec.Decode(codec.Message(context.Background()), []byte{0, 0, 0, 2, 0})
+	require.NotNil(t, err)
+	_, err = thrift.DefaultServerCodec.Encode(codec.Message(context.Background()), nil)
+	require.NotNil(t, err)
+
+	_, err = codec.Marshal(codec.SerializationTypeThriftBinary, "not a thrift struct")
+	require.NotNil(t, err)
+	require.NotNil(t, codec.Unmarshal(codec.SerializationTypeThriftCompact, []byte{1}, &struct{}{}))
+}
+
+func TestFramer(t *testing.T) {
+	frame := []byte{0, 0, 0, 3, 'a', 'b', 'c'}
+	f := thrift.DefaultFramerBuilder.New(bytes.NewReader(append(frame, 0, 0)))
+	got, err := f.ReadFrame()
+	require.Nil(t, err)
+	require.Equal(t, frame, got)
+	_, err = f.ReadFrame()
+	require.NotNil(t, err)
+
+	f = thrift.DefaultFramerBuilder.New(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}))
+	_, err = f.ReadFrame()
+	require.NotNil(t, err)
+}
@@ -14,6 +14,7 @@
 package transport
 
 import (
+	"fmt"
 	"time"
 
 	"trpc.group/trpc-go/trpc-go/codec"
@@ -38,6 +39,17 @@ type RoundTripOptions struct {
 	Msg                   codec.Msg
 	Protocol              string // protocol type
 	PreWarm               *PreWarmOptions
+	UDPFragment           *UDPFragmentOptions // enable udp fragmentation if not nil
+	MaxResponseSize       int                 // max size of responses in bytes, zero means no limit
+	// ExcludedAddrs are the local addresses of multiplexed connections which must not be picked.
+	ExcludedAddrs []string
+	// StreamPriority is the priority of the frames of the stream written to the shared connection.
+	StreamPriority StreamPriority
+
+	// AttachmentSpillThreshold is the size in bytes above which response attachments are spilled to
+	// temp files in AttachmentSpillDir, zero means never.
+	AttachmentSpillThreshold int
+	AttachmentSpillDir       string
 
 	CACertFile      string // CA certificate file
 	TLSCertFile     string // client certificate file
@@ -125,6 +137,14 @@ func WithConnectionMode(connMode ConnectionMode) RoundTripOption {
 	}
 }
 
+// WithClientUDPFragment returns a RoundTripOption which enables the fragmentation of large
+// UDP messages. The server must enable it too.
+func WithClientUDPFragment(fragment *UDPFragmentOptions) RoundTripOption {
+	return func(opts *RoundTripOptions) {
+		opts.UDPFragment = fragment
+	}
+}
+
 // WithDialTLS returns a RoundTripOption which sets UDP TLS relatives.
 func WithDialTLS(certFile, keyFile, caFile, serverName string) RoundTripOption {
 	return func(opts *RoundTripOptions) {
@@ -180,6 +200,22 @@ func WithLocalAddr(addr string) RoundTripOption {
 	}
 }
 
+// WithExcludedAddrs returns a RoundTripOption which excludes the multiplexed connections of
+// the local addresses from being picked, for example, those refusing new streams.
+func WithExcludedAddrs(addrs ...string) RoundTripOption {
+	return func(o *RoundTripOptions) {
+		o.ExcludedAddrs = addrs
+	}
+}
+
+// WithStreamPriority returns a RoundTripOption which sets the priority of the stream, by which
+// the frames of the streams sharing a connection are written.
+func WithStreamPriority(p StreamPriority) RoundTripOption {
+	return func(o *RoundTripOptions) {
+		o.StreamPriority = p
+	}
+}
+
 // WithDialTimeout returns a RoundTripOption which sets dial timeout.
 func WithDialTimeout(dur time.Duration) RoundTripOption {
 	return func(o *RoundTripOptions) {
@@ -194,6 +230,44 @@ func WithProtocol(s string) RoundTripOption {
 	}
 }
 
+// WithMaxResponseSize returns a RoundTripOption which sets the max size of responses in bytes.
+// Larger responses are discarded before their bodies are allocated, and fail with
+// errs.RetClientMsgExceedLimit if the FramerBuilder implements codec.SizeLimitedFramerBuilder.
+// Pooled and multiplexed connections are only shared by calls of the same limit.
+func WithMaxResponseSize(n int) RoundTripOption {
+	return func(o *RoundTripOptions) {
+		o.MaxResponseSize = n
+	}
+}
+
+// WithAttachmentSpill returns a RoundTripOption which spills response attachments larger than
+// threshold bytes to temp files in dir, if the FramerBuilder implements codec.SpillingFramerBuilder.
+// The default directory for temporary files is used if dir is empty.
+// Like WithMaxResponseSize, pooled and multiplexed connections are only shared by calls of the same options.
+func WithAttachmentSpill(threshold int, dir string) RoundTripOption {
+	return func(o *RoundTripOptions) {
+		o.AttachmentSpillThreshold = threshold
+		o.AttachmentSpillDir = dir
+	}
+}
+
+// LimitedFramerBuilder returns the FramerBuilder limiting the frame size to MaxResponseSize,
+// and spilling attachments larger than AttachmentSpillThreshold.
+func (o *RoundTripOptions) LimitedFramerBuilder() codec.FramerBuilder {
+	fb := codec.WithMaxFrameSize(o.FramerBuilder, o.MaxResponseSize)
+	return codec.WithAttachmentSpill(fb, o.AttachmentSpillThreshold, o.AttachmentSpillDir)
+}
+
+// FramerKey returns the key of the options by which LimitedFramerBuilder builds framers, it's empty if
+// none is set. Pooled and multiplexed connections are only shared by calls of the same key, as the
+// framers are built when the connections are created.
+func (o *RoundTripOptions) FramerKey() string {
+	if o.MaxResponseSize <= 0 && o.AttachmentSpillThreshold <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d_%d_%s", o.MaxResponseSize, o.AttachmentSpillThreshold, o.AttachmentSpillDir)
+}
+
 // WithPreWarm returns a RoundTripOption which sets prewarm options.
 // This option is intended for client initialization.
 func WithPreWarm(p PreWarmOptions) RoundTripOption {
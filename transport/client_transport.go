@@ -17,7 +17,9 @@ import (
 	"context"
 	"fmt"
 
+	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	"trpc.group/trpc-go/trpc-go/pool/connpool"
 	"trpc.group/trpc-go/trpc-go/pool/multiplexed"
@@ -75,8 +77,12 @@ func (c *clientTransport) RoundTrip(ctx context.Context, req []byte,
 	for _, o := range roundTripOpts {
 		o(opts)
 	}
+	opts.FramerBuilder = opts.LimitedFramerBuilder()
 
 	if opts.EnableMultiplexed {
+		if req, err = appendDeferredAttachment(ctx, req); err != nil {
+			return nil, err
+		}
 		return c.multiplexed(ctx, req, opts)
 	}
 
@@ -84,9 +90,25 @@ func (c *clientTransport) RoundTrip(ctx context.Context, req []byte,
 	case protocol.TCP, protocol.TCP4, protocol.TCP6, protocol.UNIX:
 		return c.tcpRoundTrip(ctx, req, opts)
 	case protocol.UDP, protocol.UDP4, protocol.UDP6:
+		if req, err = appendDeferredAttachment(ctx, req); err != nil {
+			return nil, err
+		}
 		return c.udpRoundTrip(ctx, req, opts)
 	default:
 		return nil, errs.NewFrameError(errs.RetClientConnectFail,
 			fmt.Sprintf("client transport: network %s not support", opts.Network))
 	}
 }
+
+// WritesDeferredAttachment implements attachment.DeferredWriter.
+// Deferred attachments are written after frames on tcp connections, and are appended to frames otherwise.
+func (c *clientTransport) WritesDeferredAttachment() {}
+
+// appendDeferredAttachment appends the deferred attachment of the request to req.
+func appendDeferredAttachment(ctx context.Context, req []byte) ([]byte, error) {
+	req, err := attachment.AppendDeferred(codec.Message(ctx), req)
+	if err != nil {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail, "client transport: "+err.Error())
+	}
+	return req, nil
+}
@@ -19,6 +19,7 @@ import (
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/pool/connpool"
 	"trpc.group/trpc-go/trpc-go/pool/multiplexed"
 )
 
@@ -47,6 +48,8 @@ func NewClientStreamTransport(opts ...ClientStreamTransportOption) ClientStreamT
 		multiplexedPool: multiplexed.New(
 			multiplexed.WithMaxVirConnsPerConn(options.maxConcurrentStreams),
 			multiplexed.WithMaxIdleConnsPerHost(options.maxIdleConnsPerHost),
+			multiplexed.WithDialFunc(options.dialFunc),
+			multiplexed.WithPriorityWeights(streamPriorityWeights...),
 		),
 	}
 	return t
@@ -56,6 +59,7 @@ func NewClientStreamTransport(opts ...ClientStreamTransportOption) ClientStreamT
 type cstOptions struct {
 	maxConcurrentStreams int
 	maxIdleConnsPerHost  int
+	dialFunc             connpool.DialFunc
 }
 
 // ClientStreamTransportOption sets properties of ClientStreamTransport.
@@ -75,6 +79,13 @@ func WithMaxIdleConnsPerHost(n int) ClientStreamTransportOption {
 	}
 }
 
+// WithDialFunc sets the function to dial connections, default is connpool.Dial.
+func WithDialFunc(d connpool.DialFunc) ClientStreamTransportOption {
+	return func(opts *cstOptions) {
+		opts.dialFunc = d
+	}
+}
+
 // clientStreamTransport keeps compatibility with the original client transport.
 type clientStreamTransport struct {
 	streamIDToConn  map[uint32]multiplexed.MuxConn
@@ -109,9 +120,12 @@ func (c *clientStreamTransport) Init(ctx context.Context, roundTripOpts ...Round
 			"frame builder does not implement multiplexed.FrameParser")
 	}
 	getOpts.WithFrameParser(fp)
+	getOpts.WithFramerKey(opts.FramerKey())
 	getOpts.WithDialTLS(opts.TLSCertFile, opts.TLSKeyFile, opts.CACertFile, opts.TLSServerName)
 	getOpts.WithCertProvider(opts.TLSCertProvider)
 	getOpts.WithLocalAddr(opts.LocalAddr)
+	getOpts.WithExcludedAddrs(opts.ExcludedAddrs...)
+	getOpts.WithPriority(int(opts.StreamPriority))
 	conn, err := opts.Multiplexed.GetMuxConn(ctx, opts.Network, opts.Address, getOpts)
 	if err != nil {
 		return errs.NewFrameError(errs.RetClientConnectFail,
@@ -197,6 +211,7 @@ func (c *clientStreamTransport) getOptions(ctx context.Context,
 		return nil, errs.NewFrameError(errs.RetClientConnectFail,
 			"tcp client transport: framer builder empty")
 	}
+	opts.FramerBuilder = opts.LimitedFramerBuilder()
 
 	if opts.Msg == nil {
 		return nil, errs.NewFrameError(errs.RetClientConnectFail,
@@ -21,6 +21,7 @@ import (
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
 	"trpc.group/trpc-go/trpc-go/internal/keeporder"
 	"trpc.group/trpc-go/trpc-go/internal/report"
 	"trpc.group/trpc-go/trpc-go/pool/connpool"
@@ -65,6 +66,9 @@ func (c *clientTransport) tcpRoundTrip(ctx context.Context, reqData []byte,
 	span := rpcz.SpanFromContext(ctx)
 	_, end := span.NewChild("SendMessage")
 	err = c.tcpWriteFrame(ctx, conn, reqData)
+	if err == nil {
+		err = c.tcpWriteDeferredAttachment(msg, conn)
+	}
 	end.End()
 	if err != nil {
 		return nil, err
@@ -123,6 +127,7 @@ func (c *clientTransport) dialTCP(ctx context.Context, opts *RoundTripOptions) (
 	getOpts := connpool.NewGetOptions()
 	getOpts.WithContext(ctx)
 	getOpts.WithFramerBuilder(opts.FramerBuilder)
+	getOpts.WithFramerKey(opts.FramerKey())
 	getOpts.WithDialTLS(opts.TLSCertFile, opts.TLSKeyFile, opts.CACertFile, opts.TLSServerName)
 	getOpts.WithCertProvider(opts.TLSCertProvider)
 	getOpts.WithLocalAddr(opts.LocalAddr)
@@ -178,6 +183,25 @@ func (c *clientTransport) tcpWriteFrame(ctx context.Context, conn net.Conn, reqD
 	return nil
 }
 
+// tcpWriteDeferredAttachment writes the deferred attachment of the request after the frame in chunks.
+// The connection is closed on failure, as the frame is broken.
+func (c *clientTransport) tcpWriteDeferredAttachment(msg codec.Msg, conn net.Conn) error {
+	err := attachment.WriteDeferred(msg, conn)
+	if err == nil {
+		return nil
+	}
+	if pc, ok := conn.(interface{ GetRawConn() net.Conn }); ok {
+		pc.GetRawConn().Close()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errs.NewFrameError(errs.RetClientTimeout,
+			"tcp client transport write attachment: "+err.Error())
+	}
+	return errs.NewFrameError(errs.RetClientNetErr,
+		"tcp client transport write attachment: "+err.Error())
+}
+
 // tcpReadFrame reads the tcp frame.
 func (c *clientTransport) tcpReadFrame(conn net.Conn, opts *RoundTripOptions) ([]byte, error) {
 	// send only.
@@ -226,6 +250,7 @@ func (c *clientTransport) multiplexed(ctx context.Context, req []byte, opts *Rou
 			"frame builder does not implement multiplexed.FrameParser")
 	}
 	getOpts.WithFrameParser(fp)
+	getOpts.WithFramerKey(opts.FramerKey())
 	getOpts.WithDialTLS(opts.TLSCertFile, opts.TLSKeyFile, opts.CACertFile, opts.TLSServerName)
 	getOpts.WithCertProvider(opts.TLSCertProvider)
 	getOpts.WithLocalAddr(opts.LocalAddr)
@@ -775,6 +775,13 @@ func TestWithLocalAddr(t *testing.T) {
 	assert.Equal(t, opts.LocalAddr, localAddr)
 }
 
+func TestWithExcludedAddrs(t *testing.T) {
+	opts := &transport.RoundTripOptions{}
+	o := transport.WithExcludedAddrs("127.0.0.1:8080", "127.0.0.1:8081")
+	o(opts)
+	assert.Equal(t, []string{"127.0.0.1:8080", "127.0.0.1:8081"}, opts.ExcludedAddrs)
+}
+
 func TestWithDialTimeout(t *testing.T) {
 	opts := &transport.RoundTripOptions{}
 	timeout := time.Second
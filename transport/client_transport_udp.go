@@ -17,9 +17,12 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync/atomic"
+	"time"
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/allocator"
 	"trpc.group/trpc-go/trpc-go/internal/packetbuffer"
 	"trpc.group/trpc-go/trpc-go/internal/report"
 )
@@ -53,6 +56,9 @@ func (c *clientTransport) udpRoundTrip(ctx context.Context, reqData []byte,
 	}
 
 	report.UDPClientTransportSendSize.Set(float64(len(reqData)))
+	if opts.UDPFragment != nil {
+		return c.udpFragmentRoundTrip(ctx, conn, reqData, addr, opts)
+	}
 	if err := c.udpWriteFrame(conn, reqData, addr, opts); err != nil {
 		return nil, err
 	}
@@ -179,3 +185,107 @@ func (c *clientTransport) dialUDP(ctx context.Context, opts *RoundTripOptions) (
 	}
 	return conn, addr, nil
 }
+
+var udpFragmentMsgID uint32
+
+// udpFragmentRoundTrip sends the request in fragments, and reassembles the response fragments.
+func (c *clientTransport) udpFragmentRoundTrip(ctx context.Context, conn net.PacketConn,
+	reqData []byte, addr *net.UDPAddr, opts *RoundTripOptions) ([]byte, error) {
+	fo := opts.UDPFragment.withDefaults()
+	msgID := atomic.AddUint32(&udpFragmentMsgID, 1)
+	frags, err := splitFragments(msgID, reqData, fo.MaxFragmentSize)
+	if err != nil {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail, "udp client transport: "+err.Error())
+	}
+	for _, frag := range frags {
+		if err := c.udpWriteFrame(conn, frag, addr, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ReqType == SendOnly {
+		return nil, errs.ErrClientNoResponse
+	}
+	return c.udpReadFragments(ctx, conn, addr, msgID, frags, fo, opts)
+}
+
+// udpReadFragments reads the response fragments of msgID, and answers the retransmission requests
+// of the server. If retransmission is enabled, it requests the missing fragments periodically.
+func (c *clientTransport) udpReadFragments(ctx context.Context, conn net.PacketConn, addr *net.UDPAddr,
+	msgID uint32, frags [][]byte, fo *UDPFragmentOptions, opts *RoundTripOptions) ([]byte, error) {
+	buf, i := allocator.Malloc(defaultUDPRecvBufSize)
+	defer allocator.Free(i)
+	r := newReassembler(fo)
+	deadline, hasDeadline := ctx.Deadline()
+	for {
+		var readDeadline time.Time
+		if fo.Retransmit {
+			readDeadline = time.Now().Add(fo.RetransmitInterval)
+		}
+		if hasDeadline && (readDeadline.IsZero() || deadline.Before(readDeadline)) {
+			readDeadline = deadline
+		}
+		conn.SetReadDeadline(readDeadline)
+
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			e, ok := err.(net.Error)
+			if ok && e.Timeout() && fo.Retransmit && ctx.Err() == nil &&
+				(!hasDeadline || time.Now().Before(deadline)) {
+				for _, nack := range r.check(time.Now()) {
+					if err := c.udpWriteFrame(conn, nack.pkt, addr, opts); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			report.UDPClientTransportReadFail.Incr()
+			if ok && e.Timeout() {
+				return nil, errs.NewFrameError(errs.RetClientTimeout,
+					"udp client transport ReadFrame: "+err.Error())
+			}
+			return nil, errs.NewFrameError(errs.RetClientNetErr,
+				"udp client transport ReadFrom: "+err.Error())
+		}
+
+		msg := buf[:n]
+		h, payload, ok := parseFragment(msg)
+		if ok {
+			if h.msgID != msgID {
+				continue
+			}
+			if h.typ == fragmentTypeNack {
+				for _, idx := range decodeNack(h, payload) {
+					if int(idx) >= len(frags) {
+						continue
+					}
+					report.UDPFragmentRetransmit.Incr()
+					if err := c.udpWriteFrame(conn, frags[idx], addr, opts); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			msg, err = r.add(addr, h, payload, time.Now())
+			if err != nil {
+				if err == errReassemblyOverflow {
+					report.UDPFragmentReassemblyOverflow.Incr()
+				}
+				report.UDPClientTransportReadFail.Incr()
+				return nil, errs.NewFrameError(errs.RetClientReadFrameErr,
+					"udp client transport reassemble fragment: "+err.Error())
+			}
+			if msg == nil {
+				continue
+			}
+		}
+
+		rsp, err := readUDPMessage(opts.FramerBuilder, msg)
+		if err != nil {
+			report.UDPClientTransportReadFail.Incr()
+			return nil, errs.NewFrameError(errs.RetClientReadFrameErr,
+				"udp client transport ReadFrame: "+err.Error())
+		}
+		report.UDPClientTransportReceiveSize.Set(float64(len(rsp)))
+		return rsp, nil
+	}
+}
@@ -38,8 +38,23 @@ type ListenServeOptions struct {
 	ServerAsync     bool          // whether enable server async
 	Writev          bool          // whether enable writev in server
 	CopyFrame       bool          // whether copy frame
+	ZeroCopy        bool          // whether hand pooled frames to handlers
 	IdleTimeout     time.Duration // idle timeout of connection
 
+	// UDPFragment enables the fragmentation of large UDP messages if not nil.
+	UDPFragment *UDPFragmentOptions
+
+	MaxConnections      int           // max number of concurrent connections, zero means no limit
+	MaxConnectionsPerIP int           // max number of concurrent connections of a remote IP, zero means no limit
+	ConnectionLimitWait time.Duration // time to wait for a free slot, zero means reject immediately
+
+	// MaxRequestSize is the max size of request frames or bodies in bytes, zero means no limit.
+	MaxRequestSize int
+	// AttachmentSpillThreshold is the size in bytes above which request attachments are spilled to
+	// temp files in AttachmentSpillDir, zero means never.
+	AttachmentSpillThreshold int
+	AttachmentSpillDir       string
+
 	// KeepOrderPreDecodeExtractor specifies the pre-decoding extractor to use for keeping order.
 	KeepOrderPreDecodeExtractor KeepOrderPreDecodeExtractor
 	// KeepOrderPreUnmarshalExtractor specifies the pre-unmarshalling extractor to use for keeping order.
@@ -57,6 +72,13 @@ type ListenServeOptions struct {
 	StopListening <-chan struct{}
 }
 
+// LimitedFramerBuilder returns the FramerBuilder limiting the frame size to MaxRequestSize,
+// and spilling attachments larger than AttachmentSpillThreshold.
+func (o *ListenServeOptions) LimitedFramerBuilder() codec.FramerBuilder {
+	fb := codec.WithMaxFrameSize(o.FramerBuilder, o.MaxRequestSize)
+	return codec.WithAttachmentSpill(fb, o.AttachmentSpillThreshold, o.AttachmentSpillDir)
+}
+
 func (o *ListenServeOptions) fixKeepOrder() {
 	if o.OrderedGroups == nil {
 		o.OrderedGroups = actor.Default
@@ -165,6 +187,58 @@ func WithWritev(writev bool) ListenServeOption {
 	}
 }
 
+// WithMaxConnections returns a ListenServeOption which sets the max number of concurrent connections.
+// Connections beyond the limit are rejected, or wait for a free slot if WithConnectionLimitWait is set.
+func WithMaxConnections(n int) ListenServeOption {
+	return func(options *ListenServeOptions) {
+		options.MaxConnections = n
+	}
+}
+
+// WithMaxConnectionsPerIP returns a ListenServeOption which sets the max number of concurrent
+// connections of a single remote IP.
+func WithMaxConnectionsPerIP(n int) ListenServeOption {
+	return func(options *ListenServeOptions) {
+		options.MaxConnectionsPerIP = n
+	}
+}
+
+// WithConnectionLimitWait returns a ListenServeOption which sets the time a connection waits for a free
+// slot when the connection limits are reached. Zero means the connection is rejected immediately.
+// The tnet transport always rejects immediately, since it must not block its pollers.
+func WithConnectionLimitWait(d time.Duration) ListenServeOption {
+	return func(options *ListenServeOptions) {
+		options.ConnectionLimitWait = d
+	}
+}
+
+// WithServeMaxRequestSize returns a ListenServeOption which sets the max size of requests in bytes.
+// Larger requests are discarded before their bodies are allocated, and are responded with
+// errs.RetServerMsgExceedLimit if the FramerBuilder implements codec.SizeLimitedFramerBuilder.
+func WithServeMaxRequestSize(n int) ListenServeOption {
+	return func(options *ListenServeOptions) {
+		options.MaxRequestSize = n
+	}
+}
+
+// WithServeAttachmentSpill returns a ListenServeOption which spills request attachments larger than
+// threshold bytes to temp files in dir, if the FramerBuilder implements codec.SpillingFramerBuilder.
+// The default directory for temporary files is used if dir is empty.
+func WithServeAttachmentSpill(threshold int, dir string) ListenServeOption {
+	return func(options *ListenServeOptions) {
+		options.AttachmentSpillThreshold = threshold
+		options.AttachmentSpillDir = dir
+	}
+}
+
+// WithServerUDPFragment returns a ListenServeOption which enables the fragmentation of large
+// UDP messages. The client must enable it too.
+func WithServerUDPFragment(opts *UDPFragmentOptions) ListenServeOption {
+	return func(options *ListenServeOptions) {
+		options.UDPFragment = opts
+	}
+}
+
 // WithMaxRoutines returns a ListenServeOption which sets the max number of async goroutines.
 // It's recommended to reserve twice of expected goroutines, but no less than MAXPROCS. The default
 // value is (1<<31 - 1).
@@ -175,6 +249,16 @@ func WithMaxRoutines(routines int) ListenServeOption {
 	}
 }
 
+// WithZeroCopy returns a ListenServeOption which sets whether to read frames into pooled buffers, which are
+// handed to the handler without copying and released after the handler returns and the response is written.
+// It takes effect on framers which support pooled frames, and the request body and attachment must not be
+// referenced after the handler returns.
+func WithZeroCopy(zeroCopy bool) ListenServeOption {
+	return func(opts *ListenServeOptions) {
+		opts.ZeroCopy = zeroCopy
+	}
+}
+
 // WithCopyFrame returns a ListenServeOption which sets whether copy frames.
 // In stream RPC, even server use sync mod, stream is asynchronous, we need to copy frame to avoid
 // over writing.
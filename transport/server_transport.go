@@ -95,6 +95,7 @@ func (s *serverTransport) ListenAndServe(ctx context.Context, opts ...ListenServ
 		opt(lsopts)
 	}
 	lsopts.fixKeepOrder()
+	lsopts.FramerBuilder = lsopts.LimitedFramerBuilder()
 
 	if lsopts.Listener != nil {
 		return s.listenAndServeStream(ctx, lsopts)
@@ -59,7 +59,12 @@ func (st *serverStreamTransport) Send(ctx context.Context, req []byte) error {
 	tc, ok := st.serverTransport.addrToConn[key]
 	st.serverTransport.m.RUnlock()
 	if ok && tc != nil {
-		if _, err := tc.rwc.Write(req); err != nil {
+		tc.streamWrites.acquire(StreamPriorityFromContext(ctx), len(req))
+		tc.writeMu.Lock()
+		_, err := tc.rwc.Write(req)
+		tc.writeMu.Unlock()
+		tc.streamWrites.release()
+		if err != nil {
 			tc.close()
 			st.Close(ctx)
 			return err
@@ -28,6 +28,10 @@ import (
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/internal/addrutil"
+	"trpc.group/trpc-go/trpc-go/internal/allocator"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
+	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
+	"trpc.group/trpc-go/trpc-go/internal/connlimit"
 	ikeeporder "trpc.group/trpc-go/trpc-go/internal/keeporder"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	"trpc.group/trpc-go/trpc-go/internal/report"
@@ -41,12 +45,14 @@ const defaultBufferSize = 128 * 1024
 
 type handleParam struct {
 	req   []byte
+	buf   *allocator.RefBuffer
 	c     *tcpconn
 	start time.Time
 }
 
 func (p *handleParam) reset() {
 	p.req = nil
+	p.buf = nil
 	p.c = nil
 	p.start = time.Time{}
 }
@@ -71,6 +77,7 @@ func createRoutinePool(size int) *ants.PoolWithFunc {
 			return
 		}
 		param.c.handleSync(param.req)
+		param.buf.Release()
 		param.reset()
 		handleParamPool.Put(param)
 	})
@@ -87,6 +94,7 @@ func (s *serverTransport) serveTCP(ctx context.Context, ln net.Listener, opts *L
 	if opts.ServerAsync {
 		pool = createRoutinePool(opts.Routines)
 	}
+	limiter := connlimit.New(opts.MaxConnections, opts.MaxConnectionsPerIP, opts.ConnectionLimitWait)
 	for tempDelay := time.Duration(0); ; {
 		rwc, err := ln.Accept()
 		if err != nil {
@@ -122,37 +130,63 @@ func (s *serverTransport) serveTCP(ctx context.Context, ln net.Listener, opts *L
 				}
 			}
 		}
-		reader := newReadCountingReader(codec.NewReader(rwc))
-		tc := &tcpconn{
-			conn:                           s.newConn(ctx, opts),
-			rwc:                            rwc,
-			fr:                             opts.FramerBuilder.New(reader),
-			readCounter:                    reader,
-			remoteAddr:                     rwc.RemoteAddr(),
-			localAddr:                      rwc.LocalAddr(),
-			serverAsync:                    opts.ServerAsync,
-			writev:                         opts.Writev,
-			keepOrderPreDecodeExtractor:    opts.KeepOrderPreDecodeExtractor,
-			keepOrderPreUnmarshalExtractor: opts.KeepOrderPreUnmarshalExtractor,
-			orderedGroups:                  opts.OrderedGroups,
-			st:                             s,
-			pool:                           pool,
+		// Waiting for a free slot must not block accepting other connections.
+		if limiter.Waitable() {
+			go func(rwc net.Conn) {
+				if !limiter.Acquire(rwc.RemoteAddr()) {
+					rwc.Close()
+					return
+				}
+				s.startTCPConn(ctx, rwc, opts, pool, limiter)
+			}(rwc)
+			continue
 		}
-		// Start goroutine sending with writev.
-		if tc.writev {
-			tc.buffer = writev.NewBuffer()
-			tc.closeNotify = make(chan struct{}, 1)
-			tc.buffer.Start(tc.rwc, tc.closeNotify)
+		if !limiter.Acquire(rwc.RemoteAddr()) {
+			rwc.Close()
+			continue
 		}
-		// To avoid over writing packages, checks whether should we copy packages by Framer and
-		// some other configurations.
-		tc.copyFrame = frame.ShouldCopy(opts.CopyFrame, tc.serverAsync, codec.IsSafeFramer(tc.fr))
-		key := addrutil.AddrToKey(tc.localAddr, tc.remoteAddr)
-		s.m.Lock()
-		s.addrToConn[key] = tc
-		s.m.Unlock()
-		go tc.serve()
+		s.startTCPConn(ctx, rwc, opts, pool, limiter)
+	}
+}
+
+// startTCPConn starts serving a connection which has acquired its slot of the limiter.
+func (s *serverTransport) startTCPConn(ctx context.Context, rwc net.Conn, opts *ListenServeOptions,
+	pool *ants.PoolWithFunc, limiter *connlimit.Limiter) {
+	reader := newReadCountingReader(codec.NewReader(rwc))
+	tc := &tcpconn{
+		conn:                           s.newConn(ctx, opts),
+		rwc:                            rwc,
+		fr:                             opts.FramerBuilder.New(reader),
+		fb:                             opts.FramerBuilder,
+		readCounter:                    reader,
+		remoteAddr:                     rwc.RemoteAddr(),
+		localAddr:                      rwc.LocalAddr(),
+		serverAsync:                    opts.ServerAsync,
+		writev:                         opts.Writev,
+		keepOrderPreDecodeExtractor:    opts.KeepOrderPreDecodeExtractor,
+		keepOrderPreUnmarshalExtractor: opts.KeepOrderPreUnmarshalExtractor,
+		orderedGroups:                  opts.OrderedGroups,
+		st:                             s,
+		pool:                           pool,
+		limiter:                        limiter,
 	}
+	// Start goroutine sending with writev.
+	if tc.writev {
+		tc.buffer = writev.NewBuffer()
+		tc.closeNotify = make(chan struct{}, 1)
+		tc.buffer.Start(tc.rwc, tc.closeNotify)
+	}
+	// To avoid over writing packages, checks whether should we copy packages by Framer and
+	// some other configurations.
+	tc.copyFrame = frame.ShouldCopy(opts.CopyFrame, tc.serverAsync, codec.IsSafeFramer(tc.fr))
+	if pf, ok := tc.fr.(icodec.PooledFramer); ok && opts.ZeroCopy {
+		tc.pooledFr = pf
+	}
+	key := addrutil.AddrToKey(tc.localAddr, tc.remoteAddr)
+	s.m.Lock()
+	s.addrToConn[key] = tc
+	s.m.Unlock()
+	go tc.serve()
 }
 
 func doTempDelay(tempDelay time.Duration) time.Duration {
@@ -173,17 +207,24 @@ type tcpconn struct {
 	*conn
 	rwc         net.Conn
 	fr          codec.Framer
+	fb          codec.FramerBuilder
 	readCounter *readCountingReader
 	localAddr   net.Addr
 	remoteAddr  net.Addr
 	serverAsync bool
 	writev      bool
 	copyFrame   bool
+	pooledFr    icodec.PooledFramer // reads frames into pooled buffers if zero copy is enabled.
 	closeOnce   sync.Once
 	st          *serverTransport
 	pool        *ants.PoolWithFunc
+	limiter     *connlimit.Limiter
 	buffer      *writev.Buffer
 	closeNotify chan struct{}
+	// writeMu keeps frames from interleaving with deferred attachments written in chunks.
+	writeMu sync.Mutex
+	// streamWrites schedules the writes of stream frames by the priorities of streams.
+	streamWrites streamWriteScheduler
 
 	// keepOrderPreDecodeExtractor specifies whether the current connection should keep
 	// order by a key extracted from the decoded request body.
@@ -226,11 +267,28 @@ func (c *tcpconn) close() {
 
 		// Finally, close the socket connection.
 		c.rwc.Close()
+		c.limiter.Release(c.remoteAddr)
 	})
 }
 
 // write encapsulates tcp conn write.
 func (c *tcpconn) write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeLocked(p)
+}
+
+// writeFrame writes the response frame, followed by its deferred attachment.
+func (c *tcpconn) writeFrame(msg codec.Msg, rsp []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.writeLocked(rsp); err != nil {
+		return err
+	}
+	return attachment.WriteDeferred(msg, c.rwc)
+}
+
+func (c *tcpconn) writeLocked(p []byte) (int, error) {
 	if c.writev {
 		return c.buffer.Write(p)
 	}
@@ -263,7 +321,7 @@ func (c *tcpconn) serve() {
 		if c.readCounter != nil {
 			c.readCounter.ResetReadBytes()
 		}
-		req, err := c.fr.ReadFrame()
+		req, buf, err := c.readFrame()
 		if err != nil {
 			if err == io.EOF {
 				report.TCPServerTransportReadEOF.Incr() // client has closed the connections.
@@ -284,31 +342,53 @@ func (c *tcpconn) serve() {
 			return
 		}
 		report.TCPServerTransportReceiveSize.Set(float64(len(req)))
+		// Answer heartbeat pings directly, they never reach the handler.
+		if pong, ok := codec.HeartbeatPong(c.fb, req); ok {
+			buf.Release()
+			if _, err := c.write(pong); err != nil {
+				report.TCPServerTransportWriteFail.Incr()
+				log.Trace("transport: tcpconn write heartbeat pong fail ", err)
+				return
+			}
+			continue
+		}
 		// if framer is not concurrent safe, copy the data to avoid over writing.
-		if c.copyFrame {
+		// pooled frames are owned by the handler until released.
+		if c.copyFrame && buf == nil {
 			reqCopy := make([]byte, len(req))
 			copy(reqCopy, req)
 			req = reqCopy
 		}
 
-		c.handle(req)
+		c.handle(req, buf)
 	}
 }
 
-func (c *tcpconn) handle(req []byte) {
+// readFrame reads a frame, which is in buf if buf is not nil.
+func (c *tcpconn) readFrame() ([]byte, *allocator.RefBuffer, error) {
+	if c.pooledFr != nil {
+		return c.pooledFr.ReadPooledFrame()
+	}
+	req, err := c.fr.ReadFrame()
+	return req, nil, err
+}
+
+// handle handles the request frame req, and releases buf once the request is done.
+func (c *tcpconn) handle(req []byte, buf *allocator.RefBuffer) {
 	if c.keepOrderPreDecodeExtractor != nil {
-		if ok := c.handleKeepOrderPreDecode(req); ok {
+		if ok := c.handleKeepOrderPreDecode(req, buf); ok {
 			return
 		}
 	}
 	if c.keepOrderPreUnmarshalExtractor != nil {
-		if ok := c.handleKeepOrderPreUnmarshal(req); ok {
+		if ok := c.handleKeepOrderPreUnmarshal(req, buf); ok {
 			return
 		}
 	}
 
 	if !c.serverAsync || c.pool == nil {
 		c.handleSync(req)
+		buf.Release()
 		return
 	}
 
@@ -316,16 +396,18 @@ func (c *tcpconn) handle(req []byte) {
 	// allocation and slightly promote performance.
 	args := handleParamPool.Get().(*handleParam)
 	args.req = req
+	args.buf = buf
 	args.c = c
 	args.start = time.Now()
 	if err := c.pool.Invoke(args); err != nil {
 		report.TCPServerTransportJobQueueFullFail.Incr()
 		log.Trace("transport: tcpconn serve routine pool put job queue fail ", err)
 		c.handleSyncWithErr(req, errs.ErrServerRoutinePoolBusy)
+		buf.Release()
 	}
 }
 
-func (c *tcpconn) handleKeepOrderPreDecode(req []byte) bool {
+func (c *tcpconn) handleKeepOrderPreDecode(req []byte, buf *allocator.RefBuffer) bool {
 	pdh, ok := c.handler.(ikeeporder.PreDecodeHandler)
 	if !ok {
 		panic("bug: handler must implement pre-decode interface for keep-order requests")
@@ -346,6 +428,7 @@ func (c *tcpconn) handleKeepOrderPreDecode(req []byte) bool {
 	c.orderedGroups.Add(keepOrderKey, func() {
 		defer func() {
 			codec.PutBackMessage(msg)
+			buf.Release()
 			if err := recover(); err != nil {
 				log.ErrorContextf(ctx, "[PANIC]%v\n%s\n", err, debug.Stack())
 				report.PanicNum.Incr()
@@ -356,7 +439,7 @@ func (c *tcpconn) handleKeepOrderPreDecode(req []byte) bool {
 	return true
 }
 
-func (c *tcpconn) handleKeepOrderPreUnmarshal(req []byte) bool {
+func (c *tcpconn) handleKeepOrderPreUnmarshal(req []byte, buf *allocator.RefBuffer) bool {
 	puh, ok := c.handler.(ikeeporder.PreUnmarshalHandler)
 	if !ok {
 		panic("bug: handler must implement pre-unmarshal interface for keep-order requests")
@@ -378,6 +461,7 @@ func (c *tcpconn) handleKeepOrderPreUnmarshal(req []byte) bool {
 	c.orderedGroups.Add(keepOrderKey, func() {
 		defer func() {
 			codec.PutBackMessage(msg)
+			buf.Release()
 			if err := recover(); err != nil {
 				log.ErrorContextf(ctx, "[PANIC]%v\n%s\n", err, debug.Stack())
 				report.PanicNum.Incr()
@@ -404,6 +488,12 @@ func (c *tcpconn) handleSyncWithErrAndContext(ctx context.Context, msg codec.Msg
 	msg.WithLocalAddr(c.localAddr)
 	msg.WithRemoteAddr(c.remoteAddr)
 
+	// the response attachment of known size is written after the frame in chunks,
+	// unless writev queues frames to be written by another goroutine.
+	if !c.writev {
+		attachment.AllowServerDeferred(msg)
+	}
+
 	span, ender, ctx := rpcz.NewSpanContext(ctx, "server")
 	span.SetAttribute(rpcz.TRPCAttributeRequestSize, len(req))
 
@@ -433,7 +523,7 @@ func (c *tcpconn) handleSyncWithErrAndContext(ctx context.Context, msg codec.Msg
 	{
 		// common RPC write rsp.
 		_, ender := span.NewChild("SendMessage")
-		_, err = c.write(rsp)
+		err = c.writeFrame(msg, rsp)
 		ender.End()
 	}
 
@@ -25,7 +25,7 @@ func TestTCPConnHandleKeepOrderPreDecodeRequiresHandlerInterface(t *testing.T) {
 	c := &tcpconn{conn: &conn{handler: tcpKeepOrderPlainHandler{}}}
 	require.PanicsWithValue(t,
 		"bug: handler must implement pre-decode interface for keep-order requests",
-		func() { c.handleKeepOrderPreDecode([]byte("request")) },
+		func() { c.handleKeepOrderPreDecode([]byte("request"), nil) },
 	)
 }
 
@@ -33,14 +33,14 @@ func TestTCPConnHandleKeepOrderPreDecodeFallbackOnError(t *testing.T) {
 	c := &tcpconn{conn: &conn{handler: tcpKeepOrderErrorHandler{
 		preDecodeErr: errors.New("pre-decode failed"),
 	}}}
-	require.False(t, c.handleKeepOrderPreDecode([]byte("request")))
+	require.False(t, c.handleKeepOrderPreDecode([]byte("request"), nil))
 }
 
 func TestTCPConnHandleKeepOrderPreUnmarshalRequiresHandlerInterface(t *testing.T) {
 	c := &tcpconn{conn: &conn{handler: tcpKeepOrderPlainHandler{}}}
 	require.PanicsWithValue(t,
 		"bug: handler must implement pre-unmarshal interface for keep-order requests",
-		func() { c.handleKeepOrderPreUnmarshal([]byte("request")) },
+		func() { c.handleKeepOrderPreUnmarshal([]byte("request"), nil) },
 	)
 }
 
@@ -48,7 +48,7 @@ func TestTCPConnHandleKeepOrderPreUnmarshalFallbackOnError(t *testing.T) {
 	c := &tcpconn{conn: &conn{handler: tcpKeepOrderErrorHandler{
 		preUnmarshalErr: errors.New("pre-unmarshal failed"),
 	}}}
-	require.False(t, c.handleKeepOrderPreUnmarshal([]byte("request")))
+	require.False(t, c.handleKeepOrderPreUnmarshal([]byte("request"), nil))
 }
 
 type tcpKeepOrderPlainHandler struct{}
@@ -1051,6 +1051,87 @@ func TestTCPListenAndServeWithSafeFramer(t *testing.T) {
 	assert.Equal(t, helloRsp.Msg, "HelloWorld")
 }
 
+func TestTCPListenAndServeAnswersHeartbeat(t *testing.T) {
+	addr := getFreeAddr("tcp4")
+	st := transport.NewServerTransport()
+	require.Nil(t, st.ListenAndServe(context.Background(),
+		transport.WithListenNetwork("tcp4"),
+		transport.WithListenAddress(addr),
+		// The handler always fails, which closes the connection if a ping ever reaches it.
+		transport.WithHandler(&errorHandler{}),
+		transport.WithServerFramerBuilder(trpc.DefaultFramerBuilder),
+	))
+
+	conn, err := net.Dial("tcp4", addr)
+	require.Nil(t, err)
+	defer conn.Close()
+	require.Nil(t, conn.SetDeadline(time.Now().Add(time.Second)))
+	fb := trpc.DefaultFramerBuilder
+	fr := fb.New(conn)
+	for seq := uint32(1); seq <= 2; seq++ {
+		_, err = conn.Write(fb.Ping(seq))
+		require.Nil(t, err)
+		pong, err := fr.ReadFrame()
+		require.Nil(t, err)
+		require.True(t, fb.IsPong(pong))
+		require.Equal(t, seq, binary.BigEndian.Uint32(pong[10:14]))
+	}
+}
+
+func TestTCPListenAndServeWithConnectionLimits(t *testing.T) {
+	ping := func(conn net.Conn) error {
+		fb := trpc.DefaultFramerBuilder
+		if _, err := conn.Write(fb.Ping(1)); err != nil {
+			return err
+		}
+		_, err := fb.New(conn).ReadFrame()
+		return err
+	}
+	t.Run("reject", func(t *testing.T) {
+		addr := getFreeAddr("tcp4")
+		require.Nil(t, transport.NewServerTransport().ListenAndServe(context.Background(),
+			transport.WithListenNetwork("tcp4"),
+			transport.WithListenAddress(addr),
+			transport.WithHandler(&errorHandler{}),
+			transport.WithServerFramerBuilder(trpc.DefaultFramerBuilder),
+			transport.WithMaxConnectionsPerIP(1),
+		))
+		c1, err := net.Dial("tcp4", addr)
+		require.Nil(t, err)
+		defer c1.Close()
+		require.Nil(t, c1.SetDeadline(time.Now().Add(time.Second)))
+		require.Nil(t, ping(c1))
+
+		c2, err := net.Dial("tcp4", addr)
+		require.Nil(t, err)
+		defer c2.Close()
+		require.Nil(t, c2.SetDeadline(time.Now().Add(time.Second)))
+		require.NotNil(t, ping(c2))
+	})
+	t.Run("wait", func(t *testing.T) {
+		addr := getFreeAddr("tcp4")
+		require.Nil(t, transport.NewServerTransport().ListenAndServe(context.Background(),
+			transport.WithListenNetwork("tcp4"),
+			transport.WithListenAddress(addr),
+			transport.WithHandler(&errorHandler{}),
+			transport.WithServerFramerBuilder(trpc.DefaultFramerBuilder),
+			transport.WithMaxConnections(1),
+			transport.WithConnectionLimitWait(time.Second),
+		))
+		c1, err := net.Dial("tcp4", addr)
+		require.Nil(t, err)
+		require.Nil(t, c1.SetDeadline(time.Now().Add(time.Second)))
+		require.Nil(t, ping(c1))
+
+		c2, err := net.Dial("tcp4", addr)
+		require.Nil(t, err)
+		defer c2.Close()
+		require.Nil(t, c2.SetDeadline(time.Now().Add(2*time.Second)))
+		time.AfterFunc(100*time.Millisecond, func() { c1.Close() })
+		require.Nil(t, ping(c2))
+	})
+}
+
 func TestWithDisableKeepAlives(t *testing.T) {
 	disable := true
 	o := transport.WithDisableKeepAlives(true)
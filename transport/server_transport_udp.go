@@ -23,6 +23,7 @@ import (
 	"github.com/panjf2000/ants/v2"
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/allocator"
 	"trpc.group/trpc-go/trpc-go/internal/packetbuffer"
 	"trpc.group/trpc-go/trpc-go/internal/report"
 	"trpc.group/trpc-go/trpc-go/log"
@@ -35,6 +36,9 @@ func (s *serverTransport) serveUDP(ctx context.Context, rwc *net.UDPConn, pool *
 	if s.opts.RecvUDPRawSocketBufSize > 0 {
 		rwc.SetReadBuffer(s.opts.RecvUDPRawSocketBufSize)
 	}
+	if opts.UDPFragment != nil {
+		return s.serveFragmentedUDP(ctx, rwc, pool, opts)
+	}
 
 	var tempDelay time.Duration
 	buf := packetbuffer.New(rwc, s.opts.RecvUDPPacketBufferSize)
@@ -52,14 +56,7 @@ func (s *serverTransport) serveUDP(ctx context.Context, rwc *net.UDPConn, pool *
 		req, err := fr.ReadFrame()
 		if err != nil {
 			if ne, ok := err.(net.Error); ok && ne.Temporary() {
-				if tempDelay == 0 {
-					tempDelay = 5 * time.Millisecond
-				} else {
-					tempDelay *= 2
-				}
-				if max := 1 * time.Second; tempDelay > max {
-					tempDelay = max
-				}
+				tempDelay = nextTempDelay(tempDelay)
 				time.Sleep(tempDelay)
 				continue
 			}
@@ -100,18 +97,156 @@ func (s *serverTransport) serveUDP(ctx context.Context, rwc *net.UDPConn, pool *
 			c.req = req
 		}
 
-		if pool == nil {
-			go c.serve()
+		serveUDPConn(c, pool)
+	}
+}
+
+// nextTempDelay returns the delay before retrying on a temporary read error.
+func nextTempDelay(tempDelay time.Duration) time.Duration {
+	if tempDelay == 0 {
+		tempDelay = 5 * time.Millisecond
+	} else {
+		tempDelay *= 2
+	}
+	if max := 1 * time.Second; tempDelay > max {
+		tempDelay = max
+	}
+	return tempDelay
+}
+
+func serveUDPConn(c *udpconn, pool *ants.PoolWithFunc) {
+	if pool == nil {
+		go c.serve()
+		return
+	}
+	if err := pool.Invoke(c); err != nil {
+		report.UDPServerTransportJobQueueFullFail.Incr()
+		log.Trace("transport: udpconn serve routine pool put job queue fail ", err)
+		go c.serve()
+	}
+}
+
+// serveFragmentedUDP serves UDP with fragmentation enabled. Fragments are reassembled into a
+// whole message before being handled, and the response is fragmented if the request is.
+func (s *serverTransport) serveFragmentedUDP(ctx context.Context, rwc *net.UDPConn, pool *ants.PoolWithFunc,
+	opts *ListenServeOptions) error {
+	fo := opts.UDPFragment.withDefaults()
+	r := newReassembler(fo)
+	var cache *fragmentCache
+	if fo.Retransmit {
+		cache = newFragmentCache(fo)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go checkFragments(rwc, r, cache, fo.RetransmitInterval, done)
+
+	buf, i := allocator.Malloc(s.opts.RecvUDPPacketBufferSize)
+	defer allocator.Free(i)
+	var tempDelay time.Duration
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.New("recv server close event")
+		default:
+		}
+
+		n, remoteAddr, err := rwc.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				tempDelay = nextTempDelay(tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
+			report.UDPServerTransportReadFail.Incr()
+			log.Trace("transport: udpconn serve ReadFromUDP fail ", err)
+			continue
+		}
+		tempDelay = 0
+
+		c := &udpconn{
+			conn:       s.newConn(ctx, opts),
+			rwc:        rwc,
+			remoteAddr: remoteAddr,
+		}
+		msg := buf[:n]
+		h, payload, ok := parseFragment(msg)
+		if ok {
+			if h.typ == fragmentTypeNack {
+				retransmitFragments(rwc, remoteAddr, cache, h, payload)
+				continue
+			}
+			msg, err = r.add(remoteAddr, h, payload, time.Now())
+			if err != nil {
+				if err == errReassemblyOverflow {
+					report.UDPFragmentReassemblyOverflow.Incr()
+				}
+				log.Trace("transport: udpconn serve reassemble fragment fail ", err)
+				continue
+			}
+			if msg == nil {
+				continue
+			}
+			c.fragment = &udpFragmentSession{opts: fo, msgID: h.msgID, cache: cache}
+		}
+
+		req, err := readUDPMessage(opts.FramerBuilder, msg)
+		if err != nil {
+			report.UDPServerTransportReadFail.Incr()
+			log.Trace("transport: udpconn serve ReadFrame fail ", err)
 			continue
 		}
-		if err := pool.Invoke(c); err != nil {
-			report.UDPServerTransportJobQueueFullFail.Incr()
-			log.Trace("transport: udpconn serve routine pool put job queue fail ", err)
-			go c.serve()
+		report.UDPServerTransportReceiveSize.Set(float64(len(req)))
+		c.req = req
+		serveUDPConn(c, pool)
+	}
+}
+
+// checkFragments periodically expires incomplete messages and cached fragments, and sends the
+// retransmission requests of missing fragments.
+func checkFragments(rwc *net.UDPConn, r *reassembler, cache *fragmentCache, interval time.Duration,
+	done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			for _, nack := range r.check(now) {
+				if _, err := rwc.WriteTo(nack.pkt, nack.addr); err != nil {
+					log.Tracef("udp write fragment retransmission request fail:%v", err)
+				}
+			}
+			if cache != nil {
+				cache.expire(now)
+			}
+		}
+	}
+}
+
+// retransmitFragments resends the fragments requested by the peer.
+func retransmitFragments(rwc *net.UDPConn, addr *net.UDPAddr, cache *fragmentCache,
+	h fragmentHeader, payload []byte) {
+	if cache == nil {
+		return
+	}
+	for _, frag := range cache.get(addr, h.msgID, decodeNack(h, payload)) {
+		report.UDPFragmentRetransmit.Incr()
+		if _, err := rwc.WriteToUDP(frag, addr); err != nil {
+			log.Tracef("udp retransmit fragment fail:%v", err)
+			return
 		}
 	}
 }
 
+// udpFragmentSession records the fragmentation state of a request, so that the response
+// is fragmented in the same way.
+type udpFragmentSession struct {
+	opts  *UDPFragmentOptions
+	msgID uint32
+	cache *fragmentCache
+}
+
 // udpconn is the UDP connection which is established when server receives a client connecting
 // request.
 type udpconn struct {
@@ -119,6 +254,7 @@ type udpconn struct {
 	req        []byte
 	rwc        *net.UDPConn
 	remoteAddr *net.UDPAddr
+	fragment   *udpFragmentSession // not nil if the request is fragmented
 }
 
 func (c *udpconn) serve() {
@@ -140,6 +276,10 @@ func (c *udpconn) serve() {
 	}
 
 	report.UDPServerTransportSendSize.Set(float64(len(rsp)))
+	if c.fragment != nil {
+		c.writeFragments(rsp)
+		return
+	}
 	if _, err := c.rwc.WriteToUDP(rsp, c.remoteAddr); err != nil {
 		report.UDPServerTransportWriteFail.Incr()
 		log.Tracef("udp write out fail:%v", err)
@@ -147,6 +287,25 @@ func (c *udpconn) serve() {
 	}
 }
 
+func (c *udpconn) writeFragments(rsp []byte) {
+	frags, err := splitFragments(c.fragment.msgID, rsp, c.fragment.opts.MaxFragmentSize)
+	if err != nil {
+		report.UDPServerTransportWriteFail.Incr()
+		log.Tracef("udp split fragments fail:%v", err)
+		return
+	}
+	if c.fragment.cache != nil {
+		c.fragment.cache.put(c.remoteAddr, c.fragment.msgID, frags, time.Now())
+	}
+	for _, frag := range frags {
+		if _, err := c.rwc.WriteToUDP(frag, c.remoteAddr); err != nil {
+			report.UDPServerTransportWriteFail.Incr()
+			log.Tracef("udp write out fail:%v", err)
+			return
+		}
+	}
+}
+
 func createUDPRoutinePool(size int) *ants.PoolWithFunc {
 	if size <= 0 {
 		size = math.MaxInt32
@@ -14,6 +14,7 @@
 package transport_test
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"net"
@@ -73,3 +74,92 @@ func Test_ServerTransport_UDP(t *testing.T) {
 	_, err = pc.Read(result)
 	require.Nil(t, err)
 }
+
+func Test_ServerTransport_UDPFragment(t *testing.T) {
+	addr := getFreeAddr("udp")
+	fragment := &transport.UDPFragmentOptions{MaxFragmentSize: 64, Retransmit: true}
+	require.Nil(t, transport.ListenAndServe(
+		transport.WithListenNetwork("udp"),
+		transport.WithListenAddress(addr),
+		transport.WithHandler(&echoHandler{}),
+		transport.WithServerFramerBuilder(&framerBuilder{}),
+		transport.WithServerUDPFragment(fragment),
+	))
+	time.Sleep(20 * time.Millisecond)
+
+	data := make([]byte, 4+4096)
+	binary.BigEndian.PutUint32(data, 4096)
+	for i := 4; i < len(data); i++ {
+		data[i] = byte(i)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	rsp, err := transport.RoundTrip(ctx, data,
+		transport.WithDialNetwork("udp"),
+		transport.WithDialAddress(addr),
+		transport.WithClientFramerBuilder(&framerBuilder{}),
+		transport.WithClientUDPFragment(fragment),
+	)
+	require.Nil(t, err)
+	require.Equal(t, data, rsp)
+
+	// Plain requests are still served by the fragmentation enabled server.
+	small := []byte{0, 0, 0, 2, 'h', 'i'}
+	rsp, err = transport.RoundTrip(ctx, small,
+		transport.WithDialNetwork("udp"),
+		transport.WithDialAddress(addr),
+		transport.WithClientFramerBuilder(&framerBuilder{}),
+	)
+	require.Nil(t, err)
+	require.Equal(t, small, rsp)
+}
+
+func Test_ServerTransport_UDPFragmentRetransmit(t *testing.T) {
+	addr := getFreeAddr("udp")
+	require.Nil(t, transport.ListenAndServe(
+		transport.WithListenNetwork("udp"),
+		transport.WithListenAddress(addr),
+		transport.WithHandler(&echoHandler{}),
+		transport.WithServerFramerBuilder(&framerBuilder{}),
+		transport.WithServerUDPFragment(&transport.UDPFragmentOptions{
+			Retransmit:         true,
+			RetransmitInterval: 20 * time.Millisecond,
+		}),
+	))
+	time.Sleep(20 * time.Millisecond)
+
+	fragment := func(typ uint8, index, count uint16, payload []byte) []byte {
+		pkt := make([]byte, 12, 12+len(payload))
+		binary.BigEndian.PutUint16(pkt[0:2], 0x9f7a)
+		pkt[2] = typ
+		binary.BigEndian.PutUint32(pkt[4:8], 1)
+		binary.BigEndian.PutUint16(pkt[8:10], index)
+		binary.BigEndian.PutUint16(pkt[10:12], count)
+		return append(pkt, payload...)
+	}
+	conn, err := net.Dial("udp", addr)
+	require.Nil(t, err)
+	defer conn.Close()
+	require.Nil(t, conn.SetDeadline(time.Now().Add(time.Second)))
+
+	// Send only the first fragment, server asks for the second one.
+	_, err = conn.Write(fragment(1, 0, 2, []byte{0, 0, 0, 2}))
+	require.Nil(t, err)
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	require.Nil(t, err)
+	require.Equal(t, fragment(2, 0, 1, []byte{0, 1}), buf[:n])
+
+	_, err = conn.Write(fragment(1, 1, 2, []byte("hi")))
+	require.Nil(t, err)
+	n, err = conn.Read(buf)
+	require.Nil(t, err)
+	require.Equal(t, fragment(1, 0, 1, []byte{0, 0, 0, 2, 'h', 'i'}), buf[:n])
+
+	// Server resends the cached response fragment on request.
+	_, err = conn.Write(fragment(2, 0, 1, []byte{0, 0}))
+	require.Nil(t, err)
+	n, err = conn.Read(buf)
+	require.Nil(t, err)
+	require.Equal(t, fragment(1, 0, 1, []byte{0, 0, 0, 2, 'h', 'i'}), buf[:n])
+}
@@ -0,0 +1,104 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"context"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-go/internal/wfq"
+)
+
+// StreamPriority is the priority of a stream. The frames of streams sharing a connection are
+// written by weighted fair queueing, so that streams of higher priorities get larger shares of
+// the bandwidth, while streams of lower priorities are never starved.
+type StreamPriority uint8
+
+const (
+	// StreamPriorityNormal is the default priority.
+	StreamPriorityNormal StreamPriority = iota
+	// StreamPriorityLow is the priority of streams, such as bulk transfers, which should give way to others.
+	StreamPriorityLow
+	// StreamPriorityHigh is the priority of latency sensitive streams.
+	StreamPriorityHigh
+)
+
+// streamPriorityWeights are the weights of the bytes written by each priority when the connection is busy.
+var streamPriorityWeights = []int{
+	StreamPriorityNormal: 4,
+	StreamPriorityLow:    1,
+	StreamPriorityHigh:   16,
+}
+
+// streamPriorityContextKey is the context key of the stream priority.
+var streamPriorityContextKey = &contextKey{"stream-priority"}
+
+// ContextWithStreamPriority returns a copy of ctx which carries the stream priority, the frames sent by
+// the server stream transport with the context are written by the priority.
+func ContextWithStreamPriority(ctx context.Context, p StreamPriority) context.Context {
+	return context.WithValue(ctx, streamPriorityContextKey, p)
+}
+
+// StreamPriorityFromContext gets the stream priority from context, default is StreamPriorityNormal.
+func StreamPriorityFromContext(ctx context.Context) StreamPriority {
+	p, ok := ctx.Value(streamPriorityContextKey).(StreamPriority)
+	if !ok {
+		return StreamPriorityNormal
+	}
+	return p
+}
+
+// streamWriteScheduler grants the writes of stream frames to a connection one at a time.
+// Writes waiting for their turns are granted by the priorities of their streams.
+type streamWriteScheduler struct {
+	mu      sync.Mutex
+	writing bool
+	waiters *wfq.Scheduler[chan struct{}]
+}
+
+// acquire blocks until the write of size bytes is granted, release must be called after writing.
+func (s *streamWriteScheduler) acquire(p StreamPriority, size int) {
+	s.mu.Lock()
+	if !s.writing {
+		s.writing = true
+		s.mu.Unlock()
+		return
+	}
+	if s.waiters == nil {
+		s.waiters = wfq.New[chan struct{}](streamPriorityWeights...)
+	}
+	granted := make(chan struct{})
+	s.waiters.Push(int(p), size, granted)
+	s.mu.Unlock()
+	<-granted
+}
+
+// release grants the next write waiting.
+func (s *streamWriteScheduler) release() {
+	s.mu.Lock()
+	var (
+		granted chan struct{}
+		ok      bool
+	)
+	if s.waiters != nil {
+		granted, ok = s.waiters.Pop()
+	}
+	if !ok {
+		s.writing = false
+	}
+	s.mu.Unlock()
+	if ok {
+		close(granted)
+	}
+}
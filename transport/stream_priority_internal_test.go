@@ -0,0 +1,73 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-go/internal/wfq"
+)
+
+func TestStreamPriorityContext(t *testing.T) {
+	require.Equal(t, StreamPriorityNormal, StreamPriorityFromContext(context.Background()))
+	ctx := ContextWithStreamPriority(context.Background(), StreamPriorityHigh)
+	require.Equal(t, StreamPriorityHigh, StreamPriorityFromContext(ctx))
+}
+
+func TestStreamWriteScheduler(t *testing.T) {
+	var s streamWriteScheduler
+	// The first write is granted immediately.
+	s.acquire(StreamPriorityLow, wfq.Quantum)
+
+	var (
+		mu    sync.Mutex
+		order []StreamPriority
+		wg    sync.WaitGroup
+	)
+	waiting := func() int {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.waiters == nil {
+			return 0
+		}
+		return s.waiters.Len()
+	}
+	for i, p := range []StreamPriority{
+		StreamPriorityLow, StreamPriorityLow, StreamPriorityLow,
+		StreamPriorityHigh, StreamPriorityHigh, StreamPriorityHigh,
+	} {
+		wg.Add(1)
+		go func(p StreamPriority) {
+			defer wg.Done()
+			s.acquire(p, wfq.Quantum)
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			s.release()
+		}(p)
+		// Queue the writes one by one to keep the order of those of the same priority.
+		require.Eventually(t, func() bool { return waiting() == i+1 }, time.Second, time.Millisecond)
+	}
+	s.release()
+	wg.Wait()
+
+	l, h := StreamPriorityLow, StreamPriorityHigh
+	require.Equal(t, []StreamPriority{l, h, h, h, l, l}, order)
+	require.False(t, s.writing)
+}
@@ -24,7 +24,9 @@ import (
 	"trpc.group/trpc-go/tnet"
 	"trpc.group/trpc-go/tnet/tls"
 
+	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	intertls "trpc.group/trpc-go/trpc-go/internal/tls"
 	"trpc.group/trpc-go/trpc-go/log"
@@ -91,6 +93,9 @@ func (c *clientTransport) switchNetworkToRoundTrip(
 	if err != nil {
 		return nil, err
 	}
+	if err := checkRoundTripUDPFragment(option); err != nil {
+		return nil, err
+	}
 	if err := canUseTnet(option); err != nil {
 		log.Error("switch to gonet default transport, ", err)
 		return transport.DefaultClientTransport.RoundTrip(ctx, req, opts...)
@@ -98,17 +103,38 @@ func (c *clientTransport) switchNetworkToRoundTrip(
 	log.Tracef("roundtrip to:%s is using tnet transport, current number of pollers: %d",
 		option.Address, tnet.NumPollers())
 	if option.EnableMultiplexed {
+		if req, err = appendDeferredAttachment(ctx, req); err != nil {
+			return nil, err
+		}
 		return c.multiplex(ctx, req, option)
 	}
 	switch option.Network {
 	case protocol.TCP, protocol.TCP4, protocol.TCP6:
 		return c.tcpRoundTrip(ctx, req, option)
+	case protocol.UDP, protocol.UDP4, protocol.UDP6:
+		if req, err = appendDeferredAttachment(ctx, req); err != nil {
+			return nil, err
+		}
+		return c.udpRoundTrip(ctx, req, option)
 	default:
 		return nil, errs.NewFrameError(errs.RetClientConnectFail,
 			fmt.Sprintf("tnet client transport, doesn't support network [%s]", option.Network))
 	}
 }
 
+// WritesDeferredAttachment implements attachment.DeferredWriter.
+// Deferred attachments are written after frames on tcp connections, and are appended to frames otherwise.
+func (c *clientTransport) WritesDeferredAttachment() {}
+
+// appendDeferredAttachment appends the deferred attachment of the request to req.
+func appendDeferredAttachment(ctx context.Context, req []byte) ([]byte, error) {
+	req, err := attachment.AppendDeferred(codec.Message(ctx), req)
+	if err != nil {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail, "tnet client transport: "+err.Error())
+	}
+	return req, nil
+}
+
 func buildRoundTripOptions(opts ...transport.RoundTripOption) (*transport.RoundTripOptions, error) {
 	rtOpts := &transport.RoundTripOptions{
 		Pool:        DefaultConnPool,
@@ -120,6 +146,7 @@ func buildRoundTripOptions(opts ...transport.RoundTripOption) (*transport.RoundT
 	if rtOpts.FramerBuilder == nil {
 		return nil, errs.NewFrameError(errs.RetClientConnectFail, "client transport: framer builder empty")
 	}
+	rtOpts.FramerBuilder = rtOpts.LimitedFramerBuilder()
 	return rtOpts, nil
 }
 
@@ -189,9 +216,31 @@ func validateTnetTLSConn(conn net.Conn) bool {
 	return ok
 }
 
+// checkRoundTripUDPFragment rejects udp fragmentation, which tnet doesn't support. Unlike other options not supported,
+// it's not sent by the default transport instead, so that the config is fixed explicitly.
+func checkRoundTripUDPFragment(opts *transport.RoundTripOptions) error {
+	if opts.UDPFragment == nil {
+		return nil
+	}
+	switch opts.Network {
+	case protocol.UDP, protocol.UDP4, protocol.UDP6:
+		return fmt.Errorf("tnet client transport doesn't support udp fragmentation of %s, "+
+			"use the default transport instead", opts.Address)
+	}
+	return nil
+}
+
 func canUseTnet(opts *transport.RoundTripOptions) error {
 	switch opts.Network {
 	case protocol.TCP, protocol.TCP4, protocol.TCP6:
+	case protocol.UDP, protocol.UDP4, protocol.UDP6:
+		// tnet only dials connected UDP sockets without specifying the local address.
+		if opts.ConnectionMode == transport.NotConnected || opts.LocalAddr != "" {
+			return fmt.Errorf("tnet doesn't support not connected udp or udp with local address")
+		}
+		if opts.EnableMultiplexed {
+			return fmt.Errorf("tnet doesn't support multiplexed udp")
+		}
 	default:
 		return fmt.Errorf("tnet doesn't support network [%s]", opts.Network)
 	}
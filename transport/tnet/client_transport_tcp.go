@@ -25,6 +25,7 @@ import (
 
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
 	"trpc.group/trpc-go/trpc-go/internal/report"
 	"trpc.group/trpc-go/trpc-go/log"
 	"trpc.group/trpc-go/trpc-go/pool/connpool"
@@ -53,6 +54,9 @@ func (c *clientTransport) tcpRoundTrip(ctx context.Context, reqData []byte,
 	if err := tcpWriteFrame(conn, reqData); err != nil {
 		return nil, err
 	}
+	if err := tcpWriteDeferredAttachment(msg, conn); err != nil {
+		return nil, err
+	}
 	// Receive a response.
 	return tcpReadFrame(conn, opts)
 }
@@ -101,6 +105,7 @@ func dialTCP(ctx context.Context, opts *transport.RoundTripOptions) (net.Conn, e
 	getOpts := connpool.NewGetOptions()
 	getOpts.WithContext(ctx)
 	getOpts.WithFramerBuilder(opts.FramerBuilder)
+	getOpts.WithFramerKey(opts.FramerKey())
 	getOpts.WithDialTLS(opts.TLSCertFile, opts.TLSKeyFile, opts.CACertFile, opts.TLSServerName)
 	getOpts.WithCertProvider(opts.TLSCertProvider)
 	getOpts.WithLocalAddr(opts.LocalAddr)
@@ -130,6 +135,30 @@ func tcpWriteFrame(conn net.Conn, reqData []byte) error {
 	return nil
 }
 
+// tcpWriteDeferredAttachment writes the deferred attachment of the request after the frame in chunks.
+// The connection is closed on failure, as the frame is broken.
+func tcpWriteDeferredAttachment(msg codec.Msg, conn net.Conn) error {
+	err := attachment.WriteDeferred(msg, chunkWriter{conn: conn})
+	if err == nil {
+		return nil
+	}
+	if pc, ok := conn.(interface{ GetRawConn() net.Conn }); ok {
+		pc.GetRawConn().Close()
+	}
+	return wrapNetError("tcp client tnet transport write attachment", err)
+}
+
+// chunkWriter copies chunks before writing them to the tnet connection, which keeps references to
+// the written buffers until they are sent.
+type chunkWriter struct {
+	conn net.Conn
+}
+
+// Write implements io.Writer.
+func (w chunkWriter) Write(p []byte) (int, error) {
+	return w.conn.Write(append([]byte(nil), p...))
+}
+
 func tcpReadFrame(conn net.Conn, opts *transport.RoundTripOptions) ([]byte, error) {
 	if opts.ReqType == transport.SendOnly {
 		return nil, errs.ErrClientNoResponse
@@ -184,6 +213,7 @@ func (c *clientTransport) multiplex(ctx context.Context, req []byte, opts *trans
 			"frame builder does not implement multiplexed.FrameParser")
 	}
 	getOpts.WithFrameParser(fp)
+	getOpts.WithFramerKey(opts.FramerKey())
 	getOpts.WithDialTLS(opts.TLSCertFile, opts.TLSKeyFile, opts.CACertFile, opts.TLSServerName)
 	getOpts.WithCertProvider(opts.TLSCertProvider)
 	getOpts.WithLocalAddr(opts.LocalAddr)
@@ -164,7 +164,6 @@ func TestClientTCP_CustomPool(t *testing.T) {
 }
 
 func TestClientUDP(t *testing.T) {
-	// UDP is not supported, but it will switch to gonet default transport to roundtrip.
 	startClientTest(
 		t,
 		defaultServerHandle,
@@ -181,6 +180,45 @@ func TestClientUDP(t *testing.T) {
 	)
 }
 
+func TestClientUDP_NotConnected(t *testing.T) {
+	// Not connected UDP is not supported, but it will switch to gonet default transport to roundtrip.
+	startClientTest(
+		t,
+		defaultServerHandle,
+		[]transport.ListenServeOption{transport.WithListenNetwork("udp")},
+		func(addr string) {
+			rsp, err := tnetRequest(
+				context.Background(),
+				helloWorld,
+				transport.WithDialAddress(addr),
+				transport.WithDialNetwork("udp"),
+				transport.WithConnectionMode(transport.NotConnected))
+			assert.Nil(t, err)
+			assert.Equal(t, helloWorld, rsp)
+		},
+	)
+}
+
+func TestClientUDP_Timeout(t *testing.T) {
+	startClientTest(
+		t,
+		func(ctx context.Context, req []byte) ([]byte, error) {
+			return nil, errs.ErrServerNoResponse
+		},
+		[]transport.ListenServeOption{transport.WithListenNetwork("udp")},
+		func(addr string) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := tnetRequest(
+				ctx,
+				helloWorld,
+				transport.WithDialAddress(addr),
+				transport.WithDialNetwork("udp"))
+			assert.Equal(t, errs.RetClientTimeout, errs.Code(err))
+		},
+	)
+}
+
 func TestClientUnix(t *testing.T) {
 	// Unix socket is not supported, but it will switch to gonet default transport to roundtrip.
 	unixAddr := "/tmp/server.sock"
@@ -17,14 +17,17 @@
 package tnet_test
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	trpc "trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/pool/connpool"
+	"trpc.group/trpc-go/trpc-go/transport"
 	tnettrans "trpc.group/trpc-go/trpc-go/transport/tnet"
 )
 
@@ -71,3 +74,22 @@ func TestDial(t *testing.T) {
 		})
 	}
 }
+
+func TestUDPFragmentNotSupported(t *testing.T) {
+	fragment := &transport.UDPFragmentOptions{}
+	_, err := tnettrans.NewClientTransport().RoundTrip(context.Background(), []byte("req"),
+		transport.WithDialNetwork("udp"),
+		transport.WithDialAddress("127.0.0.1:9999"),
+		transport.WithClientFramerBuilder(trpc.DefaultFramerBuilder),
+		transport.WithClientUDPFragment(fragment),
+	)
+	assert.ErrorContains(t, err, "doesn't support udp fragmentation")
+
+	err = tnettrans.NewServerTransport().ListenAndServe(context.Background(),
+		transport.WithListenNetwork("tcp,udp"),
+		transport.WithListenAddress("127.0.0.1:0"),
+		transport.WithServerFramerBuilder(trpc.DefaultFramerBuilder),
+		transport.WithServerUDPFragment(fragment),
+	)
+	assert.ErrorContains(t, err, "doesn't support udp fragmentation")
+}
@@ -0,0 +1,99 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+//go:build linux || freebsd || dragonfly || darwin
+// +build linux freebsd dragonfly darwin
+
+package tnet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"trpc.group/trpc-go/tnet"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/report"
+	"trpc.group/trpc-go/trpc-go/log"
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+func (c *clientTransport) udpRoundTrip(ctx context.Context, reqData []byte,
+	opts *transport.RoundTripOptions) ([]byte, error) {
+	conn, err := dialUDP(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	msg := codec.Message(ctx)
+	msg.WithRemoteAddr(conn.RemoteAddr())
+	msg.WithLocalAddr(conn.LocalAddr())
+
+	if err := checkContextErr(ctx); err != nil {
+		return nil, fmt.Errorf("before Write: %w", err)
+	}
+
+	report.UDPClientTransportSendSize.Set(float64(len(reqData)))
+	// Send a request, a connected udp socket sends the whole packet or nothing.
+	if _, err := conn.Write(reqData); err != nil {
+		return nil, wrapNetError("udp client tnet transport Write", err)
+	}
+	// Receive a response.
+	return udpReadFrame(conn, opts)
+}
+
+func dialUDP(ctx context.Context, opts *transport.RoundTripOptions) (tnet.PacketConn, error) {
+	if err := checkContextErr(ctx); err != nil {
+		return nil, fmt.Errorf("before udp dial, %w", err)
+	}
+	var timeout time.Duration
+	d, isSetDeadline := ctx.Deadline()
+	if isSetDeadline {
+		timeout = time.Until(d)
+	}
+	if opts.DialTimeout > 0 && (timeout == 0 || opts.DialTimeout < timeout) {
+		timeout = opts.DialTimeout
+	}
+	conn, err := tnet.DialUDP(opts.Network, opts.Address, timeout)
+	if err != nil {
+		return nil, errs.WrapFrameError(err, errs.RetClientConnectFail, "udp client transport dial")
+	}
+	// Set a deadline for subsequent reading on the connection.
+	if isSetDeadline {
+		if err := conn.SetReadDeadline(d); err != nil {
+			log.Tracef("client SetReadDeadline failed %v", err)
+		}
+	}
+	return conn, nil
+}
+
+func udpReadFrame(conn tnet.PacketConn, opts *transport.RoundTripOptions) ([]byte, error) {
+	// If it is SendOnly, returns directly without waiting for the server's response.
+	if opts.ReqType == transport.SendOnly {
+		return nil, errs.ErrClientNoResponse
+	}
+	packet, _, err := conn.ReadPacket()
+	if err != nil {
+		report.UDPClientTransportReadFail.Incr()
+		return nil, wrapNetError("udp client transport ReadPacket", err)
+	}
+	rspData, err := readUDPFrame(opts.FramerBuilder, packet)
+	if err != nil {
+		report.UDPClientTransportReadFail.Incr()
+		return nil, errs.WrapFrameError(err, errs.RetClientReadFrameErr, "udp client transport ReadFrame")
+	}
+	report.UDPClientTransportReceiveSize.Set(float64(len(rspData)))
+	return rspData, nil
+}
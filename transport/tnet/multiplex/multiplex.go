@@ -176,6 +176,9 @@ func (p *pool) GetMuxConn(
 
 func (p *pool) getHost(network string, address string, opts multiplexed.GetOptions) *host {
 	hostName := strings.Join([]string{network, address}, "_")
+	if opts.FramerKey != "" {
+		hostName += "_" + opts.FramerKey
+	}
 	p.mu.RLock()
 	if h, ok := p.hosts[hostName]; ok {
 		p.mu.RUnlock()
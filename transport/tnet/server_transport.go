@@ -51,7 +51,9 @@ var DefaultServerTransport = NewServerTransport(WithReusePort(true))
 
 // NewServerTransport creates tnet server transport.
 func NewServerTransport(opts ...ServerTransportOption) transport.ServerTransport {
-	option := &ServerTransportOptions{}
+	option := &ServerTransportOptions{
+		RecvUDPPacketBufferSize: defaultRecvUDPPacketBufferSize,
+	}
 	for _, o := range opts {
 		o(option)
 	}
@@ -67,6 +69,9 @@ func (s *serverTransport) ListenAndServe(ctx context.Context, opts ...transport.
 	log.Infof("service:%s is using tnet transport, current number of pollers: %d",
 		lsOpts.ServiceName, tnet.NumPollers())
 	networks := strings.Split(lsOpts.Network, ",")
+	if err := checkServeUDPFragment(networks, lsOpts); err != nil {
+		return err
+	}
 	for _, network := range networks {
 		lsOpts.Network = network
 		if err := s.switchNetworkToServe(ctx, lsOpts); err != nil {
@@ -91,7 +96,7 @@ func (s *serverTransport) Send(ctx context.Context, req []byte) error {
 	if !ok {
 		return errs.NewFrameError(errs.RetServerSystemErr, "can't find conn by addr")
 	}
-	if _, err := tc.rawConn.Write(req); err != nil {
+	if _, err := tc.write(req); err != nil {
 		tc.close()
 		s.Close(ctx)
 		return err
@@ -107,12 +112,32 @@ func (s *serverTransport) Close(ctx context.Context) {
 	s.deleteConn(addrutil.AddrToKey(laddr, raddr))
 }
 
+// checkServeUDPFragment rejects udp fragmentation, which tnet doesn't support. Unlike other options not supported,
+// it's not served by the default transport instead, so that the config is fixed explicitly.
+func checkServeUDPFragment(networks []string, opts *transport.ListenServeOptions) error {
+	if opts.UDPFragment == nil {
+		return nil
+	}
+	for _, network := range networks {
+		switch network {
+		case protocol.UDP, protocol.UDP4, protocol.UDP6:
+			return fmt.Errorf("tnet server transport doesn't support udp fragmentation of service %s, "+
+				"use the default transport instead", opts.ServiceName)
+		}
+	}
+	return nil
+}
+
 func (s *serverTransport) switchNetworkToServe(ctx context.Context, opts *transport.ListenServeOptions) error {
 	switch opts.Network {
 	case protocol.TCP, protocol.TCP4, protocol.TCP6:
 		if err := s.listenAndServeTCP(ctx, opts); err != nil {
 			return err
 		}
+	case protocol.UDP, protocol.UDP4, protocol.UDP6:
+		if err := s.listenAndServeUDP(ctx, opts); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("tnet server transport doesn't support network type [%s]", opts.Network)
 	}
@@ -146,5 +171,6 @@ func buildListenServeOptions(opts ...transport.ListenServeOption) (*transport.Li
 	if lsOpts.FramerBuilder == nil {
 		return nil, errors.New("transport FramerBuilder empty")
 	}
+	lsOpts.FramerBuilder = lsOpts.LimitedFramerBuilder()
 	return lsOpts, nil
 }
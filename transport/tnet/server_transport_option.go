@@ -33,8 +33,9 @@ type ServerTransportOption func(o *ServerTransportOptions)
 
 // ServerTransportOptions is server transport options struct.
 type ServerTransportOptions struct {
-	KeepAlivePeriod time.Duration
-	ReusePort       bool
+	KeepAlivePeriod         time.Duration
+	ReusePort               bool
+	RecvUDPPacketBufferSize int
 }
 
 // WithKeepAlivePeriod sets the TCP keep alive interval.
@@ -53,3 +54,11 @@ func WithReusePort(reuse bool) ServerTransportOption {
 		}
 	}
 }
+
+// WithRecvUDPPacketBufferSize returns a ServerTransportOption which sets the maximum size of
+// UDP packets that can be received, default is 65536.
+func WithRecvUDPPacketBufferSize(size int) ServerTransportOption {
+	return func(opts *ServerTransportOptions) {
+		opts.RecvUDPPacketBufferSize = size
+	}
+}
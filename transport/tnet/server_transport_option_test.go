@@ -34,4 +34,6 @@ func TestOptions(t *testing.T) {
 	opts := &tnettrans.ServerTransportOptions{}
 	tnettrans.WithKeepAlivePeriod(time.Second)(opts)
 	assert.Equal(t, time.Second, opts.KeepAlivePeriod)
+	tnettrans.WithRecvUDPPacketBufferSize(1024)(opts)
+	assert.Equal(t, 1024, opts.RecvUDPPacketBufferSize)
 }
@@ -35,6 +35,10 @@ import (
 	"trpc.group/trpc-go/trpc-go/codec"
 	"trpc.group/trpc-go/trpc-go/errs"
 	"trpc.group/trpc-go/trpc-go/internal/addrutil"
+	"trpc.group/trpc-go/trpc-go/internal/allocator"
+	"trpc.group/trpc-go/trpc-go/internal/attachment"
+	icodec "trpc.group/trpc-go/trpc-go/internal/codec"
+	"trpc.group/trpc-go/trpc-go/internal/connlimit"
 	"trpc.group/trpc-go/trpc-go/internal/protocol"
 	"trpc.group/trpc-go/trpc-go/internal/report"
 	intertls "trpc.group/trpc-go/trpc-go/internal/tls"
@@ -43,8 +47,11 @@ import (
 	"trpc.group/trpc-go/trpc-go/transport/internal/frame"
 )
 
+var errConnectionLimited = errors.New("connection limit reached")
+
 type task struct {
 	req    []byte
+	buf    *allocator.RefBuffer
 	handle handler
 	start  time.Time
 }
@@ -53,6 +60,7 @@ type handler = func(req []byte)
 
 func (t *task) reset() {
 	t.req = nil
+	t.buf = nil
 	t.handle = nil
 	t.start = time.Time{}
 }
@@ -61,9 +69,10 @@ var taskPool = &sync.Pool{
 	New: func() interface{} { return new(task) },
 }
 
-func newTask(req []byte, handle handler) *task {
+func newTask(req []byte, buf *allocator.RefBuffer, handle handler) *task {
 	t := taskPool.Get().(*task)
 	t.req = req
+	t.buf = buf
 	t.handle = handle
 	t.start = time.Now()
 	return t
@@ -85,6 +94,7 @@ func createRoutinePool(size int) *ants.PoolWithFunc {
 		}
 		report.TCPServerAsyncGoroutineScheduleDelay.Set(float64(time.Since(t.start).Microseconds()))
 		t.handle(t.req)
+		t.buf.Release()
 		t.reset()
 		taskPool.Put(t)
 	}
@@ -143,16 +153,20 @@ func (s *serverTransport) listenAndServeTCP(ctx context.Context, opts *transport
 		return fmt.Errorf("save tnet listener failed: %w", err)
 	}
 
+	// Connections are opened in pollers, which must not be blocked waiting for a free slot,
+	// so connections beyond the limits are rejected immediately regardless of ConnectionLimitWait.
+	limiter := connlimit.New(opts.MaxConnections, opts.MaxConnectionsPerIP, 0)
 	if opts.TLSCertFile != "" && opts.TLSKeyFile != "" {
-		return s.startTLSService(ctx, listener, pool, opts)
+		return s.startTLSService(ctx, listener, pool, limiter, opts)
 	}
-	return s.startService(ctx, listener, pool, opts)
+	return s.startService(ctx, listener, pool, limiter, opts)
 }
 
 func (s *serverTransport) startService(
 	ctx context.Context,
 	listener net.Listener,
 	pool *ants.PoolWithFunc,
+	limiter *connlimit.Limiter,
 	opts *transport.ListenServeOptions,
 ) error {
 	go func() {
@@ -161,12 +175,15 @@ func (s *serverTransport) startService(
 	}()
 	tnetOpts := []tnet.Option{
 		tnet.WithOnTCPOpened(func(conn tnet.Conn) error {
-			tc := s.onConnOpened(conn, pool, opts)
+			if !limiter.Acquire(conn.RemoteAddr()) {
+				return errConnectionLimited
+			}
+			tc := s.onConnOpened(conn, pool, limiter, opts)
 			conn.SetMetaData(tc)
 			return nil
 		}),
 		tnet.WithOnTCPClosed(func(conn tnet.Conn) error {
-			s.onConnClosed(conn, opts.Handler)
+			s.onConnClosed(conn, conn.GetMetaData(), opts.Handler)
 			return nil
 		}),
 		tnet.WithTCPIdleTimeout(opts.IdleTimeout),
@@ -190,6 +207,7 @@ func (s *serverTransport) startTLSService(
 	ctx context.Context,
 	listener net.Listener,
 	pool *ants.PoolWithFunc,
+	limiter *connlimit.Limiter,
 	opts *transport.ListenServeOptions,
 ) error {
 	conf, err := intertls.GetServerConfig(opts.CACertFile, opts.TLSCertFile, opts.TLSKeyFile, opts.TLSCertProvider)
@@ -199,12 +217,15 @@ func (s *serverTransport) startTLSService(
 
 	tlsOpts := []tls.ServerOption{
 		tls.WithOnOpened(func(conn tls.Conn) error {
-			tc := s.onConnOpened(conn, pool, opts)
+			if !limiter.Acquire(conn.RemoteAddr()) {
+				return errConnectionLimited
+			}
+			tc := s.onConnOpened(conn, pool, limiter, opts)
 			conn.SetMetaData(tc)
 			return nil
 		}),
 		tls.WithOnClosed(func(conn tls.Conn) error {
-			s.onConnClosed(conn, opts.Handler)
+			s.onConnClosed(conn, conn.GetMetaData(), opts.Handler)
 			return nil
 		}),
 		tls.WithServerTLSConfig(conf),
@@ -227,23 +248,28 @@ func (s *serverTransport) startTLSService(
 
 // onConnOpened is triggered after a successful connection is established with the client.
 func (s *serverTransport) onConnOpened(conn net.Conn, pool *ants.PoolWithFunc,
-	opts *transport.ListenServeOptions) *tcpConn {
+	limiter *connlimit.Limiter, opts *transport.ListenServeOptions) *tcpConn {
 	tc := &tcpConn{
 		rawConn:     conn,
 		pool:        pool,
+		limiter:     limiter,
 		handler:     opts.Handler,
 		serverAsync: opts.ServerAsync,
 		framer:      opts.FramerBuilder.New(conn),
+		fb:          opts.FramerBuilder,
 	}
 	// To avoid overwriting packets, check whether we should copy packages by Framer and some other configurations.
 	tc.copyFrame = frame.ShouldCopy(opts.CopyFrame, tc.serverAsync, codec.IsSafeFramer(tc.framer))
+	if pf, ok := tc.framer.(icodec.PooledFramer); ok && opts.ZeroCopy {
+		tc.pooledFramer = pf
+	}
 
 	s.storeConn(addrutil.AddrToKey(conn.LocalAddr(), conn.RemoteAddr()), tc)
 	return tc
 }
 
 // onConnClosed is triggered after the connection with the client is closed.
-func (s *serverTransport) onConnClosed(conn net.Conn, handler transport.Handler) {
+func (s *serverTransport) onConnClosed(conn net.Conn, metaData interface{}, handler transport.Handler) {
 	ctx, msg := codec.WithNewMessage(context.Background())
 	msg.WithLocalAddr(conn.LocalAddr())
 	msg.WithRemoteAddr(conn.RemoteAddr())
@@ -262,6 +288,10 @@ func (s *serverTransport) onConnClosed(conn net.Conn, handler transport.Handler)
 
 	// Release the connection resources stored on the transport.
 	s.deleteConn(addrutil.AddrToKey(conn.LocalAddr(), conn.RemoteAddr()))
+	// Connections rejected by the limiter have no meta data.
+	if tc, ok := metaData.(*tcpConn); ok {
+		tc.limiter.Release(conn.RemoteAddr())
+	}
 }
 
 func handleTCP(conn interface{}) error {
@@ -275,15 +305,38 @@ func handleTCP(conn interface{}) error {
 type tcpConn struct {
 	rawConn     net.Conn
 	framer      transport.Framer
+	fb          transport.FramerBuilder
 	pool        *ants.PoolWithFunc
+	limiter     *connlimit.Limiter
 	handler     transport.Handler
 	serverAsync bool
 	copyFrame   bool
+	// pooledFramer reads frames into pooled buffers if zero copy is enabled.
+	pooledFramer icodec.PooledFramer
+	// writeMu keeps frames from interleaving with deferred attachments written in chunks.
+	writeMu sync.Mutex
+}
+
+// write writes p to the connection.
+func (tc *tcpConn) write(p []byte) (int, error) {
+	tc.writeMu.Lock()
+	defer tc.writeMu.Unlock()
+	return tc.rawConn.Write(p)
+}
+
+// writeFrame writes the response frame, followed by its deferred attachment.
+func (tc *tcpConn) writeFrame(msg codec.Msg, rsp []byte) error {
+	tc.writeMu.Lock()
+	defer tc.writeMu.Unlock()
+	if _, err := tc.rawConn.Write(rsp); err != nil {
+		return err
+	}
+	return attachment.WriteDeferred(msg, chunkWriter{conn: tc.rawConn})
 }
 
 // onRequest is triggered when there is incoming data on the connection with the client.
 func (tc *tcpConn) onRequest() error {
-	req, err := tc.framer.ReadFrame()
+	req, buf, err := tc.readFrame()
 	if err != nil {
 		if err == tnet.ErrConnClosed {
 			report.TCPServerTransportReadEOF.Incr()
@@ -293,7 +346,18 @@ func (tc *tcpConn) onRequest() error {
 		log.Trace("transport: tcpConn onRequest ReadFrame fail ", err)
 		return err
 	}
-	if tc.copyFrame {
+	// Answer heartbeat pings directly, they never reach the handler.
+	if pong, ok := codec.HeartbeatPong(tc.fb, req); ok {
+		buf.Release()
+		if _, err := tc.write(pong); err != nil {
+			report.TCPServerTransportWriteFail.Incr()
+			log.Trace("transport: tcpConn write heartbeat pong fail ", err)
+			return err
+		}
+		return nil
+	}
+	// pooled frames are owned by the handler until released.
+	if tc.copyFrame && buf == nil {
 		reqCopy := make([]byte, len(req))
 		copy(reqCopy, req)
 		req = reqCopy
@@ -302,17 +366,28 @@ func (tc *tcpConn) onRequest() error {
 
 	if !tc.serverAsync || tc.pool == nil {
 		tc.handleSync(req)
+		buf.Release()
 		return nil
 	}
 
-	if err := tc.pool.Invoke(newTask(req, tc.handleSync)); err != nil {
+	if err := tc.pool.Invoke(newTask(req, buf, tc.handleSync)); err != nil {
 		report.TCPServerTransportJobQueueFullFail.Incr()
 		log.Trace("transport: tcpConn serve routine pool put job queue fail ", err)
 		tc.handleWithErr(req, errs.ErrServerRoutinePoolBusy)
+		buf.Release()
 	}
 	return nil
 }
 
+// readFrame reads a frame, which is in buf if buf is not nil.
+func (tc *tcpConn) readFrame() ([]byte, *allocator.RefBuffer, error) {
+	if tc.pooledFramer != nil {
+		return tc.pooledFramer.ReadPooledFrame()
+	}
+	req, err := tc.framer.ReadFrame()
+	return req, nil, err
+}
+
 func (tc *tcpConn) handleSync(req []byte) {
 	tc.handleWithErr(req, nil)
 }
@@ -323,6 +398,8 @@ func (tc *tcpConn) handleWithErr(req []byte, e error) {
 	msg.WithServerRspErr(e)
 	msg.WithLocalAddr(tc.rawConn.LocalAddr())
 	msg.WithRemoteAddr(tc.rawConn.RemoteAddr())
+	// the response attachment of known size is written after the frame in chunks.
+	attachment.AllowServerDeferred(msg)
 
 	rsp, err := tc.handle(ctx, req)
 	if err != nil {
@@ -335,7 +412,7 @@ func (tc *tcpConn) handleWithErr(req []byte, e error) {
 		return
 	}
 	report.TCPServerTransportSendSize.Set(float64(len(rsp)))
-	if _, err = tc.rawConn.Write(rsp); err != nil {
+	if err = tc.writeFrame(msg, rsp); err != nil {
 		report.TCPServerTransportWriteFail.Incr()
 		log.Trace("transport: tcpConn write fail ", err)
 		tc.close()
@@ -17,6 +17,7 @@
 package tnet_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -158,6 +159,109 @@ func TestServerTCP_HandleErr(t *testing.T) {
 	)
 }
 
+func TestServerTCP_AnswerHeartbeat(t *testing.T) {
+	startServerTest(
+		t,
+		errServerHandle,
+		nil,
+		func(addr string) {
+			conn, err := net.Dial("tcp", addr)
+			assert.Nil(t, err)
+			defer conn.Close()
+			assert.Nil(t, conn.SetDeadline(time.Now().Add(time.Second)))
+			fb := trpc.DefaultFramerBuilder
+			fr := fb.New(conn)
+			for seq := uint32(1); seq <= 2; seq++ {
+				_, err = conn.Write(fb.Ping(seq))
+				assert.Nil(t, err)
+				pong, err := fr.ReadFrame()
+				assert.Nil(t, err)
+				assert.True(t, fb.IsPong(pong))
+			}
+		},
+	)
+}
+
+func TestServerTCP_MaxConnections(t *testing.T) {
+	startServerTest(
+		t,
+		errServerHandle,
+		[]transport.ListenServeOption{transport.WithMaxConnections(1)},
+		func(addr string) {
+			fb := trpc.DefaultFramerBuilder
+			ping := func(conn net.Conn) error {
+				if _, err := conn.Write(fb.Ping(1)); err != nil {
+					return err
+				}
+				_, err := fb.New(conn).ReadFrame()
+				return err
+			}
+			c1, err := net.Dial("tcp", addr)
+			assert.Nil(t, err)
+			assert.Nil(t, c1.SetDeadline(time.Now().Add(time.Second)))
+			assert.Nil(t, ping(c1))
+
+			c2, err := net.Dial("tcp", addr)
+			assert.Nil(t, err)
+			defer c2.Close()
+			assert.Nil(t, c2.SetDeadline(time.Now().Add(time.Second)))
+			assert.NotNil(t, ping(c2))
+
+			// The slot is released after the first connection is closed.
+			c1.Close()
+			time.Sleep(100 * time.Millisecond)
+			c3, err := net.Dial("tcp", addr)
+			assert.Nil(t, err)
+			defer c3.Close()
+			assert.Nil(t, c3.SetDeadline(time.Now().Add(time.Second)))
+			assert.Nil(t, ping(c3))
+		},
+	)
+}
+
+func TestServerTCP_ConnectionLimitWait(t *testing.T) {
+	const wait = 2 * time.Second
+	startServerTest(
+		t,
+		errServerHandle,
+		[]transport.ListenServeOption{
+			transport.WithMaxConnections(1),
+			transport.WithConnectionLimitWait(wait),
+		},
+		func(addr string) {
+			fb := trpc.DefaultFramerBuilder
+			ping := func(conn net.Conn) error {
+				if _, err := conn.Write(fb.Ping(1)); err != nil {
+					return err
+				}
+				_, err := fb.New(conn).ReadFrame()
+				return err
+			}
+			c1, err := net.Dial("tcp", addr)
+			assert.Nil(t, err)
+			defer c1.Close()
+			assert.Nil(t, c1.SetDeadline(time.Now().Add(wait)))
+			assert.Nil(t, ping(c1))
+
+			// The connection beyond the limit is rejected without waiting on the poller.
+			start := time.Now()
+			c2, err := net.Dial("tcp", addr)
+			assert.Nil(t, err)
+			defer c2.Close()
+			assert.Nil(t, c2.SetDeadline(time.Now().Add(wait)))
+			assert.NotNil(t, ping(c2))
+			assert.Less(t, time.Since(start), wait/2)
+
+			// The connection within the limit keeps being served.
+			start = time.Now()
+			for i := 0; i < 10; i++ {
+				assert.Nil(t, ping(c1))
+			}
+			assert.Less(t, time.Since(start), wait/2)
+		},
+	)
+}
+
 func TestServerTCP_IdleTimeout(t *testing.T) {
 	startServerTest(
 		t,
@@ -327,7 +431,6 @@ func TestServerTCP_TLS(t *testing.T) {
 }
 
 func TestUDP(t *testing.T) {
-	// UDP is not supported, but it will switch to gonet default transport to serve.
 	startServerTest(
 		t,
 		defaultServerHandle,
@@ -350,6 +453,54 @@ func TestUDP(t *testing.T) {
 	)
 }
 
+func TestUDP_TnetClient(t *testing.T) {
+	startServerTest(
+		t,
+		defaultServerHandle,
+		[]transport.ListenServeOption{
+			transport.WithListenNetwork("udp"),
+			transport.WithServerAsync(true),
+		},
+		func(addr string) {
+			for i := 0; i < 10; i++ {
+				rsp, err := tnetRequest(
+					context.Background(),
+					helloWorld,
+					transport.WithDialAddress(addr),
+					transport.WithDialNetwork("udp"))
+				assert.Nil(t, err)
+				assert.Equal(t, helloWorld, rsp)
+			}
+		},
+	)
+}
+
+func TestUDP_RecvUDPPacketBufferSize(t *testing.T) {
+	addr := getAddr()
+	s := tnettrans.NewServerTransport(tnettrans.WithRecvUDPPacketBufferSize(64))
+	err := s.ListenAndServe(context.Background(), getListenServeOption(
+		transport.WithListenAddress(addr),
+		transport.WithListenNetwork("udp"),
+	)...)
+	assert.Nil(t, err)
+
+	rsp, err := gonetRequest(
+		context.Background(),
+		transport.WithDialAddress(addr),
+		transport.WithDialNetwork("udp"))
+	assert.Nil(t, err)
+	assert.Equal(t, helloWorld, rsp)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = tnetRequest(
+		ctx,
+		bytes.Repeat(helloWorld, 10),
+		transport.WithDialAddress(addr),
+		transport.WithDialNetwork("udp"))
+	assert.NotNil(t, err)
+}
+
 func TestUnix(t *testing.T) {
 	// Unix socket is not supported, but it will switch to gonet default transport to serve.
 	myAddr := "/tmp/server.sock"
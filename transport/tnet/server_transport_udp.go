@@ -0,0 +1,194 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+//go:build linux || freebsd || dragonfly || darwin
+// +build linux freebsd dragonfly darwin
+
+package tnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/panjf2000/ants/v2"
+	"trpc.group/trpc-go/tnet"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/internal/report"
+	"trpc.group/trpc-go/trpc-go/log"
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+const defaultRecvUDPPacketBufferSize = 65536
+
+func (s *serverTransport) listenAndServeUDP(ctx context.Context, opts *transport.ListenServeOptions) error {
+	// Create a goroutine pool if ServerAsync enabled.
+	var pool *ants.PoolWithFunc
+	if opts.ServerAsync {
+		pool = createRoutinePool(opts.Routines)
+	}
+
+	conns, err := s.getUDPListeners(opts)
+	if err != nil {
+		return fmt.Errorf("trpc-tnet-transport get UDP listeners fail, %w", err)
+	}
+	for _, conn := range conns {
+		if err := transport.SaveListener(conn); err != nil {
+			return fmt.Errorf("save tnet udp listener failed: %w", err)
+		}
+	}
+	go func() {
+		<-opts.StopListening
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	svr, err := tnet.NewUDPService(
+		conns,
+		func(conn tnet.PacketConn) error {
+			return s.onPacket(conn, pool, opts)
+		},
+		tnet.WithMaxUDPPacketSize(s.opts.RecvUDPPacketBufferSize),
+	)
+	if err != nil {
+		return fmt.Errorf("trpc-tnet-transport NewUDPService fail, %w", err)
+	}
+	go svr.Serve(ctx)
+	return nil
+}
+
+// getUDPListeners returns the UDP listeners. With reuse port enabled, tnet creates one listener
+// per poller, and the kernel shards packets among them.
+func (s *serverTransport) getUDPListeners(opts *transport.ListenServeOptions) ([]tnet.PacketConn, error) {
+	// During graceful restart, the relevant information has
+	// already been stored in environment variables.
+	v, _ := os.LookupEnv(transport.EnvGraceRestart)
+	ok, _ := strconv.ParseBool(v)
+	if ok {
+		pln, err := transport.GetPassedListener(opts.Network, opts.Address)
+		if err != nil {
+			return nil, err
+		}
+		ln, ok := pln.(net.PacketConn)
+		if !ok {
+			return nil, errors.New("invalid net.PacketConn")
+		}
+		conn, err := tnet.NewPacketConn(ln)
+		if err != nil {
+			return nil, err
+		}
+		return []tnet.PacketConn{conn}, nil
+	}
+	return tnet.ListenPackets(opts.Network, opts.Address, s.opts.ReusePort)
+}
+
+// onPacket is triggered when there is an incoming packet on the UDP listener.
+func (s *serverTransport) onPacket(
+	conn tnet.PacketConn,
+	pool *ants.PoolWithFunc,
+	opts *transport.ListenServeOptions,
+) error {
+	packet, addr, err := conn.ReadPacket()
+	if err != nil {
+		if err == tnet.ErrConnClosed {
+			return err
+		}
+		report.UDPServerTransportReadFail.Incr()
+		log.Trace("transport: udpConn onPacket ReadPacket fail ", err)
+		return nil
+	}
+	req, err := readUDPFrame(opts.FramerBuilder, packet)
+	if err != nil {
+		report.UDPServerTransportReadFail.Incr()
+		log.Trace("transport: udpConn onPacket ReadFrame fail ", err)
+		return nil
+	}
+	report.UDPServerTransportReceiveSize.Set(float64(len(req)))
+
+	uc := &udpConn{
+		rawConn:    conn,
+		remoteAddr: addr,
+		handler:    opts.Handler,
+	}
+	if pool == nil {
+		uc.handle(req)
+		return nil
+	}
+	if err := pool.Invoke(newTask(req, nil, uc.handle)); err != nil {
+		report.UDPServerTransportJobQueueFullFail.Incr()
+		log.Trace("transport: udpConn serve routine pool put job queue fail ", err)
+		go uc.handle(req)
+	}
+	return nil
+}
+
+// readUDPFrame reads a single frame from the packet and frees the packet.
+// One packet of udp corresponds to one frame, there should not be any remaining data after parsing.
+func readUDPFrame(fb codec.FramerBuilder, packet tnet.Packet) ([]byte, error) {
+	defer packet.Free()
+	data, err := packet.Data()
+	if err != nil {
+		return nil, err
+	}
+	reader := bytes.NewReader(data)
+	fr := fb.New(reader)
+	frame, err := fr.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("udp packet has %d remaining bytes after reading frame", reader.Len())
+	}
+	// The packet is freed on return, copy the frame if it may refer to a reused buffer.
+	if !codec.IsSafeFramer(fr) {
+		frameCopy := make([]byte, len(frame))
+		copy(frameCopy, frame)
+		frame = frameCopy
+	}
+	return frame, nil
+}
+
+// udpConn represents a request received from the UDP listener.
+type udpConn struct {
+	rawConn    tnet.PacketConn
+	remoteAddr net.Addr
+	handler    transport.Handler
+}
+
+func (uc *udpConn) handle(req []byte) {
+	ctx, msg := codec.WithNewMessage(context.Background())
+	defer codec.PutBackMessage(msg)
+	msg.WithLocalAddr(uc.rawConn.LocalAddr())
+	msg.WithRemoteAddr(uc.remoteAddr)
+
+	rsp, err := uc.handler.Handle(ctx, req)
+	if err != nil {
+		if err != errs.ErrServerNoResponse {
+			report.UDPServerTransportHandleFail.Incr()
+			log.Tracef("udp handle fail:%v", err)
+		}
+		return
+	}
+	report.UDPServerTransportSendSize.Set(float64(len(rsp)))
+	if _, err := uc.rawConn.WriteTo(rsp, uc.remoteAddr); err != nil {
+		report.UDPServerTransportWriteFail.Incr()
+		log.Tracef("udp write out fail:%v", err)
+	}
+}
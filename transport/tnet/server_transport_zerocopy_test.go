@@ -0,0 +1,133 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+//go:build linux || freebsd || dragonfly || darwin
+// +build linux freebsd dragonfly darwin
+
+package tnet_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"trpc.group/trpc-go/tnet"
+
+	trpc "trpc.group/trpc-go/trpc-go"
+	"trpc.group/trpc-go/trpc-go/codec"
+	pb "trpc.group/trpc-go/trpc-go/testdata/trpc/helloworld"
+	"trpc.group/trpc-go/trpc-go/transport"
+	tnettrans "trpc.group/trpc-go/trpc-go/transport/tnet"
+)
+
+func TestServerTCP_ZeroCopy(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		t.Run(fmt.Sprintf("async(%v)", async), func(t *testing.T) {
+			addr := serveHello(t, true, async)
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					conn, err := net.Dial("tcp", addr)
+					require.Nil(t, err)
+					defer conn.Close()
+					for j := 0; j < 100; j++ {
+						hello := strings.Repeat(fmt.Sprint(i, j), 1024)
+						require.Equal(t, hello, helloRoundTrip(t, conn, hello))
+					}
+				}(i)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func BenchmarkServerTCP_ZeroCopy(b *testing.B) {
+	for _, zeroCopy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("zeroCopy(%v)", zeroCopy), func(b *testing.B) {
+			conn, err := net.Dial("tcp", serveHello(b, zeroCopy, false))
+			require.Nil(b, err)
+			defer conn.Close()
+			hello := strings.Repeat("a", 4096)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				helloRoundTrip(b, conn, hello)
+			}
+		})
+	}
+}
+
+// serveHello serves the hello handler on a new listener, and returns the address.
+func serveHello(tb testing.TB, zeroCopy, async bool) string {
+	ln, err := tnet.Listen("tcp", "127.0.0.1:0")
+	require.Nil(tb, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	tb.Cleanup(cancel)
+	require.Nil(tb, tnettrans.NewServerTransport().ListenAndServe(ctx,
+		transport.WithListenNetwork("tcp"),
+		transport.WithListener(ln),
+		transport.WithHandler(helloHandler{}),
+		transport.WithServerFramerBuilder(trpc.DefaultFramerBuilder),
+		transport.WithServerAsync(async),
+		transport.WithZeroCopy(zeroCopy),
+	))
+	return ln.Addr().String()
+}
+
+// helloHandler unmarshals the trpc request into HelloRequest, and replies its msg.
+type helloHandler struct{}
+
+func (helloHandler) Handle(ctx context.Context, req []byte) ([]byte, error) {
+	msg := codec.Message(ctx)
+	body, err := trpc.DefaultServerCodec.Decode(msg, req)
+	if err != nil {
+		return nil, err
+	}
+	hello := &pb.HelloRequest{}
+	if err := proto.Unmarshal(body, hello); err != nil {
+		return nil, err
+	}
+	rsp, err := proto.Marshal(&pb.HelloReply{Msg: hello.Msg})
+	if err != nil {
+		return nil, err
+	}
+	return trpc.DefaultServerCodec.Encode(msg, rsp)
+}
+
+// helloRoundTrip sends hello on conn, and returns the msg of the reply.
+func helloRoundTrip(tb testing.TB, conn net.Conn, hello string) string {
+	body, err := proto.Marshal(&pb.HelloRequest{Msg: hello})
+	require.Nil(tb, err)
+	_, msg := codec.WithNewMessage(context.Background())
+	defer codec.PutBackMessage(msg)
+	msg.WithClientRPCName("/trpc.test.helloworld.Greeter/SayHello")
+	req, err := trpc.DefaultClientCodec.Encode(msg, body)
+	require.Nil(tb, err)
+	_, err = conn.Write(req)
+	require.Nil(tb, err)
+
+	rsp, err := trpc.DefaultFramerBuilder.New(conn).ReadFrame()
+	require.Nil(tb, err)
+	body, err = trpc.DefaultClientCodec.Decode(msg, rsp)
+	require.Nil(tb, err)
+	reply := &pb.HelloReply{}
+	require.Nil(tb, proto.Unmarshal(body, reply))
+	return reply.Msg
+}
@@ -0,0 +1,364 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/internal/report"
+)
+
+const (
+	defaultMaxFragmentSize    = 1400
+	defaultReassemblyTimeout  = 5 * time.Second
+	defaultMaxReassemblyBytes = 16 * 1024 * 1024
+	defaultRetransmitInterval = 200 * time.Millisecond
+
+	// Fragment header layout:
+	// magic(2) | type(1) | reserved(1) | message id(4) | index(2) | count(2)
+	fragmentMagic     = 0x9f7a
+	fragmentHeaderLen = 12
+	fragmentTypeData  = 1
+	fragmentTypeNack  = 2
+)
+
+var (
+	errInvalidFragment    = errors.New("udp fragment: invalid fragment")
+	errReassemblyOverflow = errors.New("udp fragment: reassembly buffer is full")
+)
+
+// UDPFragmentOptions is the options of the UDP fragmentation mode, in which a message larger than
+// a single packet is split into fragments with headers carrying the message id, index and count.
+// Both the client and the server must enable it. A server with fragmentation enabled accepts plain
+// packets as well, and always replies in the same mode as the request.
+// It's implemented by the default transport only, the tnet transport fails with it on UDP.
+type UDPFragmentOptions struct {
+	// MaxFragmentSize is the max size of a single UDP packet, including the fragment header.
+	MaxFragmentSize int `yaml:"max_fragment_size"`
+	// ReassemblyTimeout is the max time to wait for all fragments of a message.
+	ReassemblyTimeout time.Duration `yaml:"reassembly_timeout"`
+	// MaxReassemblyBytes is the max memory used by incomplete messages. Fragments beyond it are dropped.
+	MaxReassemblyBytes int `yaml:"max_reassembly_bytes"`
+	// Retransmit enables the selective retransmission requests of missing fragments.
+	Retransmit bool `yaml:"retransmit"`
+	// RetransmitInterval is the time to wait for a missing fragment before requesting it again.
+	RetransmitInterval time.Duration `yaml:"retransmit_interval"`
+}
+
+// withDefaults returns a copy of the options with zero values replaced by defaults.
+func (o *UDPFragmentOptions) withDefaults() *UDPFragmentOptions {
+	opts := *o
+	if opts.MaxFragmentSize <= fragmentHeaderLen {
+		opts.MaxFragmentSize = defaultMaxFragmentSize
+	}
+	if opts.ReassemblyTimeout <= 0 {
+		opts.ReassemblyTimeout = defaultReassemblyTimeout
+	}
+	if opts.MaxReassemblyBytes <= 0 {
+		opts.MaxReassemblyBytes = defaultMaxReassemblyBytes
+	}
+	if opts.RetransmitInterval <= 0 {
+		opts.RetransmitInterval = defaultRetransmitInterval
+	}
+	return &opts
+}
+
+type fragmentHeader struct {
+	typ   uint8
+	msgID uint32
+	index uint16
+	count uint16
+}
+
+func (h fragmentHeader) encode(payload []byte) []byte {
+	pkt := make([]byte, fragmentHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(pkt[0:2], fragmentMagic)
+	pkt[2] = h.typ
+	binary.BigEndian.PutUint32(pkt[4:8], h.msgID)
+	binary.BigEndian.PutUint16(pkt[8:10], h.index)
+	binary.BigEndian.PutUint16(pkt[10:12], h.count)
+	copy(pkt[fragmentHeaderLen:], payload)
+	return pkt
+}
+
+// parseFragment parses the fragment header of the packet, ok is false if it is a plain packet.
+func parseFragment(pkt []byte) (h fragmentHeader, payload []byte, ok bool) {
+	if len(pkt) < fragmentHeaderLen || binary.BigEndian.Uint16(pkt[0:2]) != fragmentMagic {
+		return h, nil, false
+	}
+	h.typ = pkt[2]
+	if h.typ != fragmentTypeData && h.typ != fragmentTypeNack {
+		return h, nil, false
+	}
+	h.msgID = binary.BigEndian.Uint32(pkt[4:8])
+	h.index = binary.BigEndian.Uint16(pkt[8:10])
+	h.count = binary.BigEndian.Uint16(pkt[10:12])
+	return h, pkt[fragmentHeaderLen:], true
+}
+
+// splitFragments splits the message into data fragments no larger than maxFragmentSize.
+func splitFragments(msgID uint32, msg []byte, maxFragmentSize int) ([][]byte, error) {
+	size := maxFragmentSize - fragmentHeaderLen
+	count := (len(msg) + size - 1) / size
+	if count == 0 {
+		count = 1
+	}
+	if count > math.MaxUint16 {
+		return nil, fmt.Errorf("udp fragment: message size %d exceeds the max fragment number", len(msg))
+	}
+	frags := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+		h := fragmentHeader{typ: fragmentTypeData, msgID: msgID, index: uint16(i), count: uint16(count)}
+		frags = append(frags, h.encode(msg[i*size:end]))
+	}
+	return frags, nil
+}
+
+// encodeNack encodes a selective retransmission request of the missing fragment indexes.
+func encodeNack(msgID uint32, missing []uint16, maxFragmentSize int) []byte {
+	if max := (maxFragmentSize - fragmentHeaderLen) / 2; len(missing) > max {
+		missing = missing[:max]
+	}
+	payload := make([]byte, 2*len(missing))
+	for i, idx := range missing {
+		binary.BigEndian.PutUint16(payload[2*i:], idx)
+	}
+	h := fragmentHeader{typ: fragmentTypeNack, msgID: msgID, count: uint16(len(missing))}
+	return h.encode(payload)
+}
+
+// decodeNack decodes the missing fragment indexes from the payload of a retransmission request.
+func decodeNack(h fragmentHeader, payload []byte) []uint16 {
+	n := int(h.count)
+	if n > len(payload)/2 {
+		n = len(payload) / 2
+	}
+	missing := make([]uint16, n)
+	for i := range missing {
+		missing[i] = binary.BigEndian.Uint16(payload[2*i:])
+	}
+	return missing
+}
+
+// readUDPMessage reads a single frame from a whole UDP message.
+// There should not be any remaining data after the frame.
+func readUDPMessage(fb codec.FramerBuilder, msg []byte) ([]byte, error) {
+	r := bytes.NewReader(msg)
+	frame, err := fb.New(r).ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("udp message is not drained, the remaining %d will be dropped", r.Len())
+	}
+	return frame, nil
+}
+
+type fragmentKey struct {
+	addr  string
+	msgID uint32
+}
+
+type partialMessage struct {
+	addr     net.Addr
+	parts    [][]byte
+	received int
+	size     int
+	created  time.Time
+	updated  time.Time
+}
+
+func (p *partialMessage) missing() []uint16 {
+	var missing []uint16
+	for i, part := range p.parts {
+		if part == nil {
+			missing = append(missing, uint16(i))
+		}
+	}
+	return missing
+}
+
+// nackRequest is a retransmission request which should be sent to addr.
+type nackRequest struct {
+	addr net.Addr
+	pkt  []byte
+}
+
+// reassembler reassembles fragments into messages with a timeout and a memory cap.
+type reassembler struct {
+	mu      sync.Mutex
+	opts    *UDPFragmentOptions
+	size    int
+	pending map[fragmentKey]*partialMessage
+}
+
+func newReassembler(opts *UDPFragmentOptions) *reassembler {
+	return &reassembler{
+		opts:    opts,
+		pending: make(map[fragmentKey]*partialMessage),
+	}
+}
+
+// add adds a data fragment from addr, and returns the whole message once all fragments arrived.
+func (r *reassembler) add(addr net.Addr, h fragmentHeader, payload []byte, now time.Time) ([]byte, error) {
+	if h.count == 0 || h.index >= h.count {
+		return nil, errInvalidFragment
+	}
+	if h.count == 1 {
+		return append([]byte(nil), payload...), nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := fragmentKey{addr: addr.String(), msgID: h.msgID}
+	p, ok := r.pending[key]
+	if !ok {
+		p = &partialMessage{addr: addr, parts: make([][]byte, h.count), created: now}
+		r.pending[key] = p
+	}
+	if len(p.parts) != int(h.count) {
+		return nil, errInvalidFragment
+	}
+	p.updated = now
+	if p.parts[h.index] != nil {
+		// Duplicated fragment caused by retransmission.
+		return nil, nil
+	}
+	if r.size+len(payload) > r.opts.MaxReassemblyBytes {
+		r.remove(key, p)
+		return nil, errReassemblyOverflow
+	}
+	p.parts[h.index] = append([]byte(nil), payload...)
+	p.received++
+	p.size += len(payload)
+	r.size += len(payload)
+	if p.received < len(p.parts) {
+		return nil, nil
+	}
+	r.remove(key, p)
+	msg := make([]byte, 0, p.size)
+	for _, part := range p.parts {
+		msg = append(msg, part...)
+	}
+	return msg, nil
+}
+
+// check drops the messages which fail to complete within the reassembly timeout, and returns
+// retransmission requests for those whose missing fragments have not arrived for a while.
+func (r *reassembler) check(now time.Time) []nackRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var nacks []nackRequest
+	for key, p := range r.pending {
+		if now.Sub(p.created) > r.opts.ReassemblyTimeout {
+			report.UDPFragmentReassemblyTimeout.Incr()
+			r.remove(key, p)
+			continue
+		}
+		if r.opts.Retransmit && now.Sub(p.updated) >= r.opts.RetransmitInterval {
+			p.updated = now
+			nacks = append(nacks, nackRequest{
+				addr: p.addr,
+				pkt:  encodeNack(key.msgID, p.missing(), r.opts.MaxFragmentSize),
+			})
+		}
+	}
+	return nacks
+}
+
+func (r *reassembler) remove(key fragmentKey, p *partialMessage) {
+	r.size -= p.size
+	delete(r.pending, key)
+}
+
+type sentFragments struct {
+	frags   [][]byte
+	size    int
+	expired time.Time
+}
+
+// fragmentCache keeps the sent fragments for retransmission until the reassembly timeout.
+type fragmentCache struct {
+	mu      sync.Mutex
+	opts    *UDPFragmentOptions
+	size    int
+	entries map[fragmentKey]*sentFragments
+}
+
+func newFragmentCache(opts *UDPFragmentOptions) *fragmentCache {
+	return &fragmentCache{
+		opts:    opts,
+		entries: make(map[fragmentKey]*sentFragments),
+	}
+}
+
+// put caches the fragments sent to addr, fragments are not cached if the cache is full.
+func (c *fragmentCache) put(addr net.Addr, msgID uint32, frags [][]byte, now time.Time) {
+	var size int
+	for _, f := range frags {
+		size += len(f)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size+size > c.opts.MaxReassemblyBytes {
+		return
+	}
+	key := fragmentKey{addr: addr.String(), msgID: msgID}
+	if old, ok := c.entries[key]; ok {
+		c.size -= old.size
+	}
+	c.entries[key] = &sentFragments{frags: frags, size: size, expired: now.Add(c.opts.ReassemblyTimeout)}
+	c.size += size
+}
+
+// get returns the cached fragments of the given indexes.
+func (c *fragmentCache) get(addr net.Addr, msgID uint32, indexes []uint16) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[fragmentKey{addr: addr.String(), msgID: msgID}]
+	if !ok {
+		return nil
+	}
+	frags := make([][]byte, 0, len(indexes))
+	for _, idx := range indexes {
+		if int(idx) < len(e.frags) {
+			frags = append(frags, e.frags[idx])
+		}
+	}
+	return frags
+}
+
+// expire removes the expired fragments.
+func (c *fragmentCache) expire(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if now.After(e.expired) {
+			c.size -= e.size
+			delete(c.entries, key)
+		}
+	}
+}
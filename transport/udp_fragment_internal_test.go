@@ -0,0 +1,124 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAndReassembleFragments(t *testing.T) {
+	opts := (&UDPFragmentOptions{MaxFragmentSize: fragmentHeaderLen + 10}).withDefaults()
+	msg := bytes.Repeat([]byte("0123456789abc"), 7)
+	frags, err := splitFragments(1, msg, opts.MaxFragmentSize)
+	require.Nil(t, err)
+	require.Len(t, frags, 10)
+
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}
+	r := newReassembler(opts)
+	now := time.Now()
+	// Deliver fragments in reverse order with a duplicated one.
+	for i := len(frags) - 1; i > 0; i-- {
+		h, payload, ok := parseFragment(frags[i])
+		require.True(t, ok)
+		got, err := r.add(addr, h, payload, now)
+		require.Nil(t, err)
+		require.Nil(t, got)
+	}
+	h, payload, _ := parseFragment(frags[1])
+	got, err := r.add(addr, h, payload, now)
+	require.Nil(t, err)
+	require.Nil(t, got)
+
+	h, payload, _ = parseFragment(frags[0])
+	got, err = r.add(addr, h, payload, now)
+	require.Nil(t, err)
+	require.Equal(t, msg, got)
+	require.Empty(t, r.pending)
+	require.Zero(t, r.size)
+
+	_, _, ok := parseFragment([]byte("plain packet"))
+	require.False(t, ok)
+}
+
+func TestReassemblerInvalidFragment(t *testing.T) {
+	r := newReassembler((&UDPFragmentOptions{}).withDefaults())
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}
+	_, err := r.add(addr, fragmentHeader{typ: fragmentTypeData, index: 2, count: 2}, nil, time.Now())
+	require.Equal(t, errInvalidFragment, err)
+
+	_, err = r.add(addr, fragmentHeader{typ: fragmentTypeData, index: 0, count: 2}, []byte("a"), time.Now())
+	require.Nil(t, err)
+	_, err = r.add(addr, fragmentHeader{typ: fragmentTypeData, index: 1, count: 3}, []byte("b"), time.Now())
+	require.Equal(t, errInvalidFragment, err)
+}
+
+func TestReassemblerOverflow(t *testing.T) {
+	r := newReassembler((&UDPFragmentOptions{MaxReassemblyBytes: 4}).withDefaults())
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}
+	_, err := r.add(addr, fragmentHeader{typ: fragmentTypeData, index: 0, count: 2}, []byte("abc"), time.Now())
+	require.Nil(t, err)
+	_, err = r.add(addr, fragmentHeader{typ: fragmentTypeData, msgID: 1, index: 0, count: 2},
+		[]byte("abc"), time.Now())
+	require.Equal(t, errReassemblyOverflow, err)
+	require.Len(t, r.pending, 1)
+	require.Equal(t, 3, r.size)
+}
+
+func TestReassemblerCheck(t *testing.T) {
+	r := newReassembler((&UDPFragmentOptions{
+		ReassemblyTimeout:  time.Second,
+		Retransmit:         true,
+		RetransmitInterval: 100 * time.Millisecond,
+	}).withDefaults())
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}
+	now := time.Now()
+	_, err := r.add(addr, fragmentHeader{typ: fragmentTypeData, msgID: 7, index: 1, count: 3},
+		[]byte("b"), now)
+	require.Nil(t, err)
+
+	require.Empty(t, r.check(now))
+	nacks := r.check(now.Add(100 * time.Millisecond))
+	require.Len(t, nacks, 1)
+	require.Equal(t, addr, nacks[0].addr)
+	h, payload, ok := parseFragment(nacks[0].pkt)
+	require.True(t, ok)
+	require.Equal(t, uint8(fragmentTypeNack), h.typ)
+	require.Equal(t, uint32(7), h.msgID)
+	require.Equal(t, []uint16{0, 2}, decodeNack(h, payload))
+
+	require.Empty(t, r.check(now.Add(2*time.Second)))
+	require.Empty(t, r.pending)
+}
+
+func TestFragmentCache(t *testing.T) {
+	c := newFragmentCache((&UDPFragmentOptions{ReassemblyTimeout: time.Second, MaxReassemblyBytes: 10}).withDefaults())
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}
+	now := time.Now()
+	c.put(addr, 1, [][]byte{[]byte("ab"), []byte("cd"), []byte("ef")}, now)
+	require.Equal(t, [][]byte{[]byte("ab"), []byte("ef")}, c.get(addr, 1, []uint16{0, 2, 3}))
+	require.Nil(t, c.get(addr, 2, []uint16{0}))
+
+	// Cache is full.
+	c.put(addr, 2, [][]byte{[]byte("0123456")}, now)
+	require.Nil(t, c.get(addr, 2, []uint16{0}))
+
+	c.expire(now.Add(2 * time.Second))
+	require.Nil(t, c.get(addr, 1, []uint16{0}))
+	require.Zero(t, c.size)
+}
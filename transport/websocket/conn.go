@@ -0,0 +1,310 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// Opcodes of WebSocket frames, see RFC 6455 section 5.2.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+const (
+	finBit  = 0x80
+	rsvBits = 0x70
+	maskBit = 0x80
+
+	maxControlPayload = 125
+	maxHeaderSize     = 14
+
+	closeNormal        = 1000
+	closeProtocolError = 1002
+	closeUnsupported   = 1003
+
+	// closeTimeout is the max time of sending the close frame when the connection is closed.
+	closeTimeout = time.Second
+)
+
+// conn is a WebSocket connection carrying a byte stream in binary messages.
+// Each Write is sent as a binary message, and Read returns the payloads of the messages received in order,
+// so that frames of the stream can be parsed regardless of the boundaries of messages.
+// Pings are answered by pongs, and the connection is closed if nothing is received within a ping interval
+// after a ping is sent.
+type conn struct {
+	net.Conn
+	br          *bufio.Reader
+	isClient    bool // Frames sent by the client are masked.
+	subprotocol string
+
+	// Fields of the data frame being read, only used by Read.
+	remaining  int64
+	masked     bool
+	mask       [4]byte
+	maskPos    int
+	readErr    error
+	fragmented bool // A fragmented message is being read.
+
+	wmu       sync.Mutex
+	closeSent uint32
+	closeOnce sync.Once
+	done      chan struct{}
+	lastRead  int64 // Unix nano of the last time anything is received.
+}
+
+func newConn(nc net.Conn, br *bufio.Reader, isClient bool, subprotocol string, pingInterval time.Duration) *conn {
+	c := &conn{
+		Conn:        nc,
+		br:          br,
+		isClient:    isClient,
+		subprotocol: subprotocol,
+		done:        make(chan struct{}),
+		lastRead:    time.Now().UnixNano(),
+	}
+	if pingInterval > 0 {
+		go c.keepalive(pingInterval)
+	}
+	return c
+}
+
+// Read reads the payloads of binary messages.
+func (c *conn) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.readErr != nil {
+			return 0, c.readErr
+		}
+		if c.readErr = c.nextFrame(); c.readErr != nil {
+			return 0, c.readErr
+		}
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.br.Read(p)
+	if n > 0 {
+		atomic.StoreInt64(&c.lastRead, time.Now().UnixNano())
+	}
+	if c.masked {
+		c.maskPos = maskBytes(c.mask, c.maskPos, p[:n])
+	}
+	c.remaining -= int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		c.readErr = err
+	}
+	return n, err
+}
+
+// Write sends p as a binary message.
+func (c *conn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(opBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close sends the close frame and closes the underlying connection.
+func (c *conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.Conn.SetWriteDeadline(time.Now().Add(closeTimeout))
+		c.sendClose(closeNormal)
+		err = c.Conn.Close()
+	})
+	return err
+}
+
+// nextFrame reads frames until the header of a data frame, and handles the control frames.
+func (c *conn) nextFrame() error {
+	var h [maxHeaderSize]byte
+	if _, err := io.ReadFull(c.br, h[:2]); err != nil {
+		return err
+	}
+	atomic.StoreInt64(&c.lastRead, time.Now().UnixNano())
+	fin, opcode := h[0]&finBit != 0, h[0]&0xf
+	masked, length := h[1]&maskBit != 0, int64(h[1]&0x7f)
+	if h[0]&rsvBits != 0 {
+		return c.fail(closeProtocolError, "reserved bits are set")
+	}
+	if masked == c.isClient {
+		return c.fail(closeProtocolError, "frames from the client must be masked, and from the server mustn't")
+	}
+	switch length {
+	case 126:
+		if _, err := io.ReadFull(c.br, h[2:4]); err != nil {
+			return err
+		}
+		length = int64(binary.BigEndian.Uint16(h[2:4]))
+	case 127:
+		if _, err := io.ReadFull(c.br, h[2:10]); err != nil {
+			return err
+		}
+		if length = int64(binary.BigEndian.Uint64(h[2:10])); length < 0 {
+			return c.fail(closeProtocolError, "invalid payload length")
+		}
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return err
+		}
+	}
+
+	switch opcode {
+	case opContinuation, opBinary:
+		// A fragmented message starts with a binary frame without FIN, and is followed by continuation
+		// frames, the last of which has FIN set. Fragments of messages must not be interleaved.
+		if continued := opcode == opContinuation; continued != c.fragmented {
+			if continued {
+				return c.fail(closeProtocolError, "continuation frame without a started message")
+			}
+			return c.fail(closeProtocolError, "new message before the fragmented message is finished")
+		}
+		c.fragmented = !fin
+		c.remaining, c.masked, c.mask, c.maskPos = length, masked, mask, 0
+		return nil
+	case opText:
+		return c.fail(closeUnsupported, "text messages are not supported")
+	case opClose, opPing, opPong:
+	default:
+		return c.fail(closeProtocolError, fmt.Sprintf("unknown opcode %d", opcode))
+	}
+	if !fin || length > maxControlPayload {
+		return c.fail(closeProtocolError, "control frames must not be fragmented or longer than 125 bytes")
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return err
+	}
+	if masked {
+		maskBytes(mask, 0, payload)
+	}
+	switch opcode {
+	case opClose:
+		c.sendClose(closeNormal)
+		return io.EOF
+	case opPing:
+		if err := c.writeFrame(opPong, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fail closes the WebSocket connection with the status code for a failure of the peer.
+func (c *conn) fail(code uint16, reason string) error {
+	c.sendClose(code)
+	return errors.New("websocket: " + reason)
+}
+
+// sendClose sends the close frame with the status code once.
+func (c *conn) sendClose(code uint16) {
+	if !atomic.CompareAndSwapUint32(&c.closeSent, 0, 1) {
+		return
+	}
+	var payload [2]byte
+	binary.BigEndian.PutUint16(payload[:], code)
+	if err := c.writeFrame(opClose, payload[:]); err != nil {
+		log.Tracef("websocket send close frame error: %v", err)
+	}
+}
+
+// writeFrame writes a whole frame.
+func (c *conn) writeFrame(opcode byte, p []byte) error {
+	var h [maxHeaderSize]byte
+	h[0] = finBit | opcode
+	n := 2
+	switch {
+	case len(p) <= maxControlPayload:
+		h[1] = byte(len(p))
+	case len(p) <= 0xffff:
+		h[1] = 126
+		binary.BigEndian.PutUint16(h[2:], uint16(len(p)))
+		n = 4
+	default:
+		h[1] = 127
+		binary.BigEndian.PutUint64(h[2:], uint64(len(p)))
+		n = 10
+	}
+	if c.isClient {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		h[1] |= maskBit
+		n += copy(h[n:], mask[:])
+		p = append([]byte(nil), p...)
+		maskBytes(mask, 0, p)
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	buffers := net.Buffers{h[:n], p}
+	_, err := buffers.WriteTo(c.Conn)
+	return err
+}
+
+// keepalive sends a ping every interval, and closes the connection if nothing has been received
+// since the last ping.
+func (c *conn) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastPing int64
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			if lastPing != 0 && atomic.LoadInt64(&c.lastRead) < lastPing {
+				log.Tracef("websocket connection %s => %s closed, no pong within %s",
+					c.LocalAddr(), c.RemoteAddr(), interval)
+				c.Close()
+				return
+			}
+			lastPing = now.UnixNano()
+			if err := c.writeFrame(opPing, nil); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// maskBytes masks b with the key starting at pos of the key, and returns the next pos.
+func maskBytes(key [4]byte, pos int, b []byte) int {
+	for i := range b {
+		b[i] ^= key[pos&3]
+		pos++
+	}
+	return pos & 3
+}
@@ -0,0 +1,210 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// acceptGUID is appended to the key of the handshake to compute Sec-WebSocket-Accept, see RFC 6455 section 1.3.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// version is the only version of WebSocket protocol supported.
+const version = "13"
+
+// upgrade upgrades the HTTP connection of r to a WebSocket connection.
+// The error has been replied to the client if upgrade fails.
+func upgrade(w http.ResponseWriter, r *http.Request, opts *options) (*conn, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "websocket handshake method must be GET", http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("websocket handshake method %s is not GET", r.Method)
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "not a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if v := r.Header.Get("Sec-WebSocket-Version"); v != version {
+		w.Header().Set("Sec-WebSocket-Version", version)
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("unsupported websocket version %q", v)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+	if !opts.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, fmt.Errorf("origin %q is not allowed", r.Header.Get("Origin"))
+	}
+	subprotocol, ok := selectSubprotocol(r.Header, opts.subprotocols)
+	if !ok {
+		http.Error(w, "unsupported subprotocols", http.StatusBadRequest)
+		return nil, fmt.Errorf("unsupported subprotocols %q", r.Header.Values("Sec-WebSocket-Protocol"))
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket upgrade is not supported", http.StatusInternalServerError)
+		return nil, errors.New("http.ResponseWriter doesn't implement http.Hijacker")
+	}
+	nc, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("hijack http connection err: %w", err)
+	}
+	// Clear the deadlines set by the HTTP server.
+	nc.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	b.WriteString("\r\n")
+	if _, err := brw.WriteString(b.String()); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("write websocket handshake response err: %w", err)
+	}
+	if err := brw.Flush(); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("write websocket handshake response err: %w", err)
+	}
+	return newConn(nc, brw.Reader, false, subprotocol, opts.pingInterval), nil
+}
+
+// handshake upgrades the client connection nc to the host to a WebSocket connection.
+func handshake(nc net.Conn, host string, timeout time.Duration, opts *options) (*conn, error) {
+	var k [16]byte
+	if _, err := rand.Read(k[:]); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(k[:])
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+opts.path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new websocket handshake request err: %w", err)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", version)
+	if len(opts.subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(opts.subprotocols, ", "))
+	}
+	if opts.origin != "" {
+		req.Header.Set("Origin", opts.origin)
+	}
+
+	if timeout > 0 {
+		nc.SetDeadline(time.Now().Add(timeout))
+		defer nc.SetDeadline(time.Time{})
+	}
+	if err := req.Write(nc); err != nil {
+		return nil, fmt.Errorf("write websocket handshake request err: %w", err)
+	}
+	br := bufio.NewReader(nc)
+	rsp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("read websocket handshake response err: %w", err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket handshake failed with status %s", rsp.Status)
+	}
+	if !headerContainsToken(rsp.Header, "Connection", "upgrade") ||
+		!headerContainsToken(rsp.Header, "Upgrade", "websocket") ||
+		rsp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, errors.New("invalid websocket handshake response")
+	}
+	subprotocol := rsp.Header.Get("Sec-WebSocket-Protocol")
+	if subprotocol != "" && !containsToken(opts.subprotocols, subprotocol) {
+		return nil, fmt.Errorf("websocket server selected unrequested subprotocol %q", subprotocol)
+	}
+	return newConn(nc, br, true, subprotocol, opts.pingInterval), nil
+}
+
+// acceptKey computes Sec-WebSocket-Accept of the key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// selectSubprotocol selects the first subprotocol requested by the client which is supported.
+// No subprotocol is selected if the client doesn't request any, and it fails if none is supported.
+func selectSubprotocol(h http.Header, supported []string) (string, bool) {
+	requested := headerTokens(h, "Sec-WebSocket-Protocol")
+	if len(requested) == 0 {
+		return "", true
+	}
+	for _, p := range requested {
+		if containsToken(supported, p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// sameOrigin allows requests without Origin, which are not from browsers, and those whose Origin
+// has the same host as the request.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, t := range headerTokens(h, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package websocket
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPath         = "/"
+	defaultPingInterval = 30 * time.Second
+)
+
+// options is the options of WebSocket transports.
+type options struct {
+	path         string
+	subprotocols []string
+	pingInterval time.Duration
+	checkOrigin  func(r *http.Request) bool // server only
+	origin       string                     // client only
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		path:         defaultPath,
+		subprotocols: []string{Subprotocol},
+		pingInterval: defaultPingInterval,
+		checkOrigin:  sameOrigin,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Option sets options of WebSocket transports.
+type Option func(*options)
+
+// WithPath sets the path of the WebSocket endpoint, default is "/".
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// WithSubprotocols sets the subprotocols, default is Subprotocol.
+// The client requests them in order, and the server selects the first one it supports.
+// The server rejects the handshake if the client requests subprotocols none of which is supported.
+func WithSubprotocols(subprotocols ...string) Option {
+	return func(o *options) {
+		o.subprotocols = subprotocols
+	}
+}
+
+// WithPingInterval sets the interval of pings, default is 30s, 0 means pings are disabled.
+// The connection is closed if nothing is received within the interval after a ping.
+func WithPingInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pingInterval = d
+	}
+}
+
+// WithCheckOrigin sets the function to check the Origin of handshakes on the server.
+// By default, requests without Origin and those whose Origin has the same host as the request are allowed.
+func WithCheckOrigin(check func(r *http.Request) bool) Option {
+	return func(o *options) {
+		o.checkOrigin = check
+	}
+}
+
+// WithAllowedOrigins allows handshakes on the server from the origins, such as "https://example.com",
+// or from any origin if "*" is given. Requests without Origin are always allowed.
+func WithAllowedOrigins(origins ...string) Option {
+	return WithCheckOrigin(func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, o := range origins {
+			if o == "*" || strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithOrigin sets the Origin of handshakes sent by the client.
+func WithOrigin(origin string) Option {
+	return func(o *options) {
+		o.origin = origin
+	}
+}
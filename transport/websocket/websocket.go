@@ -0,0 +1,190 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+// Package websocket provides stream transports carrying tRPC stream frames in binary WebSocket messages,
+// so that browsers and mobile clients can call streaming services.
+// The WebSocket connections are served by the default stream transports as if they were TCP connections,
+// therefore stream handlers work unchanged.
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/internal/protocol"
+	itls "trpc.group/trpc-go/trpc-go/internal/tls"
+	"trpc.group/trpc-go/trpc-go/log"
+	"trpc.group/trpc-go/trpc-go/pool/connpool"
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+// Subprotocol is the default WebSocket subprotocol of tRPC streams.
+const Subprotocol = "trpc"
+
+// handshakeTimeout is the max time of reading the handshake request on the server.
+const handshakeTimeout = 10 * time.Second
+
+func init() {
+	transport.RegisterServerTransport(protocol.WebSocket, DefaultServerStreamTransport)
+	transport.RegisterServerStreamTransport(protocol.WebSocket, DefaultServerStreamTransport)
+	transport.RegisterClientStreamTransport(protocol.WebSocket, DefaultClientStreamTransport)
+}
+
+// DefaultServerStreamTransport is the default WebSocket server stream transport.
+var DefaultServerStreamTransport = NewServerStreamTransport()
+
+// DefaultClientStreamTransport is the default WebSocket client stream transport.
+var DefaultClientStreamTransport = NewClientStreamTransport()
+
+// NewServerStreamTransport creates a WebSocket server stream transport.
+func NewServerStreamTransport(opts ...Option) transport.ServerStreamTransport {
+	return &serverStreamTransport{
+		ServerStreamTransport: transport.NewServerStreamTransport(),
+		opts:                  newOptions(opts...),
+	}
+}
+
+// NewClientStreamTransport creates a WebSocket client stream transport.
+func NewClientStreamTransport(opts ...Option) transport.ClientStreamTransport {
+	return transport.NewClientStreamTransport(transport.WithDialFunc(NewDialFunc(opts...)))
+}
+
+// NewDialFunc creates a function dialing WebSocket connections, which can be used by
+// multiplexed.WithDialFunc for a custom pool.
+func NewDialFunc(opts ...Option) connpool.DialFunc {
+	o := newOptions(opts...)
+	return func(dialOpts *connpool.DialOptions) (net.Conn, error) {
+		nc, err := connpool.Dial(dialOpts)
+		if err != nil {
+			return nil, err
+		}
+		c, err := handshake(nc, dialOpts.Address, dialOpts.Timeout, o)
+		if err != nil {
+			nc.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+}
+
+// serverStreamTransport serves the WebSocket connections by the default server stream transport.
+type serverStreamTransport struct {
+	transport.ServerStreamTransport
+	opts *options
+}
+
+// ListenAndServe implements transport.ServerTransport.
+// It serves WebSocket handshakes on the listener or the address, and TLS is terminated before handshakes.
+func (s *serverStreamTransport) ListenAndServe(ctx context.Context, opts ...transport.ListenServeOption) error {
+	lsOpts := &transport.ListenServeOptions{}
+	for _, opt := range opts {
+		opt(lsOpts)
+	}
+	ln := lsOpts.Listener
+	if ln == nil {
+		var err error
+		if ln, err = net.Listen(lsOpts.Network, lsOpts.Address); err != nil {
+			return fmt.Errorf("websocket server transport listen err: %w", err)
+		}
+	}
+	if lsOpts.TLSCertFile != "" && lsOpts.TLSKeyFile != "" {
+		tlsConf, err := itls.GetServerConfig(lsOpts.CACertFile, lsOpts.TLSCertFile, lsOpts.TLSKeyFile,
+			lsOpts.TLSCertProvider)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("tls get server config err: %w", err)
+		}
+		ln = tls.NewListener(ln, tlsConf)
+	}
+	l := newListener(ln, s.opts)
+	go l.serve()
+	return s.ServerStreamTransport.ListenAndServe(ctx,
+		append(opts, transport.WithListener(l), transport.WithServeTLS("", "", ""))...)
+}
+
+// listener accepts the WebSocket connections upgraded by the HTTP server.
+type listener struct {
+	ln        net.Listener
+	srv       *http.Server
+	opts      *options
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newListener(ln net.Listener, opts *options) *listener {
+	l := &listener{
+		ln:    ln,
+		opts:  opts,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+	l.srv = &http.Server{Handler: l, ReadHeaderTimeout: handshakeTimeout}
+	return l
+}
+
+// Accept implements net.Listener.
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, &net.OpError{Op: "accept", Net: l.ln.Addr().Network(), Addr: l.ln.Addr(), Err: net.ErrClosed}
+	}
+}
+
+// Close implements net.Listener. The connections which have been accepted are not closed.
+func (l *listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		l.closeErr = l.srv.Close()
+	})
+	return l.closeErr
+}
+
+// Addr implements net.Listener.
+func (l *listener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// ServeHTTP upgrades the requests to the path to WebSocket connections.
+func (l *listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != l.opts.path {
+		http.NotFound(w, r)
+		return
+	}
+	c, err := upgrade(w, r, l.opts)
+	if err != nil {
+		log.Debugf("websocket handshake from %s failed: %v", r.RemoteAddr, err)
+		return
+	}
+	select {
+	case l.conns <- c:
+	case <-l.done:
+		c.Close()
+	}
+}
+
+func (l *listener) serve() {
+	if err := l.srv.Serve(l.ln); err != http.ErrServerClosed && !errors.Is(err, net.ErrClosed) {
+		log.Errorf("websocket server transport serving on %s stopped: %v", l.ln.Addr(), err)
+	}
+	l.Close()
+}
@@ -0,0 +1,235 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 Tencent.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package websocket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/pool/connpool"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startListener starts a WebSocket listener on a random port.
+func startListener(t *testing.T, opts ...Option) *listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	l := newListener(ln, newOptions(opts...))
+	go l.serve()
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// dial dials the listener and accepts the connection on the server.
+func dial(t *testing.T, l *listener, opts ...Option) (client, server *conn) {
+	nc, err := NewDialFunc(opts...)(&connpool.DialOptions{
+		Network: "tcp",
+		Address: l.Addr().String(),
+		Timeout: time.Second,
+	})
+	require.Nil(t, err)
+	sc, err := l.Accept()
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		nc.Close()
+		sc.Close()
+	})
+	return nc.(*conn), sc.(*conn)
+}
+
+func TestConn(t *testing.T) {
+	l := startListener(t)
+	client, server := dial(t, l)
+	assert.Equal(t, Subprotocol, client.subprotocol)
+	assert.Equal(t, Subprotocol, server.subprotocol)
+
+	for _, size := range []int{0, 1, maxControlPayload + 1, 0xffff + 1} {
+		msg := bytes.Repeat([]byte{'a'}, size)
+		n, err := client.Write(msg)
+		require.Nil(t, err)
+		require.Equal(t, size, n)
+		got := make([]byte, size)
+		_, err = io.ReadFull(server, got)
+		require.Nil(t, err)
+		require.Equal(t, msg, got)
+
+		_, err = server.Write(msg)
+		require.Nil(t, err)
+		_, err = io.ReadFull(client, got)
+		require.Nil(t, err)
+		require.Equal(t, msg, got)
+	}
+
+	// Messages are read as a byte stream.
+	_, err := client.Write([]byte("hello "))
+	require.Nil(t, err)
+	_, err = client.Write([]byte("world"))
+	require.Nil(t, err)
+	got := make([]byte, len("hello world"))
+	_, err = io.ReadFull(server, got)
+	require.Nil(t, err)
+	assert.Equal(t, "hello world", string(got))
+
+	require.Nil(t, client.Close())
+	_, err = server.Read(got)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestConnTextMessage(t *testing.T) {
+	client, server := dial(t, startListener(t))
+	require.Nil(t, client.writeFrame(opText, []byte("hello")))
+	_, err := server.Read(make([]byte, 5))
+	assert.Contains(t, err.Error(), "text messages are not supported")
+	// The client receives the close frame.
+	_, err = client.Read(make([]byte, 5))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestConnFragmentedMessage(t *testing.T) {
+	client, server := dial(t, startListener(t))
+	// A binary frame without FIN followed by continuation frames.
+	_, err := server.Conn.Write([]byte{opBinary, 2, 'h', 'e', opContinuation, 1, 'l', finBit | opContinuation, 2, 'l', 'o'})
+	require.Nil(t, err)
+	got := make([]byte, len("hello"))
+	_, err = io.ReadFull(client, got)
+	require.Nil(t, err)
+	assert.Equal(t, "hello", string(got))
+
+	// A continuation frame without a started message.
+	_, err = server.Conn.Write([]byte{finBit | opContinuation, 1, 'a'})
+	require.Nil(t, err)
+	_, err = client.Read(got)
+	assert.Contains(t, err.Error(), "continuation frame without a started message")
+	assert.Equal(t, uint16(closeProtocolError), readCloseCode(t, server))
+
+	client, server = dial(t, startListener(t))
+	// A new message before the fragmented message is finished.
+	_, err = server.Conn.Write([]byte{opBinary, 1, 'a', finBit | opBinary, 1, 'b'})
+	require.Nil(t, err)
+	_, err = io.ReadFull(client, got)
+	assert.Contains(t, err.Error(), "new message before the fragmented message is finished")
+	assert.Equal(t, uint16(closeProtocolError), readCloseCode(t, server))
+}
+
+// readCloseCode reads the masked close frame sent by the client directly from the connection of the server.
+func readCloseCode(t *testing.T, server *conn) uint16 {
+	frame := make([]byte, 8)
+	_, err := io.ReadFull(server.br, frame)
+	require.Nil(t, err)
+	require.Equal(t, []byte{finBit | opClose, maskBit | 2}, frame[:2])
+	var mask [4]byte
+	copy(mask[:], frame[2:6])
+	maskBytes(mask, 0, frame[6:])
+	return binary.BigEndian.Uint16(frame[6:])
+}
+
+func TestConnKeepalive(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	l := startListener(t, WithPingInterval(interval))
+
+	client, server := dial(t, l, WithPingInterval(0))
+	// Pings are answered and pongs are received while reading.
+	go io.Copy(io.Discard, client)
+	go io.Copy(io.Discard, server)
+	time.Sleep(4 * interval)
+	_, err := server.Write([]byte("alive"))
+	assert.Nil(t, err)
+
+	nc, err := net.Dial("tcp", l.Addr().String())
+	require.Nil(t, err)
+	defer nc.Close()
+	_, err = handshake(nc, l.Addr().String(), time.Second, newOptions(WithPingInterval(0)))
+	require.Nil(t, err)
+	sc, err := l.Accept()
+	require.Nil(t, err)
+	// The client doesn't read, so pings are not answered.
+	start := time.Now()
+	_, err = io.Copy(io.Discard, sc)
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 10*interval)
+}
+
+func TestHandshake(t *testing.T) {
+	l := startListener(t,
+		WithPath("/stream"),
+		WithSubprotocols("trpc.v2", Subprotocol),
+		WithAllowedOrigins("https://example.com"))
+	dial := func(opts ...Option) (net.Conn, error) {
+		return NewDialFunc(opts...)(&connpool.DialOptions{
+			Network: "tcp",
+			Address: l.Addr().String(),
+			Timeout: time.Second,
+		})
+	}
+	t.Run("ok", func(t *testing.T) {
+		c, err := dial(WithPath("/stream"), WithSubprotocols("unknown", Subprotocol),
+			WithOrigin("https://example.com"))
+		require.Nil(t, err)
+		defer c.Close()
+		assert.Equal(t, Subprotocol, c.(*conn).subprotocol)
+		sc, err := l.Accept()
+		require.Nil(t, err)
+		sc.Close()
+	})
+	t.Run("no subprotocol", func(t *testing.T) {
+		c, err := dial(WithPath("/stream"), WithSubprotocols())
+		require.Nil(t, err)
+		defer c.Close()
+		assert.Equal(t, "", c.(*conn).subprotocol)
+		sc, err := l.Accept()
+		require.Nil(t, err)
+		sc.Close()
+	})
+	t.Run("path not found", func(t *testing.T) {
+		_, err := dial()
+		assert.Contains(t, err.Error(), "404")
+	})
+	t.Run("origin not allowed", func(t *testing.T) {
+		_, err := dial(WithPath("/stream"), WithOrigin("https://evil.com"))
+		assert.Contains(t, err.Error(), "403")
+	})
+	t.Run("unsupported subprotocol", func(t *testing.T) {
+		_, err := dial(WithPath("/stream"), WithSubprotocols("unknown"))
+		assert.Contains(t, err.Error(), "400")
+	})
+}
+
+func TestSameOrigin(t *testing.T) {
+	l := startListener(t)
+	_, err := NewDialFunc(WithOrigin("http://" + l.Addr().String()))(&connpool.DialOptions{
+		Network: "tcp",
+		Address: l.Addr().String(),
+	})
+	require.Nil(t, err)
+	_, err = NewDialFunc(WithOrigin("http://example.com"))(&connpool.DialOptions{
+		Network: "tcp",
+		Address: l.Addr().String(),
+	})
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestListenerClose(t *testing.T) {
+	l := startListener(t)
+	require.Nil(t, l.Close())
+	_, err := l.Accept()
+	assert.True(t, errors.Is(err, net.ErrClosed))
+	assert.Nil(t, l.Close())
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"trpc.group/trpc-go/trpc-go/admin"
+	"trpc.group/trpc-go/trpc-go/encryption"
 	"trpc.group/trpc-go/trpc-go/filter"
 	iprecool "trpc.group/trpc-go/trpc-go/internal/precool"
 	"trpc.group/trpc-go/trpc-go/log"
@@ -174,10 +175,31 @@ func newServiceWithConfig(cfg *Config, serviceCfg *ServiceConfig, opt ...server.
 		server.WithServerAsync(*serviceCfg.ServerAsync),
 		server.WithMaxRoutines(serviceCfg.MaxRoutines),
 		server.WithWritev(*serviceCfg.Writev),
+		server.WithZeroCopy(serviceCfg.ZeroCopy),
+		server.WithMaxConnections(serviceCfg.MaxConnections),
+		server.WithMaxConnectionsPerIP(serviceCfg.MaxConnectionsPerIP),
+		server.WithConnectionLimitWait(getMillisecond(serviceCfg.ConnectionLimitWait)),
+		server.WithMaxRequestSize(serviceCfg.MaxRequestSize),
+		server.WithMaxResponseSize(serviceCfg.MaxResponseSize),
+		server.WithMaxConcurrentStreams(serviceCfg.MaxConcurrentStreams),
+		server.WithCompressMinSize(serviceCfg.CompressMinSize),
+		server.WithCompressMaxRatio(serviceCfg.CompressMaxRatio),
+		server.WithZstdDictionary(serviceCfg.ZstdDictionary),
+		server.WithChecksum(serviceCfg.Checksum),
 	}
 	if serviceCfg.TLSCertProvider != "" {
 		opts = append(opts, server.WithCertProvider(serviceCfg.TLSCertProvider))
 	}
+	if serviceCfg.Encryption != "" {
+		p := encryption.GetKeyProvider(serviceCfg.Encryption)
+		if p == nil {
+			panic(fmt.Sprintf("encryption key provider %s no registered, do not configure", serviceCfg.Encryption))
+		}
+		opts = append(opts, server.WithEncryption(p))
+	}
+	if serviceCfg.UDPFragment != nil {
+		opts = append(opts, server.WithUDPFragment(serviceCfg.UDPFragment))
+	}
 	for i := range filters {
 		opts = append(opts, server.WithNamedFilter(filterNames[i], filters[i]))
 	}